@@ -0,0 +1,50 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+)
+
+// Grant gives a permission to a user or group, through the AccessController
+// coprocessor of the ACL table.
+func (c *client) Grant(g *hrpc.Grant) error {
+	pbmsg, err := c.sendRPC(g)
+	if err != nil {
+		return err
+	}
+	return hrpc.UnmarshalCoprocessorResponse(pbmsg, &pb.GrantResponse{})
+}
+
+// Revoke takes a permission away from a user or group, through the
+// AccessController coprocessor of the ACL table.
+func (c *client) Revoke(r *hrpc.Revoke) error {
+	pbmsg, err := c.sendRPC(r)
+	if err != nil {
+		return err
+	}
+	return hrpc.UnmarshalCoprocessorResponse(pbmsg, &pb.RevokeResponse{})
+}
+
+// GetUserPermissions returns the permissions of the users and groups on a
+// scope, through the AccessController coprocessor of the ACL table.
+func (c *client) GetUserPermissions(g *hrpc.GetUserPermissions) (
+	[]*hrpc.UserPermission, error) {
+	pbmsg, err := c.sendRPC(g)
+	if err != nil {
+		return nil, err
+	}
+	res := &pb.GetUserPermissionsResponse{}
+	if err = hrpc.UnmarshalCoprocessorResponse(pbmsg, res); err != nil {
+		return nil, err
+	}
+	perms := make([]*hrpc.UserPermission, 0, len(res.UserPermission))
+	for _, up := range res.UserPermission {
+		perms = append(perms, hrpc.ToLocalUserPermission(up))
+	}
+	return perms, nil
+}
@@ -0,0 +1,91 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"fmt"
+
+	"github.com/tsuna/gohbase/region"
+)
+
+// FailedOp describes one operation of a batch that failed.
+type FailedOp struct {
+	// Index of the operation in the batch submitted by the caller.
+	Index int
+
+	Err error
+}
+
+// BatchError is returned by batch operations when some, but not necessarily
+// all, of the operations in the batch failed.  Operations are identified by
+// their index in the batch submitted by the caller, so that callers can
+// resubmit exactly what failed.
+type BatchError struct {
+	// Successes lists the indexes of the operations that succeeded.
+	Successes []int
+
+	// Retryable lists the operations that failed because of a transient
+	// error (region moving, connection lost, deadline exceeded...) and that
+	// can be resubmitted as-is.
+	Retryable []FailedOp
+
+	// Permanent lists the operations that failed because of an error that
+	// will happen again if the operation is resubmitted as-is (no such
+	// table or column family, invalid request...).
+	Permanent []FailedOp
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%d operations of the batch failed (%d retryable, %d permanent),"+
+		" %d succeeded", len(e.Retryable)+len(e.Permanent), len(e.Retryable),
+		len(e.Permanent), len(e.Successes))
+}
+
+// Failed returns the indexes of all the operations that failed, in order.
+func (e *BatchError) Failed() []int {
+	failed := make([]int, 0, len(e.Retryable)+len(e.Permanent))
+	r, p := 0, 0
+	for r < len(e.Retryable) || p < len(e.Permanent) {
+		if p == len(e.Permanent) ||
+			r < len(e.Retryable) && e.Retryable[r].Index < e.Permanent[p].Index {
+			failed = append(failed, e.Retryable[r].Index)
+			r++
+		} else {
+			failed = append(failed, e.Permanent[p].Index)
+			p++
+		}
+	}
+	return failed
+}
+
+// newBatchError creates a BatchError out of the per-operation errors of a
+// batch, or returns nil if none of the operations failed.
+func newBatchError(errs []error) error {
+	be := &BatchError{}
+	for i, err := range errs {
+		if err == nil {
+			be.Successes = append(be.Successes, i)
+		} else if isRetryableError(err) {
+			be.Retryable = append(be.Retryable, FailedOp{Index: i, Err: err})
+		} else {
+			be.Permanent = append(be.Permanent, FailedOp{Index: i, Err: err})
+		}
+	}
+	if len(be.Retryable) == 0 && len(be.Permanent) == 0 {
+		return nil
+	}
+	return be
+}
+
+// isRetryableError returns true if the operation that returned the given
+// error can be sent again as-is and has a chance to succeed.
+func isRetryableError(err error) bool {
+	switch err.(type) {
+	case region.RetryableError, region.UnrecoverableError, region.ServerOverloadedError:
+		return true
+	}
+	return err == ErrDeadline || err == ErrMaxAttempts || err == errNoClient
+}
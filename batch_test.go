@@ -0,0 +1,152 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/filter"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+func TestBatchError(t *testing.T) {
+	if err := newBatchError([]error{nil, nil}); err != nil {
+		t.Fatalf("Expected no error when all operations succeeded, got %v", err)
+	}
+
+	permanent := errors.New("HBase Java exception " +
+		"org.apache.hadoop.hbase.regionserver.NoSuchColumnFamilyException")
+	retryable := region.RetryableError{}
+	err := newBatchError([]error{nil, permanent, retryable, nil, ErrDeadline})
+	be, ok := err.(*BatchError)
+	if !ok {
+		t.Fatalf("Expected a *BatchError, got %T", err)
+	}
+	if expected := []int{0, 3}; !reflect.DeepEqual(be.Successes, expected) {
+		t.Errorf("Expected successes %v, got %v", expected, be.Successes)
+	}
+	expectedRetryable := []FailedOp{{Index: 2, Err: retryable}, {Index: 4, Err: ErrDeadline}}
+	if !reflect.DeepEqual(be.Retryable, expectedRetryable) {
+		t.Errorf("Expected retryable failures %v, got %v", expectedRetryable, be.Retryable)
+	}
+	expectedPermanent := []FailedOp{{Index: 1, Err: permanent}}
+	if !reflect.DeepEqual(be.Permanent, expectedPermanent) {
+		t.Errorf("Expected permanent failures %v, got %v", expectedPermanent, be.Permanent)
+	}
+	if expected := []int{1, 2, 4}; !reflect.DeepEqual(be.Failed(), expected) {
+		t.Errorf("Expected failed operations %v, got %v", expected, be.Failed())
+	}
+}
+
+func TestBatchRetries(t *testing.T) {
+	c := newClient("~invalid.quorum~", ConditionalMultis(), SetRetryPolicy(RetryPolicy{
+		Backoff: Backoff{Start: time.Microsecond, Max: time.Microsecond},
+	}))
+	var multis int32
+	rs := &registryClient{
+		fakeRegionClient: fakeRegionClient{host: "rs", port: 16020},
+		respond: func(rpc hrpc.Call) hrpc.RPCResult {
+			atomic.AddInt32(&multis, 1)
+			return hrpc.RPCResult{Error: region.RetryableError{}}
+		},
+	}
+	cacheTestRegions(c, rs, rs, rs)
+
+	ctx := context.Background()
+	del, err := hrpc.NewDelStr(ctx, "test", "row", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := filter.NewBinaryComparator(filter.NewByteArrayComparable([]byte("1")))
+	cam, err := hrpc.NewCheckAndMutate(del, "cf", "a", filter.Equal, expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without a limit of attempts, the batch is still sent a bounded number
+	// of times, and the mutation can be sent again by the caller.
+	_, err = c.CheckAndMutateBatch(ctx, []*hrpc.CheckAndMutate{cam})
+	be, ok := err.(*BatchError)
+	if !ok || len(be.Retryable) != 1 {
+		t.Fatalf("Expected a retryable failure, got %v", err)
+	}
+	if n := atomic.LoadInt32(&multis); n != maxBatchRetries+1 {
+		t.Errorf("Expected the batch to be sent %d times, got %d", maxBatchRetries+1, n)
+	}
+
+	// Neither can the mutations whose region never gets a client back.
+	c = newClient("~invalid.quorum~", SetRetryPolicy(RetryPolicy{
+		Backoff: Backoff{Start: time.Microsecond, Max: time.Microsecond},
+	}))
+	cacheTestRegions(c, nil, nil, nil)
+	_, err = c.Batch(ctx, []hrpc.Call{del})
+	be, ok = err.(*BatchError)
+	if !ok || len(be.Retryable) != 1 || len(be.Permanent) != 0 {
+		t.Fatalf("Expected a retryable failure, got %v", err)
+	}
+}
+
+func TestConditionalBatch(t *testing.T) {
+	ctx := context.Background()
+	newBatch := func() []*hrpc.CheckAndPut {
+		var cas []*hrpc.CheckAndPut
+		for _, key := range []string{"a", "b"} {
+			put, err := hrpc.NewPutStr(ctx, "test", key,
+				map[string]map[string][]byte{"cf": {"a": []byte("1")}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ca, err := hrpc.NewCheckAndPut(put, "cf", "a", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cas = append(cas, ca)
+		}
+		return cas
+	}
+	var m sync.Mutex
+	var sent []string
+	rs := &registryClient{
+		fakeRegionClient: fakeRegionClient{host: "rs", port: 16020},
+		respond: func(rpc hrpc.Call) hrpc.RPCResult {
+			m.Lock()
+			sent = append(sent, rpc.GetName())
+			m.Unlock()
+			if _, ok := rpc.(*hrpc.Multi); ok {
+				return hrpc.RPCResult{Error: errors.New("no Multi expected")}
+			}
+			return hrpc.RPCResult{Msg: &pb.MutateResponse{Processed: proto.Bool(true)}}
+		},
+	}
+
+	// The RegionServers older than HBase 2.4 would ignore the conditions of
+	// a Multi, so by default each conditional Put gets its own RPC.
+	c := newClient("~invalid.quorum~")
+	cacheTestRegions(c, rs, rs, rs)
+	processed, err := c.CheckAndPutBatch(ctx, newBatch())
+	if err != nil || !reflect.DeepEqual(processed, []bool{true, true}) {
+		t.Errorf("Expected both Puts to be applied, got %v (%v)", processed, err)
+	}
+	if expected := []string{"Mutate", "Mutate"}; !reflect.DeepEqual(sent, expected) {
+		t.Errorf("Expected the RPCs %v, got %v", expected, sent)
+	}
+
+	sent = nil
+	c = newClient("~invalid.quorum~", ConditionalMultis())
+	cacheTestRegions(c, rs, rs, rs)
+	c.CheckAndPutBatch(ctx, newBatch())
+	if expected := []string{"Multi"}; !reflect.DeepEqual(sent, expected) {
+		t.Errorf("Expected the RPCs %v, got %v", expected, sent)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ErrMaxAttempts is returned when an operation was sent the maximum number
+// of times set by the MaxAttempts option without succeeding.
+var ErrMaxAttempts = errors.New("maximum number of attempts reached")
+
+// MaxAttempts will return an option that fails each operation with
+// ErrMaxAttempts once it was sent the given number of times to a RegionServer
+// without succeeding.  0, the default, doesn't limit the number of attempts.
+// Combined with MaxOperationTime, whichever limit is reached first ends the
+// operation.
+func MaxAttempts(n int) Option {
+	return func(c *client) {
+		c.maxAttempts = n
+	}
+}
+
+// MaxOperationTime will return an option that fails each operation with
+// ErrDeadline once the given time elapsed since it started, including the
+// time spent retrying it, even if the deadline of its context is later.
+// 0, the default, only relies on the context of the operation.
+func MaxOperationTime(d time.Duration) Option {
+	return func(c *client) {
+		c.maxOperationTime = d
+	}
+}
+
+// attemptsKey is the key of the attempt counters in the context of an
+// operation.
+type attemptsKey struct{}
+
+// attempts counts the attempts of an operation, in total and after the errors
+// of each class, along with the current backoffs after these errors and the
+// failovers of the master that the operation went through.
+type attempts struct {
+	m sync.Mutex
+
+	total     int
+	byClass   [numErrorClasses]int
+	backoffs  [numErrorClasses]time.Duration
+	failovers int
+}
+
+// operationContext returns the context bounding an operation started with the
+// given context, according to the maximum operation time of the client, and
+// counting its attempts.  The returned function must be called once the
+// operation is done.
+func (c *client) operationContext(ctx context.Context) (context.Context,
+	context.CancelFunc) {
+	cancel := context.CancelFunc(func() {})
+	if c.maxOperationTime > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.maxOperationTime)
+	}
+	return context.WithValue(ctx, attemptsKey{}, &attempts{}), cancel
+}
+
+// attempt counts an attempt of the operation of the given context, and
+// returns ErrMaxAttempts if it was already attempted the maximum number of
+// times.
+func (c *client) attempt(ctx context.Context) error {
+	a, ok := ctx.Value(attemptsKey{}).(*attempts)
+	if !ok {
+		return nil
+	}
+	a.m.Lock()
+	a.total++
+	n := a.total
+	a.m.Unlock()
+	if c.maxAttempts > 0 && n > c.maxAttempts {
+		return ErrMaxAttempts
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestOperationBudget(t *testing.T) {
+	c := newClient("~invalid.quorum~")
+	ctx, cancel := c.operationContext(context.Background())
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("Expected no deadline without MaxOperationTime")
+	}
+	for i := 0; i < 10; i++ {
+		if err := c.attempt(ctx); err != nil {
+			t.Fatalf("Expected unlimited attempts, got %v", err)
+		}
+	}
+	cancel()
+
+	c = newClient("~invalid.quorum~", MaxAttempts(3), MaxOperationTime(time.Minute))
+	ctx, cancel = c.operationContext(context.Background())
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || deadline.After(time.Now().Add(time.Minute)) {
+		t.Errorf("Expected a deadline within a minute, got %v", deadline)
+	}
+	for i := 0; i < 3; i++ {
+		if err := c.attempt(ctx); err != nil {
+			t.Fatalf("Expected attempt #%d to be allowed, got %v", i+1, err)
+		}
+	}
+	if err := c.attempt(ctx); err != ErrMaxAttempts {
+		t.Errorf("Expected ErrMaxAttempts, got %v", err)
+	}
+
+	// Each operation has its own attempts.
+	other, cancelOther := c.operationContext(context.Background())
+	defer cancelOther()
+	if err := c.attempt(other); err != nil {
+		t.Errorf("Expected a new operation to be allowed, got %v", err)
+	}
+
+	// The context of the operation is never later than that of the call.
+	short, cancelShort := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelShort()
+	ctx, cancel = c.operationContext(short)
+	defer cancel()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Error("Expected the operation to end with the context of the call")
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"golang.org/x/net/context"
+)
+
+// ErrMutatorClosed is returned when using a BufferedMutator after it was
+// closed.
+var ErrMutatorClosed = errors.New("buffered mutator closed")
+
+const (
+	// Default thresholds of a BufferedMutator: like in the Java client, the
+	// buffer is flushed once it holds 2MB of mutations.
+	defaultBufferBytes   = 2 * 1024 * 1024
+	defaultBufferTimeout = 30 * time.Second
+)
+
+// BufferedMutator accumulates Puts and Deletes (or any other mutation)
+// client side and sends them in batches, packing the mutations for the
+// regions of the same RegionServer in a single Multi RPC.  The buffer is
+// flushed once it reaches a size or a number of mutations, periodically if a
+// flush interval is set, when Flush is called and when it's closed.  The
+// failures of the mutations are reported to the error handler of the
+// BufferedMutator, if any, and returned by the call that flushed them: Mutate
+// returns the error of the flush it triggered, and the error of a periodic
+// flush is returned by the next call to Flush or Close, so that no failure
+// goes unnoticed.  It's safe for concurrent use.
+type BufferedMutator struct {
+	client Client
+
+	maxBytes  int
+	maxCount  int
+	interval  time.Duration
+	timeout   time.Duration
+	onFailure func(m *hrpc.Mutate, err error)
+
+	// Protects the fields below.
+	m sync.Mutex
+
+	buffer []*hrpc.Mutate
+	size   int
+	closed bool
+
+	// The error of the last periodic flush that failed, returned by the
+	// next call to Flush.
+	err error
+
+	// Serializes the flushes, so that the mutations are sent in order.
+	flushLock sync.Mutex
+
+	// Closed when the BufferedMutator is closed.
+	done chan struct{}
+}
+
+// BufferedMutatorOption is a function used to configure a BufferedMutator.
+type BufferedMutatorOption func(*BufferedMutator)
+
+// FlushBufferBytes will return an option that flushes the buffer of a
+// BufferedMutator once the size of its mutations reaches the given number of
+// bytes (2MB by default).  0 disables this threshold.
+func FlushBufferBytes(n int) BufferedMutatorOption {
+	return func(bm *BufferedMutator) {
+		bm.maxBytes = n
+	}
+}
+
+// FlushBufferCount will return an option that flushes the buffer of a
+// BufferedMutator once it holds the given number of mutations.  0, the default,
+// disables this threshold.
+func FlushBufferCount(n int) BufferedMutatorOption {
+	return func(bm *BufferedMutator) {
+		bm.maxCount = n
+	}
+}
+
+// FlushBufferInterval will return an option that flushes the buffer of a
+// BufferedMutator at the given interval, so that mutations don't stay
+// buffered for longer than that.  0, the default, disables periodic flushes.
+func FlushBufferInterval(interval time.Duration) BufferedMutatorOption {
+	return func(bm *BufferedMutator) {
+		bm.interval = interval
+	}
+}
+
+// FlushBufferTimeout will return an option that sets how long the flushes
+// that aren't triggered by a call to Flush wait for their mutations to be
+// sent (30s by default).
+func FlushBufferTimeout(timeout time.Duration) BufferedMutatorOption {
+	return func(bm *BufferedMutator) {
+		bm.timeout = timeout
+	}
+}
+
+// OnMutationFailure will return an option that calls the given function,
+// from the goroutine flushing the buffer, for each mutation that couldn't be
+// sent.
+func OnMutationFailure(f func(m *hrpc.Mutate, err error)) BufferedMutatorOption {
+	return func(bm *BufferedMutator) {
+		bm.onFailure = f
+	}
+}
+
+// NewBufferedMutator creates a BufferedMutator sending its mutations with the
+// given client.  It must be closed once done to flush the last mutations.
+func NewBufferedMutator(c Client, options ...BufferedMutatorOption) *BufferedMutator {
+	bm := &BufferedMutator{
+		client:   c,
+		maxBytes: defaultBufferBytes,
+		timeout:  defaultBufferTimeout,
+		done:     make(chan struct{}),
+	}
+	for _, option := range options {
+		option(bm)
+	}
+	if bm.interval > 0 {
+		go bm.flushPeriodically()
+	}
+	return bm
+}
+
+// Mutate buffers the given mutation.  If the buffer reaches one of its
+// thresholds, it's flushed before returning, which makes writers slow down
+// when mutations can't be sent as fast as they're buffered, and the error of
+// the flush is returned, like by Flush.
+func (bm *BufferedMutator) Mutate(m *hrpc.Mutate) error {
+	size, err := m.Size()
+	if err != nil {
+		return err
+	}
+	bm.m.Lock()
+	if bm.closed {
+		bm.m.Unlock()
+		return ErrMutatorClosed
+	}
+	bm.buffer = append(bm.buffer, m)
+	bm.size += size
+	full := bm.maxBytes > 0 && bm.size >= bm.maxBytes ||
+		bm.maxCount > 0 && len(bm.buffer) >= bm.maxCount
+	bm.m.Unlock()
+	if full {
+		ctx, cancel := context.WithTimeout(context.Background(), bm.timeout)
+		defer cancel()
+		return bm.Flush(ctx)
+	}
+	return nil
+}
+
+// Buffered returns the number of mutations currently buffered and their
+// size in bytes.
+func (bm *BufferedMutator) Buffered() (int, int) {
+	bm.m.Lock()
+	defer bm.m.Unlock()
+	return len(bm.buffer), bm.size
+}
+
+// Flush sends all the buffered mutations, and waits for them to be sent or
+// for the context to be done.  The mutations that failed are reported to the
+// error handler, and the error is then usually a *BatchError whose indexes
+// are those of the mutations in the order in which they were buffered.  If
+// they were all sent, the error of the last periodic flush that failed since
+// the previous call to Flush is returned, if any.
+func (bm *BufferedMutator) Flush(ctx context.Context) error {
+	bm.flushLock.Lock()
+	defer bm.flushLock.Unlock()
+	err := bm.flush(ctx)
+	bm.m.Lock()
+	if err == nil {
+		err = bm.err
+	}
+	bm.err = nil
+	bm.m.Unlock()
+	return err
+}
+
+// flush sends all the buffered mutations, reports the ones that failed to the
+// error handler and returns the error of the batch.  Must be called with the
+// flush lock held.
+func (bm *BufferedMutator) flush(ctx context.Context) error {
+	bm.m.Lock()
+	mutations := bm.buffer
+	bm.buffer = nil
+	bm.size = 0
+	bm.m.Unlock()
+	if len(mutations) == 0 {
+		return nil
+	}
+
+	calls := make([]hrpc.Call, len(mutations))
+	for i, m := range mutations {
+		calls[i] = m
+	}
+	_, err := bm.client.Batch(ctx, calls)
+	if err == nil || bm.onFailure == nil {
+		return err
+	}
+	if be, ok := err.(*BatchError); ok {
+		for _, op := range be.Retryable {
+			bm.onFailure(mutations[op.Index], op.Err)
+		}
+		for _, op := range be.Permanent {
+			bm.onFailure(mutations[op.Index], op.Err)
+		}
+	} else {
+		for _, m := range mutations {
+			bm.onFailure(m, err)
+		}
+	}
+	return err
+}
+
+// Close flushes the buffered mutations and stops the periodic flushes.  The
+// BufferedMutator can't be used anymore afterwards.
+func (bm *BufferedMutator) Close() error {
+	bm.m.Lock()
+	if bm.closed {
+		bm.m.Unlock()
+		return nil
+	}
+	bm.closed = true
+	bm.m.Unlock()
+	close(bm.done)
+	ctx, cancel := context.WithTimeout(context.Background(), bm.timeout)
+	defer cancel()
+	return bm.Flush(ctx)
+}
+
+// flushPeriodically flushes the buffer at the flush interval, until the
+// BufferedMutator is closed.
+func (bm *BufferedMutator) flushPeriodically() {
+	ticker := time.NewTicker(bm.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), bm.timeout)
+			bm.flushLock.Lock()
+			if err := bm.flush(ctx); err != nil {
+				bm.m.Lock()
+				bm.err = err
+				bm.m.Unlock()
+			}
+			bm.flushLock.Unlock()
+			cancel()
+		case <-bm.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"golang.org/x/net/context"
+)
+
+// batchClient is a Client that records the batches it's asked to send.
+type batchClient struct {
+	Client
+
+	m       sync.Mutex
+	batches [][]hrpc.Call
+	errs    []error
+}
+
+func (c *batchClient) Batch(ctx context.Context, calls []hrpc.Call) ([]*hrpc.Result, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.batches = append(c.batches, calls)
+	return make([]*hrpc.Result, len(calls)), newBatchError(c.errs)
+}
+
+func (c *batchClient) sent() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return len(c.batches)
+}
+
+func TestBufferedMutator(t *testing.T) {
+	newPut := func(i int) *hrpc.Mutate {
+		put, err := hrpc.NewPutStr(context.Background(), "test", fmt.Sprintf("row%d", i),
+			map[string]map[string][]byte{"cf": {"a": []byte("value")}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return put
+	}
+
+	c := &batchClient{}
+	bm := NewBufferedMutator(c, FlushBufferCount(3))
+	for i := 0; i < 5; i++ {
+		if err := bm.Mutate(newPut(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := c.sent(); n != 1 || len(c.batches[0]) != 3 {
+		t.Fatalf("Expected a single batch of 3 mutations, got %v", c.batches)
+	}
+	if n, size := bm.Buffered(); n != 2 || size <= 0 {
+		t.Errorf("Expected 2 buffered mutations, got %d (%d bytes)", n, size)
+	}
+	if err := bm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := c.sent(); n != 2 || len(c.batches[1]) != 2 {
+		t.Errorf("Expected Close to flush the 2 buffered mutations, got %v", c.batches)
+	}
+	if err := bm.Mutate(newPut(5)); err != ErrMutatorClosed {
+		t.Errorf("Expected ErrMutatorClosed, got %v", err)
+	}
+
+	oops := errors.New("oops")
+	c = &batchClient{errs: []error{nil, oops}}
+	var failed []*hrpc.Mutate
+	bm = NewBufferedMutator(c, FlushBufferBytes(1<<20),
+		OnMutationFailure(func(m *hrpc.Mutate, err error) {
+			if err != oops {
+				t.Errorf("Expected the error of the mutation, got %v", err)
+			}
+			failed = append(failed, m)
+		}))
+	puts := []*hrpc.Mutate{newPut(0), newPut(1)}
+	for _, put := range puts {
+		if err := bm.Mutate(put); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := bm.Flush(context.Background()); err == nil {
+		t.Error("Expected Flush to return an error")
+	}
+	if len(failed) != 1 || failed[0] != puts[1] {
+		t.Errorf("Expected the second mutation to fail, got %v", failed)
+	}
+	bm.Close()
+
+	c = &batchClient{}
+	bm = NewBufferedMutator(c, FlushBufferInterval(time.Millisecond))
+	if err := bm.Mutate(newPut(0)); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for c.sent() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if c.sent() == 0 {
+		t.Error("Expected the buffer to be flushed periodically")
+	}
+	bm.Close()
+
+	// Without an error handler, the failures are returned by the calls
+	// that flushed the mutations, or by the next one for the periodic
+	// flushes.
+	c = &batchClient{errs: []error{oops}}
+	bm = NewBufferedMutator(c, FlushBufferCount(1))
+	if err := bm.Mutate(newPut(0)); err == nil {
+		t.Error("Expected Mutate to return the error of its flush")
+	}
+	bm.Close()
+
+	c = &batchClient{errs: []error{oops}}
+	bm = NewBufferedMutator(c, FlushBufferInterval(time.Millisecond))
+	if err := bm.Mutate(newPut(0)); err != nil {
+		t.Fatal(err)
+	}
+	deadline = time.Now().Add(time.Second)
+	for c.sent() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := bm.Close(); err == nil {
+		t.Error("Expected Close to return the error of the periodic flush")
+	}
+}
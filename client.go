@@ -7,6 +7,8 @@ package gohbase
 
 import (
 	"bytes"
+	"container/list"
+	"crypto/tls"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -36,6 +38,12 @@ var (
 	// ErrDeadline is returned when the deadline of a request has been exceeded
 	ErrDeadline = errors.New("deadline exceeded")
 
+	errNoClient = errors.New("no client for this region")
+
+	// errRouteAgain is returned once the region of an RPC, which was
+	// unavailable, is available again, for the RPC to be routed again.
+	errRouteAgain = errors.New("the region of the RPC is available again")
+
 	// TableNotFound is returned when attempting to access a table that
 	// doesn't exist on this cluster.
 	TableNotFound = errors.New("table not found")
@@ -47,6 +55,10 @@ var (
 	// session timeout.
 	regionLookupTimeout = 30 * time.Second
 
+	// How long Close waits for the scanners that are still open to be
+	// closed on the RegionServers.
+	scannerCloseTimeout = 5 * time.Second
+
 	backoffStart = 16 * time.Millisecond
 
 	// log is used to standardize logging across all subpackages
@@ -61,7 +73,7 @@ const (
 type Option func(*client)
 
 type newRegResult struct {
-	Client *region.Client
+	Client hrpc.RegionClient
 	Err    error
 }
 
@@ -94,24 +106,44 @@ func (rcc *clientRegionCache) del(r hrpc.RegionInfo) {
 	if c != nil {
 		r.SetClient(nil)
 
-		var index int
 		for i, reg := range rcc.regions[c] {
 			if reg == r {
-				index = i
+				rcc.regions[c] = append(
+					rcc.regions[c][:i],
+					rcc.regions[c][i+1:]...)
+				break
 			}
 		}
-		rcc.regions[c] = append(
-			rcc.regions[c][:index],
-			rcc.regions[c][index+1:]...)
 	}
 }
 
+// evict removes the given region like del, and also removes its client if it
+// has no region left, in which case it returns the client to be closed.
+func (rcc *clientRegionCache) evict(r hrpc.RegionInfo) hrpc.RegionClient {
+	c := r.GetClient()
+	rcc.del(r)
+	if c == nil {
+		return nil
+	}
+	rcc.m.Lock()
+	defer rcc.m.Unlock()
+	if regions, ok := rcc.regions[c]; ok && len(regions) == 0 {
+		delete(rcc.regions, c)
+		return c
+	}
+	return nil
+}
+
 func (rcc *clientRegionCache) clientDown(reg hrpc.RegionInfo) []hrpc.RegionInfo {
 	rcc.m.Lock()
 	defer rcc.m.Unlock()
 
 	var downregions []hrpc.RegionInfo
 	c := reg.GetClient()
+	if pool, ok := c.(*connPool); ok {
+		// The other connections of the pool are opened again with it.
+		pool.Close()
+	}
 	for _, sharedReg := range rcc.regions[c] {
 		succ := sharedReg.MarkUnavailable()
 		sharedReg.SetClient(nil)
@@ -123,6 +155,59 @@ func (rcc *clientRegionCache) clientDown(reg hrpc.RegionInfo) []hrpc.RegionInfo
 	return downregions
 }
 
+// serverDown removes the client of the RegionServer at the given address from
+// the cache, if any, and marks its regions as unavailable.  It returns the
+// client and the regions that were marked as unavailable.
+func (rcc *clientRegionCache) serverDown(host string, port uint16) (hrpc.RegionClient,
+	[]hrpc.RegionInfo) {
+	rcc.m.Lock()
+	defer rcc.m.Unlock()
+
+	for client, regions := range rcc.regions {
+		if client.Host() != host || client.Port() != port {
+			continue
+		}
+		var downregions []hrpc.RegionInfo
+		for _, reg := range regions {
+			succ := reg.MarkUnavailable()
+			reg.SetClient(nil)
+			if succ {
+				downregions = append(downregions, reg)
+			}
+		}
+		delete(rcc.regions, client)
+		return client, downregions
+	}
+	return nil, nil
+}
+
+// addClient adds a region client that doesn't serve any cached region yet,
+// unless the cache already has a client for the same RegionServer in which
+// case that one is returned instead.
+func (rcc *clientRegionCache) addClient(c hrpc.RegionClient) hrpc.RegionClient {
+	rcc.m.Lock()
+	defer rcc.m.Unlock()
+
+	for client := range rcc.regions {
+		if client.Host() == c.Host() && client.Port() == c.Port() {
+			return client
+		}
+	}
+	rcc.regions[c] = nil
+	return c
+}
+
+// closeAll closes all the region clients and empties the cache.
+func (rcc *clientRegionCache) closeAll() {
+	rcc.m.Lock()
+	defer rcc.m.Unlock()
+
+	for client := range rcc.regions {
+		client.Close()
+	}
+	rcc.regions = make(map[hrpc.RegionClient][]hrpc.RegionInfo)
+}
+
 func (rcc *clientRegionCache) checkForClient(host string, port uint16) hrpc.RegionClient {
 	rcc.m.Lock()
 	defer rcc.m.Unlock()
@@ -141,6 +226,35 @@ type keyRegionCache struct {
 
 	// Maps a []byte of a region start key to a hrpc.RegionInfo
 	regions *b.Tree
+
+	// The maximum number of regions in the cache, if not 0, beyond which
+	// the least recently used ones are evicted.
+	maxSize int
+
+	// The cached regions from the most to the least recently used, and
+	// their elements by name, if maxSize isn't 0.
+	lru      *list.List
+	elements map[string]*list.Element
+
+	// The number of regions evicted so far.
+	evictions int
+
+	// The numbers of lookups that found their region in the cache or not,
+	// and of regions that expired or were replaced by a region overlapping
+	// them.
+	hits, misses, expirations, overlaps int
+
+	// The meta lookups made so far, and how long they took.
+	lookups lookupStats
+
+	// The replicas of the cached regions that TIMELINE reads were sent to,
+	// primary first, by name of the region.
+	replicas map[string][]region.Replica
+
+	// How long the regions stay in the cache, if not 0, and when they were
+	// added to it by name.
+	ttl   time.Duration
+	added map[string]time.Time
 }
 
 func (krc *keyRegionCache) get(key []byte) ([]byte, hrpc.RegionInfo) {
@@ -163,6 +277,13 @@ func (krc *keyRegionCache) get(key []byte) ([]byte, hrpc.RegionInfo) {
 		krc.m.Unlock()
 		return nil, nil
 	}
+	if krc.expired(k.([]byte), time.Now()) {
+		// Look up the region again, in case it moved.
+		krc.expirations++
+		krc.m.Unlock()
+		return nil, nil
+	}
+	krc.touch(k.([]byte))
 	krc.m.Unlock()
 	return k.([]byte), v.(hrpc.RegionInfo)
 }
@@ -230,17 +351,24 @@ func (krc *keyRegionCache) put(reg hrpc.RegionInfo) []hrpc.RegionInfo {
 	os := krc.getOverlaps(reg)
 	for _, o := range os {
 		krc.regions.Delete(o.GetName())
+		krc.forget(o.GetName())
+		if !bytes.Equal(o.GetName(), reg.GetName()) {
+			// The region split, merged or otherwise changed.
+			krc.overlaps++
+		}
 	}
 
 	krc.regions.Put(reg.GetName(), func(interface{}, bool) (interface{}, bool) {
 		return reg, true
 	})
-	return os
+	krc.add(reg)
+	return append(os, krc.evict()...)
 }
 
 func (krc *keyRegionCache) del(key []byte) bool {
 	krc.m.Lock()
 	success := krc.regions.Delete(key)
+	krc.forget(key)
 	krc.m.Unlock()
 	return success
 }
@@ -251,6 +379,20 @@ type client struct {
 
 	zkquorum string
 
+	// The ZooKeeper session over which meta or the HMaster is looked up and
+	// watched.
+	zkSession *zk.Session
+
+	// The options of the ZooKeeper session, e.g. how it authenticates.
+	zkOptions []zk.SessionOption
+
+	// The connection registry of the masters that meta or the HMaster is
+	// looked up in instead of ZooKeeper, if any.
+	registry *masterRegistry
+
+	// Used to start watching meta or the HMaster on the first lookup.
+	zkWatchOnce sync.Once
+
 	regions keyRegionCache
 
 	// TODO: document what this protects.
@@ -269,35 +411,211 @@ type client struct {
 
 	// The timeout before flushing the RPC queue in the region client
 	flushInterval time.Duration
+
+	// Decides which replica of a region reads with TIMELINE consistency
+	// are sent to.
+	replicaSelector ReplicaSelector
+
+	// How long a Scanner can stay idle before the lease of its scanner on
+	// the RegionServer gets renewed.
+	scannerRenewInterval time.Duration
+
+	// The scanners currently open on RegionServers.
+	scanners openScanners
+
+	// Ceilings of the scanner watchdog, if not 0.
+	scanMaxDuration time.Duration
+	scanMaxIdle     time.Duration
+
+	// The ID of the cluster this client must connect to, if any.
+	expectedClusterID string
+
+	// Protects clusterID, clusterVerified and clusterErr.
+	clusterLock sync.Mutex
+
+	// The ID of the cluster, once read from ZooKeeper.
+	clusterID string
+
+	// Set once the cluster ID was checked to be the expected one.
+	clusterVerified bool
+
+	// Set if the cluster ID isn't the expected one.
+	clusterErr *ClusterIDMismatchError
+
+	// Estimate of the clock of the RegionServers.
+	serverClock serverClock
+
+	// The connections to the AdminService of the RegionServers.
+	adminClients adminClients
+
+	// The pushbacks of the overloaded RegionServers.
+	overload overloadTracker
+
+	// The backoff of the lookups of the active master.
+	masterFailover masterFailover
+
+	// Look up the regions in meta for every call rather than in the cache.
+	noRegionCache bool
+
+	// Refuse to send mutations.
+	readOnly bool
+
+	// Pack the conditional mutations of the batches in the Multi RPCs.
+	conditionalMultis bool
+
+	// Limits of each operation, if not 0.
+	maxAttempts      int
+	maxOperationTime time.Duration
+
+	// The default timeouts of the RPCs.
+	timeouts Timeouts
+
+	// How the RPCs are sent again after they failed.
+	retry RetryPolicy
+
+	// The options of the region clients, e.g. how they authenticate.
+	regionOptions []region.ClientOption
+
+	// The number of connections to open to each RegionServer, if more
+	// than one.
+	connsPerServer int
+
+	// Whether regions are looked up in the secondary replicas of meta while
+	// its primary replica is unavailable.
+	useMetaReplicas bool
+
+	// The locations of the replicas of meta.
+	metaReplicas metaReplicas
+
+	// How many of the regions that follow a region looked up in meta are
+	// looked up along with it.
+	metaPrefetch int
+
+	// Whether the live RegionServers are watched in ZooKeeper, and whether
+	// the client connects to them as soon as they start.
+	watchRegionServers bool
+	preconnect         bool
+
+	// The live RegionServers, once listed from ZooKeeper.
+	liveServers liveServers
+
+	// Closed when the client is closed.
+	done chan struct{}
+
+	closeOnce sync.Once
 }
 
 // Client a regular HBase client
 type Client interface {
+	RegionLocator
+
+	Connect(ctx context.Context) error
+	Close()
+	ScannerStats() ScannerStats
+	OverloadStats() OverloadStats
+	RegionCacheStats() RegionCacheStats
 	CheckTable(ctx context.Context, table string) error
 	Scan(s *hrpc.Scan) ([]*hrpc.Result, error)
+	Scanner(s *hrpc.Scan) Scanner
+	ScanEach(s *hrpc.Scan, visit func(*hrpc.Result) error) error
+	ParallelScan(s *hrpc.Scan, parallelism int) ([]*hrpc.Result, error)
+	ScanTables(s *hrpc.Scan, tables [][]byte, maxRows, parallelism int) ([]*hrpc.Result, error)
 	Get(g *hrpc.Get) (*hrpc.Result, error)
+	Exists(g *hrpc.Get) (bool, error)
+	GetMulti(ctx context.Context, table []byte, keys [][]byte,
+		options ...func(hrpc.Call) error) ([]*hrpc.Result, error)
+	GetRanges(ctx context.Context, table []byte, ranges [][2][]byte,
+		options ...func(hrpc.Call) error) ([]*hrpc.Result, error)
 	Put(p *hrpc.Mutate) (*hrpc.Result, error)
 	Delete(d *hrpc.Mutate) (*hrpc.Result, error)
 	Append(a *hrpc.Mutate) (*hrpc.Result, error)
 	Increment(i *hrpc.Mutate) (int64, error)
+	IncrementColumns(i *hrpc.Mutate) (map[string]map[string]int64, error)
 	CheckAndPut(p *hrpc.Mutate, family string, qualifier string,
 		expectedValue []byte) (bool, error)
+	CheckAndPutBatch(ctx context.Context, cas []*hrpc.CheckAndPut) ([]bool, error)
+	CheckAndMutate(cam *hrpc.CheckAndMutate) (bool, error)
+	CheckAndMutateBatch(ctx context.Context, cams []*hrpc.CheckAndMutate) ([]bool, error)
+	MutateRow(rm *hrpc.RowMutations) error
+	Batch(ctx context.Context, calls []hrpc.Call) ([]*hrpc.Result, error)
+	GetCompactionState(ctx context.Context, table []byte) (hrpc.CompactionState, error)
+	CompactRegion(cr *hrpc.CompactRegion) error
+	CompactTable(ctx context.Context, table []byte, options ...func(hrpc.Call) error) error
+	FlushRegion(fr *hrpc.FlushRegion) error
+	FlushTable(ctx context.Context, table []byte) error
+	TableRegionCount(ctx context.Context, table []byte) (int, error)
+	TableSplitKeys(ctx context.Context, table []byte) ([][]byte, error)
+	GetTableRegions(ctx context.Context, table []byte) ([]*TableRegion, error)
+	WarmRegions(ctx context.Context, table []byte) error
+	CachedRegions(table []byte) []*TableRegion
+	InvalidateRegion(name []byte)
+	InvalidateTable(table []byte)
+	Grant(g *hrpc.Grant) error
+	Revoke(r *hrpc.Revoke) error
+	GetUserPermissions(g *hrpc.GetUserPermissions) ([]*hrpc.UserPermission, error)
+	SplitRegion(s *hrpc.SplitRegion) error
+	PartitionKeys(ctx context.Context, table []byte, keys [][]byte) ([]*ServerPartition, error)
+	ClusterID(ctx context.Context) (string, error)
+	LiveRegionServers() []hrpc.ServerName
 }
 
 // AdminClient to perform admistrative operations with HMaster
 type AdminClient interface {
+	Connect(ctx context.Context) error
+	Close()
 	CreateTable(t *hrpc.CreateTable) error
 	DeleteTable(t *hrpc.DeleteTable) error
+	TruncateTable(t *hrpc.TruncateTable) error
 	EnableTable(t *hrpc.EnableTable) error
 	DisableTable(t *hrpc.DisableTable) error
+	ListTableNames(t *hrpc.ListTableNames) ([][]byte, error)
+	GetTableDescriptors(t *hrpc.GetTableDescriptors) ([]*hrpc.TableDescriptor, error)
+	ModifyTable(t *hrpc.ModifyTable) error
+	AddColumnFamily(t *hrpc.AddColumnFamily) error
+	ModifyColumnFamily(t *hrpc.ModifyColumnFamily) error
+	DeleteColumnFamily(t *hrpc.DeleteColumnFamily) error
+	CreateNamespace(t *hrpc.CreateNamespace) error
+	ModifyNamespace(t *hrpc.ModifyNamespace) error
+	DeleteNamespace(t *hrpc.DeleteNamespace) error
+	ListNamespaceDescriptors(t *hrpc.ListNamespaceDescriptors) (
+		[]*hrpc.NamespaceDescriptor, error)
+	ListTableNamesByNamespace(t *hrpc.ListTableNamesByNamespace) ([][]byte, error)
+	Snapshot(t *hrpc.Snapshot) error
+	IsSnapshotDone(t *hrpc.IsSnapshotDone) (bool, error)
+	DeleteSnapshot(t *hrpc.DeleteSnapshot) error
+	ListSnapshots(t *hrpc.ListSnapshots) ([]*hrpc.SnapshotDescription, error)
+	RestoreSnapshot(t *hrpc.RestoreSnapshot) error
+	CloneSnapshot(t *hrpc.CloneSnapshot) error
+	MergeTableRegions(t *hrpc.MergeTableRegions) error
+	MoveRegion(t *hrpc.MoveRegion) error
+	AssignRegion(t *hrpc.AssignRegion) error
+	UnassignRegion(t *hrpc.UnassignRegion) error
+	OfflineRegion(t *hrpc.OfflineRegion) error
+	SetBalancerRunning(t *hrpc.SetBalancerRunning) (bool, error)
+	IsBalancerEnabled(t *hrpc.IsBalancerEnabled) (bool, error)
+	Balance(t *hrpc.Balance) (bool, error)
+	SetNormalizerRunning(t *hrpc.SetNormalizerRunning) (bool, error)
+	IsNormalizerEnabled(t *hrpc.IsNormalizerEnabled) (bool, error)
+	Normalize(t *hrpc.Normalize) (bool, error)
+	EnableCatalogJanitor(t *hrpc.EnableCatalogJanitor) (bool, error)
+	IsCatalogJanitorEnabled(t *hrpc.IsCatalogJanitorEnabled) (bool, error)
+	RunCatalogScan(t *hrpc.RunCatalogScan) (int, error)
+	ListRegionServers(t *hrpc.ListRegionServers) ([]*hrpc.RegionServerLoad, error)
+	DecommissionRegionServers(ctx context.Context, servers []hrpc.ServerName, offload bool) error
+	RecommissionRegionServer(ctx context.Context, server hrpc.ServerName) error
+	ListDecommissionedRegionServers(ctx context.Context) ([]hrpc.ServerName, error)
+	ClusterID(ctx context.Context) (string, error)
 }
 
-// NewClient creates a new HBase client.
+// NewClient creates a new HBase client.  It doesn't do any IO: the client
+// bootstraps itself (finds meta in ZooKeeper and connects to it) when it sends
+// its first RPC, unless Connect is called first.
 func NewClient(zkquorum string, options ...Option) Client {
 	return newClient(zkquorum, options...)
 }
 
-// NewAdminClient creates an admin HBase client.
+// NewAdminClient creates an admin HBase client.  Like NewClient, it doesn't
+// connect to the HMaster until its first RPC or a call to Connect.
 func NewAdminClient(zkquorum string, options ...Option) AdminClient {
 	c := newClient(zkquorum, options...)
 	c.clientType = adminClient
@@ -312,22 +630,38 @@ func newClient(zkquorum string, options ...Option) *client {
 		clients: clientRegionCache{
 			regions: make(map[hrpc.RegionClient][]hrpc.RegionInfo),
 		},
-		zkquorum:      zkquorum,
-		rpcQueueSize:  100,
-		flushInterval: 20 * time.Millisecond,
-		metaRegionInfo: &region.Info{
-			Table:   []byte("hbase:meta"),
-			Name:    []byte("hbase:meta,,1"),
-			StopKey: []byte{},
-		},
+		zkquorum:        zkquorum,
+		rpcQueueSize:    100,
+		flushInterval:   20 * time.Millisecond,
+		metaRegionInfo:  newMetaRegionInfo(),
 		adminRegionInfo: &region.Info{},
+		replicaSelector: primaryFirst{},
+		// Half of the default hbase.client.scanner.timeout.period.
+		scannerRenewInterval: 30 * time.Second,
+		scanners:             openScanners{scanners: make(map[*scanner]struct{})},
+		done:                 make(chan struct{}),
 	}
 	for _, option := range options {
 		option(c)
 	}
+	c.zkSession = zk.NewSession(zkquorum, c.zkOptions...)
+	if c.scanMaxDuration > 0 || c.scanMaxIdle > 0 {
+		go c.watchScanners()
+	}
 	return c
 }
 
+// newMetaRegionInfo returns the Info of the primary replica of the meta
+// region.
+func newMetaRegionInfo() *region.Info {
+	return &region.Info{
+		Table:   []byte("hbase:meta"),
+		Name:    []byte("hbase:meta,,1"),
+		ID:      1,
+		StopKey: []byte{},
+	}
+}
+
 // RpcQueueSize will return an option that will set the size of the RPC queues
 // used in a given client
 func RpcQueueSize(size int) Option {
@@ -344,6 +678,16 @@ func FlushInterval(interval time.Duration) Option {
 	}
 }
 
+// ScannerRenewInterval will return an option that sets how long a Scanner can
+// go without the caller calling Next before the client renews the lease of
+// the scanner on the RegionServer.  It should be less than the scanner
+// timeout of the cluster.  An interval of 0 disables lease renewal.
+func ScannerRenewInterval(interval time.Duration) Option {
+	return func(c *client) {
+		c.scannerRenewInterval = interval
+	}
+}
+
 // SetZnodeRoot will return an option that sets the root node of the Zookeeper namespace
 func SetZnodeRoot(name string) Option {
 	return func(c *client) {
@@ -351,6 +695,179 @@ func SetZnodeRoot(name string) Option {
 	}
 }
 
+// ZookeeperSessionTimeout will return an option that sets the timeout of the
+// ZooKeeper session of the client, 30s by default.
+func ZookeeperSessionTimeout(timeout time.Duration) Option {
+	return func(c *client) {
+		c.zkOptions = append(c.zkOptions, zk.SessionTimeout(timeout))
+	}
+}
+
+// ZookeeperReadPolicy will return an option that sets how the client reads
+// the znodes: each read times out after the given timeout unless it's 0, and
+// is attempted up to the given number of times with an exponential backoff
+// starting at the given one.
+func ZookeeperReadPolicy(timeout time.Duration, attempts int, backoff time.Duration) Option {
+	return func(c *client) {
+		c.zkOptions = append(c.zkOptions, zk.ReadPolicy(timeout, attempts, backoff))
+	}
+}
+
+// ZookeeperAuth will return an option that makes the client authenticate to
+// ZooKeeper with the given scheme and credentials before reading the znodes of
+// HBase, when they're protected by ACLs.  For the "digest" scheme, the
+// credentials are like "user:password".  Only the schemes of ZooKeeper's
+// addauth are supported: authenticating with SASL, e.g. with Kerberos, isn't.
+func ZookeeperAuth(scheme, cert string) Option {
+	return func(c *client) {
+		c.zkOptions = append(c.zkOptions, zk.Auth(scheme, cert))
+	}
+}
+
+// DebugProtos will return an option that logs the decoded request and
+// response protobufs of all the RPCs, truncated to maxSize bytes each
+// (region.DefaultMaxLoggedProto if maxSize <= 0) and with their values
+// redacted.  Unlike most options, this setting is shared by all the clients
+// of the process.  Use hrpc.DebugProtos to only log some calls.
+func DebugProtos(maxSize int) Option {
+	return func(c *client) {
+		region.SetProtoLogging(true, maxSize)
+	}
+}
+
+// SetSerializationErrorSink will return an option that sets the function
+// called with the metadata of the RPCs of the client that can't be serialized
+// or whose response can't be decoded, along with the first maxPayload bytes of
+// the offending payload.  region.LogSerializationError can be used to log
+// them.
+func SetSerializationErrorSink(sink func(*region.SerializationError), maxPayload int) Option {
+	return func(c *client) {
+		c.regionOptions = append(c.regionOptions,
+			region.SerializationErrorSink(sink, maxPayload))
+	}
+}
+
+// Application will return an option that sets the name or ID of the
+// application reported, along with the version of GoHBase, to the HBase
+// servers when connecting to them.  Like SetZnodeRoot, it applies to all the
+// clients of the process.
+func Application(name string) Option {
+	return func(c *client) {
+		region.SetApplication(name)
+	}
+}
+
+// EffectiveUser will return an option that sets the user that the client
+// reports to the servers, which run its RPCs as that user, when the cluster
+// uses simple authentication.  The default user is "gopher".
+func EffectiveUser(user string) Option {
+	return func(c *client) {
+		c.regionOptions = append(c.regionOptions, region.EffectiveUser(user))
+	}
+}
+
+// ProxyUser will return an option that makes the servers run the RPCs of the
+// client on behalf of the given user, the way a Hadoop proxy user does.  The
+// user of the client, set with EffectiveUser or Kerberos, must be allowed to
+// impersonate it in the hadoop.proxyuser settings of the cluster.
+func ProxyUser(user string) Option {
+	return func(c *client) {
+		c.regionOptions = append(c.regionOptions, region.ProxyUser(user))
+	}
+}
+
+// TLSConfig will return an option that makes the client connect to the
+// HMaster and RegionServers over TLS, with the given configuration, for
+// clusters whose RPCs are served over TLS or behind TLS proxies.  See
+// region.TLS for how the certificates are checked.
+func TLSConfig(config *tls.Config) Option {
+	return func(c *client) {
+		c.regionOptions = append(c.regionOptions, region.TLS(config))
+	}
+}
+
+// CellBlocks will return an option that makes the servers send the cells of
+// the results in cellblocks rather than in protobuf, like they do for HBase's
+// Java client, which saves a lot of CPU and allocations on large results.  The
+// cells of the mutations are still sent in protobuf, not in cellblocks.
+func CellBlocks() Option {
+	return func(c *client) {
+		c.regionOptions = append(c.regionOptions, region.CellBlocks())
+	}
+}
+
+// CellBlockCompression will return an option that makes the servers send the
+// cells of the results in cellblocks compressed with the given compressor,
+// e.g. region.Gzip, for the clients that read large results over slow links.
+func CellBlockCompression(comp region.Compressor) Option {
+	return func(c *client) {
+		c.regionOptions = append(c.regionOptions, region.CellBlockCompression(comp))
+	}
+}
+
+// Kerberos will return an option that makes the client authenticate to the
+// HMaster and RegionServers of a secure cluster with Kerberos.  The Kerberos
+// tickets are handled by the GSS-API contexts created by k.NewContext.
+func Kerberos(k *region.Kerberos) Option {
+	return func(c *client) {
+		c.regionOptions = append(c.regionOptions, region.KerberosAuth(k))
+	}
+}
+
+// Connect eagerly bootstraps the client: it looks up the meta region (or the
+// HMaster for an admin client) in ZooKeeper and connects to it, so that the
+// first RPC doesn't pay for it.  It returns ErrDeadline if the client couldn't
+// connect before the context is done.
+func (c *client) Connect(ctx context.Context) error {
+	if err := c.clusterError(); err != nil {
+		return err
+	}
+	reg := c.metaRegionInfo
+	if c.clientType == adminClient {
+		reg = c.adminRegionInfo
+	}
+	c.bootstrap(reg)
+	ch := reg.GetAvailabilityChan()
+	if ch == nil {
+		return nil
+	}
+	select {
+	case <-ch:
+		return c.clusterError()
+	case <-ctx.Done():
+		return ErrDeadline
+	}
+}
+
+// Close closes the scanners that are still open on the RegionServers (giving
+// up on the ones that can't be closed within a few seconds), then closes all
+// the connections of the client.  The client must not be used afterwards.
+func (c *client) Close() {
+	c.closeOnce.Do(func() {
+		c.scanners.closeAll(scannerCloseTimeout)
+		close(c.done)
+		c.clients.closeAll()
+		c.adminClients.closeAll()
+		for _, reg := range []hrpc.RegionInfo{c.metaRegionInfo, c.adminRegionInfo} {
+			if client := reg.GetClient(); client != nil {
+				client.Close()
+			}
+		}
+		c.zkSession.Close()
+		if c.registry != nil {
+			c.registry.close()
+		}
+	})
+}
+
+// bootstrap kicks off the goroutine that connects to the given meta or admin
+// region, unless it's already connected or being connected to.
+func (c *client) bootstrap(reg hrpc.RegionInfo) {
+	if reg.GetClient() == nil && !reg.IsUnavailable() && reg.MarkUnavailable() {
+		go c.reestablishRegion(reg)
+	}
+}
+
 // CheckTable returns an error if the given table name doesn't exist.
 func (c *client) CheckTable(ctx context.Context, table string) error {
 	getStr, err := hrpc.NewGetStr(ctx, table, "theKey")
@@ -362,95 +879,259 @@ func (c *client) CheckTable(ctx context.Context, table string) error {
 
 // Scan retrieves the values specified in families from the given range.
 func (c *client) Scan(s *hrpc.Scan) ([]*hrpc.Result, error) {
+	results, err := c.scan(s)
+	if err != nil {
+		return nil, err
+	}
+	return toLocalResults(results, s.GetColumnOrder()), nil
+}
+
+// ParallelScan retrieves the values specified in families from the given
+// range, like Scan, but splits the range along the boundaries of the regions
+// of the table and scans up to `parallelism' regions concurrently.  The
+// results are merged back in row key order.  The other scans are canceled as
+// soon as one fails, and the first error is returned.
+func (c *client) ParallelScan(s *hrpc.Scan, parallelism int) ([]*hrpc.Result, error) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	ranges, err := c.regionRanges(s.GetContext(), s.Table(), s.GetStartRow(), s.GetStopRow())
+	if err != nil {
+		return nil, err
+	}
+
+	perRange := make([][]*pb.Result, len(ranges))
+	err = scanConcurrently(s.GetContext(), len(ranges), parallelism,
+		func(ctx context.Context, i int) error {
+			sub, err := hrpc.NewScanRange(ctx, s.Table(), ranges[i].start, ranges[i].stop,
+				scanOptions(s)...)
+			if err == nil {
+				perRange[i], err = c.scan(sub)
+			}
+			return err
+		})
+	if err != nil {
+		return nil, err
+	}
+
 	var results []*pb.Result
-	var scanres *pb.ScanResponse
-	var rpc *hrpc.Scan
-	ctx := s.GetContext()
-	table := s.Table()
-	families := s.GetFamilies()
-	filters := s.GetFilter()
-	startRow := s.GetStartRow()
-	stopRow := s.GetStopRow()
-	fromTs, toTs := s.GetTimeRange()
-	maxVerions := s.GetMaxVersions()
-	numberOfRows := s.GetNumberOfRows()
-	for {
-		// Make a new Scan RPC for this region
-		if rpc != nil {
-			// If it's not the first region, we want to start at whatever the
-			// last region's StopKey was
-			startRow = rpc.GetRegionStop()
+	for i := range ranges {
+		results = append(results, perRange[i]...)
+	}
+	return toLocalResults(results, s.GetColumnOrder()), nil
+}
+
+// scanConcurrently calls scan for each of the n ranges of a scan, with up to
+// parallelism calls at a time.  The context given to the calls is canceled as
+// soon as one of them fails, and the first error is returned.
+func scanConcurrently(ctx context.Context, n, parallelism int,
+	scan func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var m sync.Mutex
+	var firstErr error
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		// The calls start in the order of their ranges.
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
 		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := scan(ctx, i)
+			<-sem
+			if err != nil {
+				m.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				m.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if firstErr == nil && ctx.Err() != nil {
+		return ErrDeadline
+	}
+	return firstErr
+}
 
-		// TODO: would be nicer to clone it in some way
-		rpc, err := hrpc.NewScanRange(ctx, table, startRow, stopRow,
-			hrpc.Families(families), hrpc.Filters(filters),
-			hrpc.TimeRangeUint64(fromTs, toTs),
-			hrpc.MaxVersions(maxVerions),
-			hrpc.NumberOfRows(numberOfRows))
-		if err != nil {
+// scan sequentially scans all the regions covering the range of the given
+// Scan request and returns the raw results.
+func (c *client) scan(s *hrpc.Scan) ([]*pb.Result, error) {
+	var results []*pb.Result
+	sc := c.newScanner(s, 0)
+	for {
+		r, err := sc.next()
+		if err == io.EOF {
+			return results, nil
+		} else if err != nil {
+			sc.Close()
 			return nil, err
 		}
+		results = append(results, r)
+	}
+}
 
-		res, err := c.sendRPC(rpc)
-		if err != nil {
-			return nil, err
+// cloneScan creates a copy of the given Scan request restricted to the given
+// key range.
+func cloneScan(s *hrpc.Scan, startRow, stopRow []byte) (*hrpc.Scan, error) {
+	return hrpc.NewScanRange(s.GetContext(), s.Table(), startRow, stopRow, scanOptions(s)...)
+}
+
+// scanOptions returns the options to create a copy of the given Scan request.
+func scanOptions(s *hrpc.Scan) []func(hrpc.Call) error {
+	// TODO: would be nicer to clone it in some way
+	fromTs, toTs := s.GetTimeRange()
+	options := []func(hrpc.Call) error{
+		hrpc.Families(s.GetFamilies()), hrpc.Filters(s.GetFilter()),
+		hrpc.TimeRangeUint64(fromTs, toTs),
+		hrpc.ClockSkew(s.GetClockSkew()),
+		hrpc.MaxVersions(s.GetMaxVersions()),
+		hrpc.StoreLimit(s.GetStoreLimit()),
+		hrpc.StoreOffset(s.GetStoreOffset()),
+		hrpc.NumberOfRows(s.GetNumberOfRows()),
+		hrpc.Consistency(s.GetConsistency()),
+		hrpc.LoadColumnFamiliesOnDemand(s.GetLoadColumnFamiliesOnDemand()),
+		hrpc.TrackScanMetrics(s.GetTrackScanMetrics()),
+		hrpc.ColumnOrder(s.GetColumnOrder()),
+		hrpc.NoRegionCache(s.GetNoRegionCache()),
+		hrpc.DebugProtos(s.GetDebugProtos()),
+		hrpc.Authorizations(s.GetAuthorizations()...),
+	}
+	for family, tr := range s.GetColumnFamilyTimeRanges() {
+		options = append(options, hrpc.ColumnFamilyTimeRangeUint64(family, tr[0], tr[1]))
+	}
+	if ranges := s.GetRanges(); len(ranges) != 0 {
+		options = append(options, hrpc.Ranges(ranges))
+	}
+	return options
+}
+
+// markStale flags the results of the given scan response as stale if they
+// were served by a secondary replica of a region.
+func markStale(scanres *pb.ScanResponse) []*pb.Result {
+	if scanres.GetStale() {
+		for _, result := range scanres.Results {
+			result.Stale = proto.Bool(true)
 		}
-		scanres = res.(*pb.ScanResponse)
-		results = append(results, scanres.Results...)
+	}
+	return scanres.Results
+}
+
+// Do we want to be returning a slice of Result objects or should we just
+// put all the Cells into the same Result object?
+func toLocalResults(results []*pb.Result, columnOrder [][2]string) []*hrpc.Result {
+	localResults := make([]*hrpc.Result, len(results))
+	for idx, result := range results {
+		localResults[idx] = hrpc.ToLocalResult(result)
+		localResults[idx].OrderColumns(columnOrder)
+	}
+	return localResults
+}
 
-		// TODO: The more_results field of the ScanResponse object was always
-		// true, so we should figure out if there's a better way to know when
-		// to move on to the next region than making an extra request and
-		// seeing if there were no results
-		for len(scanres.Results) != 0 {
-			rpc = hrpc.NewScanFromID(ctx, table, *scanres.ScannerId, rpc.Key())
+// keyRange is a half-open [start; stop[ range of row keys.  An empty stop key
+// means the end of the table.
+type keyRange struct {
+	start []byte
+	stop  []byte
+}
 
-			res, err = c.sendRPC(rpc)
+// regionRanges splits the [startRow; stopRow[ range of the given table into
+// sub-ranges that each fall within a single region of the table.
+func (c *client) regionRanges(ctx context.Context, table, startRow, stopRow []byte) (
+	[]keyRange, error) {
+	var ranges []keyRange
+	key := startRow
+	for {
+		var reg hrpc.RegionInfo
+		if !c.noRegionCache {
+			reg = c.getRegionFromCache(table, key)
+		}
+		if reg == nil {
+			var err error
+			reg, _, _, err = c.locateRegion(ctx, table, key)
 			if err != nil {
 				return nil, err
 			}
-			scanres = res.(*pb.ScanResponse)
-			results = append(results, scanres.Results...)
 		}
+		regStop := reg.GetStopKey()
+		if len(regStop) == 0 ||
+			len(stopRow) != 0 && bytes.Compare(stopRow, regStop) <= 0 {
+			return append(ranges, keyRange{start: key, stop: stopRow}), nil
+		}
+		ranges = append(ranges, keyRange{start: key, stop: regStop})
+		key = regStop
+	}
+}
 
-		rpc = hrpc.NewCloseFromID(ctx, table, *scanres.ScannerId, rpc.Key())
+func (c *client) Get(g *hrpc.Get) (*hrpc.Result, error) {
+	_, to := g.GetTimeRange()
+	c.serverClock.checkTimeRange(g, to, g.GetClockSkew())
+	for {
+		pbmsg, err := c.sendRPC(g)
 		if err != nil {
 			return nil, err
 		}
-		res, err = c.sendRPC(rpc)
-
-		// Check to see if this region is the last we should scan (either
-		// because (1) it's the last region or (3) because its stop_key is
-		// greater than or equal to the stop_key of this scanner provided
-		// that (2) we're not trying to scan until the end of the table).
-		// (1)
-		if len(rpc.GetRegionStop()) == 0 ||
-			// (2)                (3)
-			len(stopRow) != 0 && bytes.Compare(stopRow, rpc.GetRegionStop()) <= 0 {
-			// Do we want to be returning a slice of Result objects or should we just
-			// put all the Cells into the same Result object?
-			localResults := make([]*hrpc.Result, len(results))
-			for idx, result := range results {
-				localResults[idx] = hrpc.ToLocalResult(result)
-			}
-			return localResults, nil
+
+		r, ok := pbmsg.(*pb.GetResponse)
+		if !ok {
+			return nil, fmt.Errorf("sendRPC returned not a GetResponse")
+		}
+		if !g.IsClosestBefore() || len(r.GetResult().GetCell()) != 0 {
+			return getResult(g, r.Result), nil
 		}
+		// HBase only looks for the closest row before the key in the region
+		// of the key, so keep looking in the previous regions.
+		startKey := g.GetRegion().GetStartKey()
+		if len(startKey) == 0 {
+			return getResult(g, r.Result), nil
+		}
+		g = g.CloneWithKey(closestRowBefore(startKey))
 	}
 }
 
-func (c *client) Get(g *hrpc.Get) (*hrpc.Result, error) {
-	pbmsg, err := c.sendRPC(g)
-	if err != nil {
-		return nil, err
-	}
+// getResult converts the result of the given Get request, ordering its
+// columns as requested.
+func getResult(g *hrpc.Get, pbr *pb.Result) *hrpc.Result {
+	res := hrpc.ToLocalResult(pbr)
+	res.OrderColumns(g.GetColumnOrder())
+	return res
+}
 
-	r, ok := pbmsg.(*pb.GetResponse)
-	if !ok {
-		return nil, fmt.Errorf("sendRPC returned not a GetResponse")
+// closestRowBefore returns a row key right before the given non-empty key.
+// Like in HBase, when the last byte of the key isn't 0, this is only an
+// approximation: rows of more than 9 0xFF bytes after the common prefix are
+// missed.
+func closestRowBefore(key []byte) []byte {
+	last := len(key) - 1
+	if key[last] == 0 {
+		return key[:last]
 	}
+	before := make([]byte, last+1, last+10)
+	copy(before, key)
+	before[last]--
+	return append(before, bytes.Repeat([]byte{0xFF}, 9)...)
+}
 
-	return hrpc.ToLocalResult(r.Result), nil
+// Exists returns whether the row of the given Get request exists, which is
+// cheaper than fetching it when the request was created with NewGetExists.
+func (c *client) Exists(g *hrpc.Get) (bool, error) {
+	res, err := c.Get(g)
+	if err != nil {
+		return false, err
+	}
+	if res.Exists != nil {
+		return *res.Exists, nil
+	}
+	return len(res.Cells) != 0, nil
 }
 
 func (c *client) Put(p *hrpc.Mutate) (*hrpc.Result, error) {
@@ -471,15 +1152,45 @@ func (c *client) Increment(i *hrpc.Mutate) (int64, error) {
 		return 0, err
 	}
 
+	if len(r.Cells) == 0 && !i.GetReturnResults() {
+		return 0, nil
+	}
 	if len(r.Cells) != 1 {
 		return 0, fmt.Errorf("Increment returned %d cells, but we expected exactly one.",
 			len(r.Cells))
 	}
 
+	if len(r.Cells[0].Value) != 8 {
+		return 0, fmt.Errorf("Increment returned a value of %d bytes instead of 8.",
+			len(r.Cells[0].Value))
+	}
 	val := binary.BigEndian.Uint64(r.Cells[0].Value)
 	return int64(val), nil
 }
 
+// IncrementColumns sends the given Increment, which may increment several
+// columns of its row atomically, and returns the incremented values by family
+// and qualifier.
+func (c *client) IncrementColumns(i *hrpc.Mutate) (map[string]map[string]int64, error) {
+	r, err := c.mutate(i)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]map[string]int64)
+	for _, cell := range r.Cells {
+		if len(cell.Value) != 8 {
+			return nil, fmt.Errorf("Increment returned a value of %d bytes instead of 8 for %s:%s.",
+				len(cell.Value), cell.Family, cell.Qualifier)
+		}
+		family := string(cell.Family)
+		if values[family] == nil {
+			values[family] = make(map[string]int64)
+		}
+		values[family][string(cell.Qualifier)] = int64(binary.BigEndian.Uint64(cell.Value))
+	}
+	return values, nil
+}
+
 func (c *client) mutate(m *hrpc.Mutate) (*hrpc.Result, error) {
 	pbmsg, err := c.sendRPC(m)
 	if err != nil {
@@ -491,7 +1202,9 @@ func (c *client) mutate(m *hrpc.Mutate) (*hrpc.Result, error) {
 		return nil, fmt.Errorf("sendRPC returned not a MutateResponse")
 	}
 
-	return hrpc.ToLocalResult(r.Result), nil
+	res := hrpc.ToLocalResult(r.Result)
+	c.serverClock.observe(res, time.Now())
+	return res, nil
 }
 
 func (c *client) CheckAndPut(p *hrpc.Mutate, family string,
@@ -500,8 +1213,25 @@ func (c *client) CheckAndPut(p *hrpc.Mutate, family string,
 	if err != nil {
 		return false, err
 	}
+	return c.checkAndMutate(cas)
+}
+
+// CheckAndMutate applies the mutation of the given CheckAndMutate if its
+// condition holds, and returns whether it was applied.
+func (c *client) CheckAndMutate(cam *hrpc.CheckAndMutate) (bool, error) {
+	return c.checkAndMutate(cam)
+}
 
-	pbmsg, err := c.sendRPC(cas)
+// MutateRow applies all the mutations of the given RowMutations atomically.
+func (c *client) MutateRow(rm *hrpc.RowMutations) error {
+	_, errs := c.sendBatch(rm.GetContext(), []hrpc.Call{rm})
+	return errs[0]
+}
+
+// checkAndMutate sends the given CheckAndPut or CheckAndMutate and returns
+// whether its mutation was applied.
+func (c *client) checkAndMutate(rpc hrpc.Call) (bool, error) {
+	pbmsg, err := c.sendRPC(rpc)
 	if err != nil {
 		return false, err
 	}
@@ -513,14 +1243,14 @@ func (c *client) CheckAndPut(p *hrpc.Mutate, family string,
 
 	if r.Processed == nil {
 		return false, fmt.Errorf("Protobuf in the response didn't contain the field "+
-			"indicating whether the CheckAndPut was successful or not: %s", r)
+			"indicating whether the conditional mutation was applied or not: %s", r)
 	}
 
 	return r.GetProcessed(), nil
 }
 
 func (c *client) checkProcedureWithBackoff(pContext context.Context, procID uint64) error {
-	backoff := backoffStart
+	backoff := c.backoffStart()
 	ctx, cancel := context.WithTimeout(pContext, 30*time.Second)
 	defer cancel()
 
@@ -542,7 +1272,7 @@ func (c *client) checkProcedureWithBackoff(pContext context.Context, procID uint
 		case pb.GetProcedureResultResponse_FINISHED:
 			return nil
 		default:
-			backoff, err = sleepAndIncreaseBackoff(ctx, backoff)
+			backoff, err = c.sleepAndIncreaseBackoff(ctx, backoff)
 			if err != nil {
 				return err
 			}
@@ -550,60 +1280,628 @@ func (c *client) checkProcedureWithBackoff(pContext context.Context, procID uint
 	}
 }
 
-func (c *client) CreateTable(t *hrpc.CreateTable) error {
+// waitForSchemaAlter polls the master until all the regions of the given table
+// were updated with its new schema, or the context expires.
+func (c *client) waitForSchemaAlter(ctx context.Context, table []byte) error {
+	backoff := c.backoffStart()
+	for {
+		pbmsg, err := c.sendRPC(hrpc.NewGetSchemaAlterStatus(ctx, table))
+		if err != nil {
+			return err
+		}
+
+		statusRes, ok := pbmsg.(*pb.GetSchemaAlterStatusResponse)
+		if !ok {
+			return fmt.Errorf("sendRPC returned not a GetSchemaAlterStatusResponse")
+		}
+
+		if statusRes.GetYetToUpdateRegions() == 0 {
+			return nil
+		}
+		backoff, err = c.sleepAndIncreaseBackoff(ctx, backoff)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *client) CreateTable(t *hrpc.CreateTable) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	r, ok := pbmsg.(*pb.CreateTableResponse)
+	if !ok {
+		return fmt.Errorf("sendRPC returned not a CreateTableResponse")
+	}
+
+	return c.checkProcedureWithBackoff(t.GetContext(), r.GetProcId())
+}
+
+func (c *client) DeleteTable(t *hrpc.DeleteTable) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	r, ok := pbmsg.(*pb.DeleteTableResponse)
+	if !ok {
+		return fmt.Errorf("sendRPC returned not a DeleteTableResponse")
+	}
+
+	return c.checkProcedureWithBackoff(t.GetContext(), r.GetProcId())
+}
+
+func (c *client) TruncateTable(t *hrpc.TruncateTable) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	r, ok := pbmsg.(*pb.TruncateTableResponse)
+	if !ok {
+		return fmt.Errorf("sendRPC returned not a TruncateTableResponse")
+	}
+
+	// HBase versions without procedures truncate the table synchronously.
+	if r.ProcId == nil {
+		return nil
+	}
+	return c.checkProcedureWithBackoff(t.GetContext(), r.GetProcId())
+}
+
+func (c *client) EnableTable(t *hrpc.EnableTable) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	r, ok := pbmsg.(*pb.EnableTableResponse)
+	if !ok {
+		return fmt.Errorf("sendRPC returned not a EnableTableResponse")
+	}
+
+	if r.ProcId != nil {
+		err = c.checkProcedureWithBackoff(t.GetContext(), r.GetProcId())
+		if err != nil {
+			return err
+		}
+	}
+	return c.waitForTableState(t.GetContext(), t.Table(), pb.Table_ENABLED)
+}
+
+func (c *client) DisableTable(t *hrpc.DisableTable) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	r, ok := pbmsg.(*pb.DisableTableResponse)
+	if !ok {
+		return fmt.Errorf("sendRPC returned not a DisableTableResponse")
+	}
+
+	if r.ProcId != nil {
+		err = c.checkProcedureWithBackoff(t.GetContext(), r.GetProcId())
+		if err != nil {
+			return err
+		}
+	}
+	return c.waitForTableState(t.GetContext(), t.Table(), pb.Table_DISABLED)
+}
+
+// ModifyTable replaces the schema of a table and waits until all the regions of
+// the table were updated.
+func (c *client) ModifyTable(t *hrpc.ModifyTable) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := pbmsg.(*pb.ModifyTableResponse); !ok {
+		return fmt.Errorf("sendRPC returned not a ModifyTableResponse")
+	}
+
+	return c.waitForSchemaAlter(t.GetContext(), t.Table())
+}
+
+// AddColumnFamily adds a column family to a table and waits until all the
+// regions of the table were updated.
+func (c *client) AddColumnFamily(t *hrpc.AddColumnFamily) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := pbmsg.(*pb.AddColumnResponse); !ok {
+		return fmt.Errorf("sendRPC returned not a AddColumnResponse")
+	}
+
+	return c.waitForSchemaAlter(t.GetContext(), t.Table())
+}
+
+// ModifyColumnFamily replaces the schema of a column family and waits until all
+// the regions of the table were updated.
+func (c *client) ModifyColumnFamily(t *hrpc.ModifyColumnFamily) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := pbmsg.(*pb.ModifyColumnResponse); !ok {
+		return fmt.Errorf("sendRPC returned not a ModifyColumnResponse")
+	}
+
+	return c.waitForSchemaAlter(t.GetContext(), t.Table())
+}
+
+// DeleteColumnFamily deletes a column family from a table and waits until all
+// the regions of the table were updated.
+func (c *client) DeleteColumnFamily(t *hrpc.DeleteColumnFamily) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := pbmsg.(*pb.DeleteColumnResponse); !ok {
+		return fmt.Errorf("sendRPC returned not a DeleteColumnResponse")
+	}
+
+	return c.waitForSchemaAlter(t.GetContext(), t.Table())
+}
+
+// ListTableNames returns the names of the tables selected by the given
+// request, prefixed by their namespace and a colon unless they're in the
+// default namespace.
+func (c *client) ListTableNames(t *hrpc.ListTableNames) ([][]byte, error) {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := pbmsg.(*pb.GetTableNamesResponse)
+	if !ok {
+		return nil, fmt.Errorf("sendRPC returned not a GetTableNamesResponse")
+	}
+
+	names := make([][]byte, len(r.TableNames))
+	for i, tn := range r.TableNames {
+		names[i] = hrpc.FullTableName(tn)
+	}
+	return names, nil
+}
+
+// GetTableDescriptors returns the schemas of the tables selected by the given
+// request.
+func (c *client) GetTableDescriptors(t *hrpc.GetTableDescriptors) (
+	[]*hrpc.TableDescriptor, error) {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := pbmsg.(*pb.GetTableDescriptorsResponse)
+	if !ok {
+		return nil, fmt.Errorf("sendRPC returned not a GetTableDescriptorsResponse")
+	}
+
+	descriptors := make([]*hrpc.TableDescriptor, len(r.TableSchema))
+	for i, schema := range r.TableSchema {
+		descriptors[i] = hrpc.ToLocalTableDescriptor(schema)
+	}
+	return descriptors, nil
+}
+
+func (c *client) CreateNamespace(t *hrpc.CreateNamespace) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := pbmsg.(*pb.CreateNamespaceResponse); !ok {
+		return fmt.Errorf("sendRPC returned not a CreateNamespaceResponse")
+	}
+	return nil
+}
+
+func (c *client) ModifyNamespace(t *hrpc.ModifyNamespace) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := pbmsg.(*pb.ModifyNamespaceResponse); !ok {
+		return fmt.Errorf("sendRPC returned not a ModifyNamespaceResponse")
+	}
+	return nil
+}
+
+func (c *client) DeleteNamespace(t *hrpc.DeleteNamespace) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := pbmsg.(*pb.DeleteNamespaceResponse); !ok {
+		return fmt.Errorf("sendRPC returned not a DeleteNamespaceResponse")
+	}
+	return nil
+}
+
+// ListNamespaceDescriptors returns the descriptors of all the namespaces.
+func (c *client) ListNamespaceDescriptors(t *hrpc.ListNamespaceDescriptors) (
+	[]*hrpc.NamespaceDescriptor, error) {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := pbmsg.(*pb.ListNamespaceDescriptorsResponse)
+	if !ok {
+		return nil, fmt.Errorf("sendRPC returned not a ListNamespaceDescriptorsResponse")
+	}
+
+	descriptors := make([]*hrpc.NamespaceDescriptor, len(r.NamespaceDescriptor))
+	for i, nd := range r.NamespaceDescriptor {
+		descriptors[i] = hrpc.ToLocalNamespaceDescriptor(nd)
+	}
+	return descriptors, nil
+}
+
+// ListTableNamesByNamespace returns the names of the tables of a namespace,
+// prefixed by the namespace and a colon unless it's the default namespace.
+func (c *client) ListTableNamesByNamespace(t *hrpc.ListTableNamesByNamespace) (
+	[][]byte, error) {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := pbmsg.(*pb.ListTableNamesByNamespaceResponse)
+	if !ok {
+		return nil, fmt.Errorf("sendRPC returned not a ListTableNamesByNamespaceResponse")
+	}
+
+	names := make([][]byte, len(r.TableName))
+	for i, tn := range r.TableName {
+		names[i] = hrpc.FullTableName(tn)
+	}
+	return names, nil
+}
+
+// Snapshot takes a snapshot of a table and waits until it's done, or until
+// the context expires or the time the master expects the snapshot to take
+// elapses.
+func (c *client) Snapshot(t *hrpc.Snapshot) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	r, ok := pbmsg.(*pb.SnapshotResponse)
+	if !ok {
+		return fmt.Errorf("sendRPC returned not a SnapshotResponse")
+	}
+
+	ctx := t.GetContext()
+	if timeout := r.GetExpectedTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Millisecond)
+		defer cancel()
+	}
+	backoff := c.backoffStart()
+	for {
+		done, err := c.IsSnapshotDone(hrpc.NewIsSnapshotDone(ctx, t.SnapshotName(), t.Table()))
+		if err != nil {
+			return err
+		} else if done {
+			return nil
+		}
+		backoff, err = c.sleepAndIncreaseBackoff(ctx, backoff)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// IsSnapshotDone returns whether a snapshot was successfully taken.  It
+// returns an error if taking the snapshot failed.
+func (c *client) IsSnapshotDone(t *hrpc.IsSnapshotDone) (bool, error) {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return false, err
+	}
+
+	r, ok := pbmsg.(*pb.IsSnapshotDoneResponse)
+	if !ok {
+		return false, fmt.Errorf("sendRPC returned not a IsSnapshotDoneResponse")
+	}
+	return r.GetDone(), nil
+}
+
+func (c *client) DeleteSnapshot(t *hrpc.DeleteSnapshot) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := pbmsg.(*pb.DeleteSnapshotResponse); !ok {
+		return fmt.Errorf("sendRPC returned not a DeleteSnapshotResponse")
+	}
+	return nil
+}
+
+// ListSnapshots returns the descriptions of all the snapshots.
+func (c *client) ListSnapshots(t *hrpc.ListSnapshots) ([]*hrpc.SnapshotDescription, error) {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := pbmsg.(*pb.GetCompletedSnapshotsResponse)
+	if !ok {
+		return nil, fmt.Errorf("sendRPC returned not a GetCompletedSnapshotsResponse")
+	}
+
+	descriptions := make([]*hrpc.SnapshotDescription, len(r.Snapshots))
+	for i, sd := range r.Snapshots {
+		descriptions[i] = hrpc.ToLocalSnapshotDescription(sd)
+	}
+	return descriptions, nil
+}
+
+// RestoreSnapshot restores a table to one of its snapshots and waits until
+// it's done or the context expires.
+func (c *client) RestoreSnapshot(t *hrpc.RestoreSnapshot) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := pbmsg.(*pb.RestoreSnapshotResponse); !ok {
+		return fmt.Errorf("sendRPC returned not a RestoreSnapshotResponse")
+	}
+	return c.waitForRestore(t.GetContext(), t.SnapshotName(), t.Table())
+}
+
+// CloneSnapshot creates a table from a snapshot and waits until it's done or
+// the context expires.
+func (c *client) CloneSnapshot(t *hrpc.CloneSnapshot) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := pbmsg.(*pb.RestoreSnapshotResponse); !ok {
+		return fmt.Errorf("sendRPC returned not a RestoreSnapshotResponse")
+	}
+	return c.waitForRestore(t.GetContext(), t.SnapshotName(), t.Table())
+}
+
+// waitForRestore polls the master until the given table was restored to, or
+// cloned from, the given snapshot, or the context expires.
+func (c *client) waitForRestore(ctx context.Context, snapshot string, table []byte) error {
+	backoff := c.backoffStart()
+	for {
+		pbmsg, err := c.sendRPC(hrpc.NewIsRestoreSnapshotDone(ctx, snapshot, table))
+		if err != nil {
+			return err
+		}
+
+		r, ok := pbmsg.(*pb.IsRestoreSnapshotDoneResponse)
+		if !ok {
+			return fmt.Errorf("sendRPC returned not a IsRestoreSnapshotDoneResponse")
+		}
+
+		if r.GetDone() {
+			return nil
+		}
+		backoff, err = c.sleepAndIncreaseBackoff(ctx, backoff)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// MergeTableRegions asks the master to merge two regions.  The merge happens
+// asynchronously, after this returns.
+func (c *client) MergeTableRegions(t *hrpc.MergeTableRegions) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := pbmsg.(*pb.DispatchMergingRegionsResponse); !ok {
+		return fmt.Errorf("sendRPC returned not a DispatchMergingRegionsResponse")
+	}
+	return nil
+}
+
+// MoveRegion asks the master to move a region to another RegionServer.  The
+// move happens asynchronously, after this returns.
+func (c *client) MoveRegion(t *hrpc.MoveRegion) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := pbmsg.(*pb.MoveRegionResponse); !ok {
+		return fmt.Errorf("sendRPC returned not a MoveRegionResponse")
+	}
+	return nil
+}
+
+// AssignRegion asks the master to assign a region to a RegionServer.
+func (c *client) AssignRegion(t *hrpc.AssignRegion) error {
 	pbmsg, err := c.sendRPC(t)
 	if err != nil {
 		return err
 	}
 
-	r, ok := pbmsg.(*pb.CreateTableResponse)
-	if !ok {
-		return fmt.Errorf("sendRPC returned not a CreateTableResponse")
+	if _, ok := pbmsg.(*pb.AssignRegionResponse); !ok {
+		return fmt.Errorf("sendRPC returned not a AssignRegionResponse")
 	}
+	return nil
+}
 
-	return c.checkProcedureWithBackoff(t.GetContext(), r.GetProcId())
+// UnassignRegion asks the master to close a region.
+func (c *client) UnassignRegion(t *hrpc.UnassignRegion) error {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := pbmsg.(*pb.UnassignRegionResponse); !ok {
+		return fmt.Errorf("sendRPC returned not a UnassignRegionResponse")
+	}
+	return nil
 }
 
-func (c *client) DeleteTable(t *hrpc.DeleteTable) error {
+// OfflineRegion asks the master to mark a region as offline.
+func (c *client) OfflineRegion(t *hrpc.OfflineRegion) error {
 	pbmsg, err := c.sendRPC(t)
 	if err != nil {
 		return err
 	}
 
-	r, ok := pbmsg.(*pb.DeleteTableResponse)
+	if _, ok := pbmsg.(*pb.OfflineRegionResponse); !ok {
+		return fmt.Errorf("sendRPC returned not a OfflineRegionResponse")
+	}
+	return nil
+}
+
+// SetBalancerRunning turns the balancer on or off, and returns whether it was
+// on before.
+func (c *client) SetBalancerRunning(t *hrpc.SetBalancerRunning) (bool, error) {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return false, err
+	}
+
+	r, ok := pbmsg.(*pb.SetBalancerRunningResponse)
 	if !ok {
-		return fmt.Errorf("sendRPC returned not a DeleteTableResponse")
+		return false, fmt.Errorf("sendRPC returned not a SetBalancerRunningResponse")
 	}
+	return r.GetPrevBalanceValue(), nil
+}
 
-	return c.checkProcedureWithBackoff(t.GetContext(), r.GetProcId())
+// IsBalancerEnabled returns whether the balancer is on.
+func (c *client) IsBalancerEnabled(t *hrpc.IsBalancerEnabled) (bool, error) {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return false, err
+	}
+
+	r, ok := pbmsg.(*pb.IsBalancerEnabledResponse)
+	if !ok {
+		return false, fmt.Errorf("sendRPC returned not a IsBalancerEnabledResponse")
+	}
+	return r.GetEnabled(), nil
 }
 
-func (c *client) EnableTable(t *hrpc.EnableTable) error {
+// Balance runs the balancer, and returns whether it ran.  The regions it moves
+// are moved asynchronously, after this returns.
+func (c *client) Balance(t *hrpc.Balance) (bool, error) {
 	pbmsg, err := c.sendRPC(t)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	r, ok := pbmsg.(*pb.EnableTableResponse)
+	r, ok := pbmsg.(*pb.BalanceResponse)
 	if !ok {
-		return fmt.Errorf("sendRPC returned not a EnableTableResponse")
+		return false, fmt.Errorf("sendRPC returned not a BalanceResponse")
 	}
+	return r.GetBalancerRan(), nil
+}
 
-	return c.checkProcedureWithBackoff(t.GetContext(), r.GetProcId())
+// SetNormalizerRunning turns the region normalizer on or off, and returns
+// whether it was on before.
+func (c *client) SetNormalizerRunning(t *hrpc.SetNormalizerRunning) (bool, error) {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return false, err
+	}
+
+	r, ok := pbmsg.(*pb.SetNormalizerRunningResponse)
+	if !ok {
+		return false, fmt.Errorf("sendRPC returned not a SetNormalizerRunningResponse")
+	}
+	return r.GetPrevNormalizerValue(), nil
 }
 
-func (c *client) DisableTable(t *hrpc.DisableTable) error {
+// IsNormalizerEnabled returns whether the region normalizer is on.
+func (c *client) IsNormalizerEnabled(t *hrpc.IsNormalizerEnabled) (bool, error) {
 	pbmsg, err := c.sendRPC(t)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	r, ok := pbmsg.(*pb.DisableTableResponse)
+	r, ok := pbmsg.(*pb.IsNormalizerEnabledResponse)
 	if !ok {
-		return fmt.Errorf("sendRPC returned not a DisableTableResponse")
+		return false, fmt.Errorf("sendRPC returned not a IsNormalizerEnabledResponse")
 	}
+	return r.GetEnabled(), nil
+}
 
-	return c.checkProcedureWithBackoff(t.GetContext(), r.GetProcId())
+// Normalize runs the region normalizer, and returns whether it ran.  The
+// regions it splits and merges are split and merged asynchronously, after
+// this returns.
+func (c *client) Normalize(t *hrpc.Normalize) (bool, error) {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return false, err
+	}
+
+	r, ok := pbmsg.(*pb.NormalizeResponse)
+	if !ok {
+		return false, fmt.Errorf("sendRPC returned not a NormalizeResponse")
+	}
+	return r.GetNormalizerRan(), nil
+}
+
+// EnableCatalogJanitor turns the catalog janitor on or off, and returns
+// whether it was on before.
+func (c *client) EnableCatalogJanitor(t *hrpc.EnableCatalogJanitor) (bool, error) {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return false, err
+	}
+
+	r, ok := pbmsg.(*pb.EnableCatalogJanitorResponse)
+	if !ok {
+		return false, fmt.Errorf("sendRPC returned not a EnableCatalogJanitorResponse")
+	}
+	return r.GetPrevValue(), nil
+}
+
+// IsCatalogJanitorEnabled returns whether the catalog janitor is on.
+func (c *client) IsCatalogJanitorEnabled(t *hrpc.IsCatalogJanitorEnabled) (bool, error) {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return false, err
+	}
+
+	r, ok := pbmsg.(*pb.IsCatalogJanitorEnabledResponse)
+	if !ok {
+		return false, fmt.Errorf("sendRPC returned not a IsCatalogJanitorEnabledResponse")
+	}
+	return r.GetValue(), nil
+}
+
+// RunCatalogScan runs the catalog janitor, and returns the number of regions
+// it cleaned up.
+func (c *client) RunCatalogScan(t *hrpc.RunCatalogScan) (int, error) {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return 0, err
+	}
+
+	r, ok := pbmsg.(*pb.RunCatalogScanResponse)
+	if !ok {
+		return 0, fmt.Errorf("sendRPC returned not a RunCatalogScanResponse")
+	}
+	return int(r.GetScanResult()), nil
 }
 
 // Could be removed in favour of above
@@ -622,177 +1920,330 @@ func (c *client) SendRPC(rpc hrpc.Call) (*hrpc.Result, error) {
 }
 
 func (c *client) sendRPC(rpc hrpc.Call) (proto.Message, error) {
+	if err := c.checkWritable(rpc); err != nil {
+		return nil, err
+	}
+	ctx, cancel := c.operationContext(rpc.GetContext())
+	defer cancel()
+	return c.routeRPC(ctx, rpc)
+}
+
+// routeRPC sends the given RPC to its region, until it succeeds or the
+// context of the operation is done.  The RPC is routed again, through the
+// cache, every time that its region became available again after it failed.
+func (c *client) routeRPC(ctx context.Context, rpc hrpc.Call) (proto.Message, error) {
+	for {
+		msg, err := c.routeRPCOnce(ctx, rpc)
+		if err != errRouteAgain {
+			return msg, err
+		}
+	}
+}
+
+// routeRPCOnce sends the given RPC to its region, and returns errRouteAgain if
+// it must be routed again.
+func (c *client) routeRPCOnce(ctx context.Context, rpc hrpc.Call) (proto.Message, error) {
+	if err := c.clusterError(); err != nil {
+		return nil, err
+	}
+	if get, ok := rpc.(*hrpc.Get); ok && c.clientType == standardClient {
+		if id, ok := get.GetReplicaID(); ok && !bytes.Equal(rpc.Table(), metaTableName) {
+			return c.sendReplicaRPC(ctx, get, id)
+		}
+	}
+	if c.clientType == standardClient && isTimelineRead(rpc) &&
+		!bytes.Equal(rpc.Table(), metaTableName) {
+		return c.sendTimelineRPC(ctx, rpc)
+	}
+	if c.bypassRegionCache(rpc) {
+		return c.sendUncachedRPC(ctx, rpc)
+	}
 	// Check the cache for a region that can handle this request
 	reg := c.getRegionFromCache(rpc.Table(), rpc.Key())
 	if reg != nil {
-		return c.sendRPCToRegion(rpc, reg)
+		return c.sendRPCToRegion(ctx, rpc, reg)
 	} else {
-		return c.findRegionForRPC(rpc)
+		return c.findRegionForRPC(ctx, rpc)
 	}
 }
 
-func (c *client) sendRPCToRegion(rpc hrpc.Call, reg hrpc.RegionInfo) (proto.Message, error) {
-	client := reg.GetClient()
+func (c *client) sendRPCToRegion(ctx context.Context, rpc hrpc.Call,
+	reg hrpc.RegionInfo) (proto.Message, error) {
 	// On the first sendRPC to the meta or admin regions, a goroutine must be
 	// manually kicked off for the meta or admin region client
-	if reg == c.adminRegionInfo && client == nil && !c.adminRegionInfo.IsUnavailable() ||
-		reg == c.metaRegionInfo && client == nil && !c.metaRegionInfo.IsUnavailable() {
-		if reg.MarkUnavailable() {
-			go c.reestablishRegion(reg)
-		}
-	}
-	// The region was in the cache, check
-	// if the region is marked as available
-	if reg.IsUnavailable() {
-		return c.waitOnRegion(rpc, reg)
-	}
-
-	rpc.SetRegion(reg)
-
-	// Queue the RPC to be sent to the region
-	var err error
-	if client == nil {
-		err = errors.New("no client for this region")
-	} else {
-		err = client.QueueRPC(rpc)
+	if reg == c.adminRegionInfo || reg == c.metaRegionInfo {
+		c.bootstrap(reg)
 	}
+	// The RPC is sent again to the same region as long as its RegionServer
+	// pushes back, up to maxPushbackRetries times.
+	for pushbacks := 0; ; pushbacks++ {
+		client := reg.GetClient()
+		// The region was in the cache, check
+		// if the region is marked as available
+		if reg.IsUnavailable() {
+			return c.waitOnRegion(ctx, reg)
+		}
 
-	if err != nil {
-		// There was an error queueing the RPC.
-		// Mark the region as unavailable.
-		first := reg.MarkUnavailable()
-		// If this was the first goroutine to mark the region as
-		// unavailable, start a goroutine to reestablish a connection
-		if first {
-			go c.reestablishRegion(reg)
+		rpc.SetRegion(reg)
+		if err := c.attempt(ctx); err != nil {
+			return nil, err
 		}
-		// Block until the region becomes available.
-		return c.waitOnRegion(rpc, reg)
-	}
 
-	// Wait for the response
-	var res hrpc.RPCResult
-	select {
-	case res = <-rpc.GetResultChan():
-	case <-rpc.GetContext().Done():
-		return nil, ErrDeadline
-	}
+		// Queue the RPC to be sent to the region
+		timeout, stop := c.callTimer(rpc, reg)
+		var err error
+		if client == nil {
+			err = errNoClient
+		} else {
+			err = client.QueueRPC(rpc)
+		}
 
-	// Check for errors
-	if _, ok := res.Error.(region.RetryableError); ok {
-		// There's an error specific to this region, but
-		// our region client is fine. Mark this region as
-		// unavailable (as opposed to all regions sharing
-		// the client), and start a goroutine to reestablish
-		// it.
-		first := reg.MarkUnavailable()
-		if first {
-			go c.reestablishRegion(reg)
+		if err != nil {
+			stop()
+			// There was an error queueing the RPC.
+			// Mark the region as unavailable.
+			first := reg.MarkUnavailable()
+			// If this was the first goroutine to mark the region as
+			// unavailable, start a goroutine to reestablish a connection
+			if first {
+				go c.reestablishRegion(reg)
+			}
+			if err := c.retryAfter(ctx, ConnectionError, err); err != nil {
+				return nil, err
+			}
+			// Block until the region becomes available.
+			return c.waitOnRegion(ctx, reg)
 		}
-		if reg != c.metaRegionInfo && reg != c.adminRegionInfo {
-			// The client won't be in the cache if this is the
-			// meta or admin region
-			c.clients.del(reg)
+
+		// Wait for the response
+		var res hrpc.RPCResult
+		select {
+		case res = <-rpc.GetResultChan():
+			stop()
+		case <-timeout:
+			forget(client, rpc)
+			return nil, ErrCallTimeout
+		case <-ctx.Done():
+			stop()
+			return nil, ErrDeadline
 		}
-		return c.waitOnRegion(rpc, reg)
-	} else if _, ok := res.Error.(region.UnrecoverableError); ok {
-		// If it was an unrecoverable error, the region client is
-		// considered dead.
-		if reg == c.metaRegionInfo || reg == c.adminRegionInfo {
-			// If this is the admin client or the meta table, mark the
-			// region as unavailable and start up a goroutine to
-			// reconnect if it wasn't already marked as such.
+
+		// Check for errors
+		if _, ok := res.Error.(region.RetryableError); ok {
+			// There's an error specific to this region, but
+			// our region client is fine. Mark this region as
+			// unavailable (as opposed to all regions sharing
+			// the client), and start a goroutine to reestablish
+			// it.
 			first := reg.MarkUnavailable()
 			if first {
 				go c.reestablishRegion(reg)
 			}
+			if reg != c.metaRegionInfo && reg != c.adminRegionInfo {
+				// The client won't be in the cache if this is the
+				// meta or admin region
+				c.clients.del(reg)
+			}
+			if err := c.retryAfter(ctx, RegionError, res.Error); err != nil {
+				return nil, err
+			}
+			return c.waitOnRegion(ctx, reg)
+		} else if isOverloaded(res.Error) {
+			// The RegionServer is fine but too busy to take the RPC: back
+			// off before sending it there again.
+			if pushbacks == maxPushbackRetries {
+				return nil, res.Error
+			}
+			if err := c.retryAfter(ctx, OverloadedError, res.Error); err != nil {
+				return nil, err
+			}
+			if err := c.backOffOverloaded(ctx, rpc, reg, res.Error); err != nil {
+				return nil, err
+			}
+			continue
+		} else if reg == c.adminRegionInfo && isNotRunning(res.Error) {
+			// The master isn't the active one anymore, or not yet.
+			return c.masterNotRunning(ctx, rpc, client, res.Error)
+		} else if _, ok := res.Error.(region.UnrecoverableError); ok {
+			// If it was an unrecoverable error, the region client is
+			// considered dead.
+			if reg == c.metaRegionInfo || reg == c.adminRegionInfo {
+				// If this is the admin client or the meta table, mark
+				// the region as unavailable and start up a goroutine
+				// to reconnect if it wasn't already marked as such.
+				first := reg.MarkUnavailable()
+				if first {
+					go c.reestablishRegion(reg)
+				}
+			} else {
+				// Else this is a normal region. Mark all the regions
+				// sharing this region's client as unavailable, and
+				// start a goroutine to reconnect for each of them.
+				downregions := c.clients.clientDown(reg)
+				for _, downreg := range downregions {
+					go c.reestablishRegion(downreg)
+				}
+			}
+			if err := c.retryAfter(ctx, ConnectionError, res.Error); err != nil {
+				return nil, err
+			}
+
+			// Fall through to the case of the region being
+			// unavailable, which will result in blocking until it's
+			// available again.
+			return c.waitOnRegion(ctx, reg)
 		} else {
-			// Else this is a normal region. Mark all the regions
-			// sharing this region's client as unavailable, and start
-			// a goroutine to reconnect for each of them.
-			downregions := c.clients.clientDown(reg)
-			for _, downreg := range downregions {
-				go c.reestablishRegion(downreg)
+			// RPC was successfully sent, or an unknown type of error
+			// occurred. In either case, return the results.
+			if reg == c.adminRegionInfo && res.Error == nil {
+				c.masterFailover.reset()
 			}
+			return res.Msg, res.Error
 		}
+	}
+}
 
-		// Fall through to the case of the region being unavailable,
-		// which will result in blocking until it's available again.
-		return c.waitOnRegion(rpc, reg)
-	} else {
-		// RPC was successfully sent, or an unknown type of error
-		// occurred. In either case, return the results.
-		return res.Msg, res.Error
+// sendRPCDirect sends the given RPC to the given region (or region replica),
+// without trying to relocate the region if it's not available, and waits for
+// its response until the given context is done.  This is used for the RPCs
+// that only make sense on a specific RegionServer, such as the ones that fetch
+// more rows from an open scanner.
+func (c *client) sendRPCDirect(ctx context.Context, rpc hrpc.Call,
+	reg hrpc.RegionInfo) (proto.Message, error) {
+	rpc.SetRegion(reg)
+	client := reg.GetClient()
+	if client == nil {
+		return nil, errNoClient
+	}
+	var res hrpc.RPCResult
+	timeout, stop := c.callTimer(rpc, reg)
+	defer stop()
+	err := client.QueueRPC(rpc)
+	if err == nil {
+		select {
+		case res = <-rpc.GetResultChan():
+		case <-timeout:
+			forget(client, rpc)
+			return nil, ErrCallTimeout
+		case <-ctx.Done():
+			return nil, ErrDeadline
+		}
+		_, unrecoverable := res.Error.(region.UnrecoverableError)
+		if !unrecoverable {
+			return res.Msg, res.Error
+		}
+		err = res.Error
 	}
+	// The region client is dead, make all the regions it was serving
+	// find a new one.
+	for _, downreg := range c.clients.clientDown(reg) {
+		go c.reestablishRegion(downreg)
+	}
+	return nil, err
 }
 
-func (c *client) waitOnRegion(rpc hrpc.Call, reg hrpc.RegionInfo) (proto.Message, error) {
+// waitOnRegion waits for the given region to be available again, and returns
+// errRouteAgain once it is, for routeRPC to send the RPC again.
+func (c *client) waitOnRegion(ctx context.Context, reg hrpc.RegionInfo) (proto.Message, error) {
 	ch := reg.GetAvailabilityChan()
 	if ch == nil {
 		// WTF, this region is available? Maybe it was marked as such
 		// since waitOnRegion was called.
-		return c.sendRPC(rpc)
+		return nil, errRouteAgain
 	}
 	// The region is unavailable. Wait for it to become available,
 	// or for the deadline to be exceeded.
 	select {
 	case <-ch:
-		return c.sendRPC(rpc)
-	case <-rpc.GetContext().Done():
+		return nil, errRouteAgain
+	case <-ctx.Done():
 		return nil, ErrDeadline
 	}
 }
 
-func (c *client) findRegionForRPC(rpc hrpc.Call) (proto.Message, error) {
-	// The region was not in the cache, it
-	// must be looked up in the meta table
+func (c *client) findRegionForRPC(ctx context.Context, rpc hrpc.Call) (proto.Message, error) {
+	reg, err := c.findRegion(ctx, rpc.Table(), rpc.Key())
+	if err != nil {
+		return nil, err
+	}
+	return c.sendRPCToRegion(ctx, rpc, reg)
+}
 
-	backoff := backoffStart
-	ctx := rpc.GetContext()
+// findRegion returns the region that hosts the given row key of the given
+// table, looking it up in the meta table if it's not in the cache, once it's
+// available.
+func (c *client) findRegion(ctx context.Context, table, key []byte) (hrpc.RegionInfo, error) {
+	if c.noRegionCache && c.clientType == standardClient && !bytes.Equal(table, metaTableName) {
+		return c.findRegionUncached(ctx, table, key)
+	}
+	backoff := c.backoffStart()
 	for {
-		// Look up the region in the meta table
-		reg, host, port, err := c.locateRegion(ctx, rpc.Table(), rpc.Key())
-
-		if err != nil {
-			if err == TableNotFound {
-				return nil, err
-			}
-			// There was an error with the meta table. Let's sleep for some
-			// backoff amount and retry.
-			backoff, err = sleepAndIncreaseBackoff(ctx, backoff)
+		reg := c.getRegionFromCache(table, key)
+		if reg == nil {
+			// The region was not in the cache, it
+			// must be looked up in the meta table
+			newReg, host, port, err := c.locateRegion(ctx, table, key)
 			if err != nil {
-				return nil, err
+				if err == TableNotFound || err == ErrCallTimeout {
+					return nil, err
+				}
+				// There was an error with the meta table. Let's sleep for some
+				// backoff amount and retry.
+				backoff, err = c.sleepAndIncreaseBackoff(ctx, backoff)
+				if err != nil {
+					return nil, err
+				}
+				continue
 			}
-			continue
-		}
 
-		// Check that the region wasn't added to
-		// the cache while we were looking it up.
-		c.regionsLock.Lock()
-
-		if existing := c.getRegionFromCache(rpc.Table(), rpc.Key()); existing != nil {
-			// The region was added to the cache while we were looking it
-			// up. Send the RPC to the region that was in the cache.
+			// Check that the region wasn't added to
+			// the cache while we were looking it up.
+			c.regionsLock.Lock()
+			if reg = c.getRegionFromCache(table, key); reg == nil {
+				reg = newReg
+				c.cacheRegion(reg, host, port)
+				if c.metaPrefetch > 0 {
+					go c.prefetchRegions(reg)
+				}
+			}
 			c.regionsLock.Unlock()
-			return c.sendRPCToRegion(rpc, existing)
 		}
 
-		// The region wasn't added to the cache while we were looking it
-		// up. Mark this one as unavailable and add it to the cache.
-		reg.MarkUnavailable()
-		removed := c.regions.put(reg)
-		for _, r := range removed {
-			c.clients.del(r)
+		ch := reg.GetAvailabilityChan()
+		if ch == nil {
+			return reg, nil
 		}
+		// Wait for the region to become available and check the cache
+		// again, as the region may be gone by then (e.g. if the table was
+		// deleted).
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return nil, ErrDeadline
+		}
+	}
+}
 
-		c.regionsLock.Unlock()
-
-		// Start a goroutine to connect to the region
-		go c.establishRegion(reg, host, port)
+// cacheRegion marks the given region, just located in meta, as unavailable
+// and adds it to the cache, then starts a goroutine to connect to it.  It
+// must be called with regionsLock held.
+func (c *client) cacheRegion(reg hrpc.RegionInfo, host string, port uint16) {
+	reg.MarkUnavailable()
+	c.uncacheRegions(c.regions.put(reg))
+	go c.establishRegion(reg, host, port)
+}
 
-		// Wait for the new region to become
-		// available, and then send the RPC
-		return c.waitOnRegion(rpc, reg)
+// uncacheRegions removes the given regions, just removed from the region
+// cache, from the clients cache.  When the region cache is bounded, so is the
+// clients cache: the connections to the RegionServers left without any cached
+// region are closed.
+func (c *client) uncacheRegions(removed []hrpc.RegionInfo) {
+	for _, r := range removed {
+		if c.regions.maxSize == 0 {
+			c.clients.del(r)
+		} else if idle := c.clients.evict(r); idle != nil {
+			idle.Close()
+		}
 	}
 }
 
@@ -806,6 +2257,7 @@ func (c *client) getRegionFromCache(table, key []byte) hrpc.RegionInfo {
 	regionName := createRegionSearchKey(table, key)
 	_, region := c.regions.get(regionName)
 	if region == nil || !bytes.Equal(table, region.GetTable()) {
+		c.regions.countLookup(false)
 		return nil
 	}
 
@@ -813,9 +2265,11 @@ func (c *client) getRegionFromCache(table, key []byte) hrpc.RegionInfo {
 		// If the stop key is an empty byte array, it means this region is the
 		// last region for this table and this key ought to be in that region.
 		bytes.Compare(key, region.GetStopKey()) >= 0 {
+		c.regions.countLookup(false)
 		return nil
 	}
 
+	c.regions.countLookup(true)
 	return region
 }
 
@@ -836,11 +2290,50 @@ func createRegionSearchKey(table, key []byte) []byte {
 // Locates the region in which the given row key for the given table is.
 func (c *client) locateRegion(ctx context.Context,
 	table, key []byte) (hrpc.RegionInfo, string, uint16, error) {
+	metaRow, err := c.metaLookup(ctx, table, key)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	reg, host, port, err := region.ParseRegionInfo(metaRow)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if err := checkMetaEntry(table, key, reg); err != nil {
+		return nil, "", 0, err
+	}
+	return reg, host, port, nil
+}
+
+// Looks up the row of the meta table that describes the region in which the
+// given row key for the given table is, and records how long it took.
+func (c *client) metaLookup(ctx context.Context,
+	table, key []byte) (*pb.GetResponse, error) {
+	start := time.Now()
+	resp, err := c.sendMetaLookup(ctx, table, key)
+	c.regions.recordLookup(time.Since(start), err)
+	return resp, err
+}
+
+func (c *client) sendMetaLookup(ctx context.Context,
+	table, key []byte) (*pb.GetResponse, error) {
 
+	// The row of the region is the closest one before the search key, which
+	// a reversed scan stopping before the first row of the table finds.
 	metaKey := createRegionSearchKey(table, key)
-	rpc, err := hrpc.NewGetBefore(ctx, metaTableName, metaKey, hrpc.Families(infoFamily))
+	stop := append(append([]byte(nil), table...), ',')
+	rpc, err := hrpc.NewSmallScanRange(ctx, metaTableName, metaKey, stop, true,
+		hrpc.Families(infoFamily), hrpc.NumberOfRows(1))
 	if err != nil {
-		return nil, "", 0, err
+		return nil, err
+	}
+	if c.useMetaReplicas && c.metaRegionInfo.IsUnavailable() {
+		// Rather than waiting for the primary replica of meta to be
+		// available again, ask the secondary ones.
+		resp, err := c.sendTimelineRPC(ctx, rpc)
+		if err == nil {
+			return checkMetaRow(resp)
+		}
+		log.Infof("Failed to look up %q in the replicas of meta: %s", metaKey, err)
 	}
 	rpc.SetRegion(c.metaRegionInfo)
 	resp, err := c.sendRPC(rpc)
@@ -850,35 +2343,42 @@ func (c *client) locateRegion(ctx context.Context,
 		if ch != nil {
 			select {
 			case <-ch:
-				return c.locateRegion(ctx, table, key)
+				return c.sendMetaLookup(ctx, table, key)
 			case <-rpc.GetContext().Done():
-				return nil, "", 0, ErrDeadline
+				return nil, ErrDeadline
 			}
 		} else {
-			return nil, "", 0, err
+			return nil, err
 		}
 	}
 
-	metaRow := resp.(*pb.GetResponse)
-	if metaRow.Result == nil {
-		return nil, "", 0, TableNotFound
-	}
+	return checkMetaRow(resp)
+}
 
-	reg, host, port, err := region.ParseRegionInfo(metaRow)
-	if err != nil {
-		return nil, "", 0, err
+// checkMetaRow returns the row of meta returned by a lookup, or TableNotFound
+// if there's none.
+func checkMetaRow(resp proto.Message) (*pb.GetResponse, error) {
+	results := resp.(*pb.ScanResponse).GetResults()
+	if len(results) == 0 {
+		return nil, TableNotFound
 	}
+	return &pb.GetResponse{Result: results[0]}, nil
+}
+
+// checkMetaEntry returns an error if the region that meta returned for the
+// given table and row key doesn't actually contain that row key.
+func checkMetaEntry(table, key []byte, reg hrpc.RegionInfo) error {
 	if !bytes.Equal(table, reg.GetTable()) {
 		// This would indicate a bug in HBase.
-		return nil, "", 0, fmt.Errorf("WTF: Meta returned an entry for the wrong table!"+
+		return fmt.Errorf("WTF: Meta returned an entry for the wrong table!"+
 			"  Looked up table=%q key=%q got region=%s", table, key, reg)
 	} else if len(reg.GetStopKey()) != 0 &&
 		bytes.Compare(key, reg.GetStopKey()) >= 0 {
 		// This would indicate a hole in the meta table.
-		return nil, "", 0, fmt.Errorf("WTF: Meta returned an entry for the wrong region!"+
+		return fmt.Errorf("WTF: Meta returned an entry for the wrong region!"+
 			"  Looked up table=%q key=%q got region=%s", table, key, reg)
 	}
-	return reg, host, port, nil
+	return nil
 }
 
 func (c *client) reestablishRegion(reg hrpc.RegionInfo) {
@@ -888,9 +2388,15 @@ func (c *client) reestablishRegion(reg hrpc.RegionInfo) {
 func (c *client) establishRegion(originalReg hrpc.RegionInfo, host string, port uint16) {
 	var err error
 	reg := originalReg
-	backoff := backoffStart
+	backoff := c.backoffStart()
 
 	for {
+		select {
+		case <-c.done:
+			// The client was closed, don't reconnect.
+			return
+		default:
+		}
 		ctx, _ := context.WithTimeout(context.Background(), regionLookupTimeout)
 		if port != 0 && err == nil {
 			// If this isn't the admin or meta region, check if a client
@@ -915,7 +2421,7 @@ func (c *client) establishRegion(originalReg hrpc.RegionInfo, host string, port
 			} else {
 				clientType = region.MasterClient
 			}
-			go newRegionClient(ctx, ch, clientType, host, port, c.rpcQueueSize, c.flushInterval)
+			c.dialRegionServer(ctx, ch, clientType, host, port)
 
 			select {
 			case res := <-ch:
@@ -927,10 +2433,7 @@ func (c *client) establishRegion(originalReg hrpc.RegionInfo, host string, port
 						// able to find the client
 						c.clients.put(reg, res.Client)
 						if reg != originalReg {
-							removed := c.regions.put(reg)
-							for _, r := range removed {
-								c.clients.del(r)
-							}
+							c.uncacheRegions(c.regions.put(reg))
 						}
 					}
 					originalReg.MarkAvailable()
@@ -951,15 +2454,25 @@ func (c *client) establishRegion(originalReg hrpc.RegionInfo, host string, port
 			// This will be hit if either there was an error locating the
 			// region, or the region was located but there was an error
 			// connecting to it.
-			backoff, err = sleepAndIncreaseBackoff(ctx, backoff)
+			backoff, err = c.sleepAndIncreaseBackoff(ctx, backoff)
 			if err != nil {
 				continue
 			}
 		}
+		if c.clientType == adminClient || reg == c.metaRegionInfo {
+			err = c.checkClusterID(ctx)
+			if _, ok := err.(*ClusterIDMismatchError); ok {
+				log.Errorf("Not connecting to the wrong cluster: %s", err)
+				originalReg.MarkAvailable()
+				return
+			} else if err != nil {
+				continue
+			}
+		}
 		if c.clientType == adminClient {
-			host, port, err = c.zkLookup(ctx, zk.Master)
+			host, port, err = c.lookupResource(ctx, zk.Master)
 		} else if reg == c.metaRegionInfo {
-			host, port, err = c.zkLookup(ctx, zk.Meta)
+			host, port, err = c.lookupResource(ctx, zk.Meta)
 		} else {
 			reg, host, port, err = c.locateRegion(ctx, originalReg.GetTable(),
 				originalReg.GetStartKey())
@@ -967,25 +2480,16 @@ func (c *client) establishRegion(originalReg hrpc.RegionInfo, host string, port
 	}
 }
 
-func sleepAndIncreaseBackoff(ctx context.Context, backoff time.Duration) (time.Duration, error) {
-	select {
-	case <-time.After(backoff):
-	case <-ctx.Done():
-		return 0, ErrDeadline
-	}
-	// TODO: Revisit how we back off here.
-	if backoff < 5000*time.Millisecond {
-		return backoff * 2, nil
-	} else {
-		return backoff + 5000*time.Millisecond, nil
-	}
-}
-
 func newRegionClient(ctx context.Context, ret chan newRegResult, clientType region.ClientType,
-	host string, port uint16, queueSize int, queueTimeout time.Duration) {
-	c, e := region.NewClient(host, port, clientType, queueSize, queueTimeout)
+	host string, port uint16, queueSize int, queueTimeout time.Duration,
+	options ...region.ClientOption) {
+	c, e := region.NewClient(host, port, clientType, queueSize, queueTimeout, options...)
+	if e != nil {
+		ret <- newRegResult{Err: e}
+		return
+	}
 	select {
-	case ret <- newRegResult{c, e}:
+	case ret <- newRegResult{c, nil}:
 		// Hooray!
 	case <-ctx.Done():
 		// We timed out, too bad, nobody expects this client anymore, ditch it.
@@ -1001,6 +2505,16 @@ type zkResult struct {
 	err  error
 }
 
+// lookupResource looks up the meta region or HMaster in the master registry
+// if the client has one, or in ZooKeeper otherwise.
+func (c *client) lookupResource(ctx context.Context, res zk.ResourceName) (string,
+	uint16, error) {
+	if c.registry != nil {
+		return c.registryLookup(ctx, res)
+	}
+	return c.zkLookup(ctx, res)
+}
+
 // Asynchronously looks up the meta region or HMaster in ZooKeeper.
 func (c *client) zkLookup(ctx context.Context, res zk.ResourceName) (string, uint16, error) {
 	// We make this a buffered channel so that if we stop waiting due to a
@@ -1018,8 +2532,46 @@ func (c *client) zkLookup(ctx context.Context, res zk.ResourceName) (string, uin
 
 // Synchronously looks up the meta region or HMaster in ZooKeeper.
 func (c *client) zkLookupSync(res zk.ResourceName, reschan chan<- zkResult) {
-	host, port, err := zk.LocateResource(c.zkquorum, res)
+	c.zkWatchOnce.Do(func() {
+		c.watchLocation(res)
+		if c.watchRegionServers && c.clientType != adminClient {
+			c.zkSession.WatchRegionServers(c.regionServersChanged)
+		}
+	})
+	host, port, err := c.zkSession.LocateResource(res)
 
 	// This is guaranteed to never block as the channel is always buffered.
 	reschan <- zkResult{host, port, err}
 }
+
+// watchLocation watches the location of meta, or of the HMaster for an admin
+// client, in ZooKeeper, so that the client reconnects as soon as it moves
+// rather than once its RPCs fail.
+func (c *client) watchLocation(res zk.ResourceName) {
+	reg := c.metaRegionInfo
+	if c.clientType == adminClient {
+		reg = c.adminRegionInfo
+	}
+	c.zkSession.WatchResource(res, func(host string, port uint16) {
+		c.locationChanged(res, reg, host, port)
+	})
+}
+
+// locationChanged reconnects the given meta or admin region to the location
+// read from the znode of the given resource, unless it's already connected
+// there or not connected yet.
+func (c *client) locationChanged(res zk.ResourceName, reg hrpc.RegionInfo,
+	host string, port uint16) {
+	client := reg.GetClient()
+	if client == nil || (client.Host() == host && client.Port() == port) {
+		return
+	}
+	log.Infof("The %s znode moved from %s:%d to %s:%d", res, client.Host(), client.Port(),
+		host, port)
+	if reg.MarkUnavailable() {
+		go c.establishRegion(reg, host, port)
+	}
+	// The RPCs still queued on the stale client fail and wait for the new
+	// one.
+	client.Close()
+}
@@ -0,0 +1,183 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/region"
+	"github.com/tsuna/gohbase/zk"
+	"golang.org/x/net/context"
+)
+
+func TestClosestRowBefore(t *testing.T) {
+	testcases := []struct {
+		key, expected []byte
+	}{
+		{[]byte("a\x00"), []byte("a")},
+		{[]byte("\x00"), []byte("")},
+		{[]byte("b"), []byte("a\xff\xff\xff\xff\xff\xff\xff\xff\xff")},
+		{[]byte("row2"), []byte("row1\xff\xff\xff\xff\xff\xff\xff\xff\xff")},
+	}
+	for _, tc := range testcases {
+		if before := closestRowBefore(tc.key); !bytes.Equal(before, tc.expected) {
+			t.Errorf("Expected closestRowBefore(%q) = %q, got %q", tc.key, tc.expected, before)
+		}
+		if bytes.Compare(closestRowBefore(tc.key), tc.key) >= 0 {
+			t.Errorf("Expected closestRowBefore(%q) to be before it", tc.key)
+		}
+	}
+}
+
+func TestIsSingleRow(t *testing.T) {
+	testcases := []struct {
+		start, stop string
+		single      bool
+	}{
+		{"a", "a\x00", true},
+		{"", "\x00", true},
+		{"a", "b", false},
+		{"a", "", false},
+		{"a", "a\x00\x00", false},
+		{"a", "b\x00", false},
+	}
+	for _, tc := range testcases {
+		r := [2][]byte{[]byte(tc.start), []byte(tc.stop)}
+		if single := isSingleRow(r); single != tc.single {
+			t.Errorf("Expected isSingleRow(%q, %q) to be %v", tc.start, tc.stop, tc.single)
+		}
+	}
+}
+
+// cacheTestRegions caches the regions [,f[, [f,m[ and [m,[ of the table "test",
+// served by the given clients.
+func cacheTestRegions(c *client, clients ...hrpc.RegionClient) {
+	regions := []*region.Info{
+		{Table: []byte("test"), Name: []byte("test,,1"), StopKey: []byte("f")},
+		{Table: []byte("test"), Name: []byte("test,f,1"), StartKey: []byte("f"),
+			StopKey: []byte("m")},
+		{Table: []byte("test"), Name: []byte("test,m,1"), StartKey: []byte("m")},
+	}
+	for i, reg := range regions {
+		reg.SetClient(clients[i])
+		c.regions.put(reg)
+		c.clients.put(reg, clients[i])
+	}
+}
+
+func TestRegionRanges(t *testing.T) {
+	c := newClient("~invalid.quorum~")
+	rs := &fakeRegionClient{host: "rs", port: 16020}
+	cacheTestRegions(c, rs, rs, rs)
+	testcases := []struct {
+		start, stop string
+		expected    []keyRange
+	}{
+		{"", "", []keyRange{{[]byte(""), []byte("f")}, {[]byte("f"), []byte("m")},
+			{[]byte("m"), []byte("")}}},
+		{"b", "g", []keyRange{{[]byte("b"), []byte("f")}, {[]byte("f"), []byte("g")}}},
+		{"g", "h", []keyRange{{[]byte("g"), []byte("h")}}},
+		{"g", "m", []keyRange{{[]byte("g"), []byte("m")}}},
+		{"n", "", []keyRange{{[]byte("n"), []byte("")}}},
+	}
+	for _, tc := range testcases {
+		ranges, err := c.regionRanges(context.Background(), []byte("test"),
+			[]byte(tc.start), []byte(tc.stop))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ranges) != len(tc.expected) {
+			t.Errorf("Expected %d ranges for [%q; %q[, got %q", len(tc.expected),
+				tc.start, tc.stop, ranges)
+			continue
+		}
+		for i, r := range ranges {
+			if !bytes.Equal(r.start, tc.expected[i].start) ||
+				!bytes.Equal(r.stop, tc.expected[i].stop) {
+				t.Errorf("Expected range #%d of [%q; %q[ to be %q, got %q", i,
+					tc.start, tc.stop, tc.expected[i], r)
+			}
+		}
+	}
+}
+
+func TestParallelScanCancel(t *testing.T) {
+	c := newClient("~invalid.quorum~")
+	failure := errors.New("scan failed")
+	failing := &registryClient{
+		fakeRegionClient: fakeRegionClient{host: "rs1", port: 16020},
+		respond: func(rpc hrpc.Call) hrpc.RPCResult {
+			return hrpc.RPCResult{Error: failure}
+		},
+	}
+	// The scans of the other regions never get an answer, so they only
+	// return once canceled.
+	hanging := &fakeRegionClient{host: "rs2", port: 16020}
+	cacheTestRegions(c, failing, hanging, hanging)
+
+	scan, err := hrpc.NewScanStr(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.ParallelScan(scan, 3); err != failure {
+		t.Errorf("Expected the error of the failed scan, got %v", err)
+	}
+}
+
+func TestBypassRegionCache(t *testing.T) {
+	ctx := context.Background()
+	get, err := hrpc.NewGetStr(ctx, "test", "row")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uncached, err := hrpc.NewGetStr(ctx, "test", "row", hrpc.NoRegionCache(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta, err := hrpc.NewGetStr(ctx, "hbase:meta", "row", hrpc.NoRegionCache(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newClient("~invalid.quorum~")
+	if c.bypassRegionCache(get) || !c.bypassRegionCache(uncached) ||
+		!c.bypassRegionCache(uncached.CloneWithKey([]byte("other"))) {
+		t.Error("Expected only the Gets with the NoRegionCache option to bypass the cache")
+	}
+	if c.bypassRegionCache(meta) {
+		t.Error("Expected the lookups of meta to never bypass the cache")
+	}
+	c = newClient("~invalid.quorum~", NoRegionCache())
+	if !c.bypassRegionCache(get) {
+		t.Error("Expected all the calls to bypass the cache")
+	}
+	scan, err := hrpc.NewScanStr(ctx, "test", hrpc.NoRegionCache(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scan, err = cloneScan(scan, nil, nil); err != nil {
+		t.Fatal(err)
+	} else if !scan.GetNoRegionCache() {
+		t.Error("Expected a clone of a Scan to bypass the cache too")
+	}
+}
+
+func TestLocationChanged(t *testing.T) {
+	c := newClient("~invalid.quorum~")
+	defer c.Close()
+	rs1 := &fakeRegionClient{host: "rs1", port: 16020}
+	c.metaRegionInfo.SetClient(rs1)
+	c.locationChanged(zk.Meta, c.metaRegionInfo, "rs1", 16020)
+	if c.metaRegionInfo.IsUnavailable() || rs1.closed {
+		t.Error("Expected meta to stay connected to the same RegionServer")
+	}
+	c.locationChanged(zk.Meta, c.metaRegionInfo, "rs2", 16020)
+	if !c.metaRegionInfo.IsUnavailable() || !rs1.closed {
+		t.Error("Expected meta to be reconnected once moved to another RegionServer")
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+)
+
+// ClusterIDMismatchError is returned by all the RPCs of a client created
+// with the ExpectClusterID option when its ZooKeeper quorum belongs to
+// another cluster than the expected one.
+type ClusterIDMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *ClusterIDMismatchError) Error() string {
+	return fmt.Sprintf("expected to connect to HBase cluster %q, but the ZooKeeper"+
+		" quorum belongs to cluster %q", e.Expected, e.Actual)
+}
+
+// ExpectClusterID will return an option that makes the client check, when it
+// bootstraps, that the ID of the cluster found in ZooKeeper is the given one.
+// If it isn't, the client doesn't connect to the cluster and all its RPCs fail
+// with a *ClusterIDMismatchError, so that a misconfigured quorum can't make an
+// application read from or write to the wrong cluster.
+func ExpectClusterID(id string) Option {
+	return func(c *client) {
+		c.expectedClusterID = id
+	}
+}
+
+// clusterError returns the error that makes all the RPCs of this client fail,
+// if any.
+func (c *client) clusterError() error {
+	c.clusterLock.Lock()
+	defer c.clusterLock.Unlock()
+	if c.clusterErr == nil {
+		// Avoid returning a nil *ClusterIDMismatchError in a non-nil error.
+		return nil
+	}
+	return c.clusterErr
+}
+
+// checkClusterID checks that the ZooKeeper quorum of this client belongs to
+// the expected cluster, if any.  It returns a *ClusterIDMismatchError if it
+// doesn't, or another error if the cluster ID couldn't be read.
+func (c *client) checkClusterID(ctx context.Context) error {
+	c.clusterLock.Lock()
+	verified := c.expectedClusterID == "" || c.clusterVerified
+	clusterErr := c.clusterErr
+	c.clusterLock.Unlock()
+	if clusterErr != nil {
+		return clusterErr
+	} else if verified {
+		return nil
+	}
+
+	id, err := c.ClusterID(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.clusterLock.Lock()
+	defer c.clusterLock.Unlock()
+	if id != c.expectedClusterID {
+		c.clusterErr = &ClusterIDMismatchError{Expected: c.expectedClusterID, Actual: id}
+		return c.clusterErr
+	}
+	c.clusterVerified = true
+	return nil
+}
+
+// ClusterID returns the ID of the cluster that the ZooKeeper quorum of the
+// client belongs to, which identifies the cluster e.g. in its delegation
+// tokens.  It's read from ZooKeeper, or from the master registry, the first
+// time only.
+func (c *client) ClusterID(ctx context.Context) (string, error) {
+	c.clusterLock.Lock()
+	id := c.clusterID
+	c.clusterLock.Unlock()
+	if id != "" {
+		return id, nil
+	}
+	var err error
+	if c.registry != nil {
+		id, err = c.registryClusterID(ctx)
+	} else {
+		err = zkCall(ctx, func() error {
+			var err error
+			id, err = c.zkSession.GetClusterID()
+			return err
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+	c.clusterLock.Lock()
+	c.clusterID = id
+	c.clusterLock.Unlock()
+	return id, nil
+}
+
+// zkCall asynchronously calls the given function, which talks to ZooKeeper,
+// and waits for it to return or the context to expire.
+func zkCall(ctx context.Context, f func() error) error {
+	// Buffered so that the call doesn't block forever if we time out.
+	errchan := make(chan error, 1)
+	go func() {
+		errchan <- f()
+	}()
+	select {
+	case err := <-errchan:
+		return err
+	case <-ctx.Done():
+		return ErrDeadline
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"golang.org/x/net/context"
+)
+
+func TestClusterIDMismatch(t *testing.T) {
+	c := newClient("~invalid.quorum~", ExpectClusterID("expected-id"))
+	if err := c.clusterError(); err != nil {
+		t.Fatalf("Unexpected error before bootstrap: %s", err)
+	}
+	c.clusterErr = &ClusterIDMismatchError{Expected: "expected-id", Actual: "other-id"}
+	if err := c.checkClusterID(context.Background()); err != c.clusterErr {
+		t.Errorf("Expected the mismatch to be sticky, got %v", err)
+	}
+	if err := c.Connect(context.Background()); err != c.clusterErr {
+		t.Errorf("Expected Connect to fail with the mismatch, got %v", err)
+	}
+	get, err := hrpc.NewGetStr(context.Background(), "test", "row")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(get); err != c.clusterErr {
+		t.Errorf("Expected Get to fail with the mismatch, got %v", err)
+	}
+}
+
+func TestClusterID(t *testing.T) {
+	c := newClient("~invalid.quorum~", ExpectClusterID("expected-id"))
+	c.clusterID = "other-id"
+	if id, err := c.ClusterID(context.Background()); err != nil || id != "other-id" {
+		t.Errorf("Expected the cached cluster ID, got %q, %v", id, err)
+	}
+	err := c.checkClusterID(context.Background())
+	if mismatch, ok := err.(*ClusterIDMismatchError); !ok || mismatch.Actual != "other-id" {
+		t.Errorf("Expected a mismatch with the cached cluster ID, got %v", err)
+	}
+}
+
+func TestZkCall(t *testing.T) {
+	expected := errors.New("zk error")
+	if err := zkCall(context.Background(), func() error { return expected }); err != expected {
+		t.Errorf("Expected %v, got %v", expected, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	unblock := make(chan struct{})
+	defer close(unblock)
+	err := zkCall(ctx, func() error {
+		<-unblock
+		return nil
+	})
+	if err != ErrDeadline {
+		t.Errorf("Expected ErrDeadline, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"fmt"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// DecommissionRegionServers tells the master not to assign regions to the
+// given RegionServers anymore, and if offload is true, to move their regions
+// to the other RegionServers.  The moves happen asynchronously, after this
+// returns: ListRegionServers tells when the RegionServers have no regions left
+// and can be restarted.  The cluster must run HBase 2.0 or later.
+func (c *client) DecommissionRegionServers(ctx context.Context, servers []hrpc.ServerName,
+	offload bool) error {
+	_, err := c.sendRPC(hrpc.NewDecommissionRegionServers(ctx, servers, offload))
+	return err
+}
+
+// RecommissionRegionServer lets the master assign regions to the given
+// RegionServer again.  The regions it served before it was decommissioned
+// come back as the balancer moves them.
+func (c *client) RecommissionRegionServer(ctx context.Context, server hrpc.ServerName) error {
+	_, err := c.sendRPC(hrpc.NewRecommissionRegionServer(ctx, server))
+	return err
+}
+
+// ListDecommissionedRegionServers returns the RegionServers that the master
+// doesn't assign regions to, since they were decommissioned.
+func (c *client) ListDecommissionedRegionServers(ctx context.Context) (
+	[]hrpc.ServerName, error) {
+	pbmsg, err := c.sendRPC(hrpc.NewListDecommissionedRegionServers(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := pbmsg.(*pb.ListDecommissionedRegionServersResponse)
+	if !ok {
+		return nil, fmt.Errorf("sendRPC returned not a ListDecommissionedRegionServersResponse")
+	}
+	servers := make([]hrpc.ServerName, len(r.GetServerName()))
+	for i, server := range r.GetServerName() {
+		servers[i] = hrpc.ServerName{
+			Host:      server.GetHostName(),
+			Port:      uint16(server.GetPort()),
+			StartCode: server.GetStartCode(),
+		}
+	}
+	return servers, nil
+}
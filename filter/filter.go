@@ -637,10 +637,48 @@ func NewSingleColumnValueFilter(columnFamily, columnQualifier []byte, compareOp
 	}
 }
 
+// SetFilterIfMissing sets whether the rows that don't have the column are
+// filtered out.  When false, which is the default in HBase, the rows that
+// don't have the column at all are returned along with the rows whose value
+// matches.
+func (f *SingleColumnValueFilter) SetFilterIfMissing(
+	filterIfMissing bool) *SingleColumnValueFilter {
+	f.FilterIfMissing = proto.Bool(filterIfMissing)
+	return f
+}
+
+// SetLatestVersionOnly sets whether only the latest version of the column is
+// compared.  When false, a row matches if any of the versions of the column
+// fetched by the request matches.  HBase defaults to true.
+func (f *SingleColumnValueFilter) SetLatestVersionOnly(
+	latestVersionOnly bool) *SingleColumnValueFilter {
+	f.LatestVersionOnly = proto.Bool(latestVersionOnly)
+	return f
+}
+
+// validate checks that the filter can be sent to HBase.
+func (f *SingleColumnValueFilter) validate() error {
+	if len(f.ColumnFamily) == 0 {
+		return errors.New("SingleColumnValueFilter needs a column family.")
+	}
+	if f.CompareOp == nil || !CompareType(*f.CompareOp).isValid() {
+		return errors.New("Invalid compare operation specified.")
+	}
+	if CompareType(*f.CompareOp) == NoOp {
+		// HBase filters out every row that has the column with NO_OP, so this
+		// filter would either return nothing or only the rows missing the column.
+		return errors.New("SingleColumnValueFilter can't use the NoOp compare operation.")
+	}
+	if f.Comparator == nil {
+		return errors.New("SingleColumnValueFilter needs a comparator.")
+	}
+	return nil
+}
+
 // ConstructPB is TODO
 func (f *SingleColumnValueFilter) ConstructPB() (*pb.SingleColumnValueFilter, error) {
-	if !CompareType(*f.CompareOp).isValid() {
-		return nil, errors.New("Invalid compare operation specified.")
+	if err := f.validate(); err != nil {
+		return nil, err
 	}
 
 	return (*pb.SingleColumnValueFilter)(f), nil
@@ -648,6 +686,9 @@ func (f *SingleColumnValueFilter) ConstructPB() (*pb.SingleColumnValueFilter, er
 
 // ConstructPBFilter is TODO
 func (f *SingleColumnValueFilter) ConstructPBFilter() (*pb.Filter, error) {
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
 	serializedFilter, err := proto.Marshal((*pb.SingleColumnValueFilter)(f))
 	if err != nil {
 		return nil, err
@@ -672,6 +713,12 @@ func NewSingleColumnValueExcludeFilter(
 
 // ConstructPBFilter is TODO
 func (f *SingleColumnValueExcludeFilter) ConstructPBFilter() (*pb.Filter, error) {
+	if f.SingleColumnValueFilter == nil {
+		return nil, errors.New("SingleColumnValueExcludeFilter needs a SingleColumnValueFilter.")
+	}
+	if err := (*SingleColumnValueFilter)(f.SingleColumnValueFilter).validate(); err != nil {
+		return nil, err
+	}
 	serializedFilter, err := proto.Marshal((*pb.SingleColumnValueExcludeFilter)(f))
 	if err != nil {
 		return nil, err
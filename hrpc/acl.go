@@ -0,0 +1,205 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// The AccessController coprocessor only manages permissions in the region of
+// the ACL table.
+var aclTable = []byte("hbase:acl")
+
+const accessControlService = "hbase.pb.AccessControlService"
+
+// Action is something that a permission allows doing.
+type Action int32
+
+const (
+	// ActionRead allows reading.
+	ActionRead = Action(pb.Permission_READ)
+	// ActionWrite allows writing.
+	ActionWrite = Action(pb.Permission_WRITE)
+	// ActionExec allows calling coprocessor endpoints.
+	ActionExec = Action(pb.Permission_EXEC)
+	// ActionCreate allows creating, altering and deleting tables.
+	ActionCreate = Action(pb.Permission_CREATE)
+	// ActionAdmin allows administering, e.g. balancing the cluster or
+	// granting permissions.
+	ActionAdmin = Action(pb.Permission_ADMIN)
+)
+
+func (a Action) String() string {
+	return pb.Permission_Action(a).String()
+}
+
+// UserPermission is a set of actions that a user or group is allowed on a
+// scope: the whole cluster, a namespace, a table or a column family or column
+// of a table.
+type UserPermission struct {
+	// User is the name of a user, or of a group prefixed by '@'.
+	User string
+
+	// Namespace is the namespace the permission is about, if any.
+	Namespace string
+
+	// Table is the table the permission is about, if any, prefixed by its
+	// namespace and a colon unless it's in the default namespace.  Family
+	// and Qualifier narrow the permission down to a column family or column
+	// of the table.
+	Table     []byte
+	Family    []byte
+	Qualifier []byte
+
+	Actions []Action
+}
+
+// ToLocalUserPermission converts the given protobuf UserPermission into our
+// own UserPermission type.
+func ToLocalUserPermission(up *pb.UserPermission) *UserPermission {
+	perm := &UserPermission{User: string(up.GetUser())}
+	var actions []pb.Permission_Action
+	switch p := up.GetPermission(); p.GetType() {
+	case pb.Permission_Global:
+		actions = p.GetGlobalPermission().GetAction()
+	case pb.Permission_Namespace:
+		perm.Namespace = string(p.GetNamespacePermission().GetNamespaceName())
+		actions = p.GetNamespacePermission().GetAction()
+	case pb.Permission_Table:
+		tp := p.GetTablePermission()
+		perm.Table = FullTableName(tp.GetTableName())
+		perm.Family = tp.GetFamily()
+		perm.Qualifier = tp.GetQualifier()
+		actions = tp.GetAction()
+	}
+	for _, action := range actions {
+		perm.Actions = append(perm.Actions, Action(action))
+	}
+	return perm
+}
+
+// toProto returns the protobuf of the permission.
+func (up *UserPermission) toProto() (*pb.UserPermission, error) {
+	if up.User == "" {
+		return nil, errors.New("User can't be empty.")
+	}
+	if len(up.Actions) == 0 {
+		return nil, errors.New("There must be at least one action.")
+	}
+	actions := make([]pb.Permission_Action, len(up.Actions))
+	for i, action := range up.Actions {
+		actions[i] = pb.Permission_Action(action)
+	}
+	perm := &pb.Permission{}
+	switch {
+	case up.Table != nil:
+		if up.Namespace != "" {
+			return nil, errors.New("A permission can't be about both a namespace and a table.")
+		}
+		perm.Type = pb.Permission_Table.Enum()
+		perm.TablePermission = &pb.TablePermission{
+			TableName: ProtoTableName(up.Table),
+			Family:    up.Family,
+			Qualifier: up.Qualifier,
+			Action:    actions,
+		}
+	case up.Family != nil || up.Qualifier != nil:
+		return nil, errors.New("A permission about a column must be about its table.")
+	case up.Namespace != "":
+		perm.Type = pb.Permission_Namespace.Enum()
+		perm.NamespacePermission = &pb.NamespacePermission{
+			NamespaceName: []byte(up.Namespace),
+			Action:        actions,
+		}
+	default:
+		perm.Type = pb.Permission_Global.Enum()
+		perm.GlobalPermission = &pb.GlobalPermission{Action: actions}
+	}
+	return &pb.UserPermission{User: []byte(up.User), Permission: perm}, nil
+}
+
+// initACL initializes the given call to a method of the AccessControlService
+// of the region of the ACL table.
+func (cc *coprocessorCall) initACL(ctx context.Context, method string, request proto.Message) {
+	cc.table = aclTable
+	// The ACL table has a single region, so look it up by the empty key.
+	cc.key = []byte{}
+	cc.ctx = ctx
+	cc.service = accessControlService
+	cc.method = method
+	cc.request = request
+}
+
+// Grant represents a Grant call to the AccessControlService
+type Grant struct {
+	coprocessorCall
+}
+
+// NewGrant creates a new Grant request that will give the given permission to
+// its user, in addition to those the user already has.  It requires the
+// AccessController coprocessor.
+func NewGrant(ctx context.Context, permission *UserPermission) (*Grant, error) {
+	perm, err := permission.toProto()
+	if err != nil {
+		return nil, err
+	}
+	g := &Grant{}
+	g.initACL(ctx, "Grant", &pb.GrantRequest{UserPermission: perm})
+	return g, nil
+}
+
+// Revoke represents a Revoke call to the AccessControlService
+type Revoke struct {
+	coprocessorCall
+}
+
+// NewRevoke creates a new Revoke request that will take away the actions of
+// the given permission from its user, on the scope of the permission.  It
+// requires the AccessController coprocessor.
+func NewRevoke(ctx context.Context, permission *UserPermission) (*Revoke, error) {
+	perm, err := permission.toProto()
+	if err != nil {
+		return nil, err
+	}
+	r := &Revoke{}
+	r.initACL(ctx, "Revoke", &pb.RevokeRequest{UserPermission: perm})
+	return r, nil
+}
+
+// GetUserPermissions represents a GetUserPermissions call to the
+// AccessControlService
+type GetUserPermissions struct {
+	coprocessorCall
+}
+
+// NewGetUserPermissions creates a new GetUserPermissions request that will
+// return the permissions on the given table, or if table is nil, on the given
+// namespace, or if namespace is empty too, on the whole cluster.  It requires
+// the AccessController coprocessor.
+func NewGetUserPermissions(ctx context.Context, namespace string,
+	table []byte) (*GetUserPermissions, error) {
+	req := &pb.GetUserPermissionsRequest{}
+	switch {
+	case table != nil:
+		if namespace != "" {
+			return nil, errors.New("Permissions can't be listed for both a namespace and a table.")
+		}
+		req.Type = pb.Permission_Table.Enum()
+		req.TableName = ProtoTableName(table)
+	case namespace != "":
+		req.Type = pb.Permission_Namespace.Enum()
+		req.NamespaceName = []byte(namespace)
+	default:
+		req.Type = pb.Permission_Global.Enum()
+	}
+	gp := &GetUserPermissions{}
+	gp.initACL(ctx, "GetUserPermissions", req)
+	return gp, nil
+}
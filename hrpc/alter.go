@@ -0,0 +1,272 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// ModifyTable represents a ModifyTable HBase call
+type ModifyTable struct {
+	tableOp
+
+	descriptor *TableDescriptor
+}
+
+// NewModifyTable creates a new ModifyTable request that will replace the
+// schema of the table named in the given descriptor by the descriptor, e.g.
+// one returned by GetTableDescriptors and then edited.  The column families
+// missing from the descriptor are deleted.  For use by the admin client.
+func NewModifyTable(ctx context.Context, descriptor *TableDescriptor) *ModifyTable {
+	return &ModifyTable{
+		tableOp: tableOp{base{
+			table: descriptor.Name,
+			ctx:   ctx,
+		}},
+		descriptor: descriptor,
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (mt *ModifyTable) GetName() string {
+	return "ModifyTable"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (mt *ModifyTable) Serialize() ([]byte, error) {
+	tn := ProtoTableName(mt.table)
+	schema := &pb.TableSchema{
+		TableName:     tn,
+		Attributes:    attributePairs(mt.descriptor.Attributes),
+		Configuration: configurationPairs(mt.descriptor.Configuration),
+	}
+	families := make([]string, 0, len(mt.descriptor.Families))
+	for family := range mt.descriptor.Families {
+		families = append(families, family)
+	}
+	sort.Strings(families)
+	for _, family := range families {
+		schema.ColumnFamilies = append(schema.ColumnFamilies, &pb.ColumnFamilySchema{
+			Name:       []byte(family),
+			Attributes: attributePairs(mt.descriptor.Families[family]),
+		})
+	}
+	return proto.Marshal(&pb.ModifyTableRequest{TableName: tn, TableSchema: schema})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (mt *ModifyTable) NewResponse() proto.Message {
+	return &pb.ModifyTableResponse{}
+}
+
+// columnFamilyOp represents an administrative operation on the schema of a
+// column family.
+type columnFamilyOp struct {
+	tableOp
+
+	family     string
+	attributes map[string]string
+}
+
+// init initializes the given column family operation, with a copy of the
+// given attributes.
+func (cf *columnFamilyOp) init(ctx context.Context, table []byte, family string,
+	attributes map[string]string) error {
+	if family == "" {
+		return errors.New("Column family can't be empty.")
+	}
+	cf.table = table
+	cf.ctx = ctx
+	cf.family = family
+	cf.attributes = make(map[string]string, len(attributes))
+	for key, attr := range attributes {
+		cf.attributes[key] = attr
+	}
+	return nil
+}
+
+// schema returns the schema of the column family.
+func (cf *columnFamilyOp) schema() *pb.ColumnFamilySchema {
+	return &pb.ColumnFamilySchema{
+		Name:       []byte(cf.family),
+		Attributes: attributePairs(cf.attributes),
+	}
+}
+
+// tableName returns the name of the table of the column family.
+func (cf *columnFamilyOp) tableName() *pb.TableName {
+	return ProtoTableName(cf.table)
+}
+
+// AddColumnFamily represents an AddColumn HBase call
+type AddColumnFamily struct {
+	columnFamilyOp
+}
+
+// NewAddColumnFamily creates a new AddColumnFamily request that will add the
+// given column family to the given table in HBase, with the given attributes
+// and those set by the options, like Versions or Compression.  HBase uses its
+// defaults for the other attributes.  For use by the admin client.
+func NewAddColumnFamily(ctx context.Context, table []byte, family string,
+	attributes map[string]string, options ...func(Call) error) (*AddColumnFamily, error) {
+	ac := &AddColumnFamily{}
+	err := ac.init(ctx, table, family, attributes)
+	if err != nil {
+		return nil, err
+	}
+	err = applyOptions(ac, options...)
+	if err != nil {
+		return nil, err
+	}
+	return ac, nil
+}
+
+// GetName returns the name of this RPC call.
+func (ac *AddColumnFamily) GetName() string {
+	return "AddColumn"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (ac *AddColumnFamily) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.AddColumnRequest{
+		TableName:      ac.tableName(),
+		ColumnFamilies: ac.schema(),
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (ac *AddColumnFamily) NewResponse() proto.Message {
+	return &pb.AddColumnResponse{}
+}
+
+// ModifyColumnFamily represents a ModifyColumn HBase call
+type ModifyColumnFamily struct {
+	columnFamilyOp
+}
+
+// NewModifyColumnFamily creates a new ModifyColumnFamily request that will
+// replace the schema of the given column family of the given table in HBase
+// by the given attributes and those set by the options, like Versions or
+// TimeToLive.  HBase uses its defaults for the other attributes, so to change
+// only some attributes, start from those returned by GetTableDescriptors.
+// For use by the admin client.
+func NewModifyColumnFamily(ctx context.Context, table []byte, family string,
+	attributes map[string]string, options ...func(Call) error) (*ModifyColumnFamily, error) {
+	mc := &ModifyColumnFamily{}
+	err := mc.init(ctx, table, family, attributes)
+	if err != nil {
+		return nil, err
+	}
+	err = applyOptions(mc, options...)
+	if err != nil {
+		return nil, err
+	}
+	return mc, nil
+}
+
+// GetName returns the name of this RPC call.
+func (mc *ModifyColumnFamily) GetName() string {
+	return "ModifyColumn"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (mc *ModifyColumnFamily) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.ModifyColumnRequest{
+		TableName:      mc.tableName(),
+		ColumnFamilies: mc.schema(),
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (mc *ModifyColumnFamily) NewResponse() proto.Message {
+	return &pb.ModifyColumnResponse{}
+}
+
+// DeleteColumnFamily represents a DeleteColumn HBase call
+type DeleteColumnFamily struct {
+	columnFamilyOp
+}
+
+// NewDeleteColumnFamily creates a new DeleteColumnFamily request that will
+// delete the given column family, and all its data, from the given table in
+// HBase.  For use by the admin client.
+func NewDeleteColumnFamily(ctx context.Context, table []byte,
+	family string) (*DeleteColumnFamily, error) {
+	dc := &DeleteColumnFamily{}
+	err := dc.init(ctx, table, family, nil)
+	if err != nil {
+		return nil, err
+	}
+	return dc, nil
+}
+
+// GetName returns the name of this RPC call.
+func (dc *DeleteColumnFamily) GetName() string {
+	return "DeleteColumn"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (dc *DeleteColumnFamily) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.DeleteColumnRequest{
+		TableName:  dc.tableName(),
+		ColumnName: []byte(dc.family),
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (dc *DeleteColumnFamily) NewResponse() proto.Message {
+	return &pb.DeleteColumnResponse{}
+}
+
+// GetSchemaAlterStatus represents a GetSchemaAlterStatus HBase call, which
+// returns how many regions of a table have yet to be updated with its new
+// schema.
+type GetSchemaAlterStatus struct {
+	tableOp
+}
+
+// NewGetSchemaAlterStatus creates a new GetSchemaAlterStatus request for the
+// given table.  For use by the admin client.
+func NewGetSchemaAlterStatus(ctx context.Context, table []byte) *GetSchemaAlterStatus {
+	return &GetSchemaAlterStatus{
+		tableOp{base{
+			table: table,
+			ctx:   ctx,
+		}},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (gs *GetSchemaAlterStatus) GetName() string {
+	return "GetSchemaAlterStatus"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (gs *GetSchemaAlterStatus) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.GetSchemaAlterStatusRequest{
+		TableName: ProtoTableName(gs.table),
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (gs *GetSchemaAlterStatus) NewResponse() proto.Message {
+	return &pb.GetSchemaAlterStatusResponse{}
+}
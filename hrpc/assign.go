@@ -0,0 +1,237 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// regionOp represents an administrative operation on a region, named by its
+// full name like returned by RegionInfo.GetName.
+type regionOp struct {
+	tableOp
+
+	regionName []byte
+}
+
+// init initializes the given region operation.
+func (ro *regionOp) init(ctx context.Context, regionName []byte) error {
+	if len(regionName) == 0 {
+		return errors.New("Region name can't be empty.")
+	}
+	ro.ctx = ctx
+	ro.regionName = regionName
+	return nil
+}
+
+// namedRegionSpecifier returns the RegionSpecifier of the region.
+func (ro *regionOp) namedRegionSpecifier() *pb.RegionSpecifier {
+	return &pb.RegionSpecifier{
+		Type:  pb.RegionSpecifier_REGION_NAME.Enum(),
+		Value: ro.regionName,
+	}
+}
+
+// MoveRegion represents a MoveRegion HBase call
+type MoveRegion struct {
+	tableOp
+
+	encodedName string
+	dest        *pb.ServerName
+}
+
+// NewMoveRegion creates a new MoveRegion request that will move the region
+// with the given encoded name, like returned by RegionInfo.GetEncodedName, to
+// the RegionServer given with the DestinationServer option, or if none is
+// given, to a RegionServer picked by the master.  For use by the admin
+// client.
+func NewMoveRegion(ctx context.Context, encodedName string,
+	options ...func(Call) error) (*MoveRegion, error) {
+	if encodedName == "" {
+		return nil, errors.New("Region name can't be empty.")
+	}
+	mr := &MoveRegion{
+		tableOp:     tableOp{base{ctx: ctx}},
+		encodedName: encodedName,
+	}
+	err := applyOptions(mr, options...)
+	if err != nil {
+		return nil, err
+	}
+	return mr, nil
+}
+
+// DestinationServer moves the region to the RegionServer with the given host,
+// port and start code, which tells apart the successive processes of a
+// RegionServer on the same host and port.
+func DestinationServer(host string, port uint16, startCode uint64) func(Call) error {
+	return func(g Call) error {
+		mr, ok := g.(*MoveRegion)
+		if !ok {
+			return errors.New("DestinationServer option can only be used with NewMoveRegion.")
+		}
+		if host == "" {
+			return errors.New("Destination host can't be empty.")
+		}
+		mr.dest = &pb.ServerName{
+			HostName:  proto.String(host),
+			Port:      proto.Uint32(uint32(port)),
+			StartCode: proto.Uint64(startCode),
+		}
+		return nil
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (mr *MoveRegion) GetName() string {
+	return "MoveRegion"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (mr *MoveRegion) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.MoveRegionRequest{
+		Region:         encodedRegionSpecifier(mr.encodedName),
+		DestServerName: mr.dest,
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (mr *MoveRegion) NewResponse() proto.Message {
+	return &pb.MoveRegionResponse{}
+}
+
+// AssignRegion represents an AssignRegion HBase call
+type AssignRegion struct {
+	regionOp
+}
+
+// NewAssignRegion creates a new AssignRegion request that will have the
+// master assign the region with the given name to a RegionServer, e.g. after
+// it was unassigned.  For use by the admin client.
+func NewAssignRegion(ctx context.Context, regionName []byte) (*AssignRegion, error) {
+	ar := &AssignRegion{}
+	err := ar.init(ctx, regionName)
+	if err != nil {
+		return nil, err
+	}
+	return ar, nil
+}
+
+// GetName returns the name of this RPC call.
+func (ar *AssignRegion) GetName() string {
+	return "AssignRegion"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (ar *AssignRegion) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.AssignRegionRequest{Region: ar.namedRegionSpecifier()})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (ar *AssignRegion) NewResponse() proto.Message {
+	return &pb.AssignRegionResponse{}
+}
+
+// UnassignRegion represents an UnassignRegion HBase call
+type UnassignRegion struct {
+	regionOp
+
+	force bool
+}
+
+// NewUnassignRegion creates a new UnassignRegion request that will have the
+// master close the region with the given name, which then stays offline until
+// it's assigned again, unless the ForceUnassign option is given.  For use by
+// the admin client.
+func NewUnassignRegion(ctx context.Context, regionName []byte,
+	options ...func(Call) error) (*UnassignRegion, error) {
+	ur := &UnassignRegion{}
+	err := ur.init(ctx, regionName)
+	if err != nil {
+		return nil, err
+	}
+	err = applyOptions(ur, options...)
+	if err != nil {
+		return nil, err
+	}
+	return ur, nil
+}
+
+// ForceUnassign makes the master unassign the region even if it's in
+// transition, in which case it's assigned again right away.  This can unstick
+// a region stuck in transition.
+func ForceUnassign() func(Call) error {
+	return func(g Call) error {
+		ur, ok := g.(*UnassignRegion)
+		if !ok {
+			return errors.New("ForceUnassign option can only be used with NewUnassignRegion.")
+		}
+		ur.force = true
+		return nil
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (ur *UnassignRegion) GetName() string {
+	return "UnassignRegion"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (ur *UnassignRegion) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.UnassignRegionRequest{
+		Region: ur.namedRegionSpecifier(),
+		Force:  proto.Bool(ur.force),
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (ur *UnassignRegion) NewResponse() proto.Message {
+	return &pb.UnassignRegionResponse{}
+}
+
+// OfflineRegion represents an OfflineRegion HBase call
+type OfflineRegion struct {
+	regionOp
+}
+
+// NewOfflineRegion creates a new OfflineRegion request that will have the
+// master mark the region with the given name as offline, without closing it,
+// which is meant for repairing the cluster.  For use by the admin client.
+func NewOfflineRegion(ctx context.Context, regionName []byte) (*OfflineRegion, error) {
+	or := &OfflineRegion{}
+	err := or.init(ctx, regionName)
+	if err != nil {
+		return nil, err
+	}
+	return or, nil
+}
+
+// GetName returns the name of this RPC call.
+func (or *OfflineRegion) GetName() string {
+	return "OfflineRegion"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (or *OfflineRegion) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.OfflineRegionRequest{Region: or.namedRegionSpecifier()})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (or *OfflineRegion) NewResponse() proto.Message {
+	return &pb.OfflineRegionResponse{}
+}
@@ -0,0 +1,214 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// SetBalancerRunning represents a SetBalancerRunning HBase call
+type SetBalancerRunning struct {
+	tableOp
+
+	on          bool
+	synchronous bool
+}
+
+// NewSetBalancerRunning creates a new SetBalancerRunning request that will
+// turn the balancer of the master on or off.  If synchronous is true, the
+// master waits for the balancing that is running, if any, to finish before
+// responding.  For use by the admin client.
+func NewSetBalancerRunning(ctx context.Context, on, synchronous bool) *SetBalancerRunning {
+	return &SetBalancerRunning{
+		tableOp:     tableOp{base{ctx: ctx}},
+		on:          on,
+		synchronous: synchronous,
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (sb *SetBalancerRunning) GetName() string {
+	return "SetBalancerRunning"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (sb *SetBalancerRunning) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.SetBalancerRunningRequest{
+		On:          proto.Bool(sb.on),
+		Synchronous: proto.Bool(sb.synchronous),
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (sb *SetBalancerRunning) NewResponse() proto.Message {
+	return &pb.SetBalancerRunningResponse{}
+}
+
+// IsBalancerEnabled represents an IsBalancerEnabled HBase call
+type IsBalancerEnabled struct {
+	tableOp
+}
+
+// NewIsBalancerEnabled creates a new IsBalancerEnabled request that will
+// check whether the balancer of the master is on.  For use by the admin
+// client.
+func NewIsBalancerEnabled(ctx context.Context) *IsBalancerEnabled {
+	return &IsBalancerEnabled{
+		tableOp{base{ctx: ctx}},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (ib *IsBalancerEnabled) GetName() string {
+	return "IsBalancerEnabled"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (ib *IsBalancerEnabled) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.IsBalancerEnabledRequest{})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (ib *IsBalancerEnabled) NewResponse() proto.Message {
+	return &pb.IsBalancerEnabledResponse{}
+}
+
+// Balance represents a Balance HBase call
+type Balance struct {
+	tableOp
+}
+
+// NewBalance creates a new Balance request that will have the master balance
+// the regions between the RegionServers now.  The master doesn't balance
+// them when the balancer is off or some regions are in transition.  For use
+// by the admin client.
+func NewBalance(ctx context.Context) *Balance {
+	return &Balance{
+		tableOp{base{ctx: ctx}},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (b *Balance) GetName() string {
+	return "Balance"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (b *Balance) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.BalanceRequest{})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (b *Balance) NewResponse() proto.Message {
+	return &pb.BalanceResponse{}
+}
+
+// SetNormalizerRunning represents a SetNormalizerRunning HBase call
+type SetNormalizerRunning struct {
+	tableOp
+
+	on bool
+}
+
+// NewSetNormalizerRunning creates a new SetNormalizerRunning request that will
+// turn the region normalizer of the master on or off.  The normalizer splits
+// and merges the regions of the tables that have normalization enabled to even
+// out their sizes.  It requires HBase 1.2 or later.  For use by the admin
+// client.
+func NewSetNormalizerRunning(ctx context.Context, on bool) *SetNormalizerRunning {
+	return &SetNormalizerRunning{
+		tableOp: tableOp{base{ctx: ctx}},
+		on:      on,
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (sn *SetNormalizerRunning) GetName() string {
+	return "SetNormalizerRunning"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (sn *SetNormalizerRunning) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.SetNormalizerRunningRequest{On: proto.Bool(sn.on)})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (sn *SetNormalizerRunning) NewResponse() proto.Message {
+	return &pb.SetNormalizerRunningResponse{}
+}
+
+// IsNormalizerEnabled represents an IsNormalizerEnabled HBase call
+type IsNormalizerEnabled struct {
+	tableOp
+}
+
+// NewIsNormalizerEnabled creates a new IsNormalizerEnabled request that will
+// check whether the region normalizer of the master is on.  It requires HBase
+// 1.2 or later.  For use by the admin client.
+func NewIsNormalizerEnabled(ctx context.Context) *IsNormalizerEnabled {
+	return &IsNormalizerEnabled{
+		tableOp{base{ctx: ctx}},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (in *IsNormalizerEnabled) GetName() string {
+	return "IsNormalizerEnabled"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (in *IsNormalizerEnabled) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.IsNormalizerEnabledRequest{})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (in *IsNormalizerEnabled) NewResponse() proto.Message {
+	return &pb.IsNormalizerEnabledResponse{}
+}
+
+// Normalize represents a Normalize HBase call
+type Normalize struct {
+	tableOp
+}
+
+// NewNormalize creates a new Normalize request that will have the master run
+// the region normalizer now.  The master doesn't run it when it's off or some
+// regions are in transition.  It requires HBase 1.2 or later.  For use by the
+// admin client.
+func NewNormalize(ctx context.Context) *Normalize {
+	return &Normalize{
+		tableOp{base{ctx: ctx}},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (n *Normalize) GetName() string {
+	return "Normalize"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (n *Normalize) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.NormalizeRequest{})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (n *Normalize) NewResponse() proto.Message {
+	return &pb.NormalizeResponse{}
+}
@@ -6,8 +6,10 @@
 package hrpc
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 	"unsafe"
@@ -29,6 +31,9 @@ type RegionInfo interface {
 	GetStopKey() []byte
 	GetStartKey() []byte
 	GetTable() []byte
+	GetID() uint64
+	GetEncodedName() string
+	GetPB() *pb.RegionInfo
 	SetClient(client RegionClient)
 	GetClient() RegionClient
 }
@@ -83,12 +88,45 @@ type base struct {
 	resultch chan RPCResult
 
 	ctx context.Context
+
+	// Look up the region of this call in meta rather than in the cache.
+	noRegionCache bool
+
+	// Log the request and response protobufs of this call.
+	debugProtos bool
+
+	// How long to wait for the answer of the RegionServer, if not the
+	// default of the client.
+	timeout time.Duration
 }
 
 func (b *base) GetContext() context.Context {
 	return b.ctx
 }
 
+// GetNoRegionCache returns true if the region of this call is looked up in
+// meta rather than in the region cache of the client.
+func (b *base) GetNoRegionCache() bool {
+	return b.noRegionCache
+}
+
+// GetDebugProtos returns true if the request and response protobufs of this
+// call are logged.
+func (b *base) GetDebugProtos() bool {
+	return b.debugProtos
+}
+
+// GetTimeout returns how long this call waits for its RegionServer to answer,
+// or 0 if it uses the default timeout of the client.
+func (b *base) GetTimeout() time.Duration {
+	return b.timeout
+}
+
+// SetTimeout sets how long this call waits for its RegionServer to answer.
+func (b *base) SetTimeout(d time.Duration) {
+	b.timeout = d
+}
+
 func (b *base) GetRegion() RegionInfo {
 	return b.region
 }
@@ -98,10 +136,24 @@ func (b *base) SetRegion(region RegionInfo) {
 }
 
 func (b *base) regionSpecifier() *pb.RegionSpecifier {
+	return regionSpecifier(b.region)
+}
+
+// regionSpecifier returns the RegionSpecifier of the given region.
+func regionSpecifier(reg RegionInfo) *pb.RegionSpecifier {
 	regionType := pb.RegionSpecifier_REGION_NAME
 	return &pb.RegionSpecifier{
 		Type:  &regionType,
-		Value: []byte(b.region.GetName()),
+		Value: []byte(reg.GetName()),
+	}
+}
+
+// encodedRegionSpecifier returns the RegionSpecifier of the region with the
+// given encoded name.
+func encodedRegionSpecifier(name string) *pb.RegionSpecifier {
+	return &pb.RegionSpecifier{
+		Type:  pb.RegionSpecifier_ENCODED_REGION_NAME.Enum(),
+		Value: []byte(name),
 	}
 }
 
@@ -135,6 +187,22 @@ func (b *base) GetResultChan() chan RPCResult {
 	return b.resultch
 }
 
+// EmptyQualifier is the empty column qualifier.  Unlike the empty family,
+// which HBase rejects, it's a valid qualifier, distinct from no qualifier:
+// e.g. a Get of the family "cf" with the qualifiers []string{EmptyQualifier}
+// only returns the cells of the column "cf:", while a Get with no qualifiers
+// for "cf" returns all the cells of the family.
+const EmptyQualifier = ""
+
+// checkFamilies returns an error if one of the given column families is
+// empty, which HBase would reject.
+func checkFamilies(families map[string][]string) error {
+	if _, ok := families[""]; ok {
+		return errors.New("Column family can't be empty.")
+	}
+	return nil
+}
+
 // Families is used as a parameter for request creation. Adds families constraint to a request.
 func Families(fam map[string][]string) func(Call) error {
 	return func(g Call) error {
@@ -149,10 +217,48 @@ func Filters(fl filter.Filter) func(Call) error {
 	}
 }
 
+// Ranges is used as a parameter for request creation.
+// Restricts a Scan to the given disjoint row key ranges, which are half-open
+// like the range of a Scan ([start; stop[, an empty stop key meaning the end
+// of the table).  This is done with a MultiRowRangeFilter, combined with the
+// filters of the Scan if any, so that the RegionServers can skip the rows in
+// between the ranges.  The Scan starts at the smallest start row and stops at
+// the greatest stop row, unless it was created with NewScanRange, in which
+// case its range further restricts the given ranges.
+func Ranges(ranges [][2][]byte) func(Call) error {
+	return func(g Call) error {
+		scan, ok := g.(*Scan)
+		if !ok {
+			return errors.New("Ranges option can only be used with Scan queries.")
+		}
+		if len(ranges) == 0 {
+			return errors.New("Ranges option needs at least one range.")
+		}
+		var start, stop []byte
+		for i, r := range ranges {
+			if len(r[1]) != 0 && bytes.Compare(r[0], r[1]) >= 0 {
+				return fmt.Errorf("Range #%d is empty: start row %q is greater or equal"+
+					" to stop row %q", i, r[0], r[1])
+			}
+			if i == 0 || bytes.Compare(r[0], start) < 0 {
+				start = r[0]
+			}
+			if i == 0 || len(stop) != 0 && (len(r[1]) == 0 || bytes.Compare(r[1], stop) > 0) {
+				stop = r[1]
+			}
+		}
+		scan.ranges = ranges
+		scan.key = start
+		scan.startRow = start
+		scan.stopRow = stop
+		return nil
+	}
+}
+
 // TimeRange is used as a parameter for request creation. Adds TimeRange constraint to a request.
 // It will get values in range [from, to[ ('to' is exclusive).
 func TimeRange(from, to time.Time) func(Call) error {
-	return TimeRangeUint64(uint64(from.UnixNano()/1e6), uint64(to.UnixNano()/1e6))
+	return TimeRangeUint64(TimeToMillis(from), TimeToMillis(to))
 }
 
 // TimeRangeUint64 is used as a parameter for request creation.
@@ -181,6 +287,112 @@ func TimeRangeUint64(from, to uint64) func(Call) error {
 	}
 }
 
+// ClockSkew is used as a parameter for request creation.
+// Widens the time ranges of a Get or Scan request by the given allowance on
+// both ends, so that cells timestamped by RegionServers whose clock is off by
+// up to this much compared to the clock used to compute the time ranges
+// aren't silently left out.
+func ClockSkew(allowance time.Duration) func(Call) error {
+	return func(g Call) error {
+		if allowance < 0 {
+			return errors.New("ClockSkew allowance can't be negative.")
+		}
+		switch c := g.(type) {
+		default:
+			return errors.New("ClockSkew option can only be used with Get or Scan queries.")
+		case *Get:
+			c.clockSkew = allowance
+		case *Scan:
+			c.clockSkew = allowance
+		}
+		return nil
+	}
+}
+
+// widenTimeRange widens the given [from, to[ time range, in milliseconds, by
+// the given clock skew allowance.  MinTimestamp and MaxTimestamp are left
+// unchanged since the range is already open on that end.
+func widenTimeRange(from, to uint64, skew time.Duration) (uint64, uint64) {
+	ms := uint64(skew / time.Millisecond)
+	if from != MinTimestamp {
+		if from > ms {
+			from -= ms
+		} else {
+			from = MinTimestamp
+		}
+	}
+	if to != MaxTimestamp {
+		if to < MaxTimestamp-ms {
+			to += ms
+		} else {
+			to = MaxTimestamp
+		}
+	}
+	return from, to
+}
+
+// ColumnFamilyTimeRange is used as a parameter for request creation.
+// Like TimeRange, but only applies to the given column family, so that
+// different families can be read with different time windows.  It overrides
+// the TimeRange of the request for this family.
+func ColumnFamilyTimeRange(family string, from, to time.Time) func(Call) error {
+	return ColumnFamilyTimeRangeUint64(family, TimeToMillis(from), TimeToMillis(to))
+}
+
+// ColumnFamilyTimeRangeUint64 is used as a parameter for request creation.
+// Like TimeRangeUint64, but only applies to the given column family.
+// from and to should be in milliseconds.
+func ColumnFamilyTimeRangeUint64(family string, from, to uint64) func(Call) error {
+	return func(g Call) error {
+		if from >= to {
+			return fmt.Errorf("'from' timestamp (%dms) is greater"+
+				" or equal to 'to' timestamp (%dms)",
+				from, to)
+		}
+		var cfTimeRanges *map[string][2]uint64
+		switch c := g.(type) {
+		default:
+			return errors.New("ColumnFamilyTimeRange option can only be used " +
+				"with Get or Scan queries.")
+		case *Get:
+			cfTimeRanges = &c.cfTimeRanges
+		case *Scan:
+			cfTimeRanges = &c.cfTimeRanges
+		}
+		if *cfTimeRanges == nil {
+			*cfTimeRanges = make(map[string][2]uint64)
+		}
+		(*cfTimeRanges)[family] = [2]uint64{from, to}
+		return nil
+	}
+}
+
+// cfTimeRangesToPB converts per-family time ranges into their protobuf
+// representation, sorted by family for the sake of determinism.
+func cfTimeRangesToPB(cfTimeRanges map[string][2]uint64,
+	skew time.Duration) []*pb.ColumnFamilyTimeRange {
+	if len(cfTimeRanges) == 0 {
+		return nil
+	}
+	families := make([]string, 0, len(cfTimeRanges))
+	for family := range cfTimeRanges {
+		families = append(families, family)
+	}
+	sort.Strings(families)
+	ranges := make([]*pb.ColumnFamilyTimeRange, len(families))
+	for i, family := range families {
+		from, to := widenTimeRange(cfTimeRanges[family][0], cfTimeRanges[family][1], skew)
+		ranges[i] = &pb.ColumnFamilyTimeRange{
+			ColumnFamily: []byte(family),
+			TimeRange: &pb.TimeRange{
+				From: proto.Uint64(from),
+				To:   proto.Uint64(to),
+			},
+		}
+	}
+	return ranges
+}
+
 // MaxVersions is used as a parameter for request creation.
 // Adds MaxVersions constraint to a request.
 func MaxVersions(versions uint32) func(Call) error {
@@ -197,6 +409,41 @@ func MaxVersions(versions uint32) func(Call) error {
 	}
 }
 
+// StoreLimit is used as a parameter for request creation.
+// Limits the number of cells returned per column family for each row, which
+// allows to paginate through wide rows when combined with StoreOffset.
+// 0, the default, means no limit.
+func StoreLimit(limit uint32) func(Call) error {
+	return func(g Call) error {
+		switch c := g.(type) {
+		default:
+			return errors.New("StoreLimit option can only be used with Get or Scan queries.")
+		case *Get:
+			c.storeLimit = limit
+		case *Scan:
+			c.storeLimit = limit
+		}
+		return nil
+	}
+}
+
+// StoreOffset is used as a parameter for request creation.
+// Skips the given number of cells per column family for each row before
+// returning any.
+func StoreOffset(offset uint32) func(Call) error {
+	return func(g Call) error {
+		switch c := g.(type) {
+		default:
+			return errors.New("StoreOffset option can only be used with Get or Scan queries.")
+		case *Get:
+			c.storeOffset = offset
+		case *Scan:
+			c.storeOffset = offset
+		}
+		return nil
+	}
+}
+
 // NumberOfRows is used as a parameter for request creation.
 // Adds NumberOfRows constraint to a request.
 func NumberOfRows(n uint32) func(Call) error {
@@ -210,6 +457,200 @@ func NumberOfRows(n uint32) func(Call) error {
 	}
 }
 
+// LoadColumnFamiliesOnDemand is used as a parameter for request creation.
+// When enabled, the RegionServer only loads the column families that the
+// filter of a Scan deems essential, and loads the others only for the rows
+// that pass the filter.  This can significantly reduce IO when the filter only
+// references one small family.
+func LoadColumnFamiliesOnDemand(enabled bool) func(Call) error {
+	return func(g Call) error {
+		scan, ok := g.(*Scan)
+		if !ok {
+			return errors.New("LoadColumnFamiliesOnDemand option can only be used " +
+				"with Scan queries.")
+		}
+		scan.loadColumnFamiliesOnDemand = enabled
+		return nil
+	}
+}
+
+// TrackScanMetrics is used as a parameter for request creation.
+// When enabled, the RegionServers return metrics about the work they did for
+// a Scan (rows scanned and filtered, bytes read...), which can be retrieved
+// from the Scanner.
+func TrackScanMetrics(enabled bool) func(Call) error {
+	return func(g Call) error {
+		scan, ok := g.(*Scan)
+		if !ok {
+			return errors.New("TrackScanMetrics option can only be used with Scan queries.")
+		}
+		scan.trackScanMetrics = enabled
+		return nil
+	}
+}
+
+// ConsistencyType is used to set the consistency of reads with the
+// Consistency option.
+type ConsistencyType int32
+
+const (
+	// StrongConsistency is STRONG: reads are only served by the primary
+	// replica of a region.
+	StrongConsistency ConsistencyType = iota
+	// TimelineConsistency is TIMELINE: reads may be served by a secondary
+	// replica of a region, in which case results can be stale.
+	TimelineConsistency
+)
+
+// Consistency is used as a parameter for request creation.
+// Sets the consistency level of a Get or Scan request.
+func Consistency(consistency ConsistencyType) func(Call) error {
+	return func(g Call) error {
+		if consistency != StrongConsistency && consistency != TimelineConsistency {
+			return errors.New("Invalid consistency value.")
+		}
+		switch c := g.(type) {
+		default:
+			return errors.New("Consistency option can only be used with Get or Scan queries.")
+		case *Get:
+			c.consistency = consistency
+		case *Scan:
+			c.consistency = consistency
+		}
+		return nil
+	}
+}
+
+// ReplicaID is used as a parameter for request creation.
+// Sends a Get request to the given replica of its region, 0 being the primary
+// replica, instead of letting the client pick one.  A Get sent to a secondary
+// replica is always sent with TIMELINE consistency, and its result may be
+// stale.
+func ReplicaID(id uint32) func(Call) error {
+	return func(g Call) error {
+		get, ok := g.(*Get)
+		if !ok {
+			return errors.New("ReplicaID option can only be used with Get queries.")
+		}
+		get.replicaID = id
+		get.targetReplica = true
+		return nil
+	}
+}
+
+// ColumnOrder is used as a parameter for request creation.
+// Returns the cells of each row of a Get or Scan request with the cells of the
+// given family:qualifier columns first, in the given order, followed by the
+// cells of the other columns in the order HBase returns them.  HBase always
+// sorts the cells of a row by family and qualifier, so they are reordered by
+// the client once received, see Result.OrderColumns.
+func ColumnOrder(columns [][2]string) func(Call) error {
+	return func(g Call) error {
+		switch c := g.(type) {
+		default:
+			return errors.New("ColumnOrder option can only be used with Get or Scan queries.")
+		case *Get:
+			c.columnOrder = columns
+		case *Scan:
+			c.columnOrder = columns
+		}
+		return nil
+	}
+}
+
+// visibilityAttribute is the attribute of the Gets and Scans that holds their
+// authorizations, and of the mutations that holds their cell visibility, both
+// as protobufs.  They're enforced by the VisibilityController coprocessor.
+const visibilityAttribute = "VISIBILITY"
+
+// Authorizations is used as a parameter for request creation.
+// Sets the visibility labels a Get or Scan request is authorized to read, on
+// clusters running the VisibilityController coprocessor: the cells whose
+// visibility expression isn't satisfied by these labels aren't returned.  The
+// labels must also be granted to the user by the cluster.
+func Authorizations(labels ...string) func(Call) error {
+	return func(g Call) error {
+		switch c := g.(type) {
+		default:
+			return errors.New("Authorizations option can only be used with Get or Scan queries.")
+		case *Get:
+			c.authorizations = labels
+		case *Scan:
+			c.authorizations = labels
+		}
+		return nil
+	}
+}
+
+// authorizationsAttribute returns the attribute holding the given
+// authorizations.
+func authorizationsAttribute(labels []string) (*pb.NameBytesPair, error) {
+	value, err := proto.Marshal(&pb.Authorizations{Label: labels})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.NameBytesPair{Name: proto.String(visibilityAttribute), Value: value}, nil
+}
+
+// NoRegionCache is used as a parameter for request creation.
+// Makes the client look up the region of a Get, Scan or mutation in meta
+// every time it's sent, instead of using its region cache, and without
+// adding the region to the cache.  This is slow, and only meant to rule out
+// the cache when debugging or verifying data.
+func NoRegionCache(enabled bool) func(Call) error {
+	return func(g Call) error {
+		switch c := g.(type) {
+		default:
+			return errors.New("NoRegionCache option can only be used with Get, Scan " +
+				"or mutation queries.")
+		case *Get:
+			c.noRegionCache = enabled
+		case *Scan:
+			c.noRegionCache = enabled
+		case *Mutate:
+			c.noRegionCache = enabled
+		}
+		return nil
+	}
+}
+
+// DebugProtos is used as a parameter for request creation.
+// It sets whether the request and response protobufs of the call are logged,
+// with their values redacted, to diagnose what's sent to and received from
+// HBase on the wire.  See region.SetProtoLogging to log those of all calls.
+func DebugProtos(enabled bool) func(Call) error {
+	return func(g Call) error {
+		switch c := g.(type) {
+		default:
+			return errors.New("DebugProtos option can only be used with Get, Scan " +
+				"or mutation queries.")
+		case *Get:
+			c.debugProtos = enabled
+		case *Scan:
+			c.debugProtos = enabled
+		case *Mutate:
+			c.debugProtos = enabled
+		}
+		return nil
+	}
+}
+
+// Timeout is used as a parameter for request creation.
+// It sets how long a call waits for its RegionServer to answer every time
+// it's sent, overriding the timeout of the client.
+func Timeout(d time.Duration) func(Call) error {
+	return func(g Call) error {
+		c, ok := g.(interface {
+			SetTimeout(time.Duration)
+		})
+		if !ok {
+			return errors.New("Timeout option can't be used with this query.")
+		}
+		c.SetTimeout(d)
+		return nil
+	}
+}
+
 // Cell is the smallest level of granularity in returned results.
 // Represents a single cell in HBase (a row will have one cell for every qualifier).
 type Cell pb.Cell
@@ -240,4 +681,80 @@ func toLocalCells(pbr *pb.Result) []*Cell {
 	return *(*[]*Cell)(unsafe.Pointer(pbr))
 }
 
+// TimeToMillis converts the given time into an HBase timestamp, which is a
+// number of milliseconds since the Unix epoch.  Sub-millisecond precision is
+// truncated.
+func TimeToMillis(t time.Time) uint64 {
+	return uint64(t.UnixNano() / int64(time.Millisecond))
+}
+
+// MillisToTime converts the given HBase timestamp, in milliseconds since the
+// Unix epoch, into a time.Time.
+func MillisToTime(ms uint64) time.Time {
+	return time.Unix(int64(ms/1e3), int64(ms%1e3)*int64(time.Millisecond))
+}
+
+// Time returns the timestamp of this cell as a time.Time.
+func (c *Cell) Time() time.Time {
+	return MillisToTime(c.TimestampMillis())
+}
+
+// TimestampMillis returns the timestamp of this cell in milliseconds since
+// the Unix epoch, or 0 if it's not set.
+func (c *Cell) TimestampMillis() uint64 {
+	if c.Timestamp == nil {
+		return 0
+	}
+	return *c.Timestamp
+}
+
+// OrderColumns reorders the cells of this result, in place, so that the cells
+// of the given family:qualifier columns come first, in the given order.  The
+// relative order of the cells of a same column (its versions) and of the
+// cells of the columns that aren't listed is kept.
+func (r *Result) OrderColumns(columns [][2]string) {
+	if len(columns) == 0 || len(r.Cells) < 2 {
+		return
+	}
+	ranks := make(map[string]map[string]int, len(columns))
+	for i, col := range columns {
+		qualifiers, ok := ranks[col[0]]
+		if !ok {
+			qualifiers = make(map[string]int)
+			ranks[col[0]] = qualifiers
+		}
+		if _, ok := qualifiers[col[1]]; !ok {
+			qualifiers[col[1]] = i
+		}
+	}
+	byRank := cellsByRank{cells: r.Cells, ranks: make([]int, len(r.Cells))}
+	for i, cell := range r.Cells {
+		rank, ok := ranks[string(cell.Family)][string(cell.Qualifier)]
+		if !ok {
+			rank = len(columns)
+		}
+		byRank.ranks[i] = rank
+	}
+	sort.Stable(byRank)
+}
+
+// cellsByRank sorts cells by the rank of their column.
+type cellsByRank struct {
+	cells []*Cell
+	ranks []int
+}
+
+func (c cellsByRank) Len() int {
+	return len(c.cells)
+}
+
+func (c cellsByRank) Less(i, j int) bool {
+	return c.ranks[i] < c.ranks[j]
+}
+
+func (c cellsByRank) Swap(i, j int) {
+	c.cells[i], c.cells[j] = c.cells[j], c.cells[i]
+	c.ranks[i], c.ranks[j] = c.ranks[j], c.ranks[i]
+}
+
 // We can now define any helper functions on Result that we want.
@@ -0,0 +1,135 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/filter"
+	"github.com/tsuna/gohbase/pb"
+)
+
+// CheckAndMutate performs a provided Put or Delete operation if a condition
+// on its row holds: either the comparison of the value of a column with a
+// comparator, or a filter matching the row.
+type CheckAndMutate struct {
+	*Mutate
+
+	family      []byte
+	qualifier   []byte
+	compareType filter.CompareType
+	comparator  filter.Comparator
+
+	filter filter.Filter
+}
+
+// NewCheckAndMutate creates a new CheckAndMutate request that will perform
+// the provided put or delete request on the row if comparing the given
+// comparator with the value located at the mutation's row and provided
+// family:qualifier, using the given compare type, is true.  Like in HBase, the
+// comparator is on the left side: with the Less compare type, the mutation is
+// performed if the value of the comparator is less than the value of the
+// column.
+func NewCheckAndMutate(mutate *Mutate, family, qualifier string,
+	compareType filter.CompareType, comparator filter.Comparator) (*CheckAndMutate, error) {
+	if err := checkConditionalMutation(mutate); err != nil {
+		return nil, err
+	}
+	if compareType < filter.Less || compareType > filter.NoOp {
+		return nil, fmt.Errorf("Invalid compare type %d", compareType)
+	}
+	if comparator == nil {
+		return nil, errors.New("CheckAndMutate requires a comparator")
+	}
+	return &CheckAndMutate{
+		Mutate:      mutate,
+		family:      []byte(family),
+		qualifier:   []byte(qualifier),
+		compareType: compareType,
+		comparator:  comparator,
+	}, nil
+}
+
+// NewCheckAndMutateFilter creates a new CheckAndMutate request that will
+// perform the provided put or delete request on the row if the given filter
+// matches the row.  Filter-based conditions require HBase 2.
+func NewCheckAndMutateFilter(mutate *Mutate, f filter.Filter) (*CheckAndMutate, error) {
+	if err := checkConditionalMutation(mutate); err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, errors.New("CheckAndMutate requires a filter")
+	}
+	return &CheckAndMutate{
+		Mutate: mutate,
+		filter: f,
+	}, nil
+}
+
+// checkConditionalMutation returns an error if the given mutation can't be
+// performed conditionally.
+func checkConditionalMutation(mutate *Mutate) error {
+	if mutate.mutationType != pb.MutationProto_PUT &&
+		mutate.mutationType != pb.MutationProto_DELETE {
+		return errors.New("CheckAndMutate only takes Put or Delete requests")
+	}
+	return nil
+}
+
+// Serialize converts this mutate object into a protobuf message suitable for
+// sending to an HBase server
+func (cam *CheckAndMutate) Serialize() ([]byte, error) {
+	// The condition that needs to match for the edit to be applied.
+	condition, err := cam.condition()
+	if err != nil {
+		return nil, err
+	}
+
+	// The edit.
+	mutateRequest, err := cam.serializeToProto()
+	if err != nil {
+		return nil, fmt.Errorf("Error serializing request: %s", err)
+	}
+	mutateRequest.Condition = condition
+
+	return proto.Marshal(mutateRequest)
+}
+
+// condition returns the condition that needs to match for the edit to be
+// applied.
+func (cam *CheckAndMutate) condition() (*pb.Condition, error) {
+	if cam.filter != nil {
+		pbFilter, err := cam.filter.ConstructPBFilter()
+		if err != nil {
+			return nil, err
+		}
+		return &pb.Condition{
+			Row:    cam.key,
+			Filter: pbFilter,
+		}, nil
+	}
+	return newCondition(cam.key, cam.family, cam.qualifier, cam.compareType, cam.comparator)
+}
+
+// newCondition returns the condition comparing the value of the given column
+// with the given comparator.
+func newCondition(row, family, qualifier []byte, compareType filter.CompareType,
+	comparator filter.Comparator) (*pb.Condition, error) {
+	pbComparator, err := comparator.ConstructPBComparator()
+	if err != nil {
+		return nil, err
+	}
+	pbCompareType := pb.CompareType(compareType)
+	return &pb.Condition{
+		Row:         row,
+		Family:      family,
+		Qualifier:   qualifier,
+		CompareType: &pbCompareType,
+		Comparator:  pbComparator,
+	}, nil
+}
@@ -18,9 +18,9 @@ import (
 type CheckAndPut struct {
 	*Mutate
 
-	family    []byte
-	qualifier []byte
-	value     []byte
+	family     []byte
+	qualifier  []byte
+	comparator filter.Comparator
 }
 
 // NewCheckAndPut creates a new CheckAndPut request that will compare provided
@@ -28,15 +28,30 @@ type CheckAndPut struct {
 // and if they are equal, perform the provided put request on the row
 func NewCheckAndPut(put *Mutate, family string,
 	qualifier string, expectedValue []byte) (*CheckAndPut, error) {
+	expected := filter.NewByteArrayComparable(expectedValue)
+	return NewCheckAndPutComparator(put, family, qualifier,
+		filter.NewBinaryComparator(expected))
+}
+
+// NewCheckAndPutComparator creates a new CheckAndPut request that will perform
+// the provided put request on the row if the value located at put's row and
+// provided family:qualifier is equal to the value of the given comparator, as
+// defined by this comparator (e.g. a LongComparator compares the values as
+// 64-bit integers).
+func NewCheckAndPutComparator(put *Mutate, family string, qualifier string,
+	comparator filter.Comparator) (*CheckAndPut, error) {
 	if put.mutationType != pb.MutationProto_PUT {
 		return nil, fmt.Errorf("CheckAndPut only takes Put request")
 	}
+	if comparator == nil {
+		return nil, fmt.Errorf("CheckAndPut requires a comparator")
+	}
 
 	return &CheckAndPut{
-		Mutate:    put,
-		family:    []byte(family),
-		qualifier: []byte(qualifier),
-		value:     expectedValue,
+		Mutate:     put,
+		family:     []byte(family),
+		qualifier:  []byte(qualifier),
+		comparator: comparator,
 	}, nil
 }
 
@@ -44,10 +59,7 @@ func NewCheckAndPut(put *Mutate, family string,
 // sending to an HBase server
 func (cas *CheckAndPut) Serialize() ([]byte, error) {
 	// The condition that needs to match for the edit to be applied.
-	expectedValue := filter.NewByteArrayComparable(cas.value)
-	cmp := filter.NewBinaryComparator(expectedValue)
-
-	comparator, err := cmp.ConstructPBComparator()
+	condition, err := cas.condition()
 	if err != nil {
 		return nil, err
 	}
@@ -57,15 +69,13 @@ func (cas *CheckAndPut) Serialize() ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("Error serializing request: %s", err)
 	}
-
-	compareType := pb.CompareType_EQUAL
-	mutateRequest.Condition = &pb.Condition{
-		Row:         cas.key,
-		Family:      cas.family,
-		Qualifier:   cas.qualifier,
-		CompareType: &compareType,
-		Comparator:  comparator,
-	}
+	mutateRequest.Condition = condition
 
 	return proto.Marshal(mutateRequest)
 }
+
+// condition returns the condition that needs to match for the edit to be
+// applied.
+func (cas *CheckAndPut) condition() (*pb.Condition, error) {
+	return newCondition(cas.key, cas.family, cas.qualifier, filter.Equal, cas.comparator)
+}
@@ -0,0 +1,96 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// CompactRegion represents a CompactRegion HBase call, which is sent to the
+// AdminService of the RegionServer serving a region.
+type CompactRegion struct {
+	tableOp
+
+	major  bool
+	family []byte
+}
+
+// NewCompactRegion creates a new CompactRegion request that will queue a
+// compaction of all the stores of the region of the given table that holds
+// the given row key, or only of the store of the family given with the
+// CompactFamily option.  The compaction is a minor one unless the
+// MajorCompaction option is given.
+func NewCompactRegion(ctx context.Context, table, key []byte,
+	options ...func(Call) error) (*CompactRegion, error) {
+	cr := &CompactRegion{
+		tableOp: tableOp{base{
+			table: table,
+			key:   key,
+			ctx:   ctx,
+		}},
+	}
+	err := applyOptions(cr, options...)
+	if err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// MajorCompaction makes the compaction a major one, which rewrites all the
+// store files into one, dropping the deleted and expired cells.
+func MajorCompaction() func(Call) error {
+	return func(g Call) error {
+		cr, ok := g.(*CompactRegion)
+		if !ok {
+			return errors.New("MajorCompaction option can only be used with NewCompactRegion.")
+		}
+		cr.major = true
+		return nil
+	}
+}
+
+// CompactFamily only compacts the store of the given column family.
+func CompactFamily(family string) func(Call) error {
+	return func(g Call) error {
+		cr, ok := g.(*CompactRegion)
+		if !ok {
+			return errors.New("CompactFamily option can only be used with NewCompactRegion.")
+		}
+		if family == "" {
+			return errors.New("Column family can't be empty.")
+		}
+		cr.family = []byte(family)
+		return nil
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (cr *CompactRegion) GetName() string {
+	return "CompactRegion"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (cr *CompactRegion) Serialize() ([]byte, error) {
+	req := &pb.CompactRegionRequest{
+		Region: cr.regionSpecifier(),
+		Family: cr.family,
+	}
+	if cr.major {
+		req.Major = proto.Bool(true)
+	}
+	return proto.Marshal(req)
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (cr *CompactRegion) NewResponse() proto.Message {
+	return &pb.CompactRegionResponse{}
+}
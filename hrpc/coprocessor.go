@@ -0,0 +1,63 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+)
+
+// coprocessorCall represents an ExecService HBase call, which calls a method
+// of a coprocessor endpoint of the region of a table that holds a row key.
+type coprocessorCall struct {
+	tableOp
+
+	service string
+	method  string
+	request proto.Message
+}
+
+// GetName returns the name of this RPC call.
+func (cc *coprocessorCall) GetName() string {
+	return "ExecService"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (cc *coprocessorCall) Serialize() ([]byte, error) {
+	request, err := proto.Marshal(cc.request)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(&pb.CoprocessorServiceRequest{
+		Region: cc.regionSpecifier(),
+		Call: &pb.CoprocessorServiceCall{
+			Row:         cc.key,
+			ServiceName: proto.String(cc.service),
+			MethodName:  proto.String(cc.method),
+			Request:     request,
+		},
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (cc *coprocessorCall) NewResponse() proto.Message {
+	return &pb.CoprocessorServiceResponse{}
+}
+
+// UnmarshalCoprocessorResponse decodes the response of the coprocessor
+// endpoint carried by the given response of an ExecService call into the
+// given message.
+func UnmarshalCoprocessorResponse(res proto.Message, msg proto.Message) error {
+	cres, ok := res.(*pb.CoprocessorServiceResponse)
+	if !ok {
+		return fmt.Errorf("got a %T instead of CoprocessorServiceResponse", res)
+	}
+	return proto.Unmarshal(cres.GetValue().GetValue(), msg)
+}
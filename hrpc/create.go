@@ -6,7 +6,11 @@
 package hrpc
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"math/big"
+	"sort"
 	"strconv"
 
 	"github.com/golang/protobuf/proto"
@@ -20,8 +24,27 @@ type CreateTable struct {
 
 	columns    []string
 	attributes map[string]string
+
+	// Attributes of given column families overriding those above.
+	familyAttributes map[string]map[string]string
+
+	// Keys at which the table is initially split, in order.
+	splitKeys [][]byte
 }
 
+var (
+	// bloomFilterTypes are the valid values of the BLOOMFILTER attribute.
+	bloomFilterTypes = map[string]struct{}{
+		"NONE": struct{}{}, "ROW": struct{}{}, "ROWCOL": struct{}{},
+	}
+
+	// compressionTypes are the valid values of the COMPRESSION attribute.
+	compressionTypes = map[string]struct{}{
+		"NONE": struct{}{}, "LZO": struct{}{}, "GZ": struct{}{}, "SNAPPY": struct{}{},
+		"LZ4": struct{}{}, "BZIP2": struct{}{}, "ZSTD": struct{}{},
+	}
+)
+
 // NewCreateTable creates a new CreateTable request that will create the given
 // table in HBase. For use by the admin client.
 func NewCreateTable(ctx context.Context, table []byte, columns []string,
@@ -53,17 +76,149 @@ func NewCreateTable(ctx context.Context, table []byte, columns []string,
 	return ct, nil
 }
 
-// TODO: do we need to validate attributes or hbase will do it for us?
+// FamilyAttributes sets attributes of the given column family, e.g.
+// {"VERSIONS": "1", "COMPRESSION": "SNAPPY"}, overriding for this family
+// those set by the other options.  The family is added to the columns of the
+// table if needed.  The attributes aren't validated, HBase rejects the
+// invalid ones.
+func FamilyAttributes(family string, attributes map[string]string) func(Call) error {
+	return func(g Call) error {
+		ct, ok := g.(*CreateTable)
+		if !ok {
+			return errors.New("FamilyAttributes option can only be used with NewCreateTable.")
+		}
+		if family == "" {
+			return errors.New("Column family can't be empty.")
+		}
+		if ct.familyAttributes == nil {
+			ct.familyAttributes = make(map[string]map[string]string)
+		}
+		if _, ok := ct.familyAttributes[family]; !ok {
+			ct.familyAttributes[family] = make(map[string]string, len(attributes))
+			if !ct.hasColumn(family) {
+				ct.columns = append(ct.columns, family)
+			}
+		}
+		for key, attr := range attributes {
+			ct.familyAttributes[family][key] = attr
+		}
+		return nil
+	}
+}
+
+// SplitKeys makes the table start with a region for each of the given split
+// keys in addition to the first region, rather than as a single region where
+// all the writes go until it's split.  The keys can be given in any order but
+// mustn't be empty nor repeated.  HexStringSplit and UniformSplit compute
+// split keys for common key schemes.
+func SplitKeys(keys [][]byte) func(Call) error {
+	return func(g Call) error {
+		ct, ok := g.(*CreateTable)
+		if !ok {
+			return errors.New("SplitKeys option can only be used with NewCreateTable.")
+		}
+		sorted := make([][]byte, len(keys))
+		copy(sorted, keys)
+		sort.Sort(byteSlices(sorted))
+		for i, key := range sorted {
+			if len(key) == 0 {
+				return errors.New("Split keys can't be empty.")
+			}
+			if i > 0 && bytes.Equal(key, sorted[i-1]) {
+				return fmt.Errorf("Split key %q is repeated.", key)
+			}
+		}
+		ct.splitKeys = sorted
+		return nil
+	}
+}
+
+type byteSlices [][]byte
+
+func (b byteSlices) Len() int           { return len(b) }
+func (b byteSlices) Less(i, j int) bool { return bytes.Compare(b[i], b[j]) < 0 }
+func (b byteSlices) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// HexStringSplit returns the keys splitting a table into the given number of
+// regions, for row keys that start with 8 lower case hexadecimal characters,
+// e.g. the hexadecimal hash of something, like the HexStringSplit algorithm
+// of HBase.
+func HexStringSplit(regions int) [][]byte {
+	keys := splitRange(regions, big.NewInt(0), big.NewInt(0xFFFFFFFF))
+	for i, key := range keys {
+		keys[i] = []byte(fmt.Sprintf("%08x", new(big.Int).SetBytes(key)))
+	}
+	return keys
+}
+
+// UniformSplit returns the keys splitting the [start; stop] range of row keys
+// into the given number of regions of the same size, for row keys made of
+// uniformly distributed bytes, like the UniformSplit algorithm of HBase.  The
+// keys are as long as the longest of start and stop, which are padded with 0
+// bytes.  With an empty stop key, the range ends with 8 0xFF bytes.
+func UniformSplit(regions int, start, stop []byte) [][]byte {
+	if len(stop) == 0 {
+		stop = bytes.Repeat([]byte{0xFF}, 8)
+	}
+	size := len(start)
+	if len(stop) > size {
+		size = len(stop)
+	}
+	pad := func(key []byte) *big.Int {
+		padded := make([]byte, size)
+		copy(padded, key)
+		return new(big.Int).SetBytes(padded)
+	}
+	keys := splitRange(regions, pad(start), pad(stop))
+	for i, key := range keys {
+		// Left-pad the keys with 0 bytes back to the size of the range.
+		keys[i] = append(make([]byte, size-len(key)), key...)
+	}
+	return keys
+}
+
+// splitRange returns the big-endian bytes of the numbers splitting the
+// [first; last] range into the given number of parts of the same size.
+func splitRange(parts int, first, last *big.Int) [][]byte {
+	if parts < 2 {
+		return nil
+	}
+	size := new(big.Int).Sub(last, first)
+	size.Add(size, big.NewInt(1))
+	size.Div(size, big.NewInt(int64(parts)))
+	keys := make([][]byte, 0, parts-1)
+	for i := 1; i < parts; i++ {
+		key := new(big.Int).Mul(size, big.NewInt(int64(i)))
+		keys = append(keys, key.Add(key, first).Bytes())
+	}
+	return keys
+}
+
+// schemaAttributes returns the attributes of the column families created or
+// altered by the given call, if any.
+func schemaAttributes(g Call) (map[string]string, bool) {
+	switch c := g.(type) {
+	case *CreateTable:
+		return c.attributes, true
+	case *AddColumnFamily:
+		return c.attributes, true
+	case *ModifyColumnFamily:
+		return c.attributes, true
+	}
+	return nil, false
+}
 
 // Bloomfilter sets BLOOMFILTER attribute of column-family.
 func Bloomfilter(typ string) func(Call) error {
 	return func(g Call) error {
-		ct, ok := g.(*CreateTable)
+		attrs, ok := schemaAttributes(g)
 		if !ok {
-			return errors.New("Bloomfilter option can only be used with NewCreateTable.")
+			return errors.New("Bloomfilter option can only be used with family schemas.")
 		}
-		// TODO: validate typ
-		ct.attributes["BLOOMFILTER"] = typ
+		if _, ok := bloomFilterTypes[typ]; !ok {
+			return fmt.Errorf("Invalid bloom filter type %q.", typ)
+		}
+		attrs["BLOOMFILTER"] = typ
 		return nil
 	}
 }
@@ -71,12 +226,14 @@ func Bloomfilter(typ string) func(Call) error {
 // Versions sets VERSIONS attribute of column-family.
 func Versions(n int) func(Call) error {
 	return func(g Call) error {
-		ct, ok := g.(*CreateTable)
+		attrs, ok := schemaAttributes(g)
 		if !ok {
-			return errors.New("Versions option can only be used with NewCreateTable.")
+			return errors.New("Versions option can only be used with family schemas.")
+		}
+		if n < 1 {
+			return errors.New("Versions must be at least 1.")
 		}
-		// TODO: validate versions
-		ct.attributes["VERSIONS"] = strconv.Itoa(n)
+		attrs["VERSIONS"] = strconv.Itoa(n)
 		return nil
 	}
 }
@@ -84,11 +241,11 @@ func Versions(n int) func(Call) error {
 // InMemory sets IN_MEMORY attribute of column-family.
 func InMemory(isInMemory bool) func(Call) error {
 	return func(g Call) error {
-		ct, ok := g.(*CreateTable)
+		attrs, ok := schemaAttributes(g)
 		if !ok {
-			return errors.New("InMemory option can only be used with NewCreateTable.")
+			return errors.New("InMemory option can only be used with family schemas.")
 		}
-		ct.attributes["IN_MEMORY"] = strconv.FormatBool(isInMemory)
+		attrs["IN_MEMORY"] = strconv.FormatBool(isInMemory)
 		return nil
 	}
 }
@@ -96,11 +253,11 @@ func InMemory(isInMemory bool) func(Call) error {
 // KeepDeletedCells sets KEEP_DELETED_CELLS attribute of column-family.
 func KeepDeletedCells(keep bool) func(Call) error {
 	return func(g Call) error {
-		ct, ok := g.(*CreateTable)
+		attrs, ok := schemaAttributes(g)
 		if !ok {
-			return errors.New("KeepDeletedCells option can only be used with NewCreateTable.")
+			return errors.New("KeepDeletedCells option can only be used with family schemas.")
 		}
-		ct.attributes["KEEP_DELETED_CELLS"] = strconv.FormatBool(keep)
+		attrs["KEEP_DELETED_CELLS"] = strconv.FormatBool(keep)
 		return nil
 	}
 }
@@ -108,12 +265,12 @@ func KeepDeletedCells(keep bool) func(Call) error {
 // DataBlockEncoding sets DATA_BLOCK_ENCODING attribute of column-family.
 func DataBlockEncoding(typ string) func(Call) error {
 	return func(g Call) error {
-		ct, ok := g.(*CreateTable)
+		attrs, ok := schemaAttributes(g)
 		if !ok {
-			return errors.New("DataBlockEncoding option can only be used with NewCreateTable.")
+			return errors.New("DataBlockEncoding option can only be used with family schemas.")
 		}
 		// TODO: validate typ
-		ct.attributes["DATA_BLOCK_ENCODING"] = typ
+		attrs["DATA_BLOCK_ENCODING"] = typ
 		return nil
 	}
 }
@@ -121,12 +278,14 @@ func DataBlockEncoding(typ string) func(Call) error {
 // TimeToLive sets TTL attribute of column-family.
 func TimeToLive(seconds int) func(Call) error {
 	return func(g Call) error {
-		ct, ok := g.(*CreateTable)
+		attrs, ok := schemaAttributes(g)
 		if !ok {
-			return errors.New("TimeToLive option can only be used with NewCreateTable.")
+			return errors.New("TimeToLive option can only be used with family schemas.")
+		}
+		if seconds < 1 {
+			return errors.New("TimeToLive must be at least a second.")
 		}
-		// TODO: validate seconds
-		ct.attributes["TTL"] = strconv.Itoa(seconds)
+		attrs["TTL"] = strconv.Itoa(seconds)
 		return nil
 	}
 }
@@ -134,12 +293,14 @@ func TimeToLive(seconds int) func(Call) error {
 // Compression sets COMPRESSION attribute of column-family.
 func Compression(typ string) func(Call) error {
 	return func(g Call) error {
-		ct, ok := g.(*CreateTable)
+		attrs, ok := schemaAttributes(g)
 		if !ok {
-			return errors.New("Compression option can only be used with NewCreateTable.")
+			return errors.New("Compression option can only be used with family schemas.")
+		}
+		if _, ok := compressionTypes[typ]; !ok {
+			return fmt.Errorf("Invalid compression type %q.", typ)
 		}
-		// TODO: validate type
-		ct.attributes["COMPRESSION"] = typ
+		attrs["COMPRESSION"] = typ
 		return nil
 	}
 }
@@ -147,12 +308,14 @@ func Compression(typ string) func(Call) error {
 // MinVersions sets MIN_VERSIONS attribute of column-family.
 func MinVersions(n int) func(Call) error {
 	return func(g Call) error {
-		ct, ok := g.(*CreateTable)
+		attrs, ok := schemaAttributes(g)
 		if !ok {
-			return errors.New("MinVersions option can only be used with NewCreateTable.")
+			return errors.New("MinVersions option can only be used with family schemas.")
 		}
-		// TODO: validate n
-		ct.attributes["MIN_VERSIONS"] = strconv.Itoa(n)
+		if n < 0 {
+			return errors.New("MinVersions can't be negative.")
+		}
+		attrs["MIN_VERSIONS"] = strconv.Itoa(n)
 		return nil
 	}
 }
@@ -160,11 +323,11 @@ func MinVersions(n int) func(Call) error {
 // Blockcache sets BLOCKCACHE attribute of column-family.
 func Blockcache(isBlockCache bool) func(Call) error {
 	return func(g Call) error {
-		ct, ok := g.(*CreateTable)
+		attrs, ok := schemaAttributes(g)
 		if !ok {
-			return errors.New("Blockcache option can only be used with NewCreateTable.")
+			return errors.New("Blockcache option can only be used with family schemas.")
 		}
-		ct.attributes["BLOCKCACHE"] = strconv.FormatBool(isBlockCache)
+		attrs["BLOCKCACHE"] = strconv.FormatBool(isBlockCache)
 		return nil
 	}
 }
@@ -172,12 +335,14 @@ func Blockcache(isBlockCache bool) func(Call) error {
 // Blocksize sets BLOCKSIZE attribute of column-family.
 func Blocksize(kb int) func(Call) error {
 	return func(g Call) error {
-		ct, ok := g.(*CreateTable)
+		attrs, ok := schemaAttributes(g)
 		if !ok {
-			return errors.New("Blocksize option can only be used with NewCreateTable.")
+			return errors.New("Blocksize option can only be used with family schemas.")
+		}
+		if kb < 1 {
+			return errors.New("Blocksize must be positive.")
 		}
-		// TODO: validate kb
-		ct.attributes["BLOCKSIZE"] = strconv.Itoa(kb)
+		attrs["BLOCKSIZE"] = strconv.Itoa(kb)
 		return nil
 	}
 }
@@ -185,12 +350,12 @@ func Blocksize(kb int) func(Call) error {
 // ReplicationScope sets REPLICATION_SCOPE attribute of column-family.
 func ReplicationScope(n int) func(Call) error {
 	return func(g Call) error {
-		ct, ok := g.(*CreateTable)
+		attrs, ok := schemaAttributes(g)
 		if !ok {
-			return errors.New("ReplicationScope option can only be used with NewCreateTable.")
+			return errors.New("ReplicationScope option can only be used with family schemas.")
 		}
 		// TODO: validate n
-		ct.attributes["REPLICATION_SCOPE"] = strconv.Itoa(n)
+		attrs["REPLICATION_SCOPE"] = strconv.Itoa(n)
 		return nil
 	}
 }
@@ -204,33 +369,80 @@ func (ct *CreateTable) GetName() string {
 // the network
 func (ct *CreateTable) Serialize() ([]byte, error) {
 	pbcols := make([]*pb.ColumnFamilySchema, len(ct.columns))
-	attrs := make([]*pb.BytesBytesPair, 0, len(ct.attributes))
-	for key, attr := range ct.attributes {
-		attrs = append(attrs, &pb.BytesBytesPair{
-			First:  []byte(key),
-			Second: []byte(attr),
-		})
-	}
-
-	// TODO: change to be able to specify attributes per column family
 	for i, col := range ct.columns {
 		pbcols[i] = &pb.ColumnFamilySchema{
 			Name:       []byte(col),
-			Attributes: attrs,
+			Attributes: ct.familySchema(col),
 		}
 	}
 	ctable := &pb.CreateTableRequest{
 		TableSchema: &pb.TableSchema{
-			TableName: &pb.TableName{
-				Namespace: []byte("default"),
-				Qualifier: ct.table,
-			},
+			TableName:      ProtoTableName(ct.table),
 			ColumnFamilies: pbcols,
 		},
+		SplitKeys: ct.splitKeys,
 	}
 	return proto.Marshal(ctable)
 }
 
+// hasColumn returns true if the given column family is one of the columns of
+// the table.
+func (ct *CreateTable) hasColumn(family string) bool {
+	for _, col := range ct.columns {
+		if col == family {
+			return true
+		}
+	}
+	return false
+}
+
+// familySchema returns the attributes of the given column family, sorted by
+// name.
+func (ct *CreateTable) familySchema(family string) []*pb.BytesBytesPair {
+	attributes := make(map[string]string, len(ct.attributes))
+	for key, attr := range ct.attributes {
+		attributes[key] = attr
+	}
+	for key, attr := range ct.familyAttributes[family] {
+		attributes[key] = attr
+	}
+	return attributePairs(attributes)
+}
+
+// attributePairs returns the given attributes sorted by name.
+func attributePairs(attributes map[string]string) []*pb.BytesBytesPair {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attrs := make([]*pb.BytesBytesPair, len(keys))
+	for i, key := range keys {
+		attrs[i] = &pb.BytesBytesPair{
+			First:  []byte(key),
+			Second: []byte(attributes[key]),
+		}
+	}
+	return attrs
+}
+
+// configurationPairs returns the given configuration sorted by name.
+func configurationPairs(configuration map[string]string) []*pb.NameStringPair {
+	keys := make([]string, 0, len(configuration))
+	for key := range configuration {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]*pb.NameStringPair, len(keys))
+	for i, key := range keys {
+		pairs[i] = &pb.NameStringPair{
+			Name:  proto.String(key),
+			Value: proto.String(configuration[key]),
+		}
+	}
+	return pairs
+}
+
 // NewResponse creates an empty protobuf message to read the response of this
 // RPC.
 func (ct *CreateTable) NewResponse() proto.Message {
@@ -37,10 +37,7 @@ func (dt *DeleteTable) GetName() string {
 // the network
 func (dt *DeleteTable) Serialize() ([]byte, error) {
 	dtreq := &pb.DeleteTableRequest{
-		TableName: &pb.TableName{
-			Namespace: []byte("default"),
-			Qualifier: dt.table,
-		},
+		TableName: ProtoTableName(dt.table),
 	}
 	return proto.Marshal(dtreq)
 }
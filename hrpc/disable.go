@@ -37,10 +37,7 @@ func (dt *DisableTable) GetName() string {
 // the network
 func (dt *DisableTable) Serialize() ([]byte, error) {
 	dtreq := &pb.DisableTableRequest{
-		TableName: &pb.TableName{
-			Namespace: []byte("default"),
-			Qualifier: dt.table,
-		},
+		TableName: ProtoTableName(dt.table),
 	}
 	return proto.Marshal(dtreq)
 }
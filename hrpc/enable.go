@@ -37,10 +37,7 @@ func (et *EnableTable) GetName() string {
 // the network
 func (et *EnableTable) Serialize() ([]byte, error) {
 	dtreq := &pb.EnableTableRequest{
-		TableName: &pb.TableName{
-			Namespace: []byte("default"),
-			Qualifier: et.table,
-		},
+		TableName: ProtoTableName(et.table),
 	}
 	return proto.Marshal(dtreq)
 }
@@ -0,0 +1,48 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// FlushRegion represents a FlushRegion HBase call, which is sent to the
+// AdminService of the RegionServer serving a region.
+type FlushRegion struct {
+	tableOp
+}
+
+// NewFlushRegion creates a new FlushRegion request that will flush the
+// memstores of the region of the given table that holds the given row key to
+// store files.
+func NewFlushRegion(ctx context.Context, table, key []byte) *FlushRegion {
+	return &FlushRegion{
+		tableOp{base{
+			table: table,
+			key:   key,
+			ctx:   ctx,
+		}},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (fr *FlushRegion) GetName() string {
+	return "FlushRegion"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (fr *FlushRegion) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.FlushRegionRequest{Region: fr.regionSpecifier()})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (fr *FlushRegion) NewResponse() proto.Message {
+	return &pb.FlushRegionResponse{}
+}
@@ -6,6 +6,9 @@
 package hrpc
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/golang/protobuf/proto"
 	"github.com/tsuna/gohbase/filter"
 	"github.com/tsuna/gohbase/pb"
@@ -29,8 +32,29 @@ type Get struct {
 	fromTimestamp uint64
 	toTimestamp   uint64
 
+	// Per column family time ranges, as [from, to[ pairs.
+	cfTimeRanges map[string][2]uint64
+
+	// Allowance by which the time ranges are widened.
+	clockSkew time.Duration
+
 	maxVersions uint32
 
+	storeLimit  uint32
+	storeOffset uint32
+
+	consistency ConsistencyType
+
+	// Replica of the region to send this request to, if targetReplica is set.
+	replicaID     uint32
+	targetReplica bool
+
+	// Order in which the columns of the result are returned, if any.
+	columnOrder [][2]string
+
+	// Visibility labels the request is authorized to read, if any.
+	authorizations []string
+
 	filters filter.Filter
 }
 
@@ -51,6 +75,9 @@ func baseGet(ctx context.Context, table []byte, key []byte,
 	if err != nil {
 		return nil, err
 	}
+	if err = checkColumnValueFilter(g.families, g.filters); err != nil {
+		return nil, err
+	}
 	return g, nil
 }
 
@@ -66,8 +93,30 @@ func NewGetStr(ctx context.Context, table, key string,
 	return NewGet(ctx, []byte(table), []byte(key), options...)
 }
 
+// NewGetExists creates a new Get request that only checks whether the given
+// row exists in the given table, without returning any of its cells.  The
+// RegionServer can often answer it from its bloom filters.
+func NewGetExists(ctx context.Context, table, key []byte,
+	options ...func(Call) error) (*Get, error) {
+	g, err := baseGet(ctx, table, key, options...)
+	if err != nil {
+		return nil, err
+	}
+	g.existsOnly = true
+	return g, nil
+}
+
+// NewGetExistsStr creates a new Get request that only checks whether the
+// given row exists in the given table.
+func NewGetExistsStr(ctx context.Context, table, key string,
+	options ...func(Call) error) (*Get, error) {
+	return NewGetExists(ctx, []byte(table), []byte(key), options...)
+}
+
 // NewGetBefore creates a new Get request for the row with a key equal to or
-// immediately less than the given key, in the given table.
+// immediately less than the given key, in the given table.  If the region of
+// the key has no such row, Client.Get looks for it in the regions before.
+// Note that HBase 2.0 and above don't support this kind of Get anymore.
 func NewGetBefore(ctx context.Context, table, key []byte,
 	options ...func(Call) error) (*Get, error) {
 	g, err := baseGet(ctx, table, key, options...)
@@ -78,6 +127,43 @@ func NewGetBefore(ctx context.Context, table, key []byte,
 	return g, nil
 }
 
+// CloneWithKey returns a copy of this Get request for the given row key.
+// This is an internal method, users are not expected to use it.
+func (g *Get) CloneWithKey(key []byte) *Get {
+	// Every field but the base, which can't be copied, must be copied here.
+	return &Get{
+		base: base{
+			table:         g.table,
+			key:           key,
+			ctx:           g.ctx,
+			noRegionCache: g.noRegionCache,
+			debugProtos:   g.debugProtos,
+		},
+		families:       g.families,
+		closestBefore:  g.closestBefore,
+		existsOnly:     g.existsOnly,
+		fromTimestamp:  g.fromTimestamp,
+		toTimestamp:    g.toTimestamp,
+		cfTimeRanges:   g.cfTimeRanges,
+		clockSkew:      g.clockSkew,
+		maxVersions:    g.maxVersions,
+		storeLimit:     g.storeLimit,
+		storeOffset:    g.storeOffset,
+		consistency:    g.consistency,
+		replicaID:      g.replicaID,
+		targetReplica:  g.targetReplica,
+		columnOrder:    g.columnOrder,
+		authorizations: g.authorizations,
+		filters:        g.filters,
+	}
+}
+
+// IsClosestBefore returns true if this Get request returns the row right
+// before its key when there is no row for this key.
+func (g *Get) IsClosestBefore() bool {
+	return g.closestBefore
+}
+
 // GetName returns the name of this RPC call.
 func (g *Get) GetName() string {
 	return "Get"
@@ -93,6 +179,59 @@ func (g *Get) GetFamilies() map[string][]string {
 	return g.families
 }
 
+// GetConsistency returns the consistency level of this Get request.
+func (g *Get) GetConsistency() ConsistencyType {
+	return g.consistency
+}
+
+// GetReplicaID returns the ID of the replica of the region this Get request
+// must be sent to, and false if it can be sent to whichever replica the
+// client picks.
+func (g *Get) GetReplicaID() (uint32, bool) {
+	return g.replicaID, g.targetReplica
+}
+
+// GetTimeRange returns the time range of this Get request, in milliseconds.
+func (g *Get) GetTimeRange() (uint64, uint64) {
+	return g.fromTimestamp, g.toTimestamp
+}
+
+// GetClockSkew returns the allowance by which the time ranges of this Get
+// request are widened.
+func (g *Get) GetClockSkew() time.Duration {
+	return g.clockSkew
+}
+
+// GetColumnOrder returns the order in which the columns of the result of this
+// Get request are returned, nil if they're returned in the order of HBase.
+func (g *Get) GetColumnOrder() [][2]string {
+	return g.columnOrder
+}
+
+// GetAuthorizations returns the visibility labels this Get request is
+// authorized to read.
+func (g *Get) GetAuthorizations() []string {
+	return g.authorizations
+}
+
+// GetColumnFamilyTimeRanges returns the per column family time ranges of
+// this Get request, as [from, to[ pairs in milliseconds.
+func (g *Get) GetColumnFamilyTimeRanges() map[string][2]uint64 {
+	return g.cfTimeRanges
+}
+
+// GetStoreLimit returns the maximum number of cells per column family
+// returned by this Get request, 0 meaning no limit.
+func (g *Get) GetStoreLimit() uint32 {
+	return g.storeLimit
+}
+
+// GetStoreOffset returns the number of cells per column family skipped by
+// this Get request.
+func (g *Get) GetStoreOffset() uint32 {
+	return g.storeOffset
+}
+
 // SetFilter sets filter to use for this Get request.
 func (g *Get) SetFilter(f filter.Filter) error {
 	g.filters = f
@@ -102,8 +241,10 @@ func (g *Get) SetFilter(f filter.Filter) error {
 
 // SetFamilies sets families to retrieve with this Get request.
 func (g *Get) SetFamilies(f map[string][]string) error {
+	if err := checkFamilies(f); err != nil {
+		return err
+	}
 	g.families = f
-	// TODO: Validation?
 	return nil
 }
 
@@ -116,6 +257,15 @@ func (g *Get) ExistsOnly() error {
 
 // Serialize serializes this RPC into a buffer.
 func (g *Get) Serialize() ([]byte, error) {
+	get, err := g.toProto()
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(get)
+}
+
+// toProto converts this RPC into a protobuf message.
+func (g *Get) toProto() (*pb.GetRequest, error) {
 	get := &pb.GetRequest{
 		Region: g.regionSpecifier(),
 		Get: &pb.Get{
@@ -127,18 +277,37 @@ func (g *Get) Serialize() ([]byte, error) {
 	if g.maxVersions != DefaultMaxVersions {
 		get.Get.MaxVersions = &g.maxVersions
 	}
-	if g.fromTimestamp != MinTimestamp {
-		get.Get.TimeRange.From = &g.fromTimestamp
+	if g.storeLimit != 0 {
+		get.Get.StoreLimit = &g.storeLimit
+	}
+	if g.storeOffset != 0 {
+		get.Get.StoreOffset = &g.storeOffset
 	}
-	if g.toTimestamp != MaxTimestamp {
-		get.Get.TimeRange.To = &g.toTimestamp
+	from, to := widenTimeRange(g.fromTimestamp, g.toTimestamp, g.clockSkew)
+	if from != MinTimestamp {
+		get.Get.TimeRange.From = &from
 	}
+	if to != MaxTimestamp {
+		get.Get.TimeRange.To = &to
+	}
+	get.Get.CfTimeRange = cfTimeRangesToPB(g.cfTimeRanges, g.clockSkew)
 	if g.closestBefore {
 		get.Get.ClosestRowBefore = proto.Bool(true)
 	}
 	if g.existsOnly {
 		get.Get.ExistenceOnly = proto.Bool(true)
 	}
+	if g.consistency != StrongConsistency || g.targetReplica && g.replicaID != 0 {
+		consistency := pb.Consistency(TimelineConsistency)
+		get.Get.Consistency = &consistency
+	}
+	if len(g.authorizations) != 0 {
+		attr, err := authorizationsAttribute(g.authorizations)
+		if err != nil {
+			return nil, err
+		}
+		get.Get.Attribute = append(get.Get.Attribute, attr)
+	}
 	if g.filters != nil {
 		pbFilter, err := g.filters.ConstructPBFilter()
 		if err != nil {
@@ -146,7 +315,7 @@ func (g *Get) Serialize() ([]byte, error) {
 		}
 		get.Get.Filter = pbFilter
 	}
-	return proto.Marshal(get)
+	return get, nil
 }
 
 // NewResponse creates an empty protobuf message to read the response of this
@@ -155,6 +324,86 @@ func (g *Get) NewResponse() proto.Message {
 	return &pb.GetResponse{}
 }
 
+// Names of the filters that checkColumnValueFilter looks for in FilterLists.
+const (
+	filterPackage                      = "org.apache.hadoop.hbase.filter."
+	filterListName                     = filterPackage + "FilterList"
+	singleColumnValueFilterName        = filterPackage + "SingleColumnValueFilter"
+	singleColumnValueExcludeFilterName = filterPackage + "SingleColumnValueExcludeFilter"
+)
+
+// checkColumnValueFilter returns an error if the given filter, or one of the
+// filters of the given FilterList, tests the value of a column that isn't
+// fetched by the request.  HBase would then consider the column missing in
+// every row, so the filter would either return all the rows or none of them,
+// depending on its filterIfMissing flag.
+func checkColumnValueFilter(families map[string][]string, f filter.Filter) error {
+	if families == nil {
+		return nil
+	}
+	switch f := f.(type) {
+	case *filter.SingleColumnValueFilter:
+		return checkFetchedColumn(families, (*pb.SingleColumnValueFilter)(f))
+	case *filter.SingleColumnValueExcludeFilter:
+		return checkFetchedColumn(families, f.SingleColumnValueFilter)
+	case *filter.List:
+		return checkFilterList(families, (*pb.FilterList)(f))
+	}
+	return nil
+}
+
+// checkFilterList is like checkColumnValueFilter for the serialized filters of
+// a FilterList, including those of the FilterLists nested in it.
+func checkFilterList(families map[string][]string, list *pb.FilterList) error {
+	for _, f := range list.GetFilters() {
+		var err error
+		switch f.GetName() {
+		case filterListName:
+			nested := &pb.FilterList{}
+			if err = proto.Unmarshal(f.SerializedFilter, nested); err == nil {
+				err = checkFilterList(families, nested)
+			}
+		case singleColumnValueFilterName:
+			scvf := &pb.SingleColumnValueFilter{}
+			if err = proto.Unmarshal(f.SerializedFilter, scvf); err == nil {
+				err = checkFetchedColumn(families, scvf)
+			}
+		case singleColumnValueExcludeFilterName:
+			scvef := &pb.SingleColumnValueExcludeFilter{}
+			if err = proto.Unmarshal(f.SerializedFilter, scvef); err == nil {
+				err = checkFetchedColumn(families, scvef.SingleColumnValueFilter)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFetchedColumn returns an error if the column tested by the given filter
+// isn't among the given families and qualifiers.
+func checkFetchedColumn(families map[string][]string, scvf *pb.SingleColumnValueFilter) error {
+	if scvf == nil {
+		return nil
+	}
+	qualifiers, ok := families[string(scvf.ColumnFamily)]
+	if !ok {
+		return fmt.Errorf("The filter tests column %s:%s but its family isn't fetched",
+			scvf.ColumnFamily, scvf.ColumnQualifier)
+	}
+	if len(qualifiers) == 0 {
+		return nil
+	}
+	for _, q := range qualifiers {
+		if q == string(scvf.ColumnQualifier) {
+			return nil
+		}
+	}
+	return fmt.Errorf("The filter tests column %s:%s but it isn't fetched",
+		scvf.ColumnFamily, scvf.ColumnQualifier)
+}
+
 // familiesToColumn takes a map from strings to lists of strings, and converts
 // them into protobuf Columns
 func familiesToColumn(families map[string][]string) []*pb.Column {
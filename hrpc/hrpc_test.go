@@ -7,12 +7,15 @@ package hrpc_test
 
 import (
 	"bytes"
+	"encoding/binary"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/tsuna/gohbase/filter"
 	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
 	"github.com/tsuna/gohbase/region"
 	"golang.org/x/net/context"
 )
@@ -123,6 +126,2110 @@ func TestTimeRangeError(t *testing.T) {
 	}
 }
 
+func TestConsistency(t *testing.T) {
+	ctx := context.Background()
+	get, err := hrpc.NewGetStr(ctx, "test", "row", hrpc.Consistency(hrpc.TimelineConsistency))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if get.GetConsistency() != hrpc.TimelineConsistency {
+		t.Errorf("Expected TIMELINE consistency, got %v", get.GetConsistency())
+	}
+	scan, err := hrpc.NewScanStr(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scan.GetConsistency() != hrpc.StrongConsistency {
+		t.Errorf("Expected STRONG consistency by default, got %v", scan.GetConsistency())
+	}
+
+	_, err = hrpc.NewPutStr(ctx, "test", "row", nil, hrpc.Consistency(hrpc.TimelineConsistency))
+	expErr := "Consistency option can only be used with Get or Scan queries."
+	if err == nil || err.Error() != expErr {
+		t.Errorf("Expected error: %s, Got error: %s", expErr, err)
+	}
+	_, err = hrpc.NewScanStr(ctx, "test", hrpc.Consistency(42))
+	expErr = "Invalid consistency value."
+	if err == nil || err.Error() != expErr {
+		t.Errorf("Expected error: %s, Got error: %s", expErr, err)
+	}
+}
+
+func TestLoadColumnFamiliesOnDemand(t *testing.T) {
+	ctx := context.Background()
+	scan, err := hrpc.NewScanStr(ctx, "test", hrpc.LoadColumnFamiliesOnDemand(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !scan.GetLoadColumnFamiliesOnDemand() {
+		t.Error("Expected LoadColumnFamiliesOnDemand to be enabled")
+	}
+	_, err = hrpc.NewGetStr(ctx, "test", "row", hrpc.LoadColumnFamiliesOnDemand(true))
+	expErr := "LoadColumnFamiliesOnDemand option can only be used with Scan queries."
+	if err == nil || err.Error() != expErr {
+		t.Errorf("Expected error: %s, Got error: %s", expErr, err)
+	}
+}
+
+func TestSmallScanRange(t *testing.T) {
+	scan, err := hrpc.NewSmallScanRange(context.Background(), []byte("hbase:meta"),
+		[]byte("test,key,:"), []byte("test,"), true, hrpc.NumberOfRows(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !scan.GetSmall() || !scan.GetReversed() {
+		t.Error("Expected a small reversed scan")
+	}
+	scan.SetRegion(&region.Info{Name: []byte("hbase:meta,,1")})
+	b, err := scan.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.ScanRequest{}
+	if err = proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if !req.GetCloseScanner() || req.GetNumberOfRows() != 1 {
+		t.Errorf("Expected the scanner to be closed after 1 row, got %s", req)
+	}
+	if !req.GetScan().GetSmall() || !req.GetScan().GetReversed() ||
+		string(req.GetScan().GetStartRow()) != "test,key,:" {
+		t.Errorf("Unexpected scan %s", req.GetScan())
+	}
+
+	scan, err = hrpc.NewScanStr(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scan.GetSmall() || scan.GetReversed() {
+		t.Error("Didn't expect a small or reversed scan")
+	}
+}
+
+func TestRanges(t *testing.T) {
+	ctx := context.Background()
+	ranges := [][2][]byte{
+		{[]byte("c"), []byte("d")},
+		{[]byte("a"), []byte("b")},
+		{[]byte("x"), []byte("z")},
+	}
+	scan, err := hrpc.NewScanStr(ctx, "test", hrpc.Ranges(ranges))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start, stop := scan.GetStartRow(), scan.GetStopRow(); !bytes.Equal(start, []byte("a")) ||
+		!bytes.Equal(stop, []byte("z")) || !bytes.Equal(scan.Key(), []byte("a")) {
+		t.Errorf("Expected the scan to cover [a; z[, got [%q; %q[", start, stop)
+	}
+	scan.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := scan.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.ScanRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := filter.NewMultiRowRangeFilter([]*filter.RowRange{
+		filter.NewRowRange([]byte("c"), []byte("d"), true, false),
+		filter.NewRowRange([]byte("a"), []byte("b"), true, false),
+		filter.NewRowRange([]byte("x"), []byte("z"), true, false),
+	}).ConstructPBFilter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(req.Scan.Filter, expected) {
+		t.Errorf("Expected filter %s, got %s", expected, req.Scan.Filter)
+	}
+
+	// An empty stop row means the end of the table.
+	scan, err = hrpc.NewScanStr(ctx, "test", hrpc.Ranges([][2][]byte{
+		{[]byte("a"), []byte("b")},
+		{[]byte("x"), nil},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stop := scan.GetStopRow(); len(stop) != 0 {
+		t.Errorf("Expected the scan to stop at the end of the table, got %q", stop)
+	}
+
+	_, err = hrpc.NewScanStr(ctx, "test", hrpc.Ranges(nil))
+	expErr := "Ranges option needs at least one range."
+	if err == nil || err.Error() != expErr {
+		t.Errorf("Expected error: %s, Got error: %s", expErr, err)
+	}
+	_, err = hrpc.NewScanStr(ctx, "test", hrpc.Ranges([][2][]byte{{[]byte("b"), []byte("a")}}))
+	if err == nil {
+		t.Error("Expected an error for a range with start row > stop row")
+	}
+	_, err = hrpc.NewGetStr(ctx, "test", "row", hrpc.Ranges(ranges))
+	expErr = "Ranges option can only be used with Scan queries."
+	if err == nil || err.Error() != expErr {
+		t.Errorf("Expected error: %s, Got error: %s", expErr, err)
+	}
+}
+
+func TestSingleColumnValueFilter(t *testing.T) {
+	ctx := context.Background()
+	reg := &region.Info{Name: []byte("test,,1234567890")}
+	newFilter := func(family, qualifier string) *filter.SingleColumnValueFilter {
+		return filter.NewSingleColumnValueFilter([]byte(family), []byte(qualifier),
+			filter.Equal, filter.NewBinaryComparator(filter.NewByteArrayComparable([]byte("v"))),
+			false, true).SetFilterIfMissing(true).SetLatestVersionOnly(false)
+	}
+	f := (*pb.SingleColumnValueFilter)(newFilter("cf", "a"))
+	if !f.GetFilterIfMissing() || f.GetLatestVersionOnly() {
+		t.Errorf("Unexpected flags: filterIfMissing=%v latestVersionOnly=%v",
+			f.GetFilterIfMissing(), f.GetLatestVersionOnly())
+	}
+
+	testcases := []struct {
+		families map[string][]string
+		filter   filter.Filter
+		ok       bool
+	}{
+		{nil, newFilter("cf", "a"), true},
+		{map[string][]string{"cf": nil}, newFilter("cf", "a"), true},
+		{map[string][]string{"cf": []string{"b", "a"}}, newFilter("cf", "a"), true},
+		{map[string][]string{"cf": []string{"b"}}, newFilter("cf", "a"), false},
+		{map[string][]string{"cf2": nil}, newFilter("cf", "a"), false},
+		{map[string][]string{"cf2": nil},
+			filter.NewSingleColumnValueExcludeFilter(newFilter("cf", "a")), false},
+		{nil, newFilter("", "a"), false},
+		{nil, filter.NewSingleColumnValueFilter([]byte("cf"), []byte("a"), filter.NoOp,
+			filter.NewBinaryComparator(filter.NewByteArrayComparable([]byte("v"))),
+			true, true), false},
+		{map[string][]string{"cf": nil},
+			filter.NewList(filter.MustPassOne, newFilter("cf", "a")), true},
+		{map[string][]string{"cf2": nil},
+			filter.NewList(filter.MustPassOne, newFilter("cf", "a")), false},
+		{map[string][]string{"cf": []string{"a"}},
+			filter.NewList(filter.MustPassAll, filter.NewKeyOnlyFilter(false),
+				filter.NewList(filter.MustPassOne, newFilter("cf", "a"),
+					filter.NewSingleColumnValueExcludeFilter(newFilter("cf", "b")))),
+			false},
+	}
+	// The columns are checked as the request is created, the filters
+	// themselves when it's serialized.
+	for i, tc := range testcases {
+		get, err := hrpc.NewGetStr(ctx, "test", "row",
+			hrpc.Families(tc.families), hrpc.Filters(tc.filter))
+		if err == nil {
+			get.SetRegion(reg)
+			_, err = get.Serialize()
+		}
+		if (err == nil) != tc.ok {
+			t.Errorf("Test %d: expected ok=%v for a Get, got error %v", i, tc.ok, err)
+		}
+		scan, err := hrpc.NewScanStr(ctx, "test",
+			hrpc.Families(tc.families), hrpc.Filters(tc.filter))
+		if err == nil {
+			scan.SetRegion(reg)
+			_, err = scan.Serialize()
+		}
+		if (err == nil) != tc.ok {
+			t.Errorf("Test %d: expected ok=%v for a Scan, got error %v", i, tc.ok, err)
+		}
+	}
+
+	// The column is checked whatever the order of the options.
+	_, err := hrpc.NewGetStr(ctx, "test", "row",
+		hrpc.Filters(newFilter("cf", "a")), hrpc.Families(map[string][]string{"cf2": nil}))
+	if err == nil {
+		t.Error("Expected an error for a filter on a column that isn't fetched")
+	}
+}
+
+func TestStoreLimitOffset(t *testing.T) {
+	ctx := context.Background()
+	reg := &region.Info{Name: []byte("test,,1234567890")}
+	get, err := hrpc.NewGetStr(ctx, "test", "row", hrpc.StoreLimit(10), hrpc.StoreOffset(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get.SetRegion(reg)
+	b, err := get.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	getReq := &pb.GetRequest{}
+	if err := proto.Unmarshal(b, getReq); err != nil {
+		t.Fatal(err)
+	}
+	if getReq.Get.GetStoreLimit() != 10 || getReq.Get.GetStoreOffset() != 20 {
+		t.Errorf("Unexpected Get request: %s", getReq)
+	}
+
+	scan, err := hrpc.NewScanStr(ctx, "test", hrpc.StoreLimit(10), hrpc.StoreOffset(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scan.SetRegion(reg)
+	b, err = scan.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanReq := &pb.ScanRequest{}
+	if err := proto.Unmarshal(b, scanReq); err != nil {
+		t.Fatal(err)
+	}
+	if scanReq.Scan.GetStoreLimit() != 10 || scanReq.Scan.GetStoreOffset() != 20 {
+		t.Errorf("Unexpected Scan request: %s", scanReq)
+	}
+
+	// By default, nothing is sent so that HBase uses its own defaults.
+	scan, err = hrpc.NewScanStr(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scan.SetRegion(reg)
+	b, err = scan.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanReq = &pb.ScanRequest{}
+	if err := proto.Unmarshal(b, scanReq); err != nil {
+		t.Fatal(err)
+	}
+	if scanReq.Scan.StoreLimit != nil || scanReq.Scan.StoreOffset != nil {
+		t.Errorf("Unexpected Scan request: %s", scanReq)
+	}
+
+	values := map[string]map[string][]byte{"cf": map[string][]byte{"a": []byte("v")}}
+	_, err = hrpc.NewPutStr(ctx, "test", "row", values, hrpc.StoreLimit(10))
+	expErr := "StoreLimit option can only be used with Get or Scan queries."
+	if err == nil || err.Error() != expErr {
+		t.Errorf("Expected error: %s, Got error: %s", expErr, err)
+	}
+}
+
+func TestColumnFamilyTimeRange(t *testing.T) {
+	ctx := context.Background()
+	scan, err := hrpc.NewScanStr(ctx, "test",
+		hrpc.ColumnFamilyTimeRangeUint64("cf2", 30, 40),
+		hrpc.ColumnFamilyTimeRange("cf1", time.Unix(0, 10*1e6), time.Unix(0, 20*1e6)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][2]uint64{"cf1": {10, 20}, "cf2": {30, 40}}
+	if ranges := scan.GetColumnFamilyTimeRanges(); !reflect.DeepEqual(ranges, expected) {
+		t.Errorf("Expected time ranges %v, got %v", expected, ranges)
+	}
+	scan.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := scan.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.ScanRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if cfr := req.Scan.CfTimeRange; len(cfr) != 2 ||
+		string(cfr[0].ColumnFamily) != "cf1" || cfr[0].TimeRange.GetFrom() != 10 ||
+		cfr[0].TimeRange.GetTo() != 20 || string(cfr[1].ColumnFamily) != "cf2" ||
+		cfr[1].TimeRange.GetFrom() != 30 || cfr[1].TimeRange.GetTo() != 40 {
+		t.Errorf("Unexpected column family time ranges: %v", cfr)
+	}
+
+	get, err := hrpc.NewGetStr(ctx, "test", "row", hrpc.ColumnFamilyTimeRangeUint64("cf", 1, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err = get.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	getReq := &pb.GetRequest{}
+	if err := proto.Unmarshal(b, getReq); err != nil {
+		t.Fatal(err)
+	}
+	if cfr := getReq.Get.CfTimeRange; len(cfr) != 1 || string(cfr[0].ColumnFamily) != "cf" {
+		t.Errorf("Unexpected column family time ranges: %v", cfr)
+	}
+
+	_, err = hrpc.NewScanStr(ctx, "test", hrpc.ColumnFamilyTimeRangeUint64("cf", 2, 1))
+	if err == nil {
+		t.Error("Expected an error with from > to")
+	}
+}
+
+func TestNewGetExists(t *testing.T) {
+	get, err := hrpc.NewGetExistsStr(context.Background(), "test", "row")
+	if err != nil {
+		t.Fatal(err)
+	}
+	get.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := get.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.GetRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if !req.Get.GetExistenceOnly() || string(req.Get.Row) != "row" {
+		t.Errorf("Unexpected Get request: %s", req)
+	}
+}
+
+func TestCloneWithKey(t *testing.T) {
+	ctx := context.Background()
+	get, err := hrpc.NewGetBefore(ctx, []byte("test"), []byte("row2"),
+		hrpc.Families(map[string][]string{"cf": nil}), hrpc.MaxVersions(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get.SetRegion(&region.Info{Name: []byte("test,row1,1234567890")})
+	clone := get.CloneWithKey([]byte("row1"))
+	if !bytes.Equal(clone.Key(), []byte("row1")) || !bytes.Equal(clone.Table(), get.Table()) ||
+		clone.GetContext() != ctx || !clone.IsClosestBefore() || clone.GetRegion() != nil ||
+		!reflect.DeepEqual(clone.GetFamilies(), get.GetFamilies()) {
+		t.Errorf("Unexpected clone: %+v", clone)
+	}
+	clone.SetRegion(get.GetRegion())
+	b, err := clone.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.GetRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if !req.Get.GetClosestRowBefore() || req.Get.GetMaxVersions() != 3 ||
+		string(req.Get.Row) != "row1" {
+		t.Errorf("Unexpected Get request: %s", req)
+	}
+}
+
+func TestClockSkew(t *testing.T) {
+	ctx := context.Background()
+	scan, err := hrpc.NewScanStr(ctx, "test", hrpc.TimeRangeUint64(1000, 5000),
+		hrpc.ColumnFamilyTimeRangeUint64("cf", 500, hrpc.MaxTimestamp),
+		hrpc.ClockSkew(2*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skew := scan.GetClockSkew(); skew != 2*time.Second {
+		t.Errorf("Expected a clock skew of 2s, got %s", skew)
+	}
+	scan.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := scan.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.ScanRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if tr := req.Scan.TimeRange; tr.From != nil || tr.GetTo() != 7000 {
+		t.Errorf("Expected the time range [0, 7000[, got %v", tr)
+	}
+	// The time range itself isn't changed.
+	if from, to := scan.GetTimeRange(); from != 1000 || to != 5000 {
+		t.Errorf("Expected the time range [1000, 5000[, got [%d, %d[", from, to)
+	}
+	if cfr := req.Scan.CfTimeRange; len(cfr) != 1 || cfr[0].TimeRange.GetFrom() != 0 ||
+		cfr[0].TimeRange.GetTo() != hrpc.MaxTimestamp {
+		t.Errorf("Unexpected column family time ranges: %v", cfr)
+	}
+
+	get, err := hrpc.NewGetStr(ctx, "test", "row", hrpc.TimeRangeUint64(5000, 6000),
+		hrpc.ClockSkew(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err = get.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	getReq := &pb.GetRequest{}
+	if err := proto.Unmarshal(b, getReq); err != nil {
+		t.Fatal(err)
+	}
+	if tr := getReq.Get.TimeRange; tr.GetFrom() != 4000 || tr.GetTo() != 7000 {
+		t.Errorf("Expected the time range [4000, 7000[, got %v", tr)
+	}
+	if clone := get.CloneWithKey([]byte("other")); clone.GetClockSkew() != time.Second {
+		t.Errorf("Expected the clone to keep the clock skew, got %s", clone.GetClockSkew())
+	}
+
+	if _, err := hrpc.NewGetStr(ctx, "test", "row", hrpc.ClockSkew(-time.Second)); err == nil {
+		t.Error("Expected an error with a negative clock skew")
+	}
+}
+
+func TestReplicaID(t *testing.T) {
+	ctx := context.Background()
+	get, err := hrpc.NewGetStr(ctx, "test", "row")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := get.GetReplicaID(); ok {
+		t.Error("Expected no target replica by default")
+	}
+	get, err = hrpc.NewGetStr(ctx, "test", "row", hrpc.ReplicaID(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, ok := get.GetReplicaID(); !ok || id != 2 {
+		t.Errorf("Expected to target replica 2, got %d (%v)", id, ok)
+	}
+	get.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := get.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.GetRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if c := req.Get.GetConsistency(); c != pb.Consistency_TIMELINE {
+		t.Errorf("Expected a Get to a secondary replica to be TIMELINE, got %s", c)
+	}
+	if id, _ := get.CloneWithKey([]byte("other")).GetReplicaID(); id != 2 {
+		t.Errorf("Expected the clone to target replica 2, got %d", id)
+	}
+
+	if _, err := hrpc.NewScanStr(ctx, "test", hrpc.ReplicaID(1)); err == nil {
+		t.Error("Expected an error using ReplicaID with a Scan")
+	}
+}
+
+func TestColumnOrder(t *testing.T) {
+	cell := func(family, qualifier string, ts uint64) *hrpc.Cell {
+		return &hrpc.Cell{Family: []byte(family), Qualifier: []byte(qualifier),
+			Timestamp: proto.Uint64(ts)}
+	}
+	res := &hrpc.Result{Cells: []*hrpc.Cell{
+		cell("cf", "a", 2), cell("cf", "a", 1), cell("cf", "b", 1),
+		cell("cf", "c", 1), cell("cf2", "a", 1),
+	}}
+	res.OrderColumns([][2]string{{"cf2", "a"}, {"cf", "c"}, {"cf", "x"}})
+	expected := []*hrpc.Cell{
+		cell("cf2", "a", 1), cell("cf", "c", 1),
+		cell("cf", "a", 2), cell("cf", "a", 1), cell("cf", "b", 1),
+	}
+	if !reflect.DeepEqual(res.Cells, expected) {
+		t.Errorf("Expected cells %v, got %v", expected, res.Cells)
+	}
+
+	ctx := context.Background()
+	order := [][2]string{{"cf", "b"}}
+	get, err := hrpc.NewGetStr(ctx, "test", "row", hrpc.ColumnOrder(order))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := get.CloneWithKey([]byte("other")).GetColumnOrder(); !reflect.DeepEqual(o, order) {
+		t.Errorf("Expected the clone to have column order %v, got %v", order, o)
+	}
+	scan, err := hrpc.NewScanStr(ctx, "test", hrpc.ColumnOrder(order))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := scan.GetColumnOrder(); !reflect.DeepEqual(o, order) {
+		t.Errorf("Expected column order %v, got %v", order, o)
+	}
+	if _, err := hrpc.NewPutStr(ctx, "test", "row", nil, hrpc.ColumnOrder(order)); err == nil {
+		t.Error("Expected an error using ColumnOrder with a Put")
+	}
+}
+
+func TestCheckAndPutComparator(t *testing.T) {
+	ctx := context.Background()
+	put, err := hrpc.NewPutStr(ctx, "test", "row",
+		map[string]map[string][]byte{"cf": {"a": []byte("1")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmp := filter.NewLongComparator(filter.NewByteArrayComparable([]byte{0, 0, 0, 0, 0, 0, 0, 42}))
+	cas, err := hrpc.NewCheckAndPutComparator(put, "cf", "b", cmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cas.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := cas.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.MutateRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	cond := req.Condition
+	if cond == nil || string(cond.Row) != "row" || string(cond.Family) != "cf" ||
+		string(cond.Qualifier) != "b" || cond.GetCompareType() != pb.CompareType_EQUAL {
+		t.Fatalf("Unexpected condition: %v", cond)
+	}
+	if name := cond.Comparator.GetName(); name != "org.apache.hadoop.hbase.filter.LongComparator" {
+		t.Errorf("Expected a LongComparator, got %q", name)
+	}
+
+	if _, err := hrpc.NewCheckAndPutComparator(put, "cf", "b", nil); err == nil {
+		t.Error("Expected an error without a comparator")
+	}
+	del, err := hrpc.NewDelStr(ctx, "test", "row", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hrpc.NewCheckAndPutComparator(del, "cf", "b", cmp); err == nil {
+		t.Error("Expected an error with a Delete")
+	}
+}
+
+func TestGetRegionInfo(t *testing.T) {
+	gri := hrpc.NewGetRegionInfo(context.Background(), []byte("test"), []byte("row"), true)
+	gri.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := gri.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.GetRegionInfoRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if string(req.Region.Value) != "test,,1234567890" || !req.GetCompactionState() {
+		t.Errorf("Unexpected request: %v", req)
+	}
+
+	testcases := []struct {
+		a, b, merged hrpc.CompactionState
+	}{
+		{hrpc.CompactionNone, hrpc.CompactionNone, hrpc.CompactionNone},
+		{hrpc.CompactionNone, hrpc.CompactionMinor, hrpc.CompactionMinor},
+		{hrpc.CompactionMajor, hrpc.CompactionNone, hrpc.CompactionMajor},
+		{hrpc.CompactionMajor, hrpc.CompactionMajor, hrpc.CompactionMajor},
+		{hrpc.CompactionMajor, hrpc.CompactionMinor, hrpc.CompactionMajorAndMinor},
+		{hrpc.CompactionMajorAndMinor, hrpc.CompactionMinor, hrpc.CompactionMajorAndMinor},
+	}
+	for _, tc := range testcases {
+		if merged := tc.a.Merge(tc.b); merged != tc.merged {
+			t.Errorf("Expected %s merged with %s to be %s, got %s", tc.a, tc.b, tc.merged, merged)
+		}
+	}
+}
+
+func TestCheckAndMutate(t *testing.T) {
+	ctx := context.Background()
+	reg := &region.Info{Name: []byte("test,,1234567890")}
+	del, err := hrpc.NewDelStr(ctx, "test", "row", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmp := filter.NewBinaryComparator(filter.NewByteArrayComparable([]byte("42")))
+	cam, err := hrpc.NewCheckAndMutate(del, "cf", "a", filter.Less, cmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cam.SetRegion(reg)
+	b, err := cam.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.MutateRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if cond := req.Condition; string(cond.Row) != "row" || string(cond.Family) != "cf" ||
+		string(cond.Qualifier) != "a" || cond.GetCompareType() != pb.CompareType_LESS ||
+		cond.Comparator == nil || cond.Filter != nil {
+		t.Errorf("Unexpected condition: %v", cond)
+	}
+	if req.Mutation.GetMutateType() != pb.MutationProto_DELETE {
+		t.Errorf("Expected a Delete, got %v", req.Mutation)
+	}
+
+	put, err := hrpc.NewPutStr(ctx, "test", "row",
+		map[string]map[string][]byte{"cf": {"a": []byte("1")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cam, err = hrpc.NewCheckAndMutateFilter(put, filter.NewPrefixFilter([]byte("r")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cam.SetRegion(reg)
+	if b, err = cam.Serialize(); err != nil {
+		t.Fatal(err)
+	}
+	req = &pb.MutateRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if cond := req.Condition; string(cond.Row) != "row" || cond.Family != nil ||
+		cond.Comparator != nil ||
+		cond.Filter.GetName() != "org.apache.hadoop.hbase.filter.PrefixFilter" {
+		t.Errorf("Unexpected condition: %v", cond)
+	}
+
+	// Conditional mutations get their own atomic RegionAction in a Multi.
+	multi := hrpc.NewMulti(ctx)
+	multi.Add(cam)
+	if b, err = multi.Serialize(); err != nil {
+		t.Fatal(err)
+	}
+	multiReq := &pb.MultiRequest{}
+	if err := proto.Unmarshal(b, multiReq); err != nil {
+		t.Fatal(err)
+	}
+	if ra := multiReq.RegionAction; len(ra) != 1 || !ra[0].GetAtomic() || ra[0].Condition == nil {
+		t.Errorf("Unexpected region actions: %v", ra)
+	}
+
+	inc, err := hrpc.NewIncStrSingle(ctx, "test", "row", "cf", "a", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hrpc.NewCheckAndMutate(inc, "cf", "a", filter.Equal, cmp); err == nil {
+		t.Error("Expected an error with an Increment")
+	}
+	if _, err := hrpc.NewCheckAndMutate(put, "cf", "a", filter.CompareType(42), cmp); err == nil {
+		t.Error("Expected an error with an invalid compare type")
+	}
+	if _, err := hrpc.NewCheckAndMutateFilter(put, nil); err == nil {
+		t.Error("Expected an error without a filter")
+	}
+}
+
+func TestNewIncSingle(t *testing.T) {
+	inc, err := hrpc.NewIncSingle(context.Background(), []byte("test"), []byte("row"),
+		"cf", "a", -2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inc.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := inc.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.MutateRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	m := req.Mutation
+	if m.GetMutateType() != pb.MutationProto_INCREMENT || string(m.Row) != "row" ||
+		len(m.ColumnValue) != 1 || len(m.ColumnValue[0].QualifierValue) != 1 {
+		t.Fatalf("Unexpected mutation: %v", m)
+	}
+	expected := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe}
+	if v := m.ColumnValue[0].QualifierValue[0].Value; !bytes.Equal(v, expected) {
+		t.Errorf("Expected the amount to be encoded as %v, got %v", expected, v)
+	}
+}
+
+func TestReturnResults(t *testing.T) {
+	ctx := context.Background()
+	values := map[string]map[string][]byte{"cf": {"a": []byte("x")}}
+	for _, enabled := range []bool{true, false} {
+		app, err := hrpc.NewAppend(ctx, []byte("test"), []byte("row"), values,
+			hrpc.ReturnResults(enabled))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if app.GetReturnResults() != enabled {
+			t.Errorf("Expected GetReturnResults to be %v", enabled)
+		}
+		app.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+		b, err := app.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := &pb.MutateRequest{}
+		if err := proto.Unmarshal(b, req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Mutation.GetMutateType() != pb.MutationProto_APPEND {
+			t.Errorf("Expected an Append, got %v", req.Mutation)
+		}
+		attrs := req.Mutation.Attribute
+		if enabled && len(attrs) != 0 {
+			t.Errorf("Expected no attribute, got %v", attrs)
+		} else if !enabled && (len(attrs) != 1 || attrs[0].GetName() != "_rr_" ||
+			!bytes.Equal(attrs[0].Value, []byte{0})) {
+			t.Errorf("Expected the _rr_ attribute to be false, got %v", attrs)
+		}
+	}
+}
+
+func TestTTL(t *testing.T) {
+	ctx := context.Background()
+	values := map[string]map[string][]byte{"cf": {"a": []byte("x")}}
+	put, err := hrpc.NewPutStr(ctx, "test", "row", values, hrpc.TTL(90*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if put.GetTTL() != 90*time.Second {
+		t.Errorf("Expected a TTL of 90s, got %s", put.GetTTL())
+	}
+	put.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := put.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.MutateRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	attrs := req.Mutation.Attribute
+	if len(attrs) != 1 || attrs[0].GetName() != "_ttl" ||
+		binary.BigEndian.Uint64(attrs[0].Value) != 90000 {
+		t.Errorf("Expected a _ttl attribute of 90000ms, got %v", attrs)
+	}
+
+	if _, err := hrpc.NewPutStr(ctx, "test", "row", values, hrpc.TTL(0)); err == nil {
+		t.Error("Expected an error with a TTL of 0")
+	}
+	if _, err := hrpc.NewGetStr(ctx, "test", "row", hrpc.TTL(time.Second)); err == nil {
+		t.Error("Expected an error using TTL with a Get")
+	}
+}
+
+func TestAttribute(t *testing.T) {
+	ctx := context.Background()
+	del, err := hrpc.NewDelStr(ctx, "test", "row", nil,
+		hrpc.Attribute("b", []byte("2")), hrpc.Attribute("a", []byte("x")),
+		hrpc.Attribute("a", []byte("1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]byte{"a": []byte("1"), "b": []byte("2")}
+	if !reflect.DeepEqual(del.GetAttributes(), expected) {
+		t.Errorf("Expected attributes %q, got %q", expected, del.GetAttributes())
+	}
+	del.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := del.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.MutateRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	attrs := req.Mutation.Attribute
+	if len(attrs) != 2 || attrs[0].GetName() != "a" || string(attrs[0].Value) != "1" ||
+		attrs[1].GetName() != "b" || string(attrs[1].Value) != "2" {
+		t.Errorf("Expected the attributes a=1 and b=2, got %v", attrs)
+	}
+
+	if _, err := hrpc.NewDelStr(ctx, "test", "row", nil, hrpc.Attribute("", nil)); err == nil {
+		t.Error("Expected an error with an empty attribute name")
+	}
+	if _, err := hrpc.NewGetStr(ctx, "test", "row", hrpc.Attribute("a", nil)); err == nil {
+		t.Error("Expected an error using Attribute with a Get")
+	}
+}
+
+func TestVisibility(t *testing.T) {
+	ctx := context.Background()
+	values := map[string]map[string][]byte{"cf": {"a": []byte("x")}}
+	put, err := hrpc.NewPutStr(ctx, "test", "row", values, hrpc.CellVisibility("secret|public"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	put.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := put.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mutateReq := &pb.MutateRequest{}
+	if err := proto.Unmarshal(b, mutateReq); err != nil {
+		t.Fatal(err)
+	}
+	attrs := mutateReq.Mutation.Attribute
+	visibility := &pb.CellVisibility{}
+	if len(attrs) != 1 || attrs[0].GetName() != "VISIBILITY" {
+		t.Fatalf("Expected a VISIBILITY attribute, got %v", attrs)
+	}
+	if err := proto.Unmarshal(attrs[0].Value, visibility); err != nil ||
+		visibility.GetExpression() != "secret|public" {
+		t.Errorf("Expected the expression secret|public, got %v (%v)", visibility, err)
+	}
+
+	get, err := hrpc.NewGetStr(ctx, "test", "row", hrpc.Authorizations("secret", "public"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get.SetRegion(put.GetRegion())
+	if b, err = get.Serialize(); err != nil {
+		t.Fatal(err)
+	}
+	getReq := &pb.GetRequest{}
+	if err := proto.Unmarshal(b, getReq); err != nil {
+		t.Fatal(err)
+	}
+	scan, err := hrpc.NewScanStr(ctx, "test", hrpc.Authorizations("secret", "public"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scan.SetRegion(put.GetRegion())
+	if b, err = scan.Serialize(); err != nil {
+		t.Fatal(err)
+	}
+	scanReq := &pb.ScanRequest{}
+	if err := proto.Unmarshal(b, scanReq); err != nil {
+		t.Fatal(err)
+	}
+	for _, attrs := range [][]*pb.NameBytesPair{getReq.Get.Attribute, scanReq.Scan.Attribute} {
+		auths := &pb.Authorizations{}
+		if len(attrs) != 1 || attrs[0].GetName() != "VISIBILITY" {
+			t.Fatalf("Expected a VISIBILITY attribute, got %v", attrs)
+		}
+		if err := proto.Unmarshal(attrs[0].Value, auths); err != nil ||
+			!reflect.DeepEqual(auths.Label, []string{"secret", "public"}) {
+			t.Errorf("Expected the labels secret and public, got %v (%v)", auths, err)
+		}
+	}
+	if clone := get.CloneWithKey([]byte("other")); len(clone.GetAuthorizations()) != 2 {
+		t.Errorf("Expected the clone to have the authorizations, got %v",
+			clone.GetAuthorizations())
+	}
+
+	if _, err := hrpc.NewPutStr(ctx, "test", "row", values, hrpc.CellVisibility("")); err == nil {
+		t.Error("Expected an error with an empty visibility expression")
+	}
+	if _, err := hrpc.NewPutStr(ctx, "test", "row", values,
+		hrpc.Authorizations("secret")); err == nil {
+		t.Error("Expected an error using Authorizations with a Put")
+	}
+}
+
+func TestEmptyQualifier(t *testing.T) {
+	ctx := context.Background()
+	reg := &region.Info{Name: []byte("test,,1234567890")}
+	get, err := hrpc.NewGetStr(ctx, "test", "row",
+		hrpc.Families(map[string][]string{"cf": {hrpc.EmptyQualifier}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get.SetRegion(reg)
+	b, err := get.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	getReq := &pb.GetRequest{}
+	if err := proto.Unmarshal(b, getReq); err != nil {
+		t.Fatal(err)
+	}
+	if cols := getReq.Get.Column; len(cols) != 1 || len(cols[0].Qualifier) != 1 ||
+		len(cols[0].Qualifier[0]) != 0 {
+		t.Errorf("Expected a single column with the empty qualifier, got %v", cols)
+	}
+
+	del, err := hrpc.NewDelStr(ctx, "test", "row", map[string]map[string][]byte{
+		"cf": {hrpc.EmptyQualifier: nil}, "cf2": {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	del.SetRegion(reg)
+	if b, err = del.Serialize(); err != nil {
+		t.Fatal(err)
+	}
+	mutateReq := &pb.MutateRequest{}
+	if err := proto.Unmarshal(b, mutateReq); err != nil {
+		t.Fatal(err)
+	}
+	for _, column := range mutateReq.Mutation.ColumnValue {
+		if len(column.QualifierValue) != 1 {
+			t.Fatalf("Expected a single deletion for %s, got %v", column.Family, column)
+		}
+		qv := column.QualifierValue[0]
+		switch string(column.Family) {
+		case "cf":
+			if qv.Qualifier == nil || len(qv.Qualifier) != 0 ||
+				qv.GetDeleteType() != pb.MutationProto_DELETE_MULTIPLE_VERSIONS {
+				t.Errorf("Expected the column cf: to be deleted, got %v", qv)
+			}
+		case "cf2":
+			if qv.Qualifier != nil || qv.GetDeleteType() != pb.MutationProto_DELETE_FAMILY {
+				t.Errorf("Expected the family cf2 to be deleted, got %v", qv)
+			}
+		default:
+			t.Errorf("Unexpected family %q", column.Family)
+		}
+	}
+
+	if _, err := hrpc.NewGetStr(ctx, "test", "row",
+		hrpc.Families(map[string][]string{"": nil})); err == nil {
+		t.Error("Expected an error with an empty family in a Get")
+	}
+	if _, err := hrpc.NewScanStr(ctx, "test",
+		hrpc.Families(map[string][]string{"": nil})); err == nil {
+		t.Error("Expected an error with an empty family in a Scan")
+	}
+	put, err := hrpc.NewPutStr(ctx, "test", "row",
+		map[string]map[string][]byte{"": {"a": []byte("x")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := put.Serialize(); err == nil {
+		t.Error("Expected an error with an empty family in a Put")
+	}
+}
+
+func TestNewPutCells(t *testing.T) {
+	ctx := context.Background()
+	cells := []*hrpc.Cell{
+		{Family: []byte("cf"), Qualifier: []byte("a"), Value: []byte("1"),
+			Timestamp: proto.Uint64(10)},
+		{Family: []byte("cf"), Qualifier: []byte("a"), Value: []byte("2"),
+			Timestamp: proto.Uint64(20)},
+		{Row: []byte("row"), Family: []byte("cf2"), Value: []byte("3")},
+	}
+	put, err := hrpc.NewPutCells(ctx, []byte("test"), []byte("row"), cells,
+		hrpc.TimestampUint64(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	put.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := put.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.MutateRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Mutation.GetMutateType() != pb.MutationProto_PUT ||
+		req.Mutation.GetTimestamp() != 5 {
+		t.Errorf("Expected a Put at timestamp 5, got %v", req.Mutation)
+	}
+	type cell struct {
+		family, qualifier, value string
+		timestamp                *uint64
+	}
+	var got []cell
+	for _, column := range req.Mutation.ColumnValue {
+		for _, qv := range column.QualifierValue {
+			if qv.Qualifier == nil {
+				t.Errorf("Expected the qualifier to be set, got %v", qv)
+			}
+			got = append(got, cell{string(column.Family), string(qv.Qualifier),
+				string(qv.Value), qv.Timestamp})
+		}
+	}
+	expected := []cell{
+		{"cf", "a", "1", proto.Uint64(10)},
+		{"cf", "a", "2", proto.Uint64(20)},
+		{"cf2", "", "3", nil},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected cells %v, got %v", expected, got)
+	}
+
+	invalid := [][]*hrpc.Cell{
+		{{Qualifier: []byte("a")}},
+		{{Row: []byte("other"), Family: []byte("cf")}},
+		{{Family: []byte("cf"), CellType: pb.CellType_DELETE.Enum()}},
+	}
+	for _, cells := range invalid {
+		if _, err := hrpc.NewPutCells(ctx, []byte("test"), []byte("row"), cells); err == nil {
+			t.Errorf("Expected an error putting %v", cells)
+		}
+	}
+}
+
+func TestDeleteOptions(t *testing.T) {
+	ctx := context.Background()
+	del, err := hrpc.NewDelStr(ctx, "test", "row", nil,
+		hrpc.DeleteColumn("cf", "a", 42), hrpc.DeleteColumns("cf", "b", hrpc.MaxTimestamp),
+		hrpc.DeleteFamily("cf2", 10), hrpc.DeleteFamilyVersion("cf2", 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	del.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := del.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.MutateRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	type deletion struct {
+		family, qualifier string
+		deleteType        pb.MutationProto_DeleteType
+		timestamp         *uint64
+	}
+	var deletions []deletion
+	for _, column := range req.Mutation.ColumnValue {
+		for _, qv := range column.QualifierValue {
+			deletions = append(deletions, deletion{string(column.Family),
+				string(qv.Qualifier), qv.GetDeleteType(), qv.Timestamp})
+		}
+	}
+	expected := []deletion{
+		{"cf", "a", pb.MutationProto_DELETE_ONE_VERSION, proto.Uint64(42)},
+		{"cf", "b", pb.MutationProto_DELETE_MULTIPLE_VERSIONS, nil},
+		{"cf2", "", pb.MutationProto_DELETE_FAMILY, proto.Uint64(10)},
+		{"cf2", "", pb.MutationProto_DELETE_FAMILY_VERSION, proto.Uint64(7)},
+	}
+	if !reflect.DeepEqual(deletions, expected) {
+		t.Errorf("Expected deletions %v, got %v", expected, deletions)
+	}
+
+	values := map[string]map[string][]byte{"cf": {"a": []byte("x")}}
+	put, err := hrpc.NewPutStr(ctx, "test", "row", values, hrpc.DeleteFamily("cf", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := put.Serialize(); err == nil {
+		t.Error("Expected an error using DeleteFamily with a Put")
+	}
+}
+
+func TestCreateTableFamilyAttributes(t *testing.T) {
+	ctx := context.Background()
+	ct, err := hrpc.NewCreateTable(ctx, []byte("test"), []string{"cf"},
+		hrpc.Versions(5), hrpc.Compression("SNAPPY"),
+		hrpc.FamilyAttributes("cf2", map[string]string{"VERSIONS": "1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ct.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.CreateTableRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	families := req.TableSchema.ColumnFamilies
+	if len(families) != 2 || string(families[0].Name) != "cf" ||
+		string(families[1].Name) != "cf2" {
+		t.Fatalf("Expected the families cf and cf2, got %v", families)
+	}
+	expected := map[string]map[string]string{
+		"cf":  {"VERSIONS": "5", "COMPRESSION": "SNAPPY"},
+		"cf2": {"VERSIONS": "1", "COMPRESSION": "SNAPPY"},
+	}
+	for _, family := range families {
+		attrs := make(map[string]string)
+		for _, attr := range family.Attributes {
+			attrs[string(attr.First)] = string(attr.Second)
+		}
+		for key, value := range expected[string(family.Name)] {
+			if attrs[key] != value {
+				t.Errorf("Expected %s=%s for %s, got %q", key, value, family.Name, attrs[key])
+			}
+		}
+	}
+
+	invalid := []func(hrpc.Call) error{
+		hrpc.Versions(0), hrpc.TimeToLive(0), hrpc.Compression("ZIP"),
+		hrpc.Bloomfilter("ALL"), hrpc.MinVersions(-1), hrpc.Blocksize(0),
+		hrpc.FamilyAttributes("", nil),
+	}
+	for i, option := range invalid {
+		if _, err := hrpc.NewCreateTable(ctx, []byte("test"), []string{"cf"},
+			option); err == nil {
+			t.Errorf("Expected an error with invalid option #%d", i)
+		}
+	}
+}
+
+func TestSplitKeys(t *testing.T) {
+	hex := hrpc.HexStringSplit(4)
+	if expected := [][]byte{[]byte("40000000"), []byte("80000000"),
+		[]byte("c0000000")}; !reflect.DeepEqual(hex, expected) {
+		t.Errorf("Expected hex split keys %q, got %q", expected, hex)
+	}
+	uniform := hrpc.UniformSplit(2, nil, nil)
+	if expected := [][]byte{{0x80, 0, 0, 0, 0, 0, 0, 0}}; !reflect.DeepEqual(uniform, expected) {
+		t.Errorf("Expected uniform split keys %q, got %q", expected, uniform)
+	}
+	uniform = hrpc.UniformSplit(3, []byte("a"), []byte("az"))
+	if expected := [][]byte{[]byte("a)"), []byte("aR")}; !reflect.DeepEqual(uniform, expected) {
+		t.Errorf("Expected uniform split keys %q, got %q", expected, uniform)
+	}
+	if keys := hrpc.HexStringSplit(1); keys != nil {
+		t.Errorf("Expected no split keys for a single region, got %q", keys)
+	}
+
+	ct, err := hrpc.NewCreateTable(context.Background(), []byte("test"), []string{"cf"},
+		hrpc.SplitKeys([][]byte{[]byte("m"), []byte("f")}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ct.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.CreateTableRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if expected := [][]byte{[]byte("f"), []byte("m")}; !reflect.DeepEqual(req.SplitKeys,
+		expected) {
+		t.Errorf("Expected split keys %q, got %q", expected, req.SplitKeys)
+	}
+	for _, keys := range [][][]byte{{[]byte("a"), {}}, {[]byte("a"), []byte("a")}} {
+		if _, err := hrpc.NewCreateTable(context.Background(), []byte("test"),
+			[]string{"cf"}, hrpc.SplitKeys(keys)); err == nil {
+			t.Errorf("Expected an error with the split keys %q", keys)
+		}
+	}
+}
+
+func TestTruncateTable(t *testing.T) {
+	for _, preserve := range []bool{false, true} {
+		var options []func(hrpc.Call) error
+		if preserve {
+			options = append(options, hrpc.PreserveSplits())
+		}
+		tt, err := hrpc.NewTruncateTable(context.Background(), []byte("test"), options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := tt.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := &pb.TruncateTableRequest{}
+		if err := proto.Unmarshal(b, req); err != nil {
+			t.Fatal(err)
+		}
+		if string(req.TableName.Qualifier) != "test" || req.GetPreserveSplits() != preserve {
+			t.Errorf("Expected table test with preserveSplits=%v, got %s", preserve, req)
+		}
+	}
+	if _, err := hrpc.NewGetStr(context.Background(), "test", "row",
+		hrpc.PreserveSplits()); err == nil {
+		t.Error("Expected an error using PreserveSplits with a Get")
+	}
+}
+
+func TestListTables(t *testing.T) {
+	lt, err := hrpc.NewListTableNames(context.Background(), hrpc.TableRegex("test.*"),
+		hrpc.InNamespace("ns"), hrpc.IncludeSysTables())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := lt.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ltreq := &pb.GetTableNamesRequest{}
+	if err := proto.Unmarshal(b, ltreq); err != nil {
+		t.Fatal(err)
+	}
+	expected := &pb.GetTableNamesRequest{
+		Regex:            proto.String("test.*"),
+		IncludeSysTables: proto.Bool(true),
+		Namespace:        proto.String("ns"),
+	}
+	if !proto.Equal(ltreq, expected) {
+		t.Errorf("Expected %s, got %s", expected, ltreq)
+	}
+
+	gt, err := hrpc.NewGetTableDescriptors(context.Background(), [][]byte{[]byte("test")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err = gt.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gtreq := &pb.GetTableDescriptorsRequest{}
+	if err := proto.Unmarshal(b, gtreq); err != nil {
+		t.Fatal(err)
+	}
+	if len(gtreq.TableNames) != 1 || string(gtreq.TableNames[0].Qualifier) != "test" ||
+		gtreq.Regex != nil || gtreq.GetIncludeSysTables() {
+		t.Errorf("Expected the descriptor of table test only, got %s", gtreq)
+	}
+
+	if _, err := hrpc.NewGetStr(context.Background(), "test", "row",
+		hrpc.TableRegex("test.*")); err == nil {
+		t.Error("Expected an error using TableRegex with a Get")
+	}
+	if _, err := hrpc.NewListTableNames(context.Background(), hrpc.InNamespace("")); err == nil {
+		t.Error("Expected an error listing the tables of an empty namespace")
+	}
+
+	for name, tn := range map[string]*pb.TableName{
+		"test":          {Namespace: []byte("default"), Qualifier: []byte("test")},
+		"hbase:meta":    {Namespace: []byte("hbase"), Qualifier: []byte("meta")},
+		"nameless":      {Qualifier: []byte("nameless")},
+		"ns:with:colon": {Namespace: []byte("ns"), Qualifier: []byte("with:colon")},
+	} {
+		if full := hrpc.FullTableName(tn); string(full) != name {
+			t.Errorf("Expected %q, got %q", name, full)
+		}
+	}
+
+	td := hrpc.ToLocalTableDescriptor(&pb.TableSchema{
+		TableName: &pb.TableName{Namespace: []byte("ns"), Qualifier: []byte("test")},
+		Attributes: []*pb.BytesBytesPair{
+			{First: []byte("MAX_FILESIZE"), Second: []byte("1024")},
+		},
+		ColumnFamilies: []*pb.ColumnFamilySchema{{
+			Name: []byte("cf"),
+			Attributes: []*pb.BytesBytesPair{
+				{First: []byte("VERSIONS"), Second: []byte("1")},
+			},
+		}},
+	})
+	expectedTD := &hrpc.TableDescriptor{
+		Name:          []byte("ns:test"),
+		Attributes:    map[string]string{"MAX_FILESIZE": "1024"},
+		Configuration: map[string]string{},
+		Families:      map[string]map[string]string{"cf": {"VERSIONS": "1"}},
+	}
+	if !reflect.DeepEqual(td, expectedTD) {
+		t.Errorf("Expected %+v, got %+v", expectedTD, td)
+	}
+}
+
+func TestAlterSchema(t *testing.T) {
+	ac, err := hrpc.NewAddColumnFamily(context.Background(), []byte("test"), "cf",
+		map[string]string{"IN_MEMORY": "true"}, hrpc.Versions(1), hrpc.Compression("GZ"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ac.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	acreq := &pb.AddColumnRequest{}
+	if err := proto.Unmarshal(b, acreq); err != nil {
+		t.Fatal(err)
+	}
+	expected := &pb.ColumnFamilySchema{
+		Name: []byte("cf"),
+		Attributes: []*pb.BytesBytesPair{
+			{First: []byte("COMPRESSION"), Second: []byte("GZ")},
+			{First: []byte("IN_MEMORY"), Second: []byte("true")},
+			{First: []byte("VERSIONS"), Second: []byte("1")},
+		},
+	}
+	if string(acreq.TableName.Qualifier) != "test" ||
+		!proto.Equal(acreq.ColumnFamilies, expected) {
+		t.Errorf("Expected %s in table test, got %s", expected, acreq)
+	}
+
+	if _, err := hrpc.NewModifyColumnFamily(context.Background(), []byte("test"), "cf",
+		nil, hrpc.TimeToLive(0)); err == nil {
+		t.Error("Expected an error with a TTL of 0")
+	}
+	if _, err := hrpc.NewDeleteColumnFamily(context.Background(), []byte("test"),
+		""); err == nil {
+		t.Error("Expected an error deleting an empty column family")
+	}
+	if _, err := hrpc.NewGetStr(context.Background(), "test", "row",
+		hrpc.Versions(1)); err == nil {
+		t.Error("Expected an error using Versions with a Get")
+	}
+
+	mt := hrpc.NewModifyTable(context.Background(), &hrpc.TableDescriptor{
+		Name:          []byte("test"),
+		Configuration: map[string]string{"b": "2", "a": "1"},
+		Families: map[string]map[string]string{
+			"cf2": {"VERSIONS": "2"},
+			"cf1": {"VERSIONS": "1"},
+		},
+	})
+	b, err = mt.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mtreq := &pb.ModifyTableRequest{}
+	if err := proto.Unmarshal(b, mtreq); err != nil {
+		t.Fatal(err)
+	}
+	schema := mtreq.TableSchema
+	if len(schema.ColumnFamilies) != 2 || string(schema.ColumnFamilies[0].Name) != "cf1" ||
+		string(schema.ColumnFamilies[1].Name) != "cf2" {
+		t.Errorf("Expected the families cf1 and cf2 in order, got %s", schema)
+	}
+	if len(schema.Configuration) != 2 || schema.Configuration[0].GetName() != "a" {
+		t.Errorf("Expected the configuration in order, got %s", schema)
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	for name, expected := range map[string]*pb.TableName{
+		"test":       {Namespace: []byte("default"), Qualifier: []byte("test")},
+		"ns:test":    {Namespace: []byte("ns"), Qualifier: []byte("test")},
+		"hbase:meta": {Namespace: []byte("hbase"), Qualifier: []byte("meta")},
+	} {
+		if tn := hrpc.ProtoTableName([]byte(name)); !proto.Equal(tn, expected) {
+			t.Errorf("Expected %s for table %q, got %s", expected, name, tn)
+		}
+	}
+
+	dt := hrpc.NewDeleteTable(context.Background(), []byte("ns:test"))
+	b, err := dt.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dtreq := &pb.DeleteTableRequest{}
+	if err := proto.Unmarshal(b, dtreq); err != nil {
+		t.Fatal(err)
+	}
+	if ns := string(dtreq.TableName.Namespace); ns != "ns" {
+		t.Errorf("Expected to delete a table of namespace ns, got %s", dtreq)
+	}
+
+	cn := hrpc.NewCreateNamespace(context.Background(), &hrpc.NamespaceDescriptor{
+		Name:          "ns",
+		Configuration: map[string]string{"b": "2", "a": "1"},
+	})
+	b, err = cn.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cnreq := &pb.CreateNamespaceRequest{}
+	if err := proto.Unmarshal(b, cnreq); err != nil {
+		t.Fatal(err)
+	}
+	expected := &pb.NamespaceDescriptor{
+		Name: []byte("ns"),
+		Configuration: []*pb.NameStringPair{
+			{Name: proto.String("a"), Value: proto.String("1")},
+			{Name: proto.String("b"), Value: proto.String("2")},
+		},
+	}
+	if !proto.Equal(cnreq.NamespaceDescriptor, expected) {
+		t.Errorf("Expected %s, got %s", expected, cnreq.NamespaceDescriptor)
+	}
+	nd := hrpc.ToLocalNamespaceDescriptor(expected)
+	if nd.Name != "ns" || !reflect.DeepEqual(nd.Configuration,
+		map[string]string{"a": "1", "b": "2"}) {
+		t.Errorf("Unexpected namespace descriptor %+v", nd)
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	sn, err := hrpc.NewSnapshot(context.Background(), "snap", []byte("ns:test"),
+		hrpc.SkipFlush())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := sn.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snreq := &pb.SnapshotRequest{}
+	if err := proto.Unmarshal(b, snreq); err != nil {
+		t.Fatal(err)
+	}
+	expected := &pb.SnapshotDescription{
+		Name:  proto.String("snap"),
+		Table: proto.String("ns:test"),
+		Type:  pb.SnapshotDescription_SKIPFLUSH.Enum(),
+	}
+	if !proto.Equal(snreq.Snapshot, expected) {
+		t.Errorf("Expected %s, got %s", expected, snreq.Snapshot)
+	}
+	if _, err := hrpc.NewSnapshot(context.Background(), "", []byte("test")); err == nil {
+		t.Error("Expected an error with an empty snapshot name")
+	}
+	if _, err := hrpc.NewGetStr(context.Background(), "test", "row",
+		hrpc.SkipFlush()); err == nil {
+		t.Error("Expected an error using SkipFlush with a Get")
+	}
+
+	b, err = hrpc.NewDeleteSnapshot(context.Background(), "snap").Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dsreq := &pb.DeleteSnapshotRequest{}
+	if err := proto.Unmarshal(b, dsreq); err != nil {
+		t.Fatal(err)
+	}
+	if dsreq.Snapshot.GetName() != "snap" || dsreq.Snapshot.Table != nil {
+		t.Errorf("Expected to delete snapshot snap, got %s", dsreq)
+	}
+
+	sd := hrpc.ToLocalSnapshotDescription(&pb.SnapshotDescription{
+		Name:         proto.String("snap"),
+		Table:        proto.String("test"),
+		CreationTime: proto.Int64(1234),
+		Owner:        proto.String("hbase"),
+	})
+	expectedSD := &hrpc.SnapshotDescription{
+		Name:         "snap",
+		Table:        []byte("test"),
+		CreationTime: hrpc.MillisToTime(1234),
+		Type:         hrpc.SnapshotFlush,
+		Owner:        "hbase",
+	}
+	if !reflect.DeepEqual(sd, expectedSD) {
+		t.Errorf("Expected %+v, got %+v", expectedSD, sd)
+	}
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	calls := []hrpc.Call{
+		hrpc.NewRestoreSnapshot(context.Background(), "snap", []byte("test")),
+		hrpc.NewCloneSnapshot(context.Background(), "snap", []byte("ns:clone")),
+	}
+	for i, call := range calls {
+		b, err := call.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := &pb.RestoreSnapshotRequest{}
+		if err := proto.Unmarshal(b, req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Snapshot.GetName() != "snap" || req.Snapshot.GetTable() != string(call.Table()) {
+			t.Errorf("Expected to restore snapshot snap to table %q in call %d, got %s",
+				call.Table(), i, req)
+		}
+	}
+}
+
+func TestSplitAndMergeRegions(t *testing.T) {
+	ctx := context.Background()
+	sr, err := hrpc.NewSplitRegion(ctx, []byte("test"), []byte("row"), hrpc.SplitPoint([]byte("m")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sr.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := sr.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	splitReq := &pb.SplitRegionRequest{}
+	if err := proto.Unmarshal(b, splitReq); err != nil {
+		t.Fatal(err)
+	}
+	if string(splitReq.Region.Value) != "test,,1234567890" ||
+		string(splitReq.SplitPoint) != "m" {
+		t.Errorf("Expected to split region test,,1234567890 at m, got %s", splitReq)
+	}
+	if _, err := hrpc.NewSplitRegion(ctx, []byte("test"), nil, hrpc.SplitPoint(nil)); err == nil {
+		t.Error("Expected an error for an empty split point")
+	}
+
+	mr, err := hrpc.NewMergeTableRegions(ctx, "aaa", "bbb", hrpc.ForcibleMerge())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err = mr.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mergeReq := &pb.DispatchMergingRegionsRequest{}
+	if err := proto.Unmarshal(b, mergeReq); err != nil {
+		t.Fatal(err)
+	}
+	if string(mergeReq.RegionA.Value) != "aaa" || string(mergeReq.RegionB.Value) != "bbb" ||
+		mergeReq.RegionA.GetType() != pb.RegionSpecifier_ENCODED_REGION_NAME ||
+		!mergeReq.GetForcible() {
+		t.Errorf("Expected to forcibly merge regions aaa and bbb, got %s", mergeReq)
+	}
+	if _, err := hrpc.NewMergeTableRegions(ctx, "aaa", "aaa"); err == nil {
+		t.Error("Expected an error when merging a region with itself")
+	}
+	_, err = hrpc.NewMergeTableRegions(ctx, "aaa", "bbb", hrpc.SplitPoint([]byte("m")))
+	if err == nil {
+		t.Error("Expected an error for the SplitPoint option")
+	}
+}
+
+func TestAssignRegions(t *testing.T) {
+	ctx := context.Background()
+	mr, err := hrpc.NewMoveRegion(ctx, "aaa", hrpc.DestinationServer("host", 16020, 42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := mr.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	moveReq := &pb.MoveRegionRequest{}
+	if err := proto.Unmarshal(b, moveReq); err != nil {
+		t.Fatal(err)
+	}
+	dest := moveReq.DestServerName
+	if string(moveReq.Region.Value) != "aaa" ||
+		moveReq.Region.GetType() != pb.RegionSpecifier_ENCODED_REGION_NAME ||
+		dest.GetHostName() != "host" || dest.GetPort() != 16020 || dest.GetStartCode() != 42 {
+		t.Errorf("Expected to move region aaa to host,16020,42, got %s", moveReq)
+	}
+
+	name := []byte("test,,1234567890.aaa.")
+	ur, err := hrpc.NewUnassignRegion(ctx, name, hrpc.ForceUnassign())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err = ur.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unassignReq := &pb.UnassignRegionRequest{}
+	if err := proto.Unmarshal(b, unassignReq); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unassignReq.Region.Value, name) ||
+		unassignReq.Region.GetType() != pb.RegionSpecifier_REGION_NAME ||
+		!unassignReq.GetForce() {
+		t.Errorf("Expected to force the unassignment of region %q, got %s", name, unassignReq)
+	}
+
+	if _, err := hrpc.NewAssignRegion(ctx, nil); err == nil {
+		t.Error("Expected an error for an empty region name")
+	}
+	if _, err := hrpc.NewOfflineRegion(ctx, nil); err == nil {
+		t.Error("Expected an error for an empty region name")
+	}
+	if _, err := hrpc.NewMoveRegion(ctx, "aaa", hrpc.ForceUnassign()); err == nil {
+		t.Error("Expected an error for the ForceUnassign option")
+	}
+}
+
+func TestCompactRegion(t *testing.T) {
+	cr, err := hrpc.NewCompactRegion(context.Background(), []byte("test"), []byte("row"),
+		hrpc.MajorCompaction(), hrpc.CompactFamily("cf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := cr.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.CompactRegionRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if string(req.Region.Value) != "test,,1234567890" || !req.GetMajor() ||
+		string(req.Family) != "cf" {
+		t.Errorf("Expected a major compaction of family cf of test,,1234567890, got %s", req)
+	}
+	_, err = hrpc.NewSplitRegion(context.Background(), []byte("test"), nil,
+		hrpc.MajorCompaction())
+	if err == nil {
+		t.Error("Expected an error for the MajorCompaction option")
+	}
+}
+
+func TestFlushRegion(t *testing.T) {
+	fr := hrpc.NewFlushRegion(context.Background(), []byte("test"), []byte("row"))
+	fr.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := fr.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.FlushRegionRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if string(req.Region.Value) != "test,,1234567890" {
+		t.Errorf("Expected to flush region test,,1234567890, got %s", req)
+	}
+}
+
+func TestSetBalancerRunning(t *testing.T) {
+	sb := hrpc.NewSetBalancerRunning(context.Background(), false, true)
+	b, err := sb.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.SetBalancerRunningRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if req.GetOn() || !req.GetSynchronous() {
+		t.Errorf("Expected to turn the balancer off synchronously, got %s", req)
+	}
+}
+
+func TestHousekeepingSwitches(t *testing.T) {
+	b, err := hrpc.NewSetNormalizerRunning(context.Background(), true).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	normalizerReq := &pb.SetNormalizerRunningRequest{}
+	if err := proto.Unmarshal(b, normalizerReq); err != nil {
+		t.Fatal(err)
+	}
+	if !normalizerReq.GetOn() {
+		t.Errorf("Expected to turn the normalizer on, got %s", normalizerReq)
+	}
+
+	b, err = hrpc.NewEnableCatalogJanitor(context.Background(), false).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	janitorReq := &pb.EnableCatalogJanitorRequest{}
+	if err := proto.Unmarshal(b, janitorReq); err != nil {
+		t.Fatal(err)
+	}
+	if janitorReq.Enable == nil || janitorReq.GetEnable() {
+		t.Errorf("Expected to turn the catalog janitor off, got %s", janitorReq)
+	}
+}
+
+func TestToLocalRegionServerLoad(t *testing.T) {
+	info := &pb.LiveServerInfo{
+		Server: &pb.ServerName{
+			HostName:  proto.String("host"),
+			Port:      proto.Uint32(16020),
+			StartCode: proto.Uint64(42),
+		},
+		ServerLoad: &pb.ServerLoad{
+			NumberOfRequests:      proto.Uint64(10),
+			TotalNumberOfRequests: proto.Uint64(1000),
+			RegionLoads: []*pb.RegionLoad{{
+				RegionSpecifier: &pb.RegionSpecifier{
+					Type:  pb.RegionSpecifier_REGION_NAME.Enum(),
+					Value: []byte("test,,1"),
+				},
+				Storefiles:       proto.Uint32(2),
+				StorefileSize_MB: proto.Uint32(100),
+				MemstoreSize_MB:  proto.Uint32(5),
+			}, {
+				RegionSpecifier: &pb.RegionSpecifier{
+					Type:  pb.RegionSpecifier_REGION_NAME.Enum(),
+					Value: []byte("test,m,1"),
+				},
+				Storefiles:       proto.Uint32(1),
+				StorefileSize_MB: proto.Uint32(50),
+				MemstoreSize_MB:  proto.Uint32(3),
+			}},
+		},
+	}
+	rs := hrpc.ToLocalRegionServerLoad(info)
+	if rs.Host != "host" || rs.Port != 16020 || rs.StartCode != 42 {
+		t.Errorf("Expected RegionServer host,16020,42, got %s,%d,%d",
+			rs.Host, rs.Port, rs.StartCode)
+	}
+	if rs.Regions != 2 || rs.StoreFiles != 3 || rs.StoreFileSizeMB != 150 ||
+		rs.MemstoreSizeMB != 8 || rs.RequestsPerSecond != 10 || rs.TotalRequests != 1000 {
+		t.Errorf("Unexpected load %+v", rs)
+	}
+	if len(rs.RegionLoads) != 2 || string(rs.RegionLoads[1].Name) != "test,m,1" {
+		t.Errorf("Unexpected region loads %v", rs.RegionLoads)
+	}
+}
+
+func TestParseServerName(t *testing.T) {
+	sn := hrpc.ServerName{Host: "host", Port: 16020, StartCode: 1457968166535}
+	if s := sn.String(); s != "host,16020,1457968166535" {
+		t.Errorf("Expected host,16020,1457968166535, got %s", s)
+	}
+	parsed, err := hrpc.ParseServerName(sn.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != sn {
+		t.Errorf("Expected %v, got %v", sn, parsed)
+	}
+	invalid := []string{"", "host", "host,16020", ",16020,1", "host,port,1", "host,1,code"}
+	for _, name := range invalid {
+		if _, err := hrpc.ParseServerName(name); err == nil {
+			t.Errorf("Expected an error for %q", name)
+		}
+	}
+}
+
+func TestGrant(t *testing.T) {
+	perm := &hrpc.UserPermission{
+		User:    "alice",
+		Table:   []byte("ns:test"),
+		Family:  []byte("cf"),
+		Actions: []hrpc.Action{hrpc.ActionRead, hrpc.ActionWrite},
+	}
+	g, err := hrpc.NewGrant(context.Background(), perm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.GetName() != "ExecService" || string(g.Table()) != "hbase:acl" {
+		t.Errorf("Expected an ExecService call to hbase:acl, got %s to %q", g.GetName(), g.Table())
+	}
+	g.SetRegion(&region.Info{Name: []byte("hbase:acl,,1234567890")})
+	b, err := g.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.CoprocessorServiceRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Call.GetServiceName() != "hbase.pb.AccessControlService" ||
+		req.Call.GetMethodName() != "Grant" {
+		t.Errorf("Expected a call to AccessControlService.Grant, got %s", req.Call)
+	}
+	grant := &pb.GrantRequest{}
+	if err := proto.Unmarshal(req.Call.Request, grant); err != nil {
+		t.Fatal(err)
+	}
+	if back := hrpc.ToLocalUserPermission(grant.UserPermission); !reflect.DeepEqual(back, perm) {
+		t.Errorf("Expected to grant %+v, got %+v", perm, back)
+	}
+
+	invalid := []*hrpc.UserPermission{
+		{Actions: []hrpc.Action{hrpc.ActionRead}},
+		{User: "alice"},
+		{User: "alice", Namespace: "ns", Table: []byte("test"),
+			Actions: []hrpc.Action{hrpc.ActionRead}},
+		{User: "alice", Family: []byte("cf"), Actions: []hrpc.Action{hrpc.ActionRead}},
+	}
+	for _, perm := range invalid {
+		if _, err := hrpc.NewRevoke(context.Background(), perm); err == nil {
+			t.Errorf("Expected an error for %+v", perm)
+		}
+	}
+}
+
+func TestNewIncrement(t *testing.T) {
+	ctx := context.Background()
+	amount := make([]byte, 8)
+	binary.BigEndian.PutUint64(amount, 3)
+	values := map[string]map[string][]byte{"cf": {"a": amount}}
+	if _, err := hrpc.NewIncrement(ctx, []byte("test"), []byte("row"), values); err != nil {
+		t.Errorf("Expected an 8-byte amount to be accepted, got %v", err)
+	}
+	for _, bad := range [][]byte{nil, {}, {3}, make([]byte, 4), make([]byte, 9)} {
+		values := map[string]map[string][]byte{"cf": {"a": amount, "b": bad}}
+		if _, err := hrpc.NewIncrement(ctx, []byte("test"), []byte("row"), values); err == nil {
+			t.Errorf("Expected an error for an amount of %d bytes", len(bad))
+		}
+	}
+}
+
+func TestDecommissionRegionServers(t *testing.T) {
+	servers := []hrpc.ServerName{
+		{Host: "host1", Port: 16020, StartCode: 1},
+		{Host: "host2", Port: 16020, StartCode: 2},
+	}
+	b, err := hrpc.NewDecommissionRegionServers(context.Background(), servers, true).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.DecommissionRegionServersRequest{}
+	if err = proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if !req.GetOffload() || len(req.GetServerName()) != len(servers) {
+		t.Fatalf("Expected to offload %d servers, got %s", len(servers), req)
+	}
+	for i, sn := range req.GetServerName() {
+		if sn.GetHostName() != servers[i].Host || sn.GetPort() != uint32(servers[i].Port) ||
+			sn.GetStartCode() != servers[i].StartCode {
+			t.Errorf("Expected server %d to be %s, got %s", i, servers[i], sn)
+		}
+	}
+
+	b, err = hrpc.NewRecommissionRegionServer(context.Background(), servers[1]).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recommission := &pb.RecommissionRegionServerRequest{}
+	if err = proto.Unmarshal(b, recommission); err != nil {
+		t.Fatal(err)
+	}
+	if recommission.GetServerName().GetHostName() != "host2" ||
+		recommission.GetServerName().GetStartCode() != 2 {
+		t.Errorf("Expected to recommission %s, got %s", servers[1], recommission)
+	}
+}
+
+func TestNewIncMulti(t *testing.T) {
+	inc, err := hrpc.NewIncMulti(context.Background(), []byte("test"), []byte("row"),
+		map[string]map[string]int64{"cf1": {"a": 1, "b": -1}, "cf2": {"c": 256}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inc.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := inc.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.MutateRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Mutation.GetMutateType() != pb.MutationProto_INCREMENT {
+		t.Errorf("Expected an Increment, got %v", req.Mutation)
+	}
+	amounts := make(map[string]int64)
+	for _, cv := range req.Mutation.ColumnValue {
+		for _, qv := range cv.QualifierValue {
+			amounts[string(cv.Family)+":"+string(qv.Qualifier)] =
+				int64(binary.BigEndian.Uint64(qv.Value))
+		}
+	}
+	expected := map[string]int64{"cf1:a": 1, "cf1:b": -1, "cf2:c": 256}
+	if !reflect.DeepEqual(amounts, expected) {
+		t.Errorf("Expected amounts %v, got %v", expected, amounts)
+	}
+}
+
+func TestTimeHelpers(t *testing.T) {
+	ts := time.Date(2016, 3, 14, 15, 9, 26, 535897932, time.UTC)
+	ms := hrpc.TimeToMillis(ts)
+	if ms != 1457968166535 {
+		t.Errorf("Expected 1457968166535ms, got %d", ms)
+	}
+	if back := hrpc.MillisToTime(ms); !back.Equal(ts.Truncate(time.Millisecond)) {
+		t.Errorf("Expected %s, got %s", ts.Truncate(time.Millisecond), back)
+	}
+	cell := &hrpc.Cell{Timestamp: proto.Uint64(ms)}
+	if cellTime := cell.Time(); !cellTime.Equal(hrpc.MillisToTime(ms)) ||
+		cell.TimestampMillis() != ms {
+		t.Errorf("Unexpected cell time: %s", cellTime)
+	}
+
+	values := map[string]map[string][]byte{"cf": map[string][]byte{"a": []byte("v")}}
+	for _, option := range []func(hrpc.Call) error{hrpc.Timestamp(ts), hrpc.TimestampUint64(ms)} {
+		put, err := hrpc.NewPutStr(context.Background(), "test", "row", values, option)
+		if err != nil {
+			t.Fatal(err)
+		}
+		put.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+		b, err := put.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := &pb.MutateRequest{}
+		if err := proto.Unmarshal(b, req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Mutation.GetTimestamp() != ms {
+			t.Errorf("Expected timestamp %d, got %d", ms, req.Mutation.GetTimestamp())
+		}
+	}
+}
+
+func TestNewRenewFromID(t *testing.T) {
+	renew := hrpc.NewRenewFromID(context.Background(), []byte("test"), 42, nil)
+	renew.SetRegion(&region.Info{Name: []byte("test,,1234567890")})
+	b, err := renew.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.ScanRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	if req.GetScannerId() != 42 || !req.GetRenew() || req.GetNumberOfRows() != 0 ||
+		req.Scan != nil {
+		t.Errorf("Unexpected renew request: %s", req)
+	}
+}
+
+func TestMulti(t *testing.T) {
+	ctx := context.Background()
+	reg1 := &region.Info{Table: []byte("test"), Name: []byte("test,,1")}
+	reg2 := &region.Info{Table: []byte("test"), Name: []byte("test,m,2")}
+	values := map[string]map[string][]byte{"cf": {"a": []byte("1")}}
+	newCheckAndPut := func(key string, reg hrpc.RegionInfo) *hrpc.CheckAndPut {
+		put, err := hrpc.NewPutStr(ctx, "test", key, values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cas, err := hrpc.NewCheckAndPut(put, "cf", "a", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cas.SetRegion(reg)
+		return cas
+	}
+	put, err := hrpc.NewPutStr(ctx, "test", "b", values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	put.SetRegion(reg1)
+
+	multi := hrpc.NewMulti(ctx)
+	multi.Add(newCheckAndPut("a", reg1))
+	multi.Add(put)
+	multi.Add(newCheckAndPut("n", reg2))
+	multi.Add(newCheckAndPut("c", reg1))
+	b, err := multi.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.MultiRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	// Each conditional Put gets its own atomic RegionAction.
+	if len(req.RegionAction) != 4 {
+		t.Fatalf("Expected 4 region actions, got %d: %s", len(req.RegionAction), req)
+	}
+	for i, ra := range req.RegionAction {
+		conditional := i != 1
+		if ra.GetAtomic() != conditional || (ra.Condition != nil) != conditional ||
+			len(ra.Action) != 1 || ra.Action[0].GetIndex() != uint32(i) {
+			t.Errorf("Unexpected region action #%d: %s", i, ra)
+		}
+	}
+	if !bytes.Equal(req.RegionAction[2].Region.Value, reg2.Name) {
+		t.Errorf("Expected region %q, got %q", reg2.Name, req.RegionAction[2].Region.Value)
+	}
+
+	resp := &pb.MultiResponse{RegionActionResult: []*pb.RegionActionResult{
+		{Processed: proto.Bool(true), ResultOrException: []*pb.ResultOrException{
+			{Index: proto.Uint32(0), Result: &pb.Result{}},
+		}},
+		{ResultOrException: []*pb.ResultOrException{
+			{Index: proto.Uint32(1), Exception: &pb.NameBytesPair{Name: proto.String("oops")}},
+		}},
+		{Processed: proto.Bool(false)},
+		{Exception: &pb.NameBytesPair{Name: proto.String("NotServingRegionException")}},
+	}}
+	results, err := multi.Results(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Processed || results[0].Exception != nil {
+		t.Errorf("Expected the first CheckAndPut to be applied, got %+v", results[0])
+	}
+	if results[1].Exception.GetName() != "oops" {
+		t.Errorf("Expected the Put to fail, got %+v", results[1])
+	}
+	if results[2].Processed || results[2].Exception != nil {
+		t.Errorf("Expected the second CheckAndPut not to be applied, got %+v", results[2])
+	}
+	if results[3].Exception.GetName() != "NotServingRegionException" {
+		t.Errorf("Expected the third CheckAndPut to fail, got %+v", results[3])
+	}
+
+	resp.RegionActionResult[1].ResultOrException = nil
+	if _, err := multi.Results(resp); err == nil {
+		t.Error("Expected an error for a response missing a result")
+	}
+}
+
+func TestRowMutations(t *testing.T) {
+	ctx := context.Background()
+	reg := &region.Info{Table: []byte("test"), Name: []byte("test,,1")}
+	values := map[string]map[string][]byte{"cf": {"a": []byte("1")}}
+	put, err := hrpc.NewPutStr(ctx, "test", "a", values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	del, err := hrpc.NewDelStr(ctx, "test", "a", values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := hrpc.NewPutStr(ctx, "test", "b", values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hrpc.NewRowMutations(ctx, put, other); err == nil {
+		t.Error("Expected an error with mutations of different rows")
+	}
+	if _, err := hrpc.NewRowMutations(ctx); err == nil {
+		t.Error("Expected an error without mutations")
+	}
+	rm, err := hrpc.NewRowMutations(ctx, put, del)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rm.SetRegion(reg)
+	other.SetRegion(reg)
+
+	multi := hrpc.NewMulti(ctx)
+	multi.Add(other)
+	multi.Add(rm)
+	b, err := multi.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.MultiRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	// The RowMutations gets its own atomic RegionAction, with one action
+	// per mutation.
+	if len(req.RegionAction) != 2 || req.RegionAction[0].GetAtomic() {
+		t.Fatalf("Unexpected region actions: %s", req)
+	}
+	ra := req.RegionAction[1]
+	if !ra.GetAtomic() || ra.Condition != nil || len(ra.Action) != 2 ||
+		ra.Action[0].GetIndex() != 1 || ra.Action[1].GetIndex() != 2 ||
+		ra.Action[1].Mutation.GetMutateType() != pb.MutationProto_DELETE {
+		t.Errorf("Unexpected region action: %s", ra)
+	}
+
+	resp := &pb.MultiResponse{RegionActionResult: []*pb.RegionActionResult{
+		{ResultOrException: []*pb.ResultOrException{
+			{Index: proto.Uint32(0), Result: &pb.Result{}},
+		}},
+		{ResultOrException: []*pb.ResultOrException{
+			{Index: proto.Uint32(1), Result: &pb.Result{}},
+			{Index: proto.Uint32(2), Exception: &pb.NameBytesPair{Name: proto.String("oops")}},
+		}},
+	}}
+	results, err := multi.Results(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Exception != nil {
+		t.Errorf("Expected the Put to succeed, got %+v", results[0])
+	}
+	if results[1].Exception.GetName() != "oops" {
+		t.Errorf("Expected the RowMutations to fail, got %+v", results[1])
+	}
+	resp.RegionActionResult[1].ResultOrException[1].Index = proto.Uint32(3)
+	if _, err := multi.Results(resp); err == nil {
+		t.Error("Expected an error for a result of an unknown action")
+	}
+}
+
+func TestMultiGet(t *testing.T) {
+	ctx := context.Background()
+	reg1 := &region.Info{Table: []byte("test"), Name: []byte("test,,1")}
+	reg2 := &region.Info{Table: []byte("test"), Name: []byte("test,m,2")}
+	multi := hrpc.NewMulti(ctx)
+	for _, key := range []string{"a", "n", "b"} {
+		get, err := hrpc.NewGetStr(ctx, "test", key, hrpc.MaxVersions(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key < "m" {
+			get.SetRegion(reg1)
+		} else {
+			get.SetRegion(reg2)
+		}
+		multi.Add(get)
+	}
+	b, err := multi.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.MultiRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+	// The Gets for the same region are grouped in the same RegionAction.
+	if len(req.RegionAction) != 2 || len(req.RegionAction[0].Action) != 2 ||
+		len(req.RegionAction[1].Action) != 1 {
+		t.Fatalf("Unexpected region actions: %s", req)
+	}
+	if a := req.RegionAction[0].Action[1]; a.GetIndex() != 2 || string(a.Get.Row) != "b" ||
+		a.Get.GetMaxVersions() != 2 || a.Mutation != nil {
+		t.Errorf("Unexpected action: %s", a)
+	}
+
+	resp := &pb.MultiResponse{RegionActionResult: []*pb.RegionActionResult{
+		{ResultOrException: []*pb.ResultOrException{
+			{Index: proto.Uint32(0), Result: &pb.Result{}},
+			{Index: proto.Uint32(2), Result: &pb.Result{}},
+		}},
+		{ResultOrException: []*pb.ResultOrException{
+			{Index: proto.Uint32(1), Result: &pb.Result{}},
+		}},
+	}}
+	results, err := multi.Results(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, res := range results {
+		if res.Result == nil || res.Exception != nil {
+			t.Errorf("Unexpected result #%d: %+v", i, res)
+		}
+	}
+}
+
 func confirmScanAttributes(s *hrpc.Scan, ctx context.Context, table, start, stop []byte,
 	fam map[string][]string, filter1 filter.Filter) bool {
 	if s.GetContext() != ctx ||
@@ -0,0 +1,109 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// EnableCatalogJanitor represents an EnableCatalogJanitor HBase call
+type EnableCatalogJanitor struct {
+	tableOp
+
+	enable bool
+}
+
+// NewEnableCatalogJanitor creates a new EnableCatalogJanitor request that will
+// turn the catalog janitor of the master on or off.  The catalog janitor
+// cleans up the parents of split and merged regions from meta and HDFS.  For
+// use by the admin client.
+func NewEnableCatalogJanitor(ctx context.Context, enable bool) *EnableCatalogJanitor {
+	return &EnableCatalogJanitor{
+		tableOp: tableOp{base{ctx: ctx}},
+		enable:  enable,
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (ec *EnableCatalogJanitor) GetName() string {
+	return "EnableCatalogJanitor"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (ec *EnableCatalogJanitor) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.EnableCatalogJanitorRequest{Enable: proto.Bool(ec.enable)})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (ec *EnableCatalogJanitor) NewResponse() proto.Message {
+	return &pb.EnableCatalogJanitorResponse{}
+}
+
+// IsCatalogJanitorEnabled represents an IsCatalogJanitorEnabled HBase call
+type IsCatalogJanitorEnabled struct {
+	tableOp
+}
+
+// NewIsCatalogJanitorEnabled creates a new IsCatalogJanitorEnabled request
+// that will check whether the catalog janitor of the master is on.  For use
+// by the admin client.
+func NewIsCatalogJanitorEnabled(ctx context.Context) *IsCatalogJanitorEnabled {
+	return &IsCatalogJanitorEnabled{
+		tableOp{base{ctx: ctx}},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (ic *IsCatalogJanitorEnabled) GetName() string {
+	return "IsCatalogJanitorEnabled"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (ic *IsCatalogJanitorEnabled) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.IsCatalogJanitorEnabledRequest{})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (ic *IsCatalogJanitorEnabled) NewResponse() proto.Message {
+	return &pb.IsCatalogJanitorEnabledResponse{}
+}
+
+// RunCatalogScan represents a RunCatalogScan HBase call
+type RunCatalogScan struct {
+	tableOp
+}
+
+// NewRunCatalogScan creates a new RunCatalogScan request that will have the
+// catalog janitor of the master clean up meta now.  For use by the admin
+// client.
+func NewRunCatalogScan(ctx context.Context) *RunCatalogScan {
+	return &RunCatalogScan{
+		tableOp{base{ctx: ctx}},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (rc *RunCatalogScan) GetName() string {
+	return "RunCatalogScan"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (rc *RunCatalogScan) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.RunCatalogScanRequest{})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (rc *RunCatalogScan) NewResponse() proto.Message {
+	return &pb.RunCatalogScanResponse{}
+}
@@ -0,0 +1,233 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/filter"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// Multi packs several calls, possibly for different regions of the same
+// RegionServer, into a single Multi RPC.  The conditional mutations require
+// HBase 2.4 or later, whose RegionServers are the first to check the
+// conditions of a Multi.  This is an internal type, users are not expected to
+// use it directly.
+type Multi struct {
+	base
+
+	calls []Call
+
+	// The indexes in calls of the calls in each RegionAction of the last
+	// serialized request.
+	regionActions [][]int
+
+	// The index in calls of the call of each Action of the last serialized
+	// request.  A RowMutations has one Action per mutation.
+	actionCalls []int
+}
+
+// MultiResult is the outcome of one of the calls of a Multi.
+type MultiResult struct {
+	// Result of the call, if any.
+	Result *pb.Result
+
+	// Processed is true if the condition of a conditional mutation was met
+	// and the mutation was applied.
+	Processed bool
+
+	// Exception raised by HBase for this call, if any.
+	Exception *pb.NameBytesPair
+}
+
+// conditionalMutation is a mutation that is only applied if its condition
+// holds, i.e. a CheckAndPut or a CheckAndMutate.
+type conditionalMutation interface {
+	Call
+	condition() (*pb.Condition, error)
+	serializeToProto() (*pb.MutateRequest, error)
+}
+
+// NewMulti creates a new, empty, Multi RPC.
+func NewMulti(ctx context.Context) *Multi {
+	return &Multi{
+		base: base{
+			ctx: ctx,
+		},
+	}
+}
+
+// Add adds a call to this Multi.  Its region must have been set already.
+func (m *Multi) Add(call Call) {
+	if len(m.calls) == 0 {
+		m.table = call.Table()
+		m.key = call.Key()
+	}
+	m.calls = append(m.calls, call)
+}
+
+// Calls returns the calls packed in this Multi.
+func (m *Multi) Calls() []Call {
+	return m.calls
+}
+
+// GetName returns the name of this RPC call.
+func (m *Multi) GetName() string {
+	return "Multi"
+}
+
+// Serialize converts this Multi into a serialized protobuf message ready to
+// be sent to an HBase node.  The calls for the same region are grouped in the
+// same RegionAction, except for the conditional mutations and the
+// RowMutations that each get their own atomic RegionAction.  The conditions
+// are only understood by HBase 2.4 or later.
+func (m *Multi) Serialize() ([]byte, error) {
+	multi := &pb.MultiRequest{}
+	m.regionActions = nil
+	m.actionCalls = nil
+	byRegion := make(map[string]int)
+	for i, call := range m.calls {
+		index := uint32(len(m.actionCalls))
+		if rm, ok := call.(*RowMutations); ok {
+			regionAction, err := rm.regionAction(index)
+			if err != nil {
+				return nil, err
+			}
+			for range rm.mutations {
+				m.actionCalls = append(m.actionCalls, i)
+			}
+			multi.RegionAction = append(multi.RegionAction, regionAction)
+			m.regionActions = append(m.regionActions, []int{i})
+			continue
+		}
+		m.actionCalls = append(m.actionCalls, i)
+		action := &pb.Action{Index: &index}
+		var condition *pb.Condition
+		switch c := call.(type) {
+		case conditionalMutation:
+			var err error
+			condition, err = c.condition()
+			if err != nil {
+				return nil, err
+			}
+			mutateRequest, err := c.serializeToProto()
+			if err != nil {
+				return nil, fmt.Errorf("Error serializing request: %s", err)
+			}
+			action.Mutation = mutateRequest.Mutation
+		case *Mutate:
+			mutateRequest, err := c.serializeToProto()
+			if err != nil {
+				return nil, fmt.Errorf("Error serializing request: %s", err)
+			}
+			action.Mutation = mutateRequest.Mutation
+		case *Get:
+			getRequest, err := c.toProto()
+			if err != nil {
+				return nil, err
+			}
+			action.Get = getRequest.Get
+		default:
+			return nil, fmt.Errorf("%s calls can't be sent in a Multi", call.GetName())
+		}
+
+		name := string(call.GetRegion().GetName())
+		ra, ok := byRegion[name]
+		if !ok || condition != nil {
+			ra = len(multi.RegionAction)
+			regionAction := &pb.RegionAction{Region: regionSpecifier(call.GetRegion())}
+			if condition != nil {
+				regionAction.Atomic = proto.Bool(true)
+				regionAction.Condition = condition
+			} else {
+				byRegion[name] = ra
+			}
+			multi.RegionAction = append(multi.RegionAction, regionAction)
+			m.regionActions = append(m.regionActions, nil)
+		}
+		multi.RegionAction[ra].Action = append(multi.RegionAction[ra].Action, action)
+		m.regionActions[ra] = append(m.regionActions[ra], i)
+	}
+	return proto.Marshal(multi)
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (m *Multi) NewResponse() proto.Message {
+	return &pb.MultiResponse{}
+}
+
+// Results returns the outcome of each of the calls of this Multi, in the
+// order in which they were added, from the response to this Multi.
+func (m *Multi) Results(resp *pb.MultiResponse) ([]MultiResult, error) {
+	if len(resp.RegionActionResult) != len(m.regionActions) {
+		return nil, fmt.Errorf("Multi response has %d region action results, expected %d",
+			len(resp.RegionActionResult), len(m.regionActions))
+	}
+	results := make([]MultiResult, len(m.calls))
+	seen := make([]bool, len(m.calls))
+	for i, rar := range resp.RegionActionResult {
+		for _, index := range m.regionActions[i] {
+			results[index].Processed = rar.GetProcessed()
+			if rar.Exception != nil {
+				// The whole RegionAction failed.
+				results[index].Exception = rar.Exception
+				seen[index] = true
+			} else if m.isAtomic(index) {
+				// There may be no result if the condition wasn't met, or
+				// a single one for all the mutations of a row.
+				seen[index] = true
+			}
+		}
+		for _, roe := range rar.ResultOrException {
+			action := int(roe.GetIndex())
+			if action >= len(m.actionCalls) {
+				return nil, fmt.Errorf("Multi response has a result for action %d,"+
+					" only %d were sent", action, len(m.actionCalls))
+			}
+			index := m.actionCalls[action]
+			results[index].Result = roe.Result
+			if roe.Exception != nil {
+				results[index].Exception = roe.Exception
+			}
+			seen[index] = true
+		}
+	}
+	for index, ok := range seen {
+		if !ok {
+			return nil, fmt.Errorf("Multi response has no result for action %d", index)
+		}
+	}
+	return results, nil
+}
+
+// isAtomic returns true if the call at the given index got its own atomic
+// RegionAction.
+func (m *Multi) isAtomic(index int) bool {
+	switch m.calls[index].(type) {
+	case conditionalMutation, *RowMutations:
+		return true
+	}
+	return false
+}
+
+// SetFilter always returns an error when used on Multi objects. Do not use.
+// Exists solely so Multi can implement the Call interface.
+func (m *Multi) SetFilter(ft filter.Filter) error {
+	// Not allowed. Throw an error
+	return errors.New("Cannot set filter on multi operation.")
+}
+
+// SetFamilies always returns an error when used on Multi objects. Do not use.
+// Exists solely so Multi can implement the Call interface.
+func (m *Multi) SetFamilies(fam map[string][]string) error {
+	// Not allowed. Throw an error
+	return errors.New("Cannot set families on multi operation.")
+}
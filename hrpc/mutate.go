@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 	"unsafe"
@@ -69,20 +70,191 @@ type Mutate struct {
 
 	// mutation durability
 	durability DurabilityType
+
+	// Don't return the resulting cells of an Append or Increment.
+	skipResults bool
+
+	// Time to live of the cells written by this mutation, if not 0.
+	ttl time.Duration
+
+	// Attributes of the mutation, by name.
+	attributes map[string][]byte
+
+	// Columns and families deleted by a Delete, in addition to its values.
+	deletions []deletion
+
+	// Cells written by a Put, with their own timestamp, in addition to its
+	// values.
+	cells []*Cell
 }
 
+// deletion is a column or a family deleted by a Delete, along with the
+// versions deleted.
+type deletion struct {
+	deleteType pb.MutationProto_DeleteType
+	family     string
+	qualifier  string
+	// Timestamp of the deleted versions, MaxTimestamp if it's the timestamp
+	// of the Delete.
+	timestamp uint64
+}
+
+const (
+	// returnResultsAttribute is the attribute of the Appends and Increments
+	// that tells whether the RegionServer returns the resulting cells (true by
+	// default).
+	returnResultsAttribute = "_rr_"
+
+	// ttlAttribute is the attribute of the mutations that sets the time to
+	// live of their cells, in milliseconds.
+	ttlAttribute = "_ttl"
+)
+
 // Timestamp sets timestamp for mutation queries.
+// The timestamp is truncated to the millisecond, the precision of HBase.
 func Timestamp(ts time.Time) func(Call) error {
+	return TimestampUint64(TimeToMillis(ts))
+}
+
+// TimestampUint64 sets timestamp for mutation queries, in milliseconds since
+// the Unix epoch.
+func TimestampUint64(ts uint64) func(Call) error {
 	return func(o Call) error {
 		m, ok := o.(*Mutate)
 		if !ok {
 			return errors.New("Timestamp option can only be used with mutation queries.")
 		}
-		m.timestamp = uint64(ts.UnixNano() / 1e6)
+		m.timestamp = ts
+		return nil
+	}
+}
+
+// ReturnResults sets whether the RegionServer returns the resulting cells of
+// an Append or Increment, which it does by default.  Not returning them saves
+// bandwidth when the caller doesn't need the new values.  It has no effect on
+// other mutations.
+func ReturnResults(enabled bool) func(Call) error {
+	return func(o Call) error {
+		m, ok := o.(*Mutate)
+		if !ok {
+			return errors.New("ReturnResults option can only be used with mutation queries.")
+		}
+		m.skipResults = !enabled
+		return nil
+	}
+}
+
+// TTL sets the time to live of the cells written by a Put, Append or
+// Increment, after which they expire regardless of the TTL of their column
+// family.  The TTL is truncated to the millisecond, and requires HFile
+// version 3 (the default since HBase 1.0).  It has no effect on Deletes.
+func TTL(ttl time.Duration) func(Call) error {
+	return func(o Call) error {
+		m, ok := o.(*Mutate)
+		if !ok {
+			return errors.New("TTL option can only be used with mutation queries.")
+		}
+		if ttl < time.Millisecond {
+			return errors.New("TTL must be at least a millisecond.")
+		}
+		m.ttl = ttl
 		return nil
 	}
 }
 
+// Attribute sets an attribute of a Put or Delete (or any other mutation),
+// which is passed along to the coprocessors handling the mutation, e.g. the
+// visibility controller.  It can be used several times to set several
+// attributes, the last value of an attribute overriding the previous ones.
+func Attribute(name string, value []byte) func(Call) error {
+	return func(o Call) error {
+		m, ok := o.(*Mutate)
+		if !ok {
+			return errors.New("Attribute option can only be used with mutation queries.")
+		}
+		if name == "" {
+			return errors.New("Attribute name can't be empty.")
+		}
+		if m.attributes == nil {
+			m.attributes = make(map[string][]byte)
+		}
+		m.attributes[name] = value
+		return nil
+	}
+}
+
+// DeleteColumn makes a Delete delete the version of the given column at the
+// given timestamp, in milliseconds, like addColumn in the Java client.  With
+// MaxTimestamp, the timestamp of the Delete is used, and if it has none the
+// latest version is deleted.  Without any such option, a Delete deletes all
+// the versions of the columns of its values, or the whole row if it has none.
+func DeleteColumn(family, qualifier string, ts uint64) func(Call) error {
+	return addDeletion(pb.MutationProto_DELETE_ONE_VERSION, family, qualifier, ts)
+}
+
+// DeleteColumns makes a Delete delete all the versions of the given column
+// with a timestamp lower than or equal to the given one, in milliseconds,
+// like addColumns in the Java client.  With MaxTimestamp, the timestamp of
+// the Delete is used, and if it has none all the versions are deleted.
+func DeleteColumns(family, qualifier string, ts uint64) func(Call) error {
+	return addDeletion(pb.MutationProto_DELETE_MULTIPLE_VERSIONS, family, qualifier, ts)
+}
+
+// DeleteFamily makes a Delete delete all the versions of all the columns of
+// the given family with a timestamp lower than or equal to the given one, in
+// milliseconds, like addFamily in the Java client.  With MaxTimestamp, the
+// timestamp of the Delete is used, and if it has none all the versions are
+// deleted.
+func DeleteFamily(family string, ts uint64) func(Call) error {
+	return addDeletion(pb.MutationProto_DELETE_FAMILY, family, "", ts)
+}
+
+// DeleteFamilyVersion makes a Delete delete the versions of all the columns
+// of the given family at exactly the given timestamp, in milliseconds, like
+// addFamilyVersion in the Java client.  With MaxTimestamp, the timestamp of
+// the Delete is used.
+func DeleteFamilyVersion(family string, ts uint64) func(Call) error {
+	return addDeletion(pb.MutationProto_DELETE_FAMILY_VERSION, family, "", ts)
+}
+
+func addDeletion(deleteType pb.MutationProto_DeleteType, family, qualifier string,
+	ts uint64) func(Call) error {
+	return func(o Call) error {
+		m, ok := o.(*Mutate)
+		if !ok {
+			return errors.New("Delete options can only be used with Delete queries.")
+		}
+		m.deletions = append(m.deletions, deletion{
+			deleteType: deleteType,
+			family:     family,
+			qualifier:  qualifier,
+			timestamp:  ts,
+		})
+		return nil
+	}
+}
+
+// CellVisibility sets the visibility expression of the cells written by a
+// Put (or any other mutation), on clusters running the VisibilityController
+// coprocessor, e.g. "secret|(probationary&!contractor)".  Only the Gets and
+// Scans whose authorizations satisfy the expression can read the cells.
+func CellVisibility(expression string) func(Call) error {
+	return func(o Call) error {
+		m, ok := o.(*Mutate)
+		if !ok {
+			return errors.New("CellVisibility option can only be used with mutation queries.")
+		}
+		if expression == "" {
+			return errors.New("CellVisibility expression can't be empty.")
+		}
+		value, err := proto.Marshal(&pb.CellVisibility{Expression: proto.String(expression)})
+		if err != nil {
+			return err
+		}
+		return Attribute(visibilityAttribute, value)(m)
+	}
+}
+
 // Durability sets durability for mutation queries.
 func Durability(d DurabilityType) func(Call) error {
 	return func(o Call) error {
@@ -130,6 +302,35 @@ func NewPutStr(ctx context.Context, table, key string,
 	return m, nil
 }
 
+// NewPutCells creates a new Mutation request to insert the given cells in
+// the given row key of the given table.  Unlike the values of NewPutStr, each
+// cell has its own timestamp, the timestamp of the Put being used for those
+// that have none, and several versions of a column can be written at once,
+// which backfills, replication tools and idempotent rewrites need.  Only
+// the family, qualifier, timestamp and value of the cells are used, so the
+// cells of a Result can be written as-is.
+func NewPutCells(ctx context.Context, table, key []byte, cells []*Cell,
+	options ...func(Call) error) (*Mutate, error) {
+	m, err := baseMutate(ctx, string(table), string(key), nil, nil, options...)
+	if err != nil {
+		return nil, err
+	}
+	for _, cell := range cells {
+		if len(cell.Family) == 0 {
+			return nil, errors.New("Column family can't be empty.")
+		}
+		if cell.Row != nil && !bytes.Equal(cell.Row, m.key) {
+			return nil, fmt.Errorf("Cell of row %q can't be put in row %q", cell.Row, m.key)
+		}
+		if cell.CellType != nil && *cell.CellType != pb.CellType_PUT {
+			return nil, fmt.Errorf("Cell of type %s can't be put", cell.CellType)
+		}
+	}
+	m.cells = cells
+	m.mutationType = pb.MutationProto_PUT
+	return m, nil
+}
+
 // NewPutStrRef creates a new Mutation request to insert the given
 // data structure in the given row key of the given table.  The `data'
 // argument must be a string with fields defined using the "hbase" tag.
@@ -147,7 +348,9 @@ func NewPutStrRef(ctx context.Context, table, key string, data interface{},
 }
 
 // NewDelStr creates a new Mutation request to delete the given
-// family-column-values from the given row key of the given table.
+// family-column-values from the given row key of the given table.  All the
+// versions of the given columns are deleted, and the families without any
+// qualifier are deleted as a whole.  Without values, the whole row is deleted.
 func NewDelStr(ctx context.Context, table, key string,
 	values map[string]map[string][]byte, options ...func(Call) error) (*Mutate, error) {
 	m, err := baseMutate(ctx, table, key, values, nil, options...)
@@ -187,6 +390,16 @@ func NewAppStr(ctx context.Context, table, key string,
 	return m, nil
 }
 
+// NewAppend creates a new Mutation request that will append the given
+// family-column-values into the existing cells in HBase (or create them if
+// needed), in given row key of the given table.  The Append method of the
+// client returns the resulting cells, unless the ReturnResults(false) option
+// is given.
+func NewAppend(ctx context.Context, table, key []byte,
+	values map[string]map[string][]byte, options ...func(Call) error) (*Mutate, error) {
+	return NewAppStr(ctx, string(table), string(key), values, options...)
+}
+
 // NewAppStrRef creates a new Mutation request that will append the given values
 // to their existing values in HBase under the given table and key.
 func NewAppStrRef(ctx context.Context, table, key string, data interface{},
@@ -202,6 +415,49 @@ func NewAppStrRef(ctx context.Context, table, key string, data interface{},
 	return m, nil
 }
 
+// NewIncSingle creates a new Mutation request that will increment the given
+// value by amount in HBase under the given table, key, family and qualifier.
+// The Increment method of the client returns the incremented value.
+func NewIncSingle(ctx context.Context, table, key []byte, family string,
+	qualifier string, amount int64, options ...func(Call) error) (*Mutate, error) {
+	return NewIncStrSingle(ctx, string(table), string(key), family, qualifier, amount,
+		options...)
+}
+
+// NewIncrement creates a new Mutation request that will increment the given
+// values, which must be 64-bit big-endian integers, in HBase under the given
+// table and key.  It returns an error if one of the values isn't 8 bytes long.
+func NewIncrement(ctx context.Context, table, key []byte,
+	values map[string]map[string][]byte, options ...func(Call) error) (*Mutate, error) {
+	for family, qualifiers := range values {
+		for qualifier, value := range qualifiers {
+			if len(value) != 8 {
+				return nil, fmt.Errorf("The amount to increment %s:%s by is %d bytes long"+
+					" instead of 8.", family, qualifier, len(value))
+			}
+		}
+	}
+	return NewIncStr(ctx, string(table), string(key), values, options...)
+}
+
+// NewIncMulti creates a new Mutation request that will atomically increment
+// the values of all the given family-qualifiers of the given row by their
+// amount.  The IncrementColumns method of the client returns the incremented
+// values.
+func NewIncMulti(ctx context.Context, table, key []byte,
+	amounts map[string]map[string]int64, options ...func(Call) error) (*Mutate, error) {
+	values := make(map[string]map[string][]byte, len(amounts))
+	for family, qualifiers := range amounts {
+		values[family] = make(map[string][]byte, len(qualifiers))
+		for qualifier, amount := range qualifiers {
+			value := make([]byte, 8)
+			binary.BigEndian.PutUint64(value, uint64(amount))
+			values[family][qualifier] = value
+		}
+	}
+	return NewIncrement(ctx, table, key, values, options...)
+}
+
 // NewIncStrSingle creates a new Mutation request that will increment the given value
 // by amount in HBase under the given table, key, family and qualifier.
 func NewIncStrSingle(ctx context.Context, table, key string, family string,
@@ -244,11 +500,38 @@ func NewIncStrRef(ctx context.Context, table, key string, data interface{},
 	return m, nil
 }
 
+// GetTTL returns the time to live of the cells written by this mutation, 0
+// if they only expire according to the TTL of their column family.
+func (m *Mutate) GetTTL() time.Duration {
+	return m.ttl
+}
+
+// GetAttributes returns the attributes of this mutation, by name.
+func (m *Mutate) GetAttributes() map[string][]byte {
+	return m.attributes
+}
+
+// GetReturnResults returns whether the RegionServer returns the resulting
+// cells of this Append or Increment.
+func (m *Mutate) GetReturnResults() bool {
+	return !m.skipResults
+}
+
 // GetName returns the name of this RPC call.
 func (m *Mutate) GetName() string {
 	return "Mutate"
 }
 
+// Size returns the size in bytes of this mutation once serialized, without
+// the overhead of the RPC carrying it.
+func (m *Mutate) Size() (int, error) {
+	mutateRequest, err := m.serializeToProto()
+	if err != nil {
+		return 0, err
+	}
+	return proto.Size(mutateRequest.Mutation), nil
+}
+
 // Serialize converts this mutate object into a protobuf message suitable for
 // sending to an HBase server
 func (m *Mutate) Serialize() ([]byte, error) {
@@ -260,7 +543,14 @@ func (m *Mutate) Serialize() ([]byte, error) {
 }
 
 func (m *Mutate) serializeToProto() (*pb.MutateRequest, error) {
+	if len(m.deletions) != 0 && m.mutationType != pb.MutationProto_DELETE {
+		// The options are applied before the type of the mutation is known.
+		return nil, errors.New("Delete options can only be used with Delete queries.")
+	}
 	if m.data == nil {
+		if _, ok := m.values[""]; ok {
+			return nil, errors.New("Column family can't be empty.")
+		}
 		return m.serializeNoReflect(), nil
 	}
 	return m.serializeWithReflect()
@@ -287,26 +577,21 @@ func (m *Mutate) serializeNoReflect() *pb.MutateRequest {
 			}
 			j++
 		}
+		if len(v) == 0 && m.mutationType == pb.MutationProto_DELETE {
+			// A family without qualifiers, not even EmptyQualifier, is
+			// deleted as a whole, otherwise HBase would delete nothing.
+			deleteType := pb.MutationProto_DELETE_FAMILY
+			qualvals = []*pb.MutationProto_ColumnValue_QualifierValue{
+				{DeleteType: &deleteType},
+			}
+		}
 		bytevalues[i] = &pb.MutationProto_ColumnValue{
 			Family:         []byte(k),
 			QualifierValue: qualvals,
 		}
 		i++
 	}
-	durability := pb.MutationProto_Durability(m.durability)
-	mProto := &pb.MutationProto{
-		Row:         m.key,
-		MutateType:  &m.mutationType,
-		ColumnValue: bytevalues,
-		Durability:  &durability,
-	}
-	if m.timestamp != MaxTimestamp {
-		mProto.Timestamp = &m.timestamp
-	}
-	return &pb.MutateRequest{
-		Region:   m.regionSpecifier(),
-		Mutation: mProto,
-	}
+	return m.toProto(bytevalues)
 }
 
 // serializeWithReflect is a helper function for Serialize. It is used when
@@ -337,6 +622,9 @@ func (m *Mutate) serializeWithReflect() (*pb.MutateRequest, error) {
 		}
 		cfamily := cnames[0]
 		cqualifier := cnames[1]
+		if cfamily == "" {
+			return nil, fmt.Errorf("Empty column family in tag \"%s\"", tagval)
+		}
 
 		binaryValue, err := valueToBytes(valueOf.Field(i))
 		if err != nil {
@@ -364,20 +652,112 @@ func (m *Mutate) serializeWithReflect() (*pb.MutateRequest, error) {
 		pbcolumns = append(pbcolumns, colval)
 
 	}
+	return m.toProto(pbcolumns), nil
+}
+
+// toProto returns the MutateRequest for this mutation of the given columns.
+func (m *Mutate) toProto(columns []*pb.MutationProto_ColumnValue) *pb.MutateRequest {
+	columns = m.addCells(columns)
+	columns = m.addDeletions(columns)
 	durability := pb.MutationProto_Durability(m.durability)
 	mProto := &pb.MutationProto{
 		Row:         m.key,
 		MutateType:  &m.mutationType,
-		ColumnValue: pbcolumns,
+		ColumnValue: columns,
 		Durability:  &durability,
 	}
 	if m.timestamp != MaxTimestamp {
 		mProto.Timestamp = &m.timestamp
 	}
-	return &pb.MutateRequest{
-		Region:   m.regionSpecifier(),
-		Mutation: mProto,
-	}, nil
+	if m.skipResults && (m.mutationType == pb.MutationProto_APPEND ||
+		m.mutationType == pb.MutationProto_INCREMENT) {
+		mProto.Attribute = append(mProto.Attribute, &pb.NameBytesPair{
+			Name:  proto.String(returnResultsAttribute),
+			Value: []byte{0},
+		})
+	}
+	names := make([]string, 0, len(m.attributes))
+	for name := range m.attributes {
+		names = append(names, name)
+	}
+	// Sort the attributes so that the mutation is always serialized the same.
+	sort.Strings(names)
+	for _, name := range names {
+		mProto.Attribute = append(mProto.Attribute, &pb.NameBytesPair{
+			Name:  proto.String(name),
+			Value: m.attributes[name],
+		})
+	}
+	if m.ttl != 0 && m.mutationType != pb.MutationProto_DELETE {
+		ttl := make([]byte, 8)
+		binary.BigEndian.PutUint64(ttl, uint64(m.ttl/time.Millisecond))
+		mProto.Attribute = append(mProto.Attribute, &pb.NameBytesPair{
+			Name:  proto.String(ttlAttribute),
+			Value: ttl,
+		})
+	}
+	req := &pb.MutateRequest{Mutation: mProto}
+	if m.region != nil {
+		// The region isn't known yet when only sizing the mutation.
+		req.Region = m.regionSpecifier()
+	}
+	return req
+}
+
+// addCells adds the cells of this Put to the given columns.
+func (m *Mutate) addCells(
+	columns []*pb.MutationProto_ColumnValue) []*pb.MutationProto_ColumnValue {
+	for _, cell := range m.cells {
+		var column *pb.MutationProto_ColumnValue
+		columns, column = familyColumn(columns, cell.Family)
+		qualifier := cell.Qualifier
+		if qualifier == nil {
+			// Make sure the empty qualifier is sent.
+			qualifier = []byte{}
+		}
+		column.QualifierValue = append(column.QualifierValue,
+			&pb.MutationProto_ColumnValue_QualifierValue{
+				Qualifier: qualifier,
+				Value:     cell.Value,
+				Timestamp: cell.Timestamp,
+			})
+	}
+	return columns
+}
+
+// addDeletions adds the columns and families deleted by the options of this
+// Delete to the given columns.
+func (m *Mutate) addDeletions(
+	columns []*pb.MutationProto_ColumnValue) []*pb.MutationProto_ColumnValue {
+	for _, d := range m.deletions {
+		var column *pb.MutationProto_ColumnValue
+		columns, column = familyColumn(columns, []byte(d.family))
+		deleteType := d.deleteType
+		qualVal := &pb.MutationProto_ColumnValue_QualifierValue{DeleteType: &deleteType}
+		if deleteType == pb.MutationProto_DELETE_ONE_VERSION ||
+			deleteType == pb.MutationProto_DELETE_MULTIPLE_VERSIONS {
+			qualVal.Qualifier = []byte(d.qualifier)
+		}
+		if d.timestamp != MaxTimestamp {
+			ts := d.timestamp
+			qualVal.Timestamp = &ts
+		}
+		column.QualifierValue = append(column.QualifierValue, qualVal)
+	}
+	return columns
+}
+
+// familyColumn returns the column of the given family among the given
+// columns, adding it if needed.
+func familyColumn(columns []*pb.MutationProto_ColumnValue,
+	family []byte) ([]*pb.MutationProto_ColumnValue, *pb.MutationProto_ColumnValue) {
+	for _, c := range columns {
+		if bytes.Equal(c.Family, family) {
+			return columns, c
+		}
+	}
+	column := &pb.MutationProto_ColumnValue{Family: family}
+	return append(columns, column), column
 }
 
 // valueToBytes will convert a given value from the reflect package into its
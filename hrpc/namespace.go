@@ -0,0 +1,219 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// NamespaceDescriptor describes a namespace.
+type NamespaceDescriptor struct {
+	Name string
+
+	// Configuration of the namespace, e.g. its quotas like
+	// {"hbase.namespace.quota.maxtables": "10"}.
+	Configuration map[string]string
+}
+
+// ToLocalNamespaceDescriptor converts the given protobuf NamespaceDescriptor
+// into our own NamespaceDescriptor type.
+func ToLocalNamespaceDescriptor(nd *pb.NamespaceDescriptor) *NamespaceDescriptor {
+	descriptor := &NamespaceDescriptor{
+		Name:          string(nd.Name),
+		Configuration: make(map[string]string, len(nd.Configuration)),
+	}
+	for _, conf := range nd.Configuration {
+		descriptor.Configuration[conf.GetName()] = conf.GetValue()
+	}
+	return descriptor
+}
+
+// toProto returns the protobuf of the namespace descriptor.
+func (nd *NamespaceDescriptor) toProto() *pb.NamespaceDescriptor {
+	return &pb.NamespaceDescriptor{
+		Name:          []byte(nd.Name),
+		Configuration: configurationPairs(nd.Configuration),
+	}
+}
+
+// CreateNamespace represents a CreateNamespace HBase call
+type CreateNamespace struct {
+	tableOp
+
+	descriptor *NamespaceDescriptor
+}
+
+// NewCreateNamespace creates a new CreateNamespace request that will create
+// the given namespace in HBase. For use by the admin client.
+func NewCreateNamespace(ctx context.Context,
+	descriptor *NamespaceDescriptor) *CreateNamespace {
+	return &CreateNamespace{
+		tableOp:    tableOp{base{ctx: ctx}},
+		descriptor: descriptor,
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (cn *CreateNamespace) GetName() string {
+	return "CreateNamespace"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (cn *CreateNamespace) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.CreateNamespaceRequest{
+		NamespaceDescriptor: cn.descriptor.toProto(),
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (cn *CreateNamespace) NewResponse() proto.Message {
+	return &pb.CreateNamespaceResponse{}
+}
+
+// ModifyNamespace represents a ModifyNamespace HBase call
+type ModifyNamespace struct {
+	tableOp
+
+	descriptor *NamespaceDescriptor
+}
+
+// NewModifyNamespace creates a new ModifyNamespace request that will replace
+// the configuration of the namespace named in the given descriptor by that of
+// the descriptor. For use by the admin client.
+func NewModifyNamespace(ctx context.Context,
+	descriptor *NamespaceDescriptor) *ModifyNamespace {
+	return &ModifyNamespace{
+		tableOp:    tableOp{base{ctx: ctx}},
+		descriptor: descriptor,
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (mn *ModifyNamespace) GetName() string {
+	return "ModifyNamespace"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (mn *ModifyNamespace) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.ModifyNamespaceRequest{
+		NamespaceDescriptor: mn.descriptor.toProto(),
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (mn *ModifyNamespace) NewResponse() proto.Message {
+	return &pb.ModifyNamespaceResponse{}
+}
+
+// DeleteNamespace represents a DeleteNamespace HBase call
+type DeleteNamespace struct {
+	tableOp
+
+	namespace string
+}
+
+// NewDeleteNamespace creates a new DeleteNamespace request that will delete
+// the given namespace, which must have no tables, in HBase. For use by the
+// admin client.
+func NewDeleteNamespace(ctx context.Context, namespace string) *DeleteNamespace {
+	return &DeleteNamespace{
+		tableOp:   tableOp{base{ctx: ctx}},
+		namespace: namespace,
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (dn *DeleteNamespace) GetName() string {
+	return "DeleteNamespace"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (dn *DeleteNamespace) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.DeleteNamespaceRequest{
+		NamespaceName: proto.String(dn.namespace),
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (dn *DeleteNamespace) NewResponse() proto.Message {
+	return &pb.DeleteNamespaceResponse{}
+}
+
+// ListNamespaceDescriptors represents a ListNamespaceDescriptors HBase call
+type ListNamespaceDescriptors struct {
+	tableOp
+}
+
+// NewListNamespaceDescriptors creates a new ListNamespaceDescriptors request
+// that will return the descriptors of all the namespaces. For use by the admin
+// client.
+func NewListNamespaceDescriptors(ctx context.Context) *ListNamespaceDescriptors {
+	return &ListNamespaceDescriptors{
+		tableOp{base{ctx: ctx}},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (ln *ListNamespaceDescriptors) GetName() string {
+	return "ListNamespaceDescriptors"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (ln *ListNamespaceDescriptors) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.ListNamespaceDescriptorsRequest{})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (ln *ListNamespaceDescriptors) NewResponse() proto.Message {
+	return &pb.ListNamespaceDescriptorsResponse{}
+}
+
+// ListTableNamesByNamespace represents a ListTableNamesByNamespace HBase call
+type ListTableNamesByNamespace struct {
+	tableOp
+
+	namespace string
+}
+
+// NewListTableNamesByNamespace creates a new ListTableNamesByNamespace
+// request that will list the names of the tables of the given namespace. For
+// use by the admin client.
+func NewListTableNamesByNamespace(ctx context.Context,
+	namespace string) *ListTableNamesByNamespace {
+	return &ListTableNamesByNamespace{
+		tableOp:   tableOp{base{ctx: ctx}},
+		namespace: namespace,
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (lt *ListTableNamesByNamespace) GetName() string {
+	return "ListTableNamesByNamespace"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (lt *ListTableNamesByNamespace) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.ListTableNamesByNamespaceRequest{
+		NamespaceName: proto.String(lt.namespace),
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (lt *ListTableNamesByNamespace) NewResponse() proto.Message {
+	return &pb.ListTableNamesByNamespaceResponse{}
+}
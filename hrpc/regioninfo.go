@@ -0,0 +1,87 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// CompactionState is the state of the compactions of a region or table.
+type CompactionState int32
+
+const (
+	// CompactionNone means that no compaction is running.
+	CompactionNone = CompactionState(pb.GetRegionInfoResponse_NONE)
+	// CompactionMinor means that only minor compactions are running.
+	CompactionMinor = CompactionState(pb.GetRegionInfoResponse_MINOR)
+	// CompactionMajor means that only major compactions are running.
+	CompactionMajor = CompactionState(pb.GetRegionInfoResponse_MAJOR)
+	// CompactionMajorAndMinor means that both major and minor compactions
+	// are running.
+	CompactionMajorAndMinor = CompactionState(pb.GetRegionInfoResponse_MAJOR_AND_MINOR)
+)
+
+func (s CompactionState) String() string {
+	return pb.GetRegionInfoResponse_CompactionState(s).String()
+}
+
+// Merge returns the state of a table with regions in this state and in the
+// given state.
+func (s CompactionState) Merge(o CompactionState) CompactionState {
+	switch {
+	case s == o || o == CompactionNone:
+		return s
+	case s == CompactionNone:
+		return o
+	}
+	return CompactionMajorAndMinor
+}
+
+// GetRegionInfo represents a GetRegionInfo HBase call, which is sent to the
+// AdminService of the RegionServer serving a region.
+type GetRegionInfo struct {
+	tableOp
+
+	compactionState bool
+}
+
+// NewGetRegionInfo creates a new GetRegionInfo request for the region of the
+// given table that holds the given row key.  If compactionState is true, the
+// response also has the compaction state of the region.
+func NewGetRegionInfo(ctx context.Context, table, key []byte,
+	compactionState bool) *GetRegionInfo {
+	return &GetRegionInfo{
+		tableOp: tableOp{base{
+			table: table,
+			key:   key,
+			ctx:   ctx,
+		}},
+		compactionState: compactionState,
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (gri *GetRegionInfo) GetName() string {
+	return "GetRegionInfo"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (gri *GetRegionInfo) Serialize() ([]byte, error) {
+	req := &pb.GetRegionInfoRequest{Region: gri.regionSpecifier()}
+	if gri.compactionState {
+		req.CompactionState = proto.Bool(true)
+	}
+	return proto.Marshal(req)
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (gri *GetRegionInfo) NewResponse() proto.Message {
+	return &pb.GetRegionInfoResponse{}
+}
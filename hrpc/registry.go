@@ -0,0 +1,106 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// GetClusterID represents a GetClusterId HBase call, which returns the ID of
+// the cluster from the connection registry of a master.
+type GetClusterID struct {
+	tableOp
+}
+
+// NewGetClusterID creates a new GetClusterID request.  For use by the master
+// registry of the clients.
+func NewGetClusterID(ctx context.Context) *GetClusterID {
+	return &GetClusterID{
+		tableOp{base{ctx: ctx}},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (gc *GetClusterID) GetName() string {
+	return "GetClusterId"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (gc *GetClusterID) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.GetClusterIdRequest{})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (gc *GetClusterID) NewResponse() proto.Message {
+	return &pb.GetClusterIdResponse{}
+}
+
+// GetActiveMaster represents a GetActiveMaster HBase call, which returns the
+// server name of the active master from the connection registry of a master.
+type GetActiveMaster struct {
+	tableOp
+}
+
+// NewGetActiveMaster creates a new GetActiveMaster request.  For use by the
+// master registry of the clients.
+func NewGetActiveMaster(ctx context.Context) *GetActiveMaster {
+	return &GetActiveMaster{
+		tableOp{base{ctx: ctx}},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (ga *GetActiveMaster) GetName() string {
+	return "GetActiveMaster"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (ga *GetActiveMaster) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.GetActiveMasterRequest{})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (ga *GetActiveMaster) NewResponse() proto.Message {
+	return &pb.GetActiveMasterResponse{}
+}
+
+// GetMetaLocations represents a GetMetaRegionLocations HBase call, which
+// returns the locations of the replicas of meta from the connection registry
+// of a master.
+type GetMetaLocations struct {
+	tableOp
+}
+
+// NewGetMetaLocations creates a new GetMetaLocations request.  For use by the
+// master registry of the clients.
+func NewGetMetaLocations(ctx context.Context) *GetMetaLocations {
+	return &GetMetaLocations{
+		tableOp{base{ctx: ctx}},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (gm *GetMetaLocations) GetName() string {
+	return "GetMetaRegionLocations"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (gm *GetMetaLocations) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.GetMetaRegionLocationsRequest{})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (gm *GetMetaLocations) NewResponse() proto.Message {
+	return &pb.GetMetaRegionLocationsResponse{}
+}
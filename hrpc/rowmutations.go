@@ -0,0 +1,121 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/filter"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// RowMutations applies several Puts and Deletes to the same row atomically:
+// readers of the row see either none or all of them.
+type RowMutations struct {
+	base
+
+	mutations []*Mutate
+}
+
+// NewRowMutations creates a new RowMutations request applying the given Puts
+// and Deletes, which must all be for the same row of the same table, in the
+// given order.
+func NewRowMutations(ctx context.Context, mutations ...*Mutate) (*RowMutations, error) {
+	if len(mutations) == 0 {
+		return nil, errors.New("RowMutations requires at least one mutation")
+	}
+	first := mutations[0]
+	for _, m := range mutations {
+		if m.mutationType != pb.MutationProto_PUT &&
+			m.mutationType != pb.MutationProto_DELETE {
+			return nil, errors.New("RowMutations only takes Put or Delete requests")
+		}
+		if !bytes.Equal(m.table, first.table) || !bytes.Equal(m.key, first.key) {
+			return nil, fmt.Errorf("RowMutations requires mutations of the same row,"+
+				" got %q in table %q and %q in table %q", first.key, first.table, m.key, m.table)
+		}
+	}
+	return &RowMutations{
+		base: base{
+			table: first.table,
+			key:   first.key,
+			ctx:   ctx,
+		},
+		mutations: mutations,
+	}, nil
+}
+
+// Mutations returns the mutations applied by this request.
+func (rm *RowMutations) Mutations() []*Mutate {
+	return rm.mutations
+}
+
+// SetRegion sets the region of this request and of all its mutations.
+func (rm *RowMutations) SetRegion(region RegionInfo) {
+	rm.region = region
+	for _, m := range rm.mutations {
+		m.SetRegion(region)
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (rm *RowMutations) GetName() string {
+	return "Multi"
+}
+
+// Serialize converts this RowMutations into a serialized protobuf message
+// ready to be sent to an HBase node: a Multi with a single atomic
+// RegionAction.
+func (rm *RowMutations) Serialize() ([]byte, error) {
+	regionAction, err := rm.regionAction(0)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(&pb.MultiRequest{RegionAction: []*pb.RegionAction{regionAction}})
+}
+
+// regionAction returns the atomic RegionAction of this request, numbering
+// its actions from the given index.
+func (rm *RowMutations) regionAction(index uint32) (*pb.RegionAction, error) {
+	regionAction := &pb.RegionAction{
+		Region: rm.regionSpecifier(),
+		Atomic: proto.Bool(true),
+		Action: make([]*pb.Action, len(rm.mutations)),
+	}
+	for i, m := range rm.mutations {
+		mutateRequest, err := m.serializeToProto()
+		if err != nil {
+			return nil, fmt.Errorf("Error serializing request: %s", err)
+		}
+		regionAction.Action[i] = &pb.Action{
+			Index:    proto.Uint32(index + uint32(i)),
+			Mutation: mutateRequest.Mutation,
+		}
+	}
+	return regionAction, nil
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (rm *RowMutations) NewResponse() proto.Message {
+	return &pb.MultiResponse{}
+}
+
+// SetFilter always returns an error when used on RowMutations objects.
+// Exists solely so RowMutations can implement the Call interface.
+func (rm *RowMutations) SetFilter(ft filter.Filter) error {
+	return errors.New("Cannot set filter on row mutations.")
+}
+
+// SetFamilies always returns an error when used on RowMutations objects.
+// Exists solely so RowMutations can implement the Call interface.
+func (rm *RowMutations) SetFamilies(fam map[string][]string) error {
+	return errors.New("Cannot set families on row mutations.")
+}
@@ -7,6 +7,7 @@ package hrpc
 
 import (
 	"math"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/tsuna/gohbase/filter"
@@ -34,18 +35,48 @@ type Scan struct {
 
 	closeScanner bool
 
+	renew bool
+
+	// Set for the small scans, which open, read and close their scanner in
+	// a single RPC, and for those that return the rows in reverse order.
+	small    bool
+	reversed bool
+
 	startRow []byte
 	stopRow  []byte
 
 	fromTimestamp uint64
 	toTimestamp   uint64
 
+	// Per column family time ranges, as [from, to[ pairs.
+	cfTimeRanges map[string][2]uint64
+
+	// Allowance by which the time ranges are widened.
+	clockSkew time.Duration
+
 	maxVersions uint32
 
+	storeLimit  uint32
+	storeOffset uint32
+
 	scannerID uint64
 
 	numberOfRows uint32
 
+	consistency ConsistencyType
+
+	loadColumnFamiliesOnDemand bool
+
+	trackScanMetrics bool
+
+	ranges [][2][]byte
+
+	// Order in which the columns of the results are returned, if any.
+	columnOrder [][2]string
+
+	// Visibility labels the scanner is authorized to read, if any.
+	authorizations []string
+
 	filters filter.Filter
 }
 
@@ -68,7 +99,9 @@ func baseScan(ctx context.Context, table []byte, key []byte,
 	if err != nil {
 		return nil, err
 	}
-
+	if err = checkColumnValueFilter(s.families, s.filters); err != nil {
+		return nil, err
+	}
 	return s, nil
 }
 
@@ -87,6 +120,7 @@ func NewScanRange(ctx context.Context, table, startRow, stopRow []byte,
 	if err != nil {
 		return nil, err
 	}
+	scan.key = startRow
 	scan.startRow = startRow
 	scan.stopRow = stopRow
 	return scan, nil
@@ -105,12 +139,31 @@ func NewScanRangeStr(ctx context.Context, table, startRow, stopRow string,
 	return NewScanRange(ctx, []byte(table), []byte(startRow), []byte(stopRow), options...)
 }
 
+// NewSmallScanRange creates a new Scan request that will return at most
+// NumberOfRows rows of the given key range in a single RPC, which opens and
+// closes the scanner, like the scanners of the small scans of HBase.  The
+// range is half-open like for NewScanRange and must be in a single region.
+// The rows are returned in reverse order if reversed is true, in which case
+// the range is ]stopRow; startRow] -- startRow is the greatest row key of the
+// range.  This is an internal method, used to look up the regions in meta.
+func NewSmallScanRange(ctx context.Context, table, startRow, stopRow []byte,
+	reversed bool, options ...func(Call) error) (*Scan, error) {
+	scan, err := NewScanRange(ctx, table, startRow, stopRow, options...)
+	if err != nil {
+		return nil, err
+	}
+	scan.small = true
+	scan.closeScanner = true
+	scan.reversed = reversed
+	return scan, nil
+}
+
 // NewScanFromID creates a new Scan request that will return additional
 // results from the given scanner ID.  This is an internal method, users
 // are not expected to deal with scanner IDs.
 func NewScanFromID(ctx context.Context, table []byte,
-	scannerID uint64, startRow []byte) *Scan {
-	scan, _ := baseScan(ctx, table, startRow)
+	scannerID uint64, startRow []byte, options ...func(Call) error) *Scan {
+	scan, _ := baseScan(ctx, table, startRow, options...)
 	scan.scannerID = scannerID
 	return scan
 }
@@ -126,6 +179,18 @@ func NewCloseFromID(ctx context.Context, table []byte,
 	return scan
 }
 
+// NewRenewFromID creates a new Scan request that will renew the lease of the
+// scanner for the given scanner ID without returning any results.  This is an
+// internal method, users are not expected to deal with scanner IDs.
+func NewRenewFromID(ctx context.Context, table []byte,
+	scannerID uint64, startRow []byte) *Scan {
+	scan, _ := baseScan(ctx, table, startRow)
+	scan.scannerID = scannerID
+	scan.renew = true
+	scan.numberOfRows = 0
+	return scan
+}
+
 // GetName returns the name of this RPC call.
 func (s *Scan) GetName() string {
 	return "Scan"
@@ -147,6 +212,16 @@ func (s *Scan) GetFamilies() map[string][]string {
 	return s.families
 }
 
+// GetSmall returns true if this is a small scan, made of a single RPC.
+func (s *Scan) GetSmall() bool {
+	return s.small
+}
+
+// GetReversed returns true if this scan returns the rows in reverse order.
+func (s *Scan) GetReversed() bool {
+	return s.reversed
+}
+
 // GetRegionStop returns the stop key of the region currently being scanned.
 // This is an internal method, end users are not expected to use it.
 func (s *Scan) GetRegionStop() []byte {
@@ -163,17 +238,75 @@ func (s *Scan) GetTimeRange() (uint64, uint64) {
 	return s.fromTimestamp, s.toTimestamp
 }
 
+// GetClockSkew returns the allowance by which the time ranges of this
+// scanner are widened.
+func (s *Scan) GetClockSkew() time.Duration {
+	return s.clockSkew
+}
+
+// GetColumnFamilyTimeRanges returns the per column family time ranges of
+// this scanner, as [from, to[ pairs in milliseconds.
+func (s *Scan) GetColumnFamilyTimeRanges() map[string][2]uint64 {
+	return s.cfTimeRanges
+}
+
 // GetMaxVersions returns the max versions set on this scanner.
 func (s *Scan) GetMaxVersions() uint32 {
 	return s.maxVersions
 }
 
+// GetStoreLimit returns the maximum number of cells per column family
+// returned for each row by this scanner, 0 meaning no limit.
+func (s *Scan) GetStoreLimit() uint32 {
+	return s.storeLimit
+}
+
+// GetStoreOffset returns the number of cells per column family skipped for
+// each row by this scanner.
+func (s *Scan) GetStoreOffset() uint32 {
+	return s.storeOffset
+}
+
 // GetNumberOfRows returns maximum number of rows that could be fetched
 // by this scanner.
 func (s *Scan) GetNumberOfRows() uint32 {
 	return s.numberOfRows
 }
 
+// GetColumnOrder returns the order in which the columns of the results of
+// this scanner are returned, nil if they're returned in the order of HBase.
+func (s *Scan) GetColumnOrder() [][2]string {
+	return s.columnOrder
+}
+
+// GetAuthorizations returns the visibility labels this scanner is authorized
+// to read.
+func (s *Scan) GetAuthorizations() []string {
+	return s.authorizations
+}
+
+// GetConsistency returns the consistency level of this scanner.
+func (s *Scan) GetConsistency() ConsistencyType {
+	return s.consistency
+}
+
+// GetLoadColumnFamiliesOnDemand returns true if this scanner only loads the
+// non-essential column families for the rows that pass its filter.
+func (s *Scan) GetLoadColumnFamiliesOnDemand() bool {
+	return s.loadColumnFamiliesOnDemand
+}
+
+// GetTrackScanMetrics returns true if the RegionServers should return scan
+// metrics for this scanner.
+func (s *Scan) GetTrackScanMetrics() bool {
+	return s.trackScanMetrics
+}
+
+// GetRanges returns the row key ranges this scanner is restricted to, if any.
+func (s *Scan) GetRanges() [][2][]byte {
+	return s.ranges
+}
+
 // Serialize converts this Scan into a serialized protobuf message ready
 // to be sent to an HBase node.
 func (s *Scan) Serialize() ([]byte, error) {
@@ -182,8 +315,14 @@ func (s *Scan) Serialize() ([]byte, error) {
 		CloseScanner: &s.closeScanner,
 		NumberOfRows: &s.numberOfRows,
 	}
+	if s.trackScanMetrics {
+		scan.TrackScanMetrics = &s.trackScanMetrics
+	}
 	if s.scannerID != math.MaxUint64 {
 		scan.ScannerId = &s.scannerID
+		if s.renew {
+			scan.Renew = &s.renew
+		}
 		return proto.Marshal(scan)
 	}
 	scan.Scan = &pb.Scan{
@@ -195,15 +334,56 @@ func (s *Scan) Serialize() ([]byte, error) {
 	if s.maxVersions != DefaultMaxVersions {
 		scan.Scan.MaxVersions = &s.maxVersions
 	}
-	if s.fromTimestamp != MinTimestamp {
-		scan.Scan.TimeRange.From = &s.fromTimestamp
+	if s.storeLimit != 0 {
+		scan.Scan.StoreLimit = &s.storeLimit
+	}
+	if s.storeOffset != 0 {
+		scan.Scan.StoreOffset = &s.storeOffset
+	}
+	if s.small {
+		scan.Scan.Small = &s.small
+	}
+	if s.reversed {
+		scan.Scan.Reversed = &s.reversed
+	}
+	from, to := widenTimeRange(s.fromTimestamp, s.toTimestamp, s.clockSkew)
+	if from != MinTimestamp {
+		scan.Scan.TimeRange.From = &from
+	}
+	if to != MaxTimestamp {
+		scan.Scan.TimeRange.To = &to
+	}
+	scan.Scan.CfTimeRange = cfTimeRangesToPB(s.cfTimeRanges, s.clockSkew)
+	if s.consistency != StrongConsistency {
+		consistency := pb.Consistency(s.consistency)
+		scan.Scan.Consistency = &consistency
 	}
-	if s.toTimestamp != MaxTimestamp {
-		scan.Scan.TimeRange.To = &s.toTimestamp
+	if s.loadColumnFamiliesOnDemand {
+		scan.Scan.LoadColumnFamiliesOnDemand = &s.loadColumnFamiliesOnDemand
+	}
+	if len(s.authorizations) != 0 {
+		attr, err := authorizationsAttribute(s.authorizations)
+		if err != nil {
+			return nil, err
+		}
+		scan.Scan.Attribute = append(scan.Scan.Attribute, attr)
 	}
 
-	if s.filters != nil {
-		pbFilter, err := s.filters.ConstructPBFilter()
+	filters := s.filters
+	if len(s.ranges) != 0 {
+		rowRanges := make([]*filter.RowRange, len(s.ranges))
+		for i, r := range s.ranges {
+			rowRanges[i] = filter.NewRowRange(r[0], r[1], true, false)
+		}
+		rangeFilter := filter.NewMultiRowRangeFilter(rowRanges)
+		if filters != nil {
+			filters = filter.NewList(filter.MustPassAll, rangeFilter, filters)
+		} else {
+			filters = rangeFilter
+		}
+	}
+	if filters != nil {
+		pbFilter, err := filters.ConstructPBFilter()
 		if err != nil {
 			return nil, err
 		}
@@ -220,6 +400,9 @@ func (s *Scan) NewResponse() proto.Message {
 
 // SetFamilies sets the families covered by this scanner.
 func (s *Scan) SetFamilies(fam map[string][]string) error {
+	if err := checkFamilies(fam); err != nil {
+		return err
+	}
 	s.families = fam
 	return nil
 }
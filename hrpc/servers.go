@@ -0,0 +1,284 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// ServerName identifies a process of a RegionServer.
+type ServerName struct {
+	Host string
+	Port uint16
+
+	// StartCode tells apart the successive processes of a RegionServer on
+	// the same host and port.
+	StartCode uint64
+}
+
+// String returns the name of the server the way HBase writes it, like
+// "host,port,startcode".
+func (sn ServerName) String() string {
+	return sn.Host + "," + strconv.Itoa(int(sn.Port)) + "," +
+		strconv.FormatUint(sn.StartCode, 10)
+}
+
+// ParseServerName parses the name of a server written like
+// "host,port,startcode", the way HBase writes it.
+func ParseServerName(name string) (ServerName, error) {
+	parts := strings.Split(name, ",")
+	if len(parts) != 3 || parts[0] == "" {
+		return ServerName{}, fmt.Errorf("invalid server name %q", name)
+	}
+	port, err := strconv.ParseUint(parts[1], 10, 16)
+	if err != nil {
+		return ServerName{}, fmt.Errorf("invalid port in server name %q: %s", name, err)
+	}
+	startCode, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return ServerName{}, fmt.Errorf("invalid start code in server name %q: %s", name, err)
+	}
+	return ServerName{Host: parts[0], Port: uint16(port), StartCode: startCode}, nil
+}
+
+// RegionLoad is the load of a region, as last reported by its RegionServer.
+type RegionLoad struct {
+	// Name is the full name of the region, like returned by
+	// RegionInfo.GetName.
+	Name []byte
+
+	Stores          int
+	StoreFiles      int
+	StoreFileSizeMB int
+	MemstoreSizeMB  int
+
+	// ReadRequests and WriteRequests are the numbers of requests served by
+	// the region since it was opened.
+	ReadRequests  uint64
+	WriteRequests uint64
+}
+
+// RegionServerLoad is the load of a live RegionServer, as last reported by
+// it to the master.
+type RegionServerLoad struct {
+	ServerName
+
+	// Regions is the number of regions served by the RegionServer, and
+	// StoreFiles, StoreFileSizeMB and MemstoreSizeMB are the sums of those
+	// of its regions.
+	Regions         int
+	StoreFiles      int
+	StoreFileSizeMB int
+	MemstoreSizeMB  int
+
+	// RequestsPerSecond is the rate of requests over the last report
+	// interval, and TotalRequests the number of requests since the
+	// RegionServer started.
+	RequestsPerSecond float64
+	TotalRequests     uint64
+
+	UsedHeapMB int
+	MaxHeapMB  int
+
+	// RegionLoads are the loads of the regions of the RegionServer.
+	RegionLoads []*RegionLoad
+}
+
+// ToLocalRegionServerLoad converts the given protobuf LiveServerInfo into our
+// own RegionServerLoad type.
+func ToLocalRegionServerLoad(info *pb.LiveServerInfo) *RegionServerLoad {
+	server := info.GetServer()
+	load := info.GetServerLoad()
+	rs := &RegionServerLoad{
+		ServerName: ServerName{
+			Host:      server.GetHostName(),
+			Port:      uint16(server.GetPort()),
+			StartCode: server.GetStartCode(),
+		},
+		Regions:           len(load.GetRegionLoads()),
+		RequestsPerSecond: float64(load.GetNumberOfRequests()),
+		TotalRequests:     load.GetTotalNumberOfRequests(),
+		UsedHeapMB:        int(load.GetUsedHeap_MB()),
+		MaxHeapMB:         int(load.GetMaxHeap_MB()),
+		RegionLoads:       make([]*RegionLoad, 0, len(load.GetRegionLoads())),
+	}
+	for _, rl := range load.GetRegionLoads() {
+		region := &RegionLoad{
+			Name:            rl.GetRegionSpecifier().GetValue(),
+			Stores:          int(rl.GetStores()),
+			StoreFiles:      int(rl.GetStorefiles()),
+			StoreFileSizeMB: int(rl.GetStorefileSize_MB()),
+			MemstoreSizeMB:  int(rl.GetMemstoreSize_MB()),
+			ReadRequests:    rl.GetReadRequestsCount(),
+			WriteRequests:   rl.GetWriteRequestsCount(),
+		}
+		rs.StoreFiles += region.StoreFiles
+		rs.StoreFileSizeMB += region.StoreFileSizeMB
+		rs.MemstoreSizeMB += region.MemstoreSizeMB
+		rs.RegionLoads = append(rs.RegionLoads, region)
+	}
+	return rs
+}
+
+// ListRegionServers represents a GetClusterStatus HBase call, which returns
+// the status of the cluster, including the loads of its live RegionServers.
+type ListRegionServers struct {
+	tableOp
+}
+
+// NewListRegionServers creates a new ListRegionServers request that will
+// return the live RegionServers and their load.  For use by the admin client.
+func NewListRegionServers(ctx context.Context) *ListRegionServers {
+	return &ListRegionServers{
+		tableOp{base{ctx: ctx}},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (lr *ListRegionServers) GetName() string {
+	return "GetClusterStatus"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (lr *ListRegionServers) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.GetClusterStatusRequest{})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (lr *ListRegionServers) NewResponse() proto.Message {
+	return &pb.GetClusterStatusResponse{}
+}
+
+// toProto converts the server name into its protobuf form.
+func (sn ServerName) toProto() *pb.ServerName {
+	return &pb.ServerName{
+		HostName:  proto.String(sn.Host),
+		Port:      proto.Uint32(uint32(sn.Port)),
+		StartCode: proto.Uint64(sn.StartCode),
+	}
+}
+
+// DecommissionRegionServers represents a DecommissionRegionServers HBase call
+type DecommissionRegionServers struct {
+	tableOp
+
+	servers []ServerName
+	offload bool
+}
+
+// NewDecommissionRegionServers creates a new DecommissionRegionServers
+// request that will tell the master not to assign regions to the given
+// RegionServers anymore, and if offload is true, to move their regions to the
+// other RegionServers.  The cluster must run HBase 2.0 or later.  For use by
+// the admin client.
+func NewDecommissionRegionServers(ctx context.Context, servers []ServerName,
+	offload bool) *DecommissionRegionServers {
+	return &DecommissionRegionServers{
+		tableOp: tableOp{base{ctx: ctx}},
+		servers: servers,
+		offload: offload,
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (dr *DecommissionRegionServers) GetName() string {
+	return "DecommissionRegionServers"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (dr *DecommissionRegionServers) Serialize() ([]byte, error) {
+	req := &pb.DecommissionRegionServersRequest{
+		ServerName: make([]*pb.ServerName, len(dr.servers)),
+		Offload:    proto.Bool(dr.offload),
+	}
+	for i, server := range dr.servers {
+		req.ServerName[i] = server.toProto()
+	}
+	return proto.Marshal(req)
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (dr *DecommissionRegionServers) NewResponse() proto.Message {
+	return &pb.DecommissionRegionServersResponse{}
+}
+
+// RecommissionRegionServer represents a RecommissionRegionServer HBase call
+type RecommissionRegionServer struct {
+	tableOp
+
+	server ServerName
+}
+
+// NewRecommissionRegionServer creates a new RecommissionRegionServer request
+// that will let the master assign regions to the given RegionServer again.
+// The cluster must run HBase 2.0 or later.  For use by the admin client.
+func NewRecommissionRegionServer(ctx context.Context,
+	server ServerName) *RecommissionRegionServer {
+	return &RecommissionRegionServer{
+		tableOp: tableOp{base{ctx: ctx}},
+		server:  server,
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (rr *RecommissionRegionServer) GetName() string {
+	return "RecommissionRegionServer"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (rr *RecommissionRegionServer) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.RecommissionRegionServerRequest{ServerName: rr.server.toProto()})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (rr *RecommissionRegionServer) NewResponse() proto.Message {
+	return &pb.RecommissionRegionServerResponse{}
+}
+
+// ListDecommissionedRegionServers represents a ListDecommissionedRegionServers
+// HBase call
+type ListDecommissionedRegionServers struct {
+	tableOp
+}
+
+// NewListDecommissionedRegionServers creates a new
+// ListDecommissionedRegionServers request that will return the RegionServers
+// that the master doesn't assign regions to.  The cluster must run HBase 2.0
+// or later.  For use by the admin client.
+func NewListDecommissionedRegionServers(ctx context.Context) *ListDecommissionedRegionServers {
+	return &ListDecommissionedRegionServers{
+		tableOp{base{ctx: ctx}},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (lr *ListDecommissionedRegionServers) GetName() string {
+	return "ListDecommissionedRegionServers"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (lr *ListDecommissionedRegionServers) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.ListDecommissionedRegionServersRequest{})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (lr *ListDecommissionedRegionServers) NewResponse() proto.Message {
+	return &pb.ListDecommissionedRegionServersResponse{}
+}
@@ -0,0 +1,381 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// SnapshotType is the way a snapshot of a table was taken.
+type SnapshotType int32
+
+const (
+	// SnapshotFlush means that the memstores were flushed before the
+	// snapshot was taken, so that it has all the data of the table.
+	SnapshotFlush = SnapshotType(pb.SnapshotDescription_FLUSH)
+	// SnapshotSkipFlush means that the snapshot was taken without flushing
+	// the memstores, so it doesn't have the data in them.
+	SnapshotSkipFlush = SnapshotType(pb.SnapshotDescription_SKIPFLUSH)
+	// SnapshotDisabled means that the table was disabled when the snapshot
+	// was taken.
+	SnapshotDisabled = SnapshotType(pb.SnapshotDescription_DISABLED)
+)
+
+func (t SnapshotType) String() string {
+	return pb.SnapshotDescription_Type(t).String()
+}
+
+// SnapshotDescription describes a snapshot of a table.
+type SnapshotDescription struct {
+	Name string
+
+	// Table is the name of the table, prefixed by its namespace and a colon
+	// unless it's in the default namespace.
+	Table []byte
+
+	CreationTime time.Time
+	Type         SnapshotType
+	Owner        string
+}
+
+// ToLocalSnapshotDescription converts the given protobuf SnapshotDescription
+// into our own SnapshotDescription type.
+func ToLocalSnapshotDescription(sd *pb.SnapshotDescription) *SnapshotDescription {
+	description := &SnapshotDescription{
+		Name:  sd.GetName(),
+		Table: []byte(sd.GetTable()),
+		Type:  SnapshotType(sd.GetType()),
+		Owner: sd.GetOwner(),
+	}
+	if sd.CreationTime != nil {
+		description.CreationTime = MillisToTime(uint64(sd.GetCreationTime()))
+	}
+	return description
+}
+
+// snapshotDescription returns the protobuf describing the given snapshot of
+// the given table.
+func snapshotDescription(name string, table []byte) *pb.SnapshotDescription {
+	sd := &pb.SnapshotDescription{Name: proto.String(name)}
+	if table != nil {
+		sd.Table = proto.String(string(table))
+	}
+	return sd
+}
+
+// snapshotOp represents an administrative operation on a snapshot.
+type snapshotOp struct {
+	tableOp
+
+	name string
+}
+
+// SnapshotName returns the name of the snapshot.
+func (so *snapshotOp) SnapshotName() string {
+	return so.name
+}
+
+// Snapshot represents a Snapshot HBase call
+type Snapshot struct {
+	snapshotOp
+
+	skipFlush bool
+}
+
+// NewSnapshot creates a new Snapshot request that will take a snapshot of
+// the given table with the given name, which must be unique. For use by the
+// admin client.
+func NewSnapshot(ctx context.Context, name string, table []byte,
+	options ...func(Call) error) (*Snapshot, error) {
+	if name == "" {
+		return nil, errors.New("Snapshot name can't be empty.")
+	}
+	sn := &Snapshot{
+		snapshotOp: snapshotOp{
+			tableOp: tableOp{base{
+				table: table,
+				ctx:   ctx,
+			}},
+			name: name,
+		},
+	}
+	err := applyOptions(sn, options...)
+	if err != nil {
+		return nil, err
+	}
+	return sn, nil
+}
+
+// SkipFlush makes the snapshot be taken without flushing the memstores
+// first, which is faster but leaves out of the snapshot the data that is only
+// in the memstores.
+func SkipFlush() func(Call) error {
+	return func(g Call) error {
+		sn, ok := g.(*Snapshot)
+		if !ok {
+			return errors.New("SkipFlush option can only be used with NewSnapshot.")
+		}
+		sn.skipFlush = true
+		return nil
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (sn *Snapshot) GetName() string {
+	return "Snapshot"
+}
+
+// description returns the protobuf describing the snapshot to take.
+func (sn *Snapshot) description() *pb.SnapshotDescription {
+	sd := snapshotDescription(sn.name, sn.table)
+	if sn.skipFlush {
+		sd.Type = pb.SnapshotDescription_SKIPFLUSH.Enum()
+	} else {
+		sd.Type = pb.SnapshotDescription_FLUSH.Enum()
+	}
+	return sd
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (sn *Snapshot) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.SnapshotRequest{Snapshot: sn.description()})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (sn *Snapshot) NewResponse() proto.Message {
+	return &pb.SnapshotResponse{}
+}
+
+// IsSnapshotDone represents an IsSnapshotDone HBase call
+type IsSnapshotDone struct {
+	snapshotOp
+}
+
+// NewIsSnapshotDone creates a new IsSnapshotDone request that will check
+// whether the snapshot with the given name of the given table was
+// successfully taken. For use by the admin client.
+func NewIsSnapshotDone(ctx context.Context, name string, table []byte) *IsSnapshotDone {
+	return &IsSnapshotDone{
+		snapshotOp{
+			tableOp: tableOp{base{
+				table: table,
+				ctx:   ctx,
+			}},
+			name: name,
+		},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (sd *IsSnapshotDone) GetName() string {
+	return "IsSnapshotDone"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (sd *IsSnapshotDone) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.IsSnapshotDoneRequest{
+		Snapshot: snapshotDescription(sd.name, sd.table),
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (sd *IsSnapshotDone) NewResponse() proto.Message {
+	return &pb.IsSnapshotDoneResponse{}
+}
+
+// DeleteSnapshot represents a DeleteSnapshot HBase call
+type DeleteSnapshot struct {
+	snapshotOp
+}
+
+// NewDeleteSnapshot creates a new DeleteSnapshot request that will delete
+// the snapshot with the given name. For use by the admin client.
+func NewDeleteSnapshot(ctx context.Context, name string) *DeleteSnapshot {
+	return &DeleteSnapshot{
+		snapshotOp{
+			tableOp: tableOp{base{ctx: ctx}},
+			name:    name,
+		},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (ds *DeleteSnapshot) GetName() string {
+	return "DeleteSnapshot"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (ds *DeleteSnapshot) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.DeleteSnapshotRequest{
+		Snapshot: snapshotDescription(ds.name, nil),
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (ds *DeleteSnapshot) NewResponse() proto.Message {
+	return &pb.DeleteSnapshotResponse{}
+}
+
+// ListSnapshots represents a GetCompletedSnapshots HBase call
+type ListSnapshots struct {
+	tableOp
+}
+
+// NewListSnapshots creates a new ListSnapshots request that will return the
+// descriptions of all the snapshots that were taken. For use by the admin
+// client.
+func NewListSnapshots(ctx context.Context) *ListSnapshots {
+	return &ListSnapshots{
+		tableOp{base{ctx: ctx}},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (ls *ListSnapshots) GetName() string {
+	return "GetCompletedSnapshots"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (ls *ListSnapshots) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.GetCompletedSnapshotsRequest{})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (ls *ListSnapshots) NewResponse() proto.Message {
+	return &pb.GetCompletedSnapshotsResponse{}
+}
+
+// RestoreSnapshot represents a RestoreSnapshot HBase call
+type RestoreSnapshot struct {
+	snapshotOp
+}
+
+// NewRestoreSnapshot creates a new RestoreSnapshot request that will restore
+// the given table to the snapshot with the given name that was taken of it.
+// The table must be disabled. For use by the admin client.
+func NewRestoreSnapshot(ctx context.Context, name string, table []byte) *RestoreSnapshot {
+	return &RestoreSnapshot{
+		snapshotOp{
+			tableOp: tableOp{base{
+				table: table,
+				ctx:   ctx,
+			}},
+			name: name,
+		},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (rs *RestoreSnapshot) GetName() string {
+	return "RestoreSnapshot"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (rs *RestoreSnapshot) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.RestoreSnapshotRequest{
+		Snapshot: snapshotDescription(rs.name, rs.table),
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (rs *RestoreSnapshot) NewResponse() proto.Message {
+	return &pb.RestoreSnapshotResponse{}
+}
+
+// CloneSnapshot represents a RestoreSnapshot HBase call to a table that
+// doesn't exist, which creates it
+type CloneSnapshot struct {
+	snapshotOp
+}
+
+// NewCloneSnapshot creates a new CloneSnapshot request that will create the
+// given table, which mustn't exist, with the schema and data of the snapshot
+// with the given name. For use by the admin client.
+func NewCloneSnapshot(ctx context.Context, name string, table []byte) *CloneSnapshot {
+	return &CloneSnapshot{
+		snapshotOp{
+			tableOp: tableOp{base{
+				table: table,
+				ctx:   ctx,
+			}},
+			name: name,
+		},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (cs *CloneSnapshot) GetName() string {
+	return "RestoreSnapshot"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (cs *CloneSnapshot) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.RestoreSnapshotRequest{
+		Snapshot: snapshotDescription(cs.name, cs.table),
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (cs *CloneSnapshot) NewResponse() proto.Message {
+	return &pb.RestoreSnapshotResponse{}
+}
+
+// IsRestoreSnapshotDone represents an IsRestoreSnapshotDone HBase call
+type IsRestoreSnapshotDone struct {
+	snapshotOp
+}
+
+// NewIsRestoreSnapshotDone creates a new IsRestoreSnapshotDone request that
+// will check whether the given table was restored to, or cloned from, the
+// snapshot with the given name. For use by the admin client.
+func NewIsRestoreSnapshotDone(ctx context.Context, name string,
+	table []byte) *IsRestoreSnapshotDone {
+	return &IsRestoreSnapshotDone{
+		snapshotOp{
+			tableOp: tableOp{base{
+				table: table,
+				ctx:   ctx,
+			}},
+			name: name,
+		},
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (rd *IsRestoreSnapshotDone) GetName() string {
+	return "IsRestoreSnapshotDone"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (rd *IsRestoreSnapshotDone) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.IsRestoreSnapshotDoneRequest{
+		Snapshot: snapshotDescription(rd.name, rd.table),
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (rd *IsRestoreSnapshotDone) NewResponse() proto.Message {
+	return &pb.IsRestoreSnapshotDoneResponse{}
+}
@@ -0,0 +1,146 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// SplitRegion represents a SplitRegion HBase call, which is sent to the
+// AdminService of the RegionServer serving a region.
+type SplitRegion struct {
+	tableOp
+
+	splitPoint []byte
+}
+
+// NewSplitRegion creates a new SplitRegion request that will split in two the
+// region of the given table that holds the given row key.  The RegionServer
+// picks the split point, usually the middle of the largest store of the
+// region, unless one is given with the SplitPoint option.
+func NewSplitRegion(ctx context.Context, table, key []byte,
+	options ...func(Call) error) (*SplitRegion, error) {
+	sr := &SplitRegion{
+		tableOp: tableOp{base{
+			table: table,
+			key:   key,
+			ctx:   ctx,
+		}},
+	}
+	err := applyOptions(sr, options...)
+	if err != nil {
+		return nil, err
+	}
+	return sr, nil
+}
+
+// SplitPoint makes the region be split at the given row key, which becomes
+// the start key of the second region.
+func SplitPoint(key []byte) func(Call) error {
+	return func(g Call) error {
+		sr, ok := g.(*SplitRegion)
+		if !ok {
+			return errors.New("SplitPoint option can only be used with NewSplitRegion.")
+		}
+		if len(key) == 0 {
+			return errors.New("Split point can't be empty.")
+		}
+		sr.splitPoint = key
+		return nil
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (sr *SplitRegion) GetName() string {
+	return "SplitRegion"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (sr *SplitRegion) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.SplitRegionRequest{
+		Region:     sr.regionSpecifier(),
+		SplitPoint: sr.splitPoint,
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (sr *SplitRegion) NewResponse() proto.Message {
+	return &pb.SplitRegionResponse{}
+}
+
+// MergeTableRegions represents a DispatchMergingRegions HBase call, which
+// merges two regions of a table.
+type MergeTableRegions struct {
+	tableOp
+
+	regionA  string
+	regionB  string
+	forcible bool
+}
+
+// NewMergeTableRegions creates a new MergeTableRegions request that will
+// merge the two given regions, named by their encoded name like returned by
+// RegionInfo.GetEncodedName, into one.  The regions must be adjacent unless
+// the ForcibleMerge option is given.  For use by the admin client.
+func NewMergeTableRegions(ctx context.Context, regionA, regionB string,
+	options ...func(Call) error) (*MergeTableRegions, error) {
+	if regionA == "" || regionB == "" {
+		return nil, errors.New("Region names can't be empty.")
+	}
+	if regionA == regionB {
+		return nil, errors.New("Can't merge a region with itself.")
+	}
+	mr := &MergeTableRegions{
+		tableOp: tableOp{base{ctx: ctx}},
+		regionA: regionA,
+		regionB: regionB,
+	}
+	err := applyOptions(mr, options...)
+	if err != nil {
+		return nil, err
+	}
+	return mr, nil
+}
+
+// ForcibleMerge allows merging two regions that aren't adjacent, which
+// leaves a region spanning the keys of the regions between them.
+func ForcibleMerge() func(Call) error {
+	return func(g Call) error {
+		mr, ok := g.(*MergeTableRegions)
+		if !ok {
+			return errors.New("ForcibleMerge option can only be used with NewMergeTableRegions.")
+		}
+		mr.forcible = true
+		return nil
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (mr *MergeTableRegions) GetName() string {
+	return "DispatchMergingRegions"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (mr *MergeTableRegions) Serialize() ([]byte, error) {
+	return proto.Marshal(&pb.DispatchMergingRegionsRequest{
+		RegionA:  encodedRegionSpecifier(mr.regionA),
+		RegionB:  encodedRegionSpecifier(mr.regionB),
+		Forcible: proto.Bool(mr.forcible),
+	})
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (mr *MergeTableRegions) NewResponse() proto.Message {
+	return &pb.DispatchMergingRegionsResponse{}
+}
@@ -0,0 +1,248 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// tableFilter selects the tables listed by ListTableNames and
+// GetTableDescriptors.
+type tableFilter struct {
+	regex            string
+	includeSysTables bool
+	namespace        string
+}
+
+// ListTableNames represents a GetTableNames HBase call, which lists the names
+// of the tables.
+type ListTableNames struct {
+	tableOp
+	tableFilter
+}
+
+// NewListTableNames creates a new ListTableNames request that will list the
+// names of the user tables of all the namespaces, or of the tables selected by
+// the TableRegex, IncludeSysTables and InNamespace options.  For use by the
+// admin client.
+func NewListTableNames(ctx context.Context,
+	options ...func(Call) error) (*ListTableNames, error) {
+	lt := &ListTableNames{
+		tableOp: tableOp{base{
+			ctx: ctx,
+		}},
+	}
+	err := applyOptions(lt, options...)
+	if err != nil {
+		return nil, err
+	}
+	return lt, nil
+}
+
+// GetName returns the name of this RPC call.
+func (lt *ListTableNames) GetName() string {
+	return "GetTableNames"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (lt *ListTableNames) Serialize() ([]byte, error) {
+	ltreq := &pb.GetTableNamesRequest{
+		IncludeSysTables: proto.Bool(lt.includeSysTables),
+	}
+	if lt.regex != "" {
+		ltreq.Regex = proto.String(lt.regex)
+	}
+	if lt.namespace != "" {
+		ltreq.Namespace = proto.String(lt.namespace)
+	}
+	return proto.Marshal(ltreq)
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (lt *ListTableNames) NewResponse() proto.Message {
+	return &pb.GetTableNamesResponse{}
+}
+
+// GetTableDescriptors represents a GetTableDescriptors HBase call, which
+// returns the schemas of tables.
+type GetTableDescriptors struct {
+	tableOp
+	tableFilter
+
+	tables [][]byte
+}
+
+// NewGetTableDescriptors creates a new GetTableDescriptors request that will
+// return the schemas of the given tables, or if none are given, of the tables
+// selected like with NewListTableNames.  For use by the admin client.
+func NewGetTableDescriptors(ctx context.Context, tables [][]byte,
+	options ...func(Call) error) (*GetTableDescriptors, error) {
+	gt := &GetTableDescriptors{
+		tableOp: tableOp{base{
+			ctx: ctx,
+		}},
+		tables: tables,
+	}
+	err := applyOptions(gt, options...)
+	if err != nil {
+		return nil, err
+	}
+	return gt, nil
+}
+
+// GetName returns the name of this RPC call.
+func (gt *GetTableDescriptors) GetName() string {
+	return "GetTableDescriptors"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (gt *GetTableDescriptors) Serialize() ([]byte, error) {
+	gtreq := &pb.GetTableDescriptorsRequest{
+		IncludeSysTables: proto.Bool(gt.includeSysTables),
+	}
+	for _, table := range gt.tables {
+		gtreq.TableNames = append(gtreq.TableNames, ProtoTableName(table))
+	}
+	if gt.regex != "" {
+		gtreq.Regex = proto.String(gt.regex)
+	}
+	if gt.namespace != "" {
+		gtreq.Namespace = proto.String(gt.namespace)
+	}
+	return proto.Marshal(gtreq)
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (gt *GetTableDescriptors) NewResponse() proto.Message {
+	return &pb.GetTableDescriptorsResponse{}
+}
+
+// getTableFilter returns the filter of the given call, if it lists tables.
+func getTableFilter(g Call) (*tableFilter, bool) {
+	switch c := g.(type) {
+	case *ListTableNames:
+		return &c.tableFilter, true
+	case *GetTableDescriptors:
+		return &c.tableFilter, true
+	}
+	return nil, false
+}
+
+// TableRegex only lists the tables whose name, without its namespace,
+// matches the given Java regular expression.
+func TableRegex(regex string) func(Call) error {
+	return func(g Call) error {
+		f, ok := getTableFilter(g)
+		if !ok {
+			return errors.New("TableRegex option can only be used with table listings.")
+		}
+		f.regex = regex
+		return nil
+	}
+}
+
+// IncludeSysTables also lists the system tables, like hbase:meta.
+func IncludeSysTables() func(Call) error {
+	return func(g Call) error {
+		f, ok := getTableFilter(g)
+		if !ok {
+			return errors.New("IncludeSysTables option can only be used with table listings.")
+		}
+		f.includeSysTables = true
+		return nil
+	}
+}
+
+// InNamespace only lists the tables of the given namespace.
+func InNamespace(namespace string) func(Call) error {
+	return func(g Call) error {
+		f, ok := getTableFilter(g)
+		if !ok {
+			return errors.New("InNamespace option can only be used with table listings.")
+		}
+		if namespace == "" {
+			return errors.New("Namespace can't be empty.")
+		}
+		f.namespace = namespace
+		return nil
+	}
+}
+
+// TableDescriptor is the schema of a table.
+type TableDescriptor struct {
+	// Name is the name of the table, prefixed by its namespace and a colon
+	// unless it's in the default namespace.
+	Name []byte
+
+	// Attributes of the table, e.g. {"MAX_FILESIZE": "10737418240"}.
+	Attributes map[string]string
+
+	// Configuration overriding that of the cluster for the table.
+	Configuration map[string]string
+
+	// Families maps the column families of the table to their attributes,
+	// like those given to the FamilyAttributes option of NewCreateTable.
+	Families map[string]map[string]string
+}
+
+// DefaultNamespace is the namespace of the tables whose name isn't prefixed
+// by a namespace and a colon.
+const DefaultNamespace = "default"
+
+// FullTableName returns the name of the given table, prefixed by its
+// namespace and a colon unless it's in the default namespace.
+func FullTableName(tn *pb.TableName) []byte {
+	if ns := tn.GetNamespace(); len(ns) != 0 && string(ns) != DefaultNamespace {
+		name := make([]byte, 0, len(ns)+1+len(tn.GetQualifier()))
+		name = append(append(append(name, ns...), ':'), tn.GetQualifier()...)
+		return name
+	}
+	return tn.GetQualifier()
+}
+
+// ProtoTableName returns the protobuf TableName of the given table, whose
+// name is prefixed by its namespace and a colon, like "ns:table", unless
+// it's in the default namespace.
+func ProtoTableName(table []byte) *pb.TableName {
+	if colon := bytes.IndexByte(table, ':'); colon >= 0 {
+		return &pb.TableName{Namespace: table[:colon], Qualifier: table[colon+1:]}
+	}
+	return &pb.TableName{Namespace: []byte(DefaultNamespace), Qualifier: table}
+}
+
+// ToLocalTableDescriptor converts the given protobuf TableSchema into our own
+// TableDescriptor type.
+func ToLocalTableDescriptor(schema *pb.TableSchema) *TableDescriptor {
+	td := &TableDescriptor{
+		Name:          FullTableName(schema.GetTableName()),
+		Attributes:    make(map[string]string, len(schema.Attributes)),
+		Configuration: make(map[string]string, len(schema.Configuration)),
+		Families:      make(map[string]map[string]string, len(schema.ColumnFamilies)),
+	}
+	for _, attr := range schema.Attributes {
+		td.Attributes[string(attr.First)] = string(attr.Second)
+	}
+	for _, conf := range schema.Configuration {
+		td.Configuration[conf.GetName()] = conf.GetValue()
+	}
+	for _, cf := range schema.ColumnFamilies {
+		attrs := make(map[string]string, len(cf.Attributes))
+		for _, attr := range cf.Attributes {
+			attrs[string(attr.First)] = string(attr.Second)
+		}
+		td.Families[string(cf.Name)] = attrs
+	}
+	return td
+}
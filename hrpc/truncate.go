@@ -0,0 +1,73 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package hrpc
+
+import (
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// TruncateTable represents a TruncateTable HBase call
+type TruncateTable struct {
+	tableOp
+
+	preserveSplits bool
+}
+
+// NewTruncateTable creates a new TruncateTable request that will delete all
+// the data of the given table in HBase, which must be disabled, and recreate
+// it empty with the same schema.  For use by the admin client.
+func NewTruncateTable(ctx context.Context, table []byte,
+	options ...func(Call) error) (*TruncateTable, error) {
+	tt := &TruncateTable{
+		tableOp: tableOp{base{
+			table: table,
+			ctx:   ctx,
+		}},
+	}
+	err := applyOptions(tt, options...)
+	if err != nil {
+		return nil, err
+	}
+	return tt, nil
+}
+
+// PreserveSplits makes the truncated table keep the regions it was split
+// into, rather than being recreated as a single region.
+func PreserveSplits() func(Call) error {
+	return func(g Call) error {
+		tt, ok := g.(*TruncateTable)
+		if !ok {
+			return errors.New("PreserveSplits option can only be used with NewTruncateTable.")
+		}
+		tt.preserveSplits = true
+		return nil
+	}
+}
+
+// GetName returns the name of this RPC call.
+func (tt *TruncateTable) GetName() string {
+	return "truncateTable"
+}
+
+// Serialize will convert this HBase call into a slice of bytes to be written to
+// the network
+func (tt *TruncateTable) Serialize() ([]byte, error) {
+	ttreq := &pb.TruncateTableRequest{
+		TableName:      ProtoTableName(tt.table),
+		PreserveSplits: proto.Bool(tt.preserveSplits),
+	}
+	return proto.Marshal(ttreq)
+}
+
+// NewResponse creates an empty protobuf message to read the response of this
+// RPC.
+func (tt *TruncateTable) NewResponse() proto.Message {
+	return &pb.TruncateTableResponse{}
+}
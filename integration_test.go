@@ -11,12 +11,15 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/tsuna/gohbase"
+	"github.com/tsuna/gohbase/filter"
 	"github.com/tsuna/gohbase/hrpc"
 	"github.com/tsuna/gohbase/test"
 	"golang.org/x/net/context"
@@ -92,6 +95,26 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestConnect(t *testing.T) {
+	c := gohbase.NewClient(*host)
+	ctx, _ := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := c.Connect(ctx); err != nil {
+		t.Fatalf("Connect returned an error: %v", err)
+	}
+	// Connecting again is a no-op.
+	if err := c.Connect(ctx); err != nil {
+		t.Errorf("Second Connect returned an error: %v", err)
+	}
+	if err := insertKeyValue(c, "connect", "cf", []byte("1")); err != nil {
+		t.Errorf("Put returned an error: %v", err)
+	}
+
+	ac := gohbase.NewAdminClient(*host)
+	if err := ac.Connect(ctx); err != nil {
+		t.Errorf("Connect of the admin client returned an error: %v", err)
+	}
+}
+
 func TestGetDoesntExist(t *testing.T) {
 	key := "row1.5"
 	c := gohbase.NewClient(*host)
@@ -113,6 +136,148 @@ func TestGetDoesntExist(t *testing.T) {
 	}
 }
 
+func TestExists(t *testing.T) {
+	key := "row1.5"
+	c := gohbase.NewClient(*host)
+	if err := insertKeyValue(c, key, "cf", []byte("1")); err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+	for row, expected := range map[string]bool{key: true, "row1.5.doesntexist": false} {
+		get, err := hrpc.NewGetExistsStr(context.Background(), table, row)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exists, err := c.Exists(get)
+		if err != nil {
+			t.Fatalf("Exists returned an error: %v", err)
+		}
+		if exists != expected {
+			t.Errorf("Expected row %q exists=%v, got %v", row, expected, exists)
+		}
+	}
+}
+
+func TestGetMulti(t *testing.T) {
+	keyPrefix := "row15"
+	if err := performNPuts(keyPrefix, 5); err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+	c := gohbase.NewClient(*host)
+	keys := [][]byte{[]byte("row153"), []byte("row15.doesntexist"), []byte("row150")}
+	results, err := c.GetMulti(context.Background(), []byte(table), keys,
+		hrpc.Families(map[string][]string{"cf": nil}))
+	if err != nil {
+		t.Fatalf("GetMulti returned an error: %v", err)
+	}
+	if len(results) != len(keys) {
+		t.Fatalf("Expected %d results, got %d", len(keys), len(results))
+	}
+	if len(results[0].Cells) != 1 || string(results[0].Cells[0].Value) != "3" {
+		t.Errorf("Unexpected result for %q: %v", keys[0], results[0])
+	}
+	if len(results[1].Cells) != 0 {
+		t.Errorf("Expected no cells for %q, got %v", keys[1], results[1])
+	}
+	if len(results[2].Cells) != 1 || string(results[2].Cells[0].Value) != "0" {
+		t.Errorf("Unexpected result for %q: %v", keys[2], results[2])
+	}
+}
+
+func TestBatch(t *testing.T) {
+	keyPrefix := "row18"
+	if err := performNPuts(keyPrefix, 2); err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+	c := gohbase.NewClient(*host)
+	ctx := context.Background()
+	put, err := hrpc.NewPutStr(ctx, table, "row182",
+		map[string]map[string][]byte{"cf": {"a": []byte("2")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	del, err := hrpc.NewDelStr(ctx, table, "row180", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get, err := hrpc.NewGetStr(ctx, table, "row181")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := c.Batch(ctx, []hrpc.Call{put, del, get})
+	if err != nil {
+		t.Fatalf("Batch returned an error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if len(results[2].Cells) != 1 || string(results[2].Cells[0].Value) != "1" {
+		t.Errorf("Unexpected result for the Get: %v", results[2])
+	}
+
+	keys := [][]byte{[]byte("row180"), []byte("row182")}
+	rows, err := c.GetMulti(ctx, []byte(table), keys)
+	if err != nil {
+		t.Fatalf("GetMulti returned an error: %v", err)
+	}
+	if len(rows[0].Cells) != 0 || len(rows[1].Cells) != 1 {
+		t.Errorf("Expected the Delete and the Put to be applied, got %v and %v",
+			rows[0], rows[1])
+	}
+
+	scan, err := hrpc.NewScanStr(ctx, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Batch(ctx, []hrpc.Call{scan}); err == nil {
+		t.Error("Expected an error batching a Scan")
+	}
+}
+
+func TestGetRanges(t *testing.T) {
+	keyPrefix := "row17"
+	if err := performNPuts(keyPrefix, 6); err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+	c := gohbase.NewClient(*host)
+	ranges := [][2][]byte{
+		{[]byte("row174"), []byte("row176")},
+		{[]byte("row171"), []byte("row171\x00")},
+		{[]byte("row17.doesntexist"), []byte("row17.doesntexist\x00")},
+		{[]byte("row175"), []byte("row175\x00")},
+	}
+	results, err := c.GetRanges(context.Background(), []byte(table), ranges,
+		hrpc.Families(map[string][]string{"cf": nil}))
+	if err != nil {
+		t.Fatalf("GetRanges returned an error: %v", err)
+	}
+	var rows []string
+	for _, res := range results {
+		rows = append(rows, string(res.Cells[0].Row))
+	}
+	if expected := []string{"row171", "row174", "row175"}; !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected rows %q, got %q", expected, rows)
+	}
+}
+
+func TestGetBefore(t *testing.T) {
+	c := gohbase.NewClient(*host)
+	if err := insertKeyValue(c, "row16a", "cf", []byte("1")); err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+	get, err := hrpc.NewGetBefore(context.Background(), []byte(table), []byte("row16b"),
+		hrpc.Families(map[string][]string{"cf": nil}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsp, err := c.Get(get)
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	if len(rsp.Cells) != 1 || string(rsp.Cells[0].Row) != "row16a" {
+		t.Errorf("Expected to get row16a, got %v", rsp.Cells)
+	}
+}
+
 func TestGetBadColumnFamily(t *testing.T) {
 	key := "row1.625"
 	c := gohbase.NewClient(*host)
@@ -131,6 +296,159 @@ func TestGetBadColumnFamily(t *testing.T) {
 	}
 }
 
+func TestGetCompactionState(t *testing.T) {
+	c := gohbase.NewClient(*host)
+	state, err := c.GetCompactionState(context.Background(), []byte(table))
+	if err != nil {
+		t.Fatalf("GetCompactionState returned an error: %v", err)
+	}
+	if state != hrpc.CompactionNone {
+		t.Errorf("Expected no compaction to be running, got %s", state)
+	}
+}
+
+func TestCompactTable(t *testing.T) {
+	c := gohbase.NewClient(*host)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	err := c.CompactTable(ctx, []byte(table), hrpc.MajorCompaction(), hrpc.CompactFamily("cf"))
+	if err != nil {
+		t.Fatalf("CompactTable returned an error: %v", err)
+	}
+	for {
+		state, err := c.GetCompactionState(ctx, []byte(table))
+		if err != nil {
+			t.Fatalf("GetCompactionState returned an error: %v", err)
+		}
+		if state == hrpc.CompactionNone {
+			return
+		}
+		select {
+		case <-time.After(500 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatalf("Expected the compaction to finish, still got %s", state)
+		}
+	}
+}
+
+func TestFlushTable(t *testing.T) {
+	c := gohbase.NewClient(*host)
+	if err := insertKeyValue(c, "flush", "cf", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.FlushTable(context.Background(), []byte(table)); err != nil {
+		t.Fatalf("FlushTable returned an error: %v", err)
+	}
+}
+
+func TestUserPermissions(t *testing.T) {
+	c := gohbase.NewClient(*host)
+	perm := &hrpc.UserPermission{
+		User:    "gohbase_test_" + getTimestampString(),
+		Table:   []byte(table),
+		Actions: []hrpc.Action{hrpc.ActionRead},
+	}
+	grant, err := hrpc.NewGrant(context.Background(), perm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Grant(grant); err != nil {
+		t.Fatalf("Grant returned an error: %v", err)
+	}
+	hasPermission := func() bool {
+		gp, err := hrpc.NewGetUserPermissions(context.Background(), "", []byte(table))
+		if err != nil {
+			t.Fatal(err)
+		}
+		perms, err := c.GetUserPermissions(gp)
+		if err != nil {
+			t.Fatalf("GetUserPermissions returned an error: %v", err)
+		}
+		for _, p := range perms {
+			if p.User == perm.User {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasPermission() {
+		t.Errorf("Expected %s to have a permission on %s", perm.User, table)
+	}
+	revoke, err := hrpc.NewRevoke(context.Background(), perm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Revoke(revoke); err != nil {
+		t.Fatalf("Revoke returned an error: %v", err)
+	}
+	if hasPermission() {
+		t.Errorf("Expected %s to have no permission on %s anymore", perm.User, table)
+	}
+}
+
+func TestTableRegions(t *testing.T) {
+	c := gohbase.NewClient(*host)
+	count, err := c.TableRegionCount(context.Background(), []byte(table))
+	if err != nil {
+		t.Fatalf("TableRegionCount returned an error: %v", err)
+	}
+	if count < 1 {
+		t.Errorf("Expected at least one region, got %d", count)
+	}
+	keys, err := c.TableSplitKeys(context.Background(), []byte(table))
+	if err != nil {
+		t.Fatalf("TableSplitKeys returned an error: %v", err)
+	}
+	if len(keys) != count-1 {
+		t.Errorf("Expected %d split keys, got %q", count-1, keys)
+	}
+	regions, err := c.GetTableRegions(context.Background(), []byte(table))
+	if err != nil {
+		t.Fatalf("GetTableRegions returned an error: %v", err)
+	}
+	if len(regions) != count {
+		t.Fatalf("Expected %d regions, got %d", count, len(regions))
+	}
+	var start []byte
+	for _, reg := range regions {
+		if !bytes.Equal(reg.StartKey, start) {
+			t.Errorf("Expected region %q to start at %q", reg.Name, start)
+		}
+		if reg.Host == "" || reg.Port == 0 || reg.ID == 0 {
+			t.Errorf("Expected region %q to be assigned, got %+v", reg.Name, reg)
+		}
+		start = reg.StopKey
+	}
+	if len(start) != 0 {
+		t.Errorf("Expected the last region to end the table, got %q", start)
+	}
+	_, err = c.TableRegionCount(context.Background(), []byte("nonexistenttable"))
+	if err != gohbase.TableNotFound {
+		t.Errorf("Expected TableNotFound, got %v", err)
+	}
+}
+
+func TestWarmRegions(t *testing.T) {
+	c := gohbase.NewClient(*host)
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := c.WarmRegions(ctx, []byte(table)); err != nil {
+		t.Fatalf("WarmRegions returned an error: %v", err)
+	}
+	// The regions are cached and connected to, so this doesn't look up meta.
+	get, err := hrpc.NewGetStr(ctx, table, "row1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Get(get); err != nil {
+		t.Errorf("Get returned an error: %v", err)
+	}
+	if err = c.WarmRegions(ctx, []byte("nonexistenttable")); err != gohbase.TableNotFound {
+		t.Errorf("Expected TableNotFound, got %v", err)
+	}
+}
+
 func TestGetMultipleCells(t *testing.T) {
 	key := "row1.75"
 	c := gohbase.NewClient(*host, gohbase.FlushInterval(time.Millisecond*2))
@@ -183,6 +501,27 @@ func TestPut(t *testing.T) {
 	}
 }
 
+func TestEmptyQualifier(t *testing.T) {
+	key := "TestEmptyQualifier"
+	c := gohbase.NewClient(*host)
+	values := map[string]map[string][]byte{
+		"cf": {hrpc.EmptyQualifier: []byte("empty"), "a": []byte("a")}}
+	put, err := hrpc.NewPutStr(context.Background(), table, key, values)
+	if _, err = c.Put(put); err != nil {
+		t.Fatalf("Put failed: %s", err)
+	}
+	get, err := hrpc.NewGetStr(context.Background(), table, key,
+		hrpc.Families(map[string][]string{"cf": {hrpc.EmptyQualifier}}))
+	rsp, err := c.Get(get)
+	if err != nil {
+		t.Fatalf("Get failed: %s", err)
+	}
+	if len(rsp.Cells) != 1 || len(rsp.Cells[0].Qualifier) != 0 ||
+		string(rsp.Cells[0].Value) != "empty" {
+		t.Errorf("Expected only the cell of the empty qualifier, got %v", rsp.Cells)
+	}
+}
+
 func TestPutReflection(t *testing.T) {
 	key := "row2.25"
 	number := 150
@@ -427,6 +766,33 @@ func TestPutTimestamp(t *testing.T) {
 	}
 }
 
+func TestPutCells(t *testing.T) {
+	key := "TestPutCells"
+	c := gohbase.NewClient(*host)
+	ts1, ts2 := uint64(10), uint64(20)
+	cells := []*hrpc.Cell{
+		{Family: []byte("cf"), Qualifier: []byte("a"), Value: []byte("1"), Timestamp: &ts1},
+		{Family: []byte("cf"), Qualifier: []byte("a"), Value: []byte("2"), Timestamp: &ts2},
+	}
+	put, err := hrpc.NewPutCells(context.Background(), []byte(table), []byte(key), cells)
+	if err != nil {
+		t.Fatalf("NewPutCells failed: %s", err)
+	}
+	if _, err = c.Put(put); err != nil {
+		t.Fatalf("Put failed: %s", err)
+	}
+	get, err := hrpc.NewGetStr(context.Background(), table, key,
+		hrpc.Families(map[string][]string{"cf": nil}), hrpc.MaxVersions(10))
+	rsp, err := c.Get(get)
+	if err != nil {
+		t.Fatalf("Get failed: %s", err)
+	}
+	if len(rsp.Cells) != 2 || *rsp.Cells[0].Timestamp != 20 ||
+		string(rsp.Cells[0].Value) != "2" || *rsp.Cells[1].Timestamp != 10 {
+		t.Errorf("Expected the versions 20 and 10, got %v", rsp.Cells)
+	}
+}
+
 func TestDeleteTimestamp(t *testing.T) {
 	key := "TestDeleteTimestamp"
 	c := gohbase.NewClient(*host)
@@ -454,6 +820,48 @@ func TestDeleteTimestamp(t *testing.T) {
 	}
 }
 
+func TestDeleteVersions(t *testing.T) {
+	key := "TestDeleteVersions"
+	c := gohbase.NewClient(*host)
+	for _, ts := range []int64{10, 20, 30} {
+		err := insertKeyValue(c, key, "cf", []byte("1"), hrpc.Timestamp(time.Unix(0, ts*1e6)))
+		if err != nil {
+			t.Fatalf("Put failed: %s", err)
+		}
+	}
+	versions := func() []uint64 {
+		get, err := hrpc.NewGetStr(context.Background(), table, key,
+			hrpc.Families(map[string][]string{"cf": nil}), hrpc.MaxVersions(10))
+		rsp, err := c.Get(get)
+		if err != nil {
+			t.Fatalf("Get failed: %s", err)
+		}
+		var ts []uint64
+		for _, cell := range rsp.Cells {
+			ts = append(ts, *cell.Timestamp)
+		}
+		return ts
+	}
+
+	del, err := hrpc.NewDelStr(context.Background(), table, key, nil,
+		hrpc.DeleteColumn("cf", "a", 20))
+	if _, err = c.Delete(del); err != nil {
+		t.Fatalf("Delete failed: %s", err)
+	}
+	if ts := versions(); !reflect.DeepEqual(ts, []uint64{30, 10}) {
+		t.Errorf("Expected the versions 30 and 10 to be left, got %v", ts)
+	}
+
+	del, err = hrpc.NewDelStr(context.Background(), table, key, nil,
+		hrpc.DeleteFamily("cf", 10))
+	if _, err = c.Delete(del); err != nil {
+		t.Fatalf("Delete failed: %s", err)
+	}
+	if ts := versions(); !reflect.DeepEqual(ts, []uint64{30}) {
+		t.Errorf("Expected the version 30 to be left, got %v", ts)
+	}
+}
+
 func TestGetTimeRangeVersions(t *testing.T) {
 	key := "TestGetTimeRangeVersions"
 	c := gohbase.NewClient(*host)
@@ -595,6 +1003,154 @@ func TestScanTimeRangeVersions(t *testing.T) {
 	}
 }
 
+func TestParallelScan(t *testing.T) {
+	keyPrefix := "row11"
+	err := performNPuts(keyPrefix, 20)
+	if err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+	c := gohbase.NewClient(*host)
+	scan, err := hrpc.NewScanRangeStr(context.Background(), table, keyPrefix, "row12",
+		hrpc.Families(map[string][]string{"cf": nil}))
+	if err != nil {
+		t.Fatalf("Scan req failed: %s", err)
+	}
+	expected, err := c.Scan(scan)
+	if err != nil {
+		t.Fatalf("Scan failed: %s", err)
+	}
+	rsp, err := c.ParallelScan(scan, 4)
+	if err != nil {
+		t.Fatalf("ParallelScan failed: %s", err)
+	}
+	if len(rsp) != len(expected) {
+		t.Fatalf("Expected rows: %d, Got rows: %d", len(expected), len(rsp))
+	}
+	for i := range rsp {
+		if !bytes.Equal(rsp[i].Cells[0].Row, expected[i].Cells[0].Row) {
+			t.Errorf("Row #%d mismatch. Expected: %q, Got: %q",
+				i, expected[i].Cells[0].Row, rsp[i].Cells[0].Row)
+		}
+	}
+}
+
+func TestScannerRenew(t *testing.T) {
+	keyPrefix := "row13"
+	err := performNPuts(keyPrefix, 5)
+	if err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+	c := gohbase.NewClient(*host, gohbase.ScannerRenewInterval(100*time.Millisecond))
+	scan, err := hrpc.NewScanRangeStr(context.Background(), table, keyPrefix, "row14",
+		hrpc.Families(map[string][]string{"cf": nil}), hrpc.NumberOfRows(1))
+	if err != nil {
+		t.Fatalf("Scan req failed: %s", err)
+	}
+	sc := c.Scanner(scan)
+	var rows int
+	for {
+		_, err := sc.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Next failed: %s", err)
+		}
+		rows++
+		// Be a slow consumer, so that the lease gets renewed.
+		time.Sleep(300 * time.Millisecond)
+	}
+	if rows != 5 {
+		t.Errorf("Expected rows: %d, Got rows: %d", 5, rows)
+	}
+	if err := sc.Close(); err != nil {
+		t.Errorf("Close failed: %s", err)
+	}
+}
+
+func TestScanEach(t *testing.T) {
+	keyPrefix := "row19"
+	if err := performNPuts(keyPrefix, 5); err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+	c := gohbase.NewClient(*host)
+	scan, err := hrpc.NewScanRangeStr(context.Background(), table, keyPrefix, "row2",
+		hrpc.Families(map[string][]string{"cf": nil}), hrpc.NumberOfRows(2))
+	if err != nil {
+		t.Fatalf("Scan req failed: %s", err)
+	}
+	var rows []string
+	err = c.ScanEach(scan, func(res *hrpc.Result) error {
+		rows = append(rows, string(res.Cells[0].Row))
+		if len(rows) == 3 {
+			return gohbase.ErrStopScan
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ScanEach returned an error: %v", err)
+	}
+	if expected := []string{"row190", "row191", "row192"}; !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected rows %q, got %q", expected, rows)
+	}
+}
+
+func TestScanTables(t *testing.T) {
+	keyPrefix := "row12"
+	if err := performNPuts(keyPrefix, 3); err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+	c := gohbase.NewClient(*host)
+	scan, err := hrpc.NewScanRangeStr(context.Background(), table, keyPrefix, "row13",
+		hrpc.Families(map[string][]string{"cf": nil}))
+	if err != nil {
+		t.Fatalf("Scan req failed: %s", err)
+	}
+	// The same table stands for two shards.
+	tables := [][]byte{[]byte(table), []byte(table)}
+	results, err := c.ScanTables(scan, tables, 5, 1)
+	if err != nil {
+		t.Fatalf("ScanTables returned an error: %v", err)
+	}
+	var rows []string
+	for _, res := range results {
+		rows = append(rows, string(res.Cells[0].Row))
+	}
+	expected := []string{"row120", "row121", "row122", "row120", "row121"}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected rows %q, got %q", expected, rows)
+	}
+}
+
+func TestScanRanges(t *testing.T) {
+	keyPrefix := "row14"
+	err := performNPuts(keyPrefix, 10)
+	if err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+	c := gohbase.NewClient(*host)
+	scan, err := hrpc.NewScanStr(context.Background(), table,
+		hrpc.Families(map[string][]string{"cf": nil}),
+		hrpc.Ranges([][2][]byte{
+			{[]byte("row141"), []byte("row143")},
+			{[]byte("row147"), []byte("row148")},
+		}))
+	if err != nil {
+		t.Fatalf("Scan req failed: %s", err)
+	}
+	rsp, err := c.Scan(scan)
+	if err != nil {
+		t.Fatalf("Scan failed: %s", err)
+	}
+	var rows []string
+	for _, r := range rsp {
+		rows = append(rows, string(r.Cells[0].Row))
+	}
+	expected := []string{"row141", "row142", "row147"}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected rows %v, got %v", expected, rows)
+	}
+}
+
 func TestAppend(t *testing.T) {
 	key := "row7"
 	c := gohbase.NewClient(*host)
@@ -634,6 +1190,20 @@ func TestAppend(t *testing.T) {
 		t.Errorf("Append returned an incorrect result. Expected: %v, Receieved: %v",
 			[]byte("Hello my name is Dog."), result)
 	}
+
+	// Appending without getting the resulting value back.
+	appRequest, err = hrpc.NewAppend(context.Background(), []byte(table), []byte(key),
+		map[string]map[string][]byte{"cf": {"a": []byte("!")}}, hrpc.ReturnResults(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	appRsp, err = c.Append(appRequest)
+	if err != nil {
+		t.Fatalf("Append returned an error: %v", err)
+	}
+	if len(appRsp.Cells) != 0 {
+		t.Errorf("Expected Append not to return any cell, got %v", appRsp.Cells)
+	}
 }
 
 func TestIncrement(t *testing.T) {
@@ -660,6 +1230,36 @@ func TestIncrement(t *testing.T) {
 	if result != 6 {
 		t.Fatalf("Increment's result is %d, want 6", result)
 	}
+
+	incRequest, err = hrpc.NewIncSingle(context.Background(), []byte(table), []byte(key),
+		"cf", "a", -2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = c.Increment(incRequest)
+	if err != nil {
+		t.Fatalf("Increment returned an error: %v", err)
+	}
+	if result != 4 {
+		t.Fatalf("Increment's result is %d, want 4", result)
+	}
+}
+
+func TestIncrementColumns(t *testing.T) {
+	c := gohbase.NewClient(*host)
+	inc, err := hrpc.NewIncMulti(context.Background(), []byte(table), []byte("row102.7"),
+		map[string]map[string]int64{"cf": {"a": 3, "b": -2}, "cf2": {"c": 7}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := c.IncrementColumns(inc)
+	if err != nil {
+		t.Fatalf("IncrementColumns returned an error: %v", err)
+	}
+	expected := map[string]map[string]int64{"cf": {"a": 3, "b": -2}, "cf2": {"c": 7}}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
 }
 
 func TestIncrementParallel(t *testing.T) {
@@ -699,6 +1299,110 @@ func TestIncrementParallel(t *testing.T) {
 	}
 }
 
+func TestCheckAndMutate(t *testing.T) {
+	c := gohbase.NewClient(*host)
+	key := "row101"
+	if err := insertKeyValue(c, key, "cf", []byte("5")); err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+	values := map[string]map[string][]byte{"cf": {"b": []byte("x")}}
+	testcases := []struct {
+		compareType filter.CompareType
+		value       string
+		applied     bool
+	}{
+		{filter.Less, "7", false},
+		{filter.Less, "3", true},
+		{filter.GreaterOrEqual, "5", true},
+		{filter.NotEqual, "5", false},
+	}
+	for _, tc := range testcases {
+		put, err := hrpc.NewPutStr(context.Background(), table, key, values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmp := filter.NewBinaryComparator(filter.NewByteArrayComparable([]byte(tc.value)))
+		cam, err := hrpc.NewCheckAndMutate(put, "cf", "a", tc.compareType, cmp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		applied, err := c.CheckAndMutate(cam)
+		if err != nil {
+			t.Fatalf("CheckAndMutate returned an error: %v", err)
+		}
+		if applied != tc.applied {
+			t.Errorf("Expected the Put to be applied with %q %d the value to be %v",
+				tc.value, tc.compareType, tc.applied)
+		}
+	}
+}
+
+func TestPutTTL(t *testing.T) {
+	c := gohbase.NewClient(*host)
+	key := "row104"
+	if err := insertKeyValue(c, key, "cf", []byte("1"), hrpc.TTL(time.Second)); err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+	get, err := hrpc.NewGetStr(context.Background(), table, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Get(get)
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	} else if len(res.Cells) != 1 {
+		t.Fatalf("Expected the cell before it expires, got %v", res.Cells)
+	}
+	time.Sleep(1500 * time.Millisecond)
+	if res, err = c.Get(get); err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	} else if len(res.Cells) != 0 {
+		t.Errorf("Expected the cell to have expired, got %v", res.Cells)
+	}
+}
+
+func TestMutateRow(t *testing.T) {
+	c := gohbase.NewClient(*host)
+	key := "row103"
+	if err := insertKeyValue(c, key, "cf", []byte("1")); err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+	ctx := context.Background()
+	put, err := hrpc.NewPutStr(ctx, table, key,
+		map[string]map[string][]byte{"cf": {"b": []byte("2")}, "cf2": {"a": []byte("3")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	del, err := hrpc.NewDelStr(ctx, table, key,
+		map[string]map[string][]byte{"cf": {"a": nil}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rm, err := hrpc.NewRowMutations(ctx, put, del)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MutateRow(rm); err != nil {
+		t.Fatalf("MutateRow returned an error: %v", err)
+	}
+
+	get, err := hrpc.NewGetStr(ctx, table, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Get(get)
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	var columns []string
+	for _, cell := range res.Cells {
+		columns = append(columns, string(cell.Family)+":"+string(cell.Qualifier))
+	}
+	if expected := []string{"cf:b", "cf2:a"}; !reflect.DeepEqual(columns, expected) {
+		t.Errorf("Expected columns %v, got %v", expected, columns)
+	}
+}
+
 func TestCheckAndPut(t *testing.T) {
 	c := gohbase.NewClient(*host)
 
@@ -762,6 +1466,95 @@ func TestCheckAndPutNotPut(t *testing.T) {
 	}
 }
 
+func TestCheckAndPutBatch(t *testing.T) {
+	c := gohbase.NewClient(*host)
+	values := map[string]map[string][]byte{"cf": map[string][]byte{"a": []byte("1")}}
+	keys := []string{"row102.1", "row102.2", "row102.3"}
+	newBatch := func() []*hrpc.CheckAndPut {
+		cas := make([]*hrpc.CheckAndPut, len(keys))
+		for i, key := range keys {
+			putRequest, err := hrpc.NewPutStr(context.Background(), table, key, values)
+			if err != nil {
+				t.Fatalf("NewPutStr returned an error: %v", err)
+			}
+			cas[i], err = hrpc.NewCheckAndPut(putRequest, "cf", "a", []byte{})
+			if err != nil {
+				t.Fatalf("NewCheckAndPut returned an error: %v", err)
+			}
+		}
+		return cas
+	}
+
+	// The cells don't exist yet, all the Puts should be applied.
+	processed, err := c.CheckAndPutBatch(context.Background(), newBatch())
+	if err != nil {
+		t.Fatalf("CheckAndPutBatch error: %s", err)
+	}
+	for i, p := range processed {
+		if !p {
+			t.Errorf("Expected the Put of %q to be applied", keys[i])
+		}
+	}
+
+	// Now they do, none of them should be.
+	processed, err = c.CheckAndPutBatch(context.Background(), newBatch())
+	if err != nil {
+		t.Fatalf("CheckAndPutBatch error: %s", err)
+	}
+	for i, p := range processed {
+		if p {
+			t.Errorf("Expected the Put of %q not to be applied", keys[i])
+		}
+	}
+}
+
+func TestCheckAndMutateBatch(t *testing.T) {
+	c := gohbase.NewClient(*host)
+	keys := []string{"row102.8", "row102.9"}
+	for _, key := range keys {
+		if err := insertKeyValue(c, key, "cf", []byte("1")); err != nil {
+			t.Fatalf("Put returned an error: %v", err)
+		}
+	}
+	newBatch := func() []*hrpc.CheckAndMutate {
+		cams := make([]*hrpc.CheckAndMutate, len(keys))
+		for i, key := range keys {
+			del, err := hrpc.NewDelStr(context.Background(), table, key, nil)
+			if err != nil {
+				t.Fatalf("NewDelStr returned an error: %v", err)
+			}
+			expected := filter.NewBinaryComparator(filter.NewByteArrayComparable([]byte("1")))
+			cams[i], err = hrpc.NewCheckAndMutate(del, "cf", "a", filter.Equal, expected)
+			if err != nil {
+				t.Fatalf("NewCheckAndMutate returned an error: %v", err)
+			}
+		}
+		return cams
+	}
+
+	// The cells hold the expected value, both rows should be deleted.
+	processed, err := c.CheckAndMutateBatch(context.Background(), newBatch())
+	if err != nil {
+		t.Fatalf("CheckAndMutateBatch error: %s", err)
+	}
+	for i, p := range processed {
+		if !p {
+			t.Errorf("Expected the Delete of %q to be applied", keys[i])
+		}
+	}
+
+	// Now the cells are gone, none of the Deletes should be applied.
+	processed, err = c.CheckAndMutateBatch(context.Background(), newBatch())
+	if err != nil {
+		t.Fatalf("CheckAndMutateBatch error: %s", err)
+	}
+	for i, p := range processed {
+		if p {
+			t.Errorf("Expected the Delete of %q not to be applied", keys[i])
+		}
+	}
+}
+
 func TestCheckAndPutParallel(t *testing.T) {
 	c := gohbase.NewClient(*host)
 
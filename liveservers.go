@@ -0,0 +1,122 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"golang.org/x/net/context"
+)
+
+// liveServers holds the RegionServers that have a znode in ZooKeeper, by
+// address.
+type liveServers struct {
+	m sync.Mutex
+
+	servers map[string]hrpc.ServerName
+}
+
+// WatchRegionServers will return an option that makes the client watch the
+// znodes of the live RegionServers in ZooKeeper, once it first looks up meta.
+// When a RegionServer dies and its znode goes away, the client reconnects its
+// regions right away rather than once their RPCs time out.  If preconnect is
+// true, the client also connects to the RegionServers as soon as they start,
+// so that the first RPCs sent to them don't wait for the connection.
+func WatchRegionServers(preconnect bool) Option {
+	return func(c *client) {
+		c.watchRegionServers = true
+		c.preconnect = preconnect
+	}
+}
+
+// LiveRegionServers returns the RegionServers listed in ZooKeeper the last
+// time they changed.  It's empty unless the client is created with the
+// WatchRegionServers option and has looked up meta.
+func (c *client) LiveRegionServers() []hrpc.ServerName {
+	c.liveServers.m.Lock()
+	defer c.liveServers.m.Unlock()
+	servers := make([]hrpc.ServerName, 0, len(c.liveServers.servers))
+	for _, server := range c.liveServers.servers {
+		servers = append(servers, server)
+	}
+	return servers
+}
+
+// regionServersChanged is called with the names of the znodes of the live
+// RegionServers every time they change.  The regions of the RegionServers that
+// went away are reconnected, and the RegionServers that started are connected
+// to if the client preconnects.
+func (c *client) regionServersChanged(names []string) {
+	servers := make(map[string]hrpc.ServerName, len(names))
+	for _, name := range names {
+		server, err := hrpc.ParseServerName(name)
+		if err != nil {
+			log.Warningf("Ignoring the znode of a RegionServer: %s", err)
+			continue
+		}
+		servers[serverAddr(server)] = server
+	}
+
+	c.liveServers.m.Lock()
+	old := c.liveServers.servers
+	c.liveServers.servers = servers
+	c.liveServers.m.Unlock()
+
+	for addr, server := range old {
+		// A RegionServer that restarted on the same address has a new
+		// start code, and the connection to its previous process is dead.
+		if servers[addr] != server {
+			log.Infof("RegionServer %s went away", server)
+			c.serverDown(server.Host, server.Port)
+		}
+	}
+	if !c.preconnect {
+		return
+	}
+	for addr, server := range servers {
+		if old[addr] != server {
+			go c.preconnectTo(server)
+		}
+	}
+}
+
+// serverDown closes the connection to the RegionServer at the given address,
+// if any, and reconnects the regions it served.
+func (c *client) serverDown(host string, port uint16) {
+	client, downregions := c.clients.serverDown(host, port)
+	if client != nil {
+		client.Close()
+	}
+	for _, reg := range downregions {
+		go c.reestablishRegion(reg)
+	}
+	reg := c.metaRegionInfo
+	if client = reg.GetClient(); client != nil && client.Host() == host &&
+		client.Port() == port {
+		if reg.MarkUnavailable() {
+			go c.reestablishRegion(reg)
+		}
+		client.Close()
+	}
+}
+
+// preconnectTo connects to the given RegionServer, unless it's connected to
+// already.
+func (c *client) preconnectTo(server hrpc.ServerName) {
+	ctx, cancel := context.WithTimeout(context.Background(), regionLookupTimeout)
+	defer cancel()
+	if _, err := c.regionClientFor(ctx, server.Host, server.Port); err != nil {
+		log.Warningf("Failed to connect to RegionServer %s: %s", server, err)
+	}
+}
+
+// serverAddr returns the address of the given RegionServer, like "host:port".
+func serverAddr(server hrpc.ServerName) string {
+	return net.JoinHostPort(server.Host, strconv.Itoa(int(server.Port)))
+}
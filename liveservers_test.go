@@ -0,0 +1,58 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"testing"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/region"
+)
+
+func TestRegionServersChanged(t *testing.T) {
+	c := newClient("~invalid.quorum~", WatchRegionServers(false))
+	defer c.Close()
+	rs1 := &fakeRegionClient{host: "rs1", port: 16020}
+	rs2 := &fakeRegionClient{host: "rs2", port: 16020}
+	reg1 := &region.Info{Table: []byte("test"), Name: []byte("test,,1"), StopKey: []byte("m")}
+	reg2 := &region.Info{Table: []byte("test"), Name: []byte("test,m,1"), StartKey: []byte("m")}
+	for _, r := range []struct {
+		reg    *region.Info
+		client hrpc.RegionClient
+	}{{reg1, rs1}, {reg2, rs2}} {
+		r.reg.SetClient(r.client)
+		c.regions.put(r.reg)
+		c.clients.put(r.reg, r.client)
+	}
+
+	c.regionServersChanged([]string{"rs1,16020,1", "rs2,16020,1", "invalid"})
+	if servers := c.LiveRegionServers(); len(servers) != 2 {
+		t.Fatalf("Expected 2 live RegionServers, got %v", servers)
+	}
+	if rs1.closed || rs2.closed || reg1.IsUnavailable() || reg2.IsUnavailable() {
+		t.Fatal("No RegionServer went away yet")
+	}
+
+	// rs2 restarts, so its connection is dead even though it's back.
+	c.regionServersChanged([]string{"rs1,16020,1", "rs2,16020,2"})
+	if rs1.closed || reg1.IsUnavailable() {
+		t.Error("rs1 didn't go away")
+	}
+	if !rs2.closed {
+		t.Error("The client of rs2 wasn't closed")
+	}
+	if !reg2.IsUnavailable() || reg2.GetClient() != nil {
+		t.Error("The region of rs2 isn't being reconnected")
+	}
+	if c.clients.checkForClient("rs2", 16020) != nil {
+		t.Error("The client of rs2 is still cached")
+	}
+	servers := c.LiveRegionServers()
+	expected := hrpc.ServerName{Host: "rs2", Port: 16020, StartCode: 2}
+	if len(servers) != 2 || (servers[0] != expected && servers[1] != expected) {
+		t.Errorf("Expected rs2 to have start code 2, got %v", servers)
+	}
+}
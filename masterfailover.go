@@ -0,0 +1,103 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+const (
+	// How long to back off at most before looking up the active master
+	// again, while it fails over.
+	masterBackoffMax = 5 * time.Second
+
+	// How many times an operation looks up the active master again before
+	// failing with the error of the last master that rejected it.
+	maxMasterFailovers = 10
+)
+
+// masterFailover is the backoff of the lookups of the active master, which
+// grows while the masters keep rejecting the RPCs during a failover.
+type masterFailover struct {
+	m sync.Mutex
+
+	backoff time.Duration
+}
+
+// next returns how long to back off before looking up the active master again,
+// according to the given backoff.
+func (mf *masterFailover) next(b Backoff) time.Duration {
+	mf.m.Lock()
+	defer mf.m.Unlock()
+	if mf.backoff == 0 {
+		mf.backoff = b.first(backoffStart)
+	} else if mf.backoff = b.next(mf.backoff); mf.backoff > masterBackoffMax {
+		mf.backoff = masterBackoffMax
+	}
+	return mf.backoff
+}
+
+// reset starts the backoff over, once the active master serves the RPCs.
+func (mf *masterFailover) reset() {
+	mf.m.Lock()
+	mf.backoff = 0
+	mf.m.Unlock()
+}
+
+// failover counts a failover of the master during the operation of the given
+// context, and returns false once the operation went through more than
+// maxMasterFailovers of them.
+func failover(ctx context.Context) bool {
+	a, ok := ctx.Value(attemptsKey{}).(*attempts)
+	if !ok {
+		return true
+	}
+	a.m.Lock()
+	defer a.m.Unlock()
+	a.failovers++
+	return a.failovers <= maxMasterFailovers
+}
+
+// isNotRunning returns true if the given error means that the server isn't
+// running, or isn't the active master.
+func isNotRunning(err error) bool {
+	_, ok := err.(region.ServerNotRunningError)
+	return ok
+}
+
+// masterNotRunning handles the given RPC of an admin client, rejected through
+// the given client by a master that isn't the active master or isn't
+// initialized yet: after backing off, the active master is looked up again in
+// ZooKeeper, or in the master registry, and the RPC is sent to it, up to
+// maxMasterFailovers times.
+func (c *client) masterNotRunning(ctx context.Context, rpc hrpc.Call,
+	client hrpc.RegionClient, err error) (proto.Message, error) {
+	if !failover(ctx) {
+		return nil, err
+	}
+	backoff := c.masterFailover.next(c.retry.Backoff)
+	log.Warningf("The master at %s:%d rejected a %s call, looking it up again in %s: %s",
+		client.Host(), client.Port(), rpc.GetName(), backoff, err)
+	select {
+	case <-time.After(c.retry.Backoff.jittered(backoff)):
+	case <-ctx.Done():
+		return nil, ErrDeadline
+	}
+	reg := c.adminRegionInfo
+	if reg.GetClient() == client && reg.MarkUnavailable() {
+		go c.reestablishRegion(reg)
+		// The RPCs still queued on the stale client fail and wait for
+		// the new one.
+		client.Close()
+	}
+	return c.waitOnRegion(ctx, reg)
+}
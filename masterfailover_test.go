@@ -0,0 +1,113 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+func TestMasterNotRunning(t *testing.T) {
+	c := newClient("", MasterRegistry("master"))
+	c.clientType = adminClient
+	defer c.Close()
+	// The registry points to an active master that can't be connected to,
+	// so that the admin region stays unavailable.
+	c.registry.client = &registryClient{
+		fakeRegionClient: fakeRegionClient{host: "master", port: defaultMasterPort},
+		respond: func(rpc hrpc.Call) hrpc.RPCResult {
+			return hrpc.RPCResult{Msg: &pb.GetActiveMasterResponse{
+				ServerName: &pb.ServerName{
+					HostName: proto.String("~invalid.master~"),
+					Port:     proto.Uint32(defaultMasterPort),
+				},
+			}}
+		},
+	}
+	backup := &registryClient{
+		fakeRegionClient: fakeRegionClient{host: "backup", port: defaultMasterPort},
+		respond: func(rpc hrpc.Call) hrpc.RPCResult {
+			return hrpc.RPCResult{Error: region.NewException(
+				"org.apache.hadoop.hbase.MasterNotRunningException", "")}
+		},
+	}
+	c.adminRegionInfo.SetClient(backup)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	lt, err := hrpc.NewListTableNames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.ListTableNames(lt); err != ErrDeadline {
+		t.Errorf("Expected the call to wait for the active master, got %v", err)
+	}
+	if !backup.closed {
+		t.Error("The connection to the backup master wasn't closed")
+	}
+	if !c.adminRegionInfo.IsUnavailable() {
+		t.Error("The active master isn't being looked up again")
+	}
+}
+
+func TestMaxMasterFailovers(t *testing.T) {
+	c := newClient("", MasterRegistry("master"))
+	c.clientType = adminClient
+	defer c.Close()
+	backup := &fakeRegionClient{host: "backup", port: defaultMasterPort}
+	c.adminRegionInfo.SetClient(backup)
+
+	ctx, cancel := c.operationContext(context.Background())
+	defer cancel()
+	for i := 0; i < maxMasterFailovers; i++ {
+		if !failover(ctx) {
+			t.Fatalf("Expected failover #%d to be allowed", i+1)
+		}
+	}
+	// Past the maximum, the operation fails right away with the error of
+	// the master, without looking it up again.
+	notRunning := region.NewException("org.apache.hadoop.hbase.MasterNotRunningException", "")
+	lt, err := hrpc.NewListTableNames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.masterNotRunning(ctx, lt, backup, notRunning); err != notRunning {
+		t.Errorf("Expected the error of the master, got %v", err)
+	}
+	if backup.closed || c.adminRegionInfo.IsUnavailable() {
+		t.Error("Expected the master not to be looked up again")
+	}
+}
+
+func TestMasterFailoverBackoff(t *testing.T) {
+	var mf masterFailover
+	if backoff := mf.next(Backoff{}); backoff != backoffStart {
+		t.Errorf("Expected a first backoff of %s, got %s", backoffStart, backoff)
+	}
+	if backoff := mf.next(Backoff{}); backoff != 2*backoffStart {
+		t.Errorf("Expected a second backoff of %s, got %s", 2*backoffStart, backoff)
+	}
+	for i := 0; i < 20; i++ {
+		mf.next(Backoff{})
+	}
+	if backoff := mf.next(Backoff{}); backoff != masterBackoffMax {
+		t.Errorf("Expected the backoff to be capped at %s, got %s", masterBackoffMax, backoff)
+	}
+	mf.reset()
+	if backoff := mf.next(Backoff{}); backoff != backoffStart {
+		t.Errorf("Expected the backoff to start over, got %s", backoff)
+	}
+	if isNotRunning(errors.New("some error")) {
+		t.Error("A plain error doesn't mean the master isn't running")
+	}
+}
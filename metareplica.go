@@ -0,0 +1,147 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/tsuna/gohbase/region"
+	"github.com/tsuna/gohbase/zk"
+	"golang.org/x/net/context"
+)
+
+var errNoMetaReplica = errors.New("the location of no replica of meta is known")
+
+// metaReplicas holds the locations of the replicas of meta, which are kept up
+// to date by watching their znodes once they're first needed.
+type metaReplicas struct {
+	m sync.Mutex
+
+	// Set once the znodes are watched.
+	watched bool
+
+	// The IDs of the replicas, primary first.
+	ids []uint32
+
+	// The locations of the replicas that were read from ZooKeeper.
+	locations map[uint32]ReplicaLocation
+}
+
+// UseMetaReplicas will return an option that makes the client look up the
+// regions in the secondary replicas of meta while its primary replica is
+// unavailable, e.g. while it moves.  The cluster must have
+// hbase.meta.replica.count > 1.  The regions looked up in a secondary replica
+// may be stale, in which case they're looked up again once their RegionServer
+// says it doesn't serve them.  The TIMELINE reads of hbase:meta are served by
+// its replicas regardless of this option.
+func UseMetaReplicas() Option {
+	return func(c *client) {
+		c.useMetaReplicas = true
+	}
+}
+
+// set sets the location of the given replica.
+func (mr *metaReplicas) set(id uint32, host string, port uint16) {
+	mr.m.Lock()
+	mr.locations[id] = ReplicaLocation{ReplicaID: id, Host: host, Port: port}
+	mr.m.Unlock()
+}
+
+// watchMetaReplicas starts watching the locations of the replicas of meta,
+// unless they're watched already.
+func (c *client) watchMetaReplicas(ctx context.Context) error {
+	c.metaReplicas.m.Lock()
+	watched := c.metaReplicas.watched
+	c.metaReplicas.m.Unlock()
+	if watched {
+		return nil
+	}
+	var ids []uint32
+	err := zkCall(ctx, func() error {
+		var err error
+		ids, err = c.zkSession.MetaReplicaIDs()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	c.metaReplicas.m.Lock()
+	defer c.metaReplicas.m.Unlock()
+	if c.metaReplicas.watched {
+		return nil
+	}
+	c.metaReplicas.watched = true
+	c.metaReplicas.ids = append([]uint32{0}, ids...)
+	c.metaReplicas.locations = make(map[uint32]ReplicaLocation, len(ids)+1)
+	for _, id := range c.metaReplicas.ids {
+		id := id
+		c.zkSession.WatchResource(zk.MetaReplica(id), func(host string, port uint16) {
+			c.metaReplicas.set(id, host, port)
+		})
+	}
+	return nil
+}
+
+// metaReplicaLocations returns the known locations of the replicas of meta,
+// primary first.  They're read from the master registry every time if the
+// client has one, since it can't be watched.
+func (c *client) metaReplicaLocations(ctx context.Context) ([]ReplicaLocation, error) {
+	if c.registry != nil {
+		locations, err := c.registryMetaLocations(ctx)
+		if err != nil {
+			return nil, err
+		}
+		sort.Sort(replicaLocations(locations))
+		return locations, nil
+	}
+	if err := c.watchMetaReplicas(ctx); err != nil {
+		return nil, err
+	}
+	c.metaReplicas.m.Lock()
+	defer c.metaReplicas.m.Unlock()
+	locations := make([]ReplicaLocation, 0, len(c.metaReplicas.ids))
+	for _, id := range c.metaReplicas.ids {
+		if loc, ok := c.metaReplicas.locations[id]; ok {
+			locations = append(locations, loc)
+		}
+		// Otherwise the watch didn't read the location yet.
+	}
+	return locations, nil
+}
+
+// replicaLocations sorts the locations of the replicas of a region by replica
+// ID, primary first.
+type replicaLocations []ReplicaLocation
+
+func (rl replicaLocations) Len() int           { return len(rl) }
+func (rl replicaLocations) Less(i, j int) bool { return rl[i].ReplicaID < rl[j].ReplicaID }
+func (rl replicaLocations) Swap(i, j int)      { rl[i], rl[j] = rl[j], rl[i] }
+
+// lookupMetaReplicas returns the replicas of meta whose location is known,
+// primary first.
+func (c *client) lookupMetaReplicas(ctx context.Context) ([]region.Replica, error) {
+	locations, err := c.metaReplicaLocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Not c.metaRegionInfo, whose client mustn't change.
+	meta := newMetaRegionInfo()
+	replicas := make([]region.Replica, 0, len(locations))
+	for _, loc := range locations {
+		replicas = append(replicas, region.Replica{
+			Info: meta.Replica(loc.ReplicaID),
+			Host: loc.Host,
+			Port: loc.Port,
+		})
+	}
+	if len(replicas) == 0 {
+		return nil, errNoMetaReplica
+	}
+	return replicas, nil
+}
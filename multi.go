@@ -0,0 +1,323 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+// maxBatchRetries is how many times the calls of a batch that failed with a
+// transient error are sent again, unless the client limits the attempts.
+const maxBatchRetries = 10
+
+// ConditionalMultis will return an option that packs the conditional mutations
+// of the batches in the Multi RPCs, along with the other calls for the regions
+// of the same RegionServer, rather than sending each of them in its own RPC.
+// This requires HBase 2.4 or later: the older RegionServers ignore the
+// conditions of the Multi RPCs, and apply the mutations even if their
+// condition doesn't hold.
+func ConditionalMultis() Option {
+	return func(c *client) {
+		c.conditionalMultis = true
+	}
+}
+
+// CheckAndPutBatch applies each of the given Puts if its condition is met,
+// like CheckAndPut, and sends them in parallel.  With the ConditionalMultis
+// option, which requires HBase 2.4 or later, all the conditional Puts for the
+// regions of the same RegionServer are sent in a single RPC.  It returns
+// whether each of the Puts was applied.  If some of them failed, the error is
+// a *BatchError.
+func (c *client) CheckAndPutBatch(ctx context.Context,
+	cas []*hrpc.CheckAndPut) ([]bool, error) {
+	calls := make([]hrpc.Call, len(cas))
+	for i, ca := range cas {
+		calls[i] = ca
+	}
+	return c.sendConditionalBatch(ctx, calls)
+}
+
+// CheckAndMutateBatch applies each of the given Puts or Deletes if its
+// condition holds, like CheckAndMutate, and sends them in parallel.  With the
+// ConditionalMultis option, which requires HBase 2.4 or later, all the
+// conditional mutations for the regions of the same RegionServer are sent in a
+// single RPC.  It returns whether each of the mutations was applied.  If some
+// of them failed, the error is a *BatchError.
+func (c *client) CheckAndMutateBatch(ctx context.Context,
+	cams []*hrpc.CheckAndMutate) ([]bool, error) {
+	calls := make([]hrpc.Call, len(cams))
+	for i, cam := range cams {
+		calls[i] = cam
+	}
+	return c.sendConditionalBatch(ctx, calls)
+}
+
+// sendConditionalBatch sends the given conditional mutations in a batch and
+// returns whether each of them was applied.
+func (c *client) sendConditionalBatch(ctx context.Context, calls []hrpc.Call) ([]bool, error) {
+	results, errs := c.sendBatch(ctx, calls)
+	processed := make([]bool, len(calls))
+	for i, res := range results {
+		processed[i] = res.Processed
+	}
+	return processed, newBatchError(errs)
+}
+
+// GetMulti retrieves the given rows of the given table, packing the Gets for
+// the regions of the same RegionServer in a single RPC.  The results are in
+// the same order as the keys.  If some of the Gets failed, their result is
+// nil and the error is a *BatchError.
+func (c *client) GetMulti(ctx context.Context, table []byte, keys [][]byte,
+	options ...func(hrpc.Call) error) ([]*hrpc.Result, error) {
+	calls := make([]hrpc.Call, len(keys))
+	for i, key := range keys {
+		get, err := hrpc.NewGet(ctx, table, key, options...)
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = get
+	}
+	results, errs := c.sendBatch(ctx, calls)
+	rows := make([]*hrpc.Result, len(keys))
+	for i, res := range results {
+		if errs[i] == nil {
+			rows[i] = getResult(calls[i].(*hrpc.Get), res.Result)
+		}
+	}
+	return rows, newBatchError(errs)
+}
+
+// Batch sends the given Gets, mutations, conditional mutations and
+// RowMutations, possibly for different tables and regions, packing the calls
+// for the regions of the same RegionServer in a single Multi RPC.  It returns
+// the result of each call, in the same order as the calls.  If some of the
+// calls failed, their result is nil and the error is a *BatchError.  The
+// conditional mutations are each sent in their own RPC, unless the
+// ConditionalMultis option, which requires HBase 2.4 or later, is set.  Whether
+// a conditional mutation was applied isn't returned, use CheckAndPutBatch for
+// that.
+func (c *client) Batch(ctx context.Context, calls []hrpc.Call) ([]*hrpc.Result, error) {
+	for _, call := range calls {
+		switch call.(type) {
+		case *hrpc.Get, *hrpc.Mutate, *hrpc.CheckAndPut, *hrpc.CheckAndMutate,
+			*hrpc.RowMutations:
+		default:
+			return nil, fmt.Errorf("%s calls can't be sent in a batch", call.GetName())
+		}
+	}
+	results, errs := c.sendBatch(ctx, calls)
+	rows := make([]*hrpc.Result, len(calls))
+	for i, res := range results {
+		if errs[i] != nil {
+			continue
+		}
+		if get, ok := calls[i].(*hrpc.Get); ok {
+			rows[i] = getResult(get, res.Result)
+		} else {
+			rows[i] = hrpc.ToLocalResult(res.Result)
+		}
+	}
+	return rows, newBatchError(errs)
+}
+
+// sendBatch sends the given calls to their regions, packing the calls for the
+// regions of the same RegionServer in a single Multi RPC, except for the
+// conditional mutations, which are each sent in their own RPC without the
+// ConditionalMultis option.  The calls that fail because their region moved or
+// their RegionServer went away are sent again until the context is done, as
+// well as the ones their RegionServer pushed back unless they must be shed, up
+// to maxBatchRetries times unless the client limits the attempts itself.  It
+// returns the outcome of each call.
+func (c *client) sendBatch(ctx context.Context,
+	calls []hrpc.Call) ([]hrpc.MultiResult, []error) {
+	results := make([]hrpc.MultiResult, len(calls))
+	errs := make([]error, len(calls))
+	pending := make([]int, 0, len(calls))
+	for i, call := range calls {
+		if err := c.checkWritable(call); err != nil {
+			errs[i] = err
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+	backoff := c.backoffStart()
+	for retries := 0; ; retries++ {
+		if err := c.attempt(ctx); err != nil {
+			for _, i := range pending {
+				errs[i] = err
+			}
+			return results, errs
+		}
+		byClient := make(map[hrpc.RegionClient][]int)
+		var single []int
+		for _, i := range pending {
+			find := c.findRegion
+			if c.bypassRegionCache(calls[i]) {
+				find = c.findRegionUncached
+			}
+			reg, err := find(ctx, calls[i].Table(), calls[i].Key())
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			calls[i].SetRegion(reg)
+			client := reg.GetClient()
+			if client == nil {
+				// The region went down again (or its client was closed).
+				errs[i] = errNoClient
+				continue
+			}
+			if isConditional(calls[i]) && !c.conditionalMultis {
+				single = append(single, i)
+				continue
+			}
+			byClient[client] = append(byClient[client], i)
+		}
+
+		var wg sync.WaitGroup
+		waits := make([]time.Duration, 0, len(byClient)+len(single))
+		for _, i := range single {
+			wg.Add(1)
+			waits = append(waits, 0)
+			go func(i int, wait *time.Duration) {
+				defer wg.Done()
+				*wait = c.sendConditional(ctx, calls[i], &results[i], &errs[i])
+			}(i, &waits[len(waits)-1])
+		}
+		for client, indexes := range byClient {
+			wg.Add(1)
+			waits = append(waits, 0)
+			go func(client hrpc.RegionClient, indexes []int, wait *time.Duration) {
+				defer wg.Done()
+				*wait = c.sendMulti(ctx, client, calls, indexes, results, errs)
+			}(client, indexes, &waits[len(waits)-1])
+		}
+		wg.Wait()
+
+		var retry []int
+		for _, i := range pending {
+			if isOverloaded(errs[i]) && c.overload.shedCall(calls[i]) {
+				errs[i] = ErrServerOverloaded
+			} else if isRetryableError(errs[i]) && errs[i] != ErrDeadline &&
+				errs[i] != ErrMaxAttempts {
+				retry = append(retry, i)
+			}
+		}
+		if len(retry) == 0 || c.maxAttempts == 0 && retries == maxBatchRetries {
+			// The calls still failing keep their last error, which
+			// tells that they can be sent again.
+			return results, errs
+		}
+		for _, wait := range waits {
+			if wait > backoff {
+				// A RegionServer pushed back, back off longer.
+				backoff = wait
+			}
+		}
+		var err error
+		backoff, err = c.sleepAndIncreaseBackoff(ctx, backoff)
+		if err != nil {
+			for _, i := range retry {
+				errs[i] = err
+			}
+			return results, errs
+		}
+		pending = retry
+	}
+}
+
+// isConditional returns true if the given call is a conditional mutation.
+func isConditional(call hrpc.Call) bool {
+	switch call.(type) {
+	case *hrpc.CheckAndPut, *hrpc.CheckAndMutate:
+		return true
+	}
+	return false
+}
+
+// sendConditional sends the given conditional mutation of a batch in its own
+// Mutate RPC, and stores its outcome in the given result and error.  It returns
+// how long to back off if the RegionServer pushed back.
+func (c *client) sendConditional(ctx context.Context, call hrpc.Call,
+	result *hrpc.MultiResult, err *error) time.Duration {
+	reg := call.GetRegion()
+	client := reg.GetClient()
+	msg, e := c.sendRPCDirect(ctx, call, reg)
+	*err = e
+	if e == nil {
+		resp := msg.(*pb.MutateResponse)
+		*result = hrpc.MultiResult{Result: resp.Result, Processed: resp.GetProcessed()}
+	} else if _, ok := e.(region.RetryableError); ok {
+		// The region isn't served by this RegionServer anymore.
+		if reg.MarkUnavailable() {
+			go c.reestablishRegion(reg)
+		}
+		c.clients.del(reg)
+	} else if isOverloaded(e) {
+		return c.overload.pushedBack(client, 1, time.Now())
+	}
+	return 0
+}
+
+// sendMulti sends the calls at the given indexes, which must all be for the
+// regions of the given RegionServer, in a single Multi RPC.  It returns how
+// long to back off if the RegionServer pushed back.
+func (c *client) sendMulti(ctx context.Context, client hrpc.RegionClient, calls []hrpc.Call,
+	indexes []int, results []hrpc.MultiResult, errs []error) time.Duration {
+	multi := hrpc.NewMulti(ctx)
+	for _, i := range indexes {
+		multi.Add(calls[i])
+	}
+	msg, err := c.sendRPCDirect(ctx, multi, calls[indexes[0]].GetRegion())
+	var multiResults []hrpc.MultiResult
+	if err == nil {
+		multiResults, err = multi.Results(msg.(*pb.MultiResponse))
+	}
+	if err != nil {
+		for _, i := range indexes {
+			errs[i] = err
+		}
+		if isOverloaded(err) {
+			return c.overload.pushedBack(client, len(indexes), time.Now())
+		}
+		return 0
+	}
+
+	overloaded := 0
+	for k, i := range indexes {
+		res := multiResults[k]
+		results[i] = res
+		errs[i] = nil
+		if res.Exception == nil {
+			continue
+		}
+		errs[i] = region.NewException(res.Exception.GetName(), string(res.Exception.Value))
+		if _, ok := errs[i].(region.RetryableError); ok {
+			// The region isn't served by this RegionServer anymore.
+			reg := calls[i].GetRegion()
+			if reg.MarkUnavailable() {
+				go c.reestablishRegion(reg)
+			}
+			c.clients.del(reg)
+		} else if isOverloaded(errs[i]) {
+			overloaded++
+		}
+	}
+	if overloaded != 0 {
+		return c.overload.pushedBack(client, overloaded, time.Now())
+	}
+	return 0
+}
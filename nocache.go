@@ -0,0 +1,98 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"bytes"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+// NoRegionCache will return an option that makes the client look up the
+// region of every call in meta, instead of using its region cache.  This is
+// slow, and only meant to rule out the cache when debugging or verifying
+// data.  See also the hrpc.NoRegionCache option to do this for some calls
+// only.
+func NoRegionCache() Option {
+	return func(c *client) {
+		c.noRegionCache = true
+	}
+}
+
+// bypassRegionCache returns true if the region of the given RPC must be looked
+// up in meta rather than in the cache.
+func (c *client) bypassRegionCache(rpc hrpc.Call) bool {
+	if c.clientType != standardClient || bytes.Equal(rpc.Table(), metaTableName) {
+		return false
+	}
+	if c.noRegionCache {
+		return true
+	}
+	r, ok := rpc.(interface {
+		GetNoRegionCache() bool
+	})
+	return ok && r.GetNoRegionCache()
+}
+
+// findRegionUncached looks up in meta the region that hosts the given row key
+// of the given table, without using or populating the region cache, and
+// connects to its RegionServer unless there's already a connection to it.
+func (c *client) findRegionUncached(ctx context.Context,
+	table, key []byte) (hrpc.RegionInfo, error) {
+	backoff := c.backoffStart()
+	for {
+		reg, host, port, err := c.locateRegion(ctx, table, key)
+		if err == nil {
+			var client hrpc.RegionClient
+			client, err = c.regionClientFor(ctx, host, port)
+			if err == nil {
+				reg.SetClient(client)
+				return reg, nil
+			}
+		}
+		if err == TableNotFound || err == ErrDeadline || err == ErrCallTimeout {
+			return nil, err
+		}
+		if backoff, err = c.sleepAndIncreaseBackoff(ctx, backoff); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sendUncachedRPC sends the given RPC to its region, looking up the region in
+// meta every time the RPC is sent again.
+func (c *client) sendUncachedRPC(ctx context.Context, rpc hrpc.Call) (proto.Message, error) {
+	backoff := c.backoffStart()
+	for {
+		reg, err := c.findRegionUncached(ctx, rpc.Table(), rpc.Key())
+		if err != nil {
+			return nil, err
+		}
+		if err := c.attempt(ctx); err != nil {
+			return nil, err
+		}
+		msg, err := c.sendRPCDirect(ctx, rpc, reg)
+		switch err.(type) {
+		case region.RetryableError, region.ServerOverloadedError:
+		case region.UnrecoverableError:
+			// The connection is dead, the cached regions it served must
+			// be reestablished too.
+			for _, down := range c.clients.clientDown(reg) {
+				go c.reestablishRegion(down)
+			}
+		default:
+			if err != errNoClient {
+				return msg, err
+			}
+		}
+		if backoff, err = c.sleepAndIncreaseBackoff(ctx, backoff); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+// ErrServerOverloaded is returned for the calls shed by the ShedOnOverload
+// option because their RegionServer pushed back.
+var ErrServerOverloaded = errors.New("RegionServer overloaded, call shed")
+
+const (
+	// How long to back off after a first pushback of a RegionServer.  This
+	// is more than for other errors, since sending the calls again right
+	// away would only make the overload worse.
+	overloadBackoffStart = 250 * time.Millisecond
+
+	// How long to back off at most after pushbacks of a RegionServer.
+	overloadBackoffMax = 10 * time.Second
+
+	// How long after its last pushback a RegionServer is considered to have
+	// recovered, its backoff starting over.
+	overloadRecovery = 30 * time.Second
+
+	// How many times an RPC is sent again to a RegionServer that keeps
+	// pushing back, after which it fails with the last pushback.
+	maxPushbackRetries = 10
+)
+
+// OverloadStats counts the pushbacks of the RegionServers.
+type OverloadStats struct {
+	// Pushbacks is the number of calls rejected by a RegionServer because
+	// its call queue was full.
+	Pushbacks int
+
+	// Shed is the number of those calls that were failed with
+	// ErrServerOverloaded instead of being sent again.
+	Shed int
+}
+
+// ShedOnOverload will return an option that fails the calls for which shed
+// returns true with ErrServerOverloaded, instead of sending them again after
+// backing off, when their RegionServer pushes back because its call queue is
+// full.  This lets the lower-priority calls give way to the other ones.
+func ShedOnOverload(shed func(hrpc.Call) bool) Option {
+	return func(c *client) {
+		c.overload.shed = shed
+	}
+}
+
+// overloadTracker keeps track of the pushbacks of the RegionServers.
+type overloadTracker struct {
+	m sync.Mutex
+
+	// The current backoff and last pushback of each RegionServer that
+	// pushed back, by host:port.
+	servers map[string]*pushback
+
+	stats OverloadStats
+
+	// Whether a call must be shed when its RegionServer pushes back, if set.
+	shed func(hrpc.Call) bool
+
+	// The backoff after the pushbacks, if set by the retry policy.
+	backoff Backoff
+}
+
+type pushback struct {
+	backoff time.Duration
+	last    time.Time
+}
+
+// pushedBack records that the given RegionServer pushed back the given
+// number of calls, and returns how long to back off before sending them
+// there again.
+func (o *overloadTracker) pushedBack(rc hrpc.RegionClient, calls int,
+	now time.Time) time.Duration {
+	addr := "?"
+	if rc != nil {
+		addr = net.JoinHostPort(rc.Host(), strconv.Itoa(int(rc.Port())))
+	}
+	o.m.Lock()
+	defer o.m.Unlock()
+	o.stats.Pushbacks += calls
+	if o.servers == nil {
+		o.servers = make(map[string]*pushback)
+	}
+	p, ok := o.servers[addr]
+	if !ok || now.Sub(p.last) > overloadRecovery {
+		p = &pushback{backoff: o.backoff.first(overloadBackoffStart)}
+		o.servers[addr] = p
+	} else if o.backoff.Max == 0 && o.backoff.Multiplier == 0 {
+		p.backoff *= 2
+		if p.backoff > overloadBackoffMax {
+			p.backoff = overloadBackoffMax
+		}
+	} else {
+		p.backoff = o.backoff.next(p.backoff)
+	}
+	p.last = now
+	return o.backoff.jittered(p.backoff)
+}
+
+// shedCall returns true if the given call, which was pushed back, must be
+// failed with ErrServerOverloaded instead of being sent again.
+func (o *overloadTracker) shedCall(rpc hrpc.Call) bool {
+	if o.shed == nil || !o.shed(rpc) {
+		return false
+	}
+	o.m.Lock()
+	o.stats.Shed++
+	o.m.Unlock()
+	return true
+}
+
+// OverloadStats returns the number of pushbacks of the RegionServers and of
+// calls shed because of them.
+func (c *client) OverloadStats() OverloadStats {
+	c.overload.m.Lock()
+	defer c.overload.m.Unlock()
+	return c.overload.stats
+}
+
+// backOffOverloaded records that the RegionServer of the given region pushed
+// back the given call, and waits before the call can be sent again.  It
+// returns ErrServerOverloaded if the call must be shed instead.
+func (c *client) backOffOverloaded(ctx context.Context, rpc hrpc.Call,
+	reg hrpc.RegionInfo, err error) error {
+	backoff := c.overload.pushedBack(reg.GetClient(), 1, time.Now())
+	if c.overload.shedCall(rpc) {
+		return ErrServerOverloaded
+	}
+	log.Warningf("%s pushed back a %s call, backing off for %s: %s",
+		reg, rpc.GetName(), backoff, err)
+	select {
+	case <-time.After(backoff):
+		return nil
+	case <-ctx.Done():
+		return ErrDeadline
+	}
+}
+
+// isOverloaded returns true if the given error is a pushback of an
+// overloaded RegionServer.
+func isOverloaded(err error) bool {
+	_, ok := err.(region.ServerOverloadedError)
+	return ok
+}
@@ -0,0 +1,93 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+func TestOverloadTracker(t *testing.T) {
+	get, err := hrpc.NewGetStr(context.Background(), "test", "row")
+	if err != nil {
+		t.Fatal(err)
+	}
+	put, err := hrpc.NewPutStr(context.Background(), "test", "row", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newClient("~invalid.quorum~", ShedOnOverload(func(rpc hrpc.Call) bool {
+		_, ok := rpc.(*hrpc.Get)
+		return ok
+	}))
+
+	now := time.Now()
+	expected := []time.Duration{
+		overloadBackoffStart, 2 * overloadBackoffStart, 4 * overloadBackoffStart,
+	}
+	for i, backoff := range expected {
+		if b := c.overload.pushedBack(nil, 2, now.Add(time.Duration(i)*time.Second)); b != backoff {
+			t.Errorf("Expected pushback #%d to back off for %s, got %s", i, backoff, b)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		c.overload.pushedBack(nil, 1, now)
+	}
+	if b := c.overload.pushedBack(nil, 1, now); b != overloadBackoffMax {
+		t.Errorf("Expected to back off for at most %s, got %s", overloadBackoffMax, b)
+	}
+	later := now.Add(overloadRecovery + time.Second)
+	if b := c.overload.pushedBack(nil, 1, later); b != overloadBackoffStart {
+		t.Errorf("Expected the backoff to start over after recovery, got %s", b)
+	}
+
+	if !c.overload.shedCall(get) {
+		t.Error("Expected the Get to be shed")
+	}
+	if c.overload.shedCall(put) {
+		t.Error("Expected the Put not to be shed")
+	}
+	expectedStats := OverloadStats{Pushbacks: 18, Shed: 1}
+	if stats := c.OverloadStats(); stats != expectedStats {
+		t.Errorf("Expected %+v, got %+v", expectedStats, stats)
+	}
+}
+
+func TestPushbackRetries(t *testing.T) {
+	c := newClient("~invalid.quorum~", SetRetryPolicy(RetryPolicy{
+		Overrides: map[ErrorClass]ClassPolicy{
+			OverloadedError: {Backoff: Backoff{Start: time.Microsecond, Max: time.Microsecond}},
+		},
+	}))
+	var sent int32
+	pushback := region.NewException("org.apache.hadoop.hbase.CallQueueTooBigException", "")
+	rs := &registryClient{
+		fakeRegionClient: fakeRegionClient{host: "rs", port: 16020},
+		respond: func(rpc hrpc.Call) hrpc.RPCResult {
+			atomic.AddInt32(&sent, 1)
+			return hrpc.RPCResult{Error: pushback}
+		},
+	}
+	cacheTestRegions(c, rs, rs, rs)
+
+	// Even without a limit of attempts, the Get isn't sent forever to a
+	// RegionServer that keeps pushing back.
+	get, err := hrpc.NewGetStr(context.Background(), "test", "row")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Get(get); err != pushback {
+		t.Errorf("Expected the last pushback, got %v", err)
+	}
+	if n := atomic.LoadInt32(&sent); n != maxPushbackRetries+1 {
+		t.Errorf("Expected the Get to be sent %d times, got %d", maxPushbackRetries+1, n)
+	}
+}
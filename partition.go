@@ -0,0 +1,104 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"bytes"
+	"net"
+	"sort"
+	"strconv"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"golang.org/x/net/context"
+)
+
+// RegionPartition is a group of keys served by the same region.
+type RegionPartition struct {
+	// Region is the region serving the keys, as currently known by the client.
+	Region hrpc.RegionInfo
+
+	// Keys are the keys served by the region, in the order they were given.
+	Keys [][]byte
+
+	// Indexes are the positions of the keys in the keys that were given.
+	Indexes []int
+}
+
+// ServerPartition is a group of keys served by the same RegionServer.
+type ServerPartition struct {
+	// Host and Port of the RegionServer.  Host is empty for the keys whose
+	// region was just found to be unavailable again.
+	Host string
+	Port uint16
+
+	// Regions are the regions of the RegionServer serving the keys, ordered
+	// by start key.
+	Regions []*RegionPartition
+}
+
+// PartitionKeys groups the given keys of the given table by region and by
+// RegionServer, according to the regions currently known by the client and
+// looking up in meta those that aren't, so that applications doing their own
+// batching can align their work with the topology of the cluster.  The
+// RegionServers are ordered by host and port.  Since regions can move or
+// split at any time, the partitioning is only a hint.
+func (c *client) PartitionKeys(ctx context.Context, table []byte,
+	keys [][]byte) ([]*ServerPartition, error) {
+	byRegion := make(map[hrpc.RegionInfo]*RegionPartition)
+	byServer := make(map[string]*ServerPartition)
+	for i, key := range keys {
+		reg, err := c.findRegion(ctx, table, key)
+		if err != nil {
+			return nil, err
+		}
+		rp, ok := byRegion[reg]
+		if !ok {
+			rp = &RegionPartition{Region: reg}
+			byRegion[reg] = rp
+			var host string
+			var port uint16
+			if client := reg.GetClient(); client != nil {
+				host, port = client.Host(), client.Port()
+			}
+			addr := net.JoinHostPort(host, strconv.Itoa(int(port)))
+			sp, ok := byServer[addr]
+			if !ok {
+				sp = &ServerPartition{Host: host, Port: port}
+				byServer[addr] = sp
+			}
+			sp.Regions = append(sp.Regions, rp)
+		}
+		rp.Keys = append(rp.Keys, key)
+		rp.Indexes = append(rp.Indexes, i)
+	}
+
+	servers := make([]*ServerPartition, 0, len(byServer))
+	for _, sp := range byServer {
+		sort.Sort(regionPartitions(sp.Regions))
+		servers = append(servers, sp)
+	}
+	sort.Sort(serverPartitions(servers))
+	return servers, nil
+}
+
+type regionPartitions []*RegionPartition
+
+func (p regionPartitions) Len() int      { return len(p) }
+func (p regionPartitions) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p regionPartitions) Less(i, j int) bool {
+	return bytes.Compare(p[i].Region.GetStartKey(), p[j].Region.GetStartKey()) < 0
+}
+
+type serverPartitions []*ServerPartition
+
+func (p serverPartitions) Len() int      { return len(p) }
+func (p serverPartitions) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p serverPartitions) Less(i, j int) bool {
+	if p[i].Host != p[j].Host {
+		return p[i].Host < p[j].Host
+	}
+	return p[i].Port < p[j].Port
+}
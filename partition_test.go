@@ -0,0 +1,76 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+// fakeRegionClient is a RegionClient that doesn't connect anywhere.
+type fakeRegionClient struct {
+	host   string
+	port   uint16
+	closed bool
+}
+
+func (rc *fakeRegionClient) Close()                       { rc.closed = true }
+func (rc *fakeRegionClient) Host() string                 { return rc.host }
+func (rc *fakeRegionClient) Port() uint16                 { return rc.port }
+func (rc *fakeRegionClient) QueueRPC(rpc hrpc.Call) error { return nil }
+
+func TestPartitionKeys(t *testing.T) {
+	c := newClient("~invalid.quorum~")
+	rs1 := &fakeRegionClient{host: "rs1", port: 16020}
+	rs2 := &fakeRegionClient{host: "rs2", port: 16020}
+	regions := []*region.Info{
+		{Table: []byte("test"), Name: []byte("test,,1"), StopKey: []byte("f")},
+		{Table: []byte("test"), Name: []byte("test,f,1"), StartKey: []byte("f"),
+			StopKey: []byte("m")},
+		{Table: []byte("test"), Name: []byte("test,m,1"), StartKey: []byte("m")},
+	}
+	for i, reg := range regions {
+		client := hrpc.RegionClient(rs1)
+		if i == 1 {
+			client = rs2
+		}
+		reg.SetClient(client)
+		c.regions.put(reg)
+		c.clients.put(reg, client)
+	}
+
+	keys := [][]byte{[]byte("z"), []byte("g"), []byte("a"), []byte("n"), []byte("b")}
+	servers, err := c.PartitionKeys(context.Background(), []byte("test"), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 2 || servers[0].Host != "rs1" || servers[1].Host != "rs2" {
+		t.Fatalf("Expected the keys of rs1 and rs2, got %#v", servers)
+	}
+	type partition struct {
+		region  string
+		keys    [][]byte
+		indexes []int
+	}
+	var got []partition
+	for _, sp := range servers {
+		for _, rp := range sp.Regions {
+			got = append(got, partition{string(rp.Region.GetName()), rp.Keys, rp.Indexes})
+		}
+	}
+	expected := []partition{
+		{"test,,1", [][]byte{[]byte("a"), []byte("b")}, []int{2, 4}},
+		{"test,m,1", [][]byte{[]byte("z"), []byte("n")}, []int{0, 3}},
+		{"test,f,1", [][]byte{[]byte("g")}, []int{1}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected partitions %v, got %v", expected, got)
+	}
+}
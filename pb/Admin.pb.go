@@ -0,0 +1,247 @@
+// Code generated by protoc-gen-go.
+// source: Admin.proto
+// DO NOT EDIT!
+
+package pb
+
+import proto "github.com/golang/protobuf/proto"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = math.Inf
+
+type GetRegionInfoResponse_CompactionState int32
+
+const (
+	GetRegionInfoResponse_NONE            GetRegionInfoResponse_CompactionState = 0
+	GetRegionInfoResponse_MINOR           GetRegionInfoResponse_CompactionState = 1
+	GetRegionInfoResponse_MAJOR           GetRegionInfoResponse_CompactionState = 2
+	GetRegionInfoResponse_MAJOR_AND_MINOR GetRegionInfoResponse_CompactionState = 3
+)
+
+var GetRegionInfoResponse_CompactionState_name = map[int32]string{
+	0: "NONE",
+	1: "MINOR",
+	2: "MAJOR",
+	3: "MAJOR_AND_MINOR",
+}
+var GetRegionInfoResponse_CompactionState_value = map[string]int32{
+	"NONE":            0,
+	"MINOR":           1,
+	"MAJOR":           2,
+	"MAJOR_AND_MINOR": 3,
+}
+
+func (x GetRegionInfoResponse_CompactionState) Enum() *GetRegionInfoResponse_CompactionState {
+	p := new(GetRegionInfoResponse_CompactionState)
+	*p = x
+	return p
+}
+func (x GetRegionInfoResponse_CompactionState) String() string {
+	return proto.EnumName(GetRegionInfoResponse_CompactionState_name, int32(x))
+}
+func (x *GetRegionInfoResponse_CompactionState) UnmarshalJSON(data []byte) error {
+	value, err := proto.UnmarshalJSONEnum(GetRegionInfoResponse_CompactionState_value, data, "GetRegionInfoResponse_CompactionState")
+	if err != nil {
+		return err
+	}
+	*x = GetRegionInfoResponse_CompactionState(value)
+	return nil
+}
+
+type GetRegionInfoRequest struct {
+	Region           *RegionSpecifier `protobuf:"bytes,1,req,name=region" json:"region,omitempty"`
+	CompactionState  *bool            `protobuf:"varint,2,opt,name=compaction_state" json:"compaction_state,omitempty"`
+	XXX_unrecognized []byte           `json:"-"`
+}
+
+func (m *GetRegionInfoRequest) Reset()         { *m = GetRegionInfoRequest{} }
+func (m *GetRegionInfoRequest) String() string { return proto.CompactTextString(m) }
+func (*GetRegionInfoRequest) ProtoMessage()    {}
+
+func (m *GetRegionInfoRequest) GetRegion() *RegionSpecifier {
+	if m != nil {
+		return m.Region
+	}
+	return nil
+}
+
+func (m *GetRegionInfoRequest) GetCompactionState() bool {
+	if m != nil && m.CompactionState != nil {
+		return *m.CompactionState
+	}
+	return false
+}
+
+type GetRegionInfoResponse struct {
+	RegionInfo       *RegionInfo                            `protobuf:"bytes,1,req,name=region_info" json:"region_info,omitempty"`
+	CompactionState  *GetRegionInfoResponse_CompactionState `protobuf:"varint,2,opt,name=compaction_state,enum=pb.GetRegionInfoResponse_CompactionState" json:"compaction_state,omitempty"`
+	IsRecovering     *bool                                  `protobuf:"varint,3,opt,name=isRecovering" json:"isRecovering,omitempty"`
+	XXX_unrecognized []byte                                 `json:"-"`
+}
+
+func (m *GetRegionInfoResponse) Reset()         { *m = GetRegionInfoResponse{} }
+func (m *GetRegionInfoResponse) String() string { return proto.CompactTextString(m) }
+func (*GetRegionInfoResponse) ProtoMessage()    {}
+
+func (m *GetRegionInfoResponse) GetRegionInfo() *RegionInfo {
+	if m != nil {
+		return m.RegionInfo
+	}
+	return nil
+}
+
+func (m *GetRegionInfoResponse) GetCompactionState() GetRegionInfoResponse_CompactionState {
+	if m != nil && m.CompactionState != nil {
+		return *m.CompactionState
+	}
+	return GetRegionInfoResponse_NONE
+}
+
+func (m *GetRegionInfoResponse) GetIsRecovering() bool {
+	if m != nil && m.IsRecovering != nil {
+		return *m.IsRecovering
+	}
+	return false
+}
+
+type SplitRegionRequest struct {
+	Region           *RegionSpecifier `protobuf:"bytes,1,req,name=region" json:"region,omitempty"`
+	SplitPoint       []byte           `protobuf:"bytes,2,opt,name=split_point" json:"split_point,omitempty"`
+	XXX_unrecognized []byte           `json:"-"`
+}
+
+func (m *SplitRegionRequest) Reset()         { *m = SplitRegionRequest{} }
+func (m *SplitRegionRequest) String() string { return proto.CompactTextString(m) }
+func (*SplitRegionRequest) ProtoMessage()    {}
+
+func (m *SplitRegionRequest) GetRegion() *RegionSpecifier {
+	if m != nil {
+		return m.Region
+	}
+	return nil
+}
+
+func (m *SplitRegionRequest) GetSplitPoint() []byte {
+	if m != nil {
+		return m.SplitPoint
+	}
+	return nil
+}
+
+type SplitRegionResponse struct {
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *SplitRegionResponse) Reset()         { *m = SplitRegionResponse{} }
+func (m *SplitRegionResponse) String() string { return proto.CompactTextString(m) }
+func (*SplitRegionResponse) ProtoMessage()    {}
+
+type CompactRegionRequest struct {
+	Region           *RegionSpecifier `protobuf:"bytes,1,req,name=region" json:"region,omitempty"`
+	Major            *bool            `protobuf:"varint,2,opt,name=major" json:"major,omitempty"`
+	Family           []byte           `protobuf:"bytes,3,opt,name=family" json:"family,omitempty"`
+	XXX_unrecognized []byte           `json:"-"`
+}
+
+func (m *CompactRegionRequest) Reset()         { *m = CompactRegionRequest{} }
+func (m *CompactRegionRequest) String() string { return proto.CompactTextString(m) }
+func (*CompactRegionRequest) ProtoMessage()    {}
+
+func (m *CompactRegionRequest) GetRegion() *RegionSpecifier {
+	if m != nil {
+		return m.Region
+	}
+	return nil
+}
+
+func (m *CompactRegionRequest) GetMajor() bool {
+	if m != nil && m.Major != nil {
+		return *m.Major
+	}
+	return false
+}
+
+func (m *CompactRegionRequest) GetFamily() []byte {
+	if m != nil {
+		return m.Family
+	}
+	return nil
+}
+
+type CompactRegionResponse struct {
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *CompactRegionResponse) Reset()         { *m = CompactRegionResponse{} }
+func (m *CompactRegionResponse) String() string { return proto.CompactTextString(m) }
+func (*CompactRegionResponse) ProtoMessage()    {}
+
+type FlushRegionRequest struct {
+	Region              *RegionSpecifier `protobuf:"bytes,1,req,name=region" json:"region,omitempty"`
+	IfOlderThanTs       *uint64          `protobuf:"varint,2,opt,name=if_older_than_ts" json:"if_older_than_ts,omitempty"`
+	WriteFlushWalMarker *bool            `protobuf:"varint,3,opt,name=write_flush_wal_marker" json:"write_flush_wal_marker,omitempty"`
+	XXX_unrecognized    []byte           `json:"-"`
+}
+
+func (m *FlushRegionRequest) Reset()         { *m = FlushRegionRequest{} }
+func (m *FlushRegionRequest) String() string { return proto.CompactTextString(m) }
+func (*FlushRegionRequest) ProtoMessage()    {}
+
+func (m *FlushRegionRequest) GetRegion() *RegionSpecifier {
+	if m != nil {
+		return m.Region
+	}
+	return nil
+}
+
+func (m *FlushRegionRequest) GetIfOlderThanTs() uint64 {
+	if m != nil && m.IfOlderThanTs != nil {
+		return *m.IfOlderThanTs
+	}
+	return 0
+}
+
+func (m *FlushRegionRequest) GetWriteFlushWalMarker() bool {
+	if m != nil && m.WriteFlushWalMarker != nil {
+		return *m.WriteFlushWalMarker
+	}
+	return false
+}
+
+type FlushRegionResponse struct {
+	LastFlushTime       *uint64 `protobuf:"varint,1,req,name=last_flush_time" json:"last_flush_time,omitempty"`
+	Flushed             *bool   `protobuf:"varint,2,opt,name=flushed" json:"flushed,omitempty"`
+	WroteFlushWalMarker *bool   `protobuf:"varint,3,opt,name=wrote_flush_wal_marker" json:"wrote_flush_wal_marker,omitempty"`
+	XXX_unrecognized    []byte  `json:"-"`
+}
+
+func (m *FlushRegionResponse) Reset()         { *m = FlushRegionResponse{} }
+func (m *FlushRegionResponse) String() string { return proto.CompactTextString(m) }
+func (*FlushRegionResponse) ProtoMessage()    {}
+
+func (m *FlushRegionResponse) GetLastFlushTime() uint64 {
+	if m != nil && m.LastFlushTime != nil {
+		return *m.LastFlushTime
+	}
+	return 0
+}
+
+func (m *FlushRegionResponse) GetFlushed() bool {
+	if m != nil && m.Flushed != nil {
+		return *m.Flushed
+	}
+	return false
+}
+
+func (m *FlushRegionResponse) GetWroteFlushWalMarker() bool {
+	if m != nil && m.WroteFlushWalMarker != nil {
+		return *m.WroteFlushWalMarker
+	}
+	return false
+}
+
+func init() {
+	proto.RegisterEnum("pb.GetRegionInfoResponse_CompactionState", GetRegionInfoResponse_CompactionState_name, GetRegionInfoResponse_CompactionState_value)
+}
@@ -15,6 +15,7 @@ It is generated from these files:
 	Filter.proto
 	FS.proto
 	HBase.proto
+	MapReduce.proto
 	Master.proto
 	MultiRowMutation.proto
 	Quota.proto
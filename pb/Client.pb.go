@@ -248,9 +248,10 @@ type Get struct {
 	ExistenceOnly *bool `protobuf:"varint,10,opt,name=existence_only,def=0" json:"existence_only,omitempty"`
 	// If the row to get doesn't exist, return the
 	// closest row before.
-	ClosestRowBefore *bool        `protobuf:"varint,11,opt,name=closest_row_before,def=0" json:"closest_row_before,omitempty"`
-	Consistency      *Consistency `protobuf:"varint,12,opt,name=consistency,enum=pb.Consistency,def=0" json:"consistency,omitempty"`
-	XXX_unrecognized []byte       `json:"-"`
+	ClosestRowBefore *bool                    `protobuf:"varint,11,opt,name=closest_row_before,def=0" json:"closest_row_before,omitempty"`
+	Consistency      *Consistency             `protobuf:"varint,12,opt,name=consistency,enum=pb.Consistency,def=0" json:"consistency,omitempty"`
+	CfTimeRange      []*ColumnFamilyTimeRange `protobuf:"bytes,13,rep,name=cf_time_range" json:"cf_time_range,omitempty"`
+	XXX_unrecognized []byte                   `json:"-"`
 }
 
 func (m *Get) Reset()         { *m = Get{} }
@@ -347,6 +348,13 @@ func (m *Get) GetConsistency() Consistency {
 	return Default_Get_Consistency
 }
 
+func (m *Get) GetCfTimeRange() []*ColumnFamilyTimeRange {
+	if m != nil {
+		return m.CfTimeRange
+	}
+	return nil
+}
+
 type Result struct {
 	// Result includes the Cells or else it just has a count of Cells
 	// that are carried otherwise.
@@ -462,10 +470,12 @@ func (m *GetResponse) GetResult() *Result {
 // Condition is used in check and mutate operations.
 type Condition struct {
 	Row              []byte       `protobuf:"bytes,1,req,name=row" json:"row,omitempty"`
-	Family           []byte       `protobuf:"bytes,2,req,name=family" json:"family,omitempty"`
-	Qualifier        []byte       `protobuf:"bytes,3,req,name=qualifier" json:"qualifier,omitempty"`
-	CompareType      *CompareType `protobuf:"varint,4,req,name=compare_type,enum=pb.CompareType" json:"compare_type,omitempty"`
-	Comparator       *Comparator  `protobuf:"bytes,5,req,name=comparator" json:"comparator,omitempty"`
+	Family           []byte       `protobuf:"bytes,2,opt,name=family" json:"family,omitempty"`
+	Qualifier        []byte       `protobuf:"bytes,3,opt,name=qualifier" json:"qualifier,omitempty"`
+	CompareType      *CompareType `protobuf:"varint,4,opt,name=compare_type,enum=pb.CompareType" json:"compare_type,omitempty"`
+	Comparator       *Comparator  `protobuf:"bytes,5,opt,name=comparator" json:"comparator,omitempty"`
+	TimeRange        *TimeRange   `protobuf:"bytes,6,opt,name=time_range" json:"time_range,omitempty"`
+	Filter           *Filter      `protobuf:"bytes,7,opt,name=filter" json:"filter,omitempty"`
 	XXX_unrecognized []byte       `json:"-"`
 }
 
@@ -508,6 +518,20 @@ func (m *Condition) GetComparator() *Comparator {
 	return nil
 }
 
+func (m *Condition) GetTimeRange() *TimeRange {
+	if m != nil {
+		return m.TimeRange
+	}
+	return nil
+}
+
+func (m *Condition) GetFilter() *Filter {
+	if m != nil {
+		return m.Filter
+	}
+	return nil
+}
+
 // *
 // A specific mutation inside a mutate request.
 // It can be an append, increment, put or delete based
@@ -777,7 +801,9 @@ type Scan struct {
 	Reversed                   *bool            `protobuf:"varint,15,opt,name=reversed,def=0" json:"reversed,omitempty"`
 	Consistency                *Consistency     `protobuf:"varint,16,opt,name=consistency,enum=pb.Consistency,def=0" json:"consistency,omitempty"`
 	Caching                    *uint32          `protobuf:"varint,17,opt,name=caching" json:"caching,omitempty"`
-	XXX_unrecognized           []byte           `json:"-"`
+	// Field 18 (allow_partial_results) isn't used by this client.
+	CfTimeRange      []*ColumnFamilyTimeRange `protobuf:"bytes,19,rep,name=cf_time_range" json:"cf_time_range,omitempty"`
+	XXX_unrecognized []byte                   `json:"-"`
 }
 
 func (m *Scan) Reset()         { *m = Scan{} }
@@ -908,6 +934,13 @@ func (m *Scan) GetCaching() uint32 {
 	return 0
 }
 
+func (m *Scan) GetCfTimeRange() []*ColumnFamilyTimeRange {
+	if m != nil {
+		return m.CfTimeRange
+	}
+	return nil
+}
+
 // *
 // A scan request. Initially, it should specify a scan. Later on, you
 // can use the scanner id returned to fetch result batches with a different
@@ -927,6 +960,8 @@ type ScanRequest struct {
 	NextCallSeq             *uint64          `protobuf:"varint,6,opt,name=next_call_seq" json:"next_call_seq,omitempty"`
 	ClientHandlesPartials   *bool            `protobuf:"varint,7,opt,name=client_handles_partials" json:"client_handles_partials,omitempty"`
 	ClientHandlesHeartbeats *bool            `protobuf:"varint,8,opt,name=client_handles_heartbeats" json:"client_handles_heartbeats,omitempty"`
+	TrackScanMetrics        *bool            `protobuf:"varint,9,opt,name=track_scan_metrics" json:"track_scan_metrics,omitempty"`
+	Renew                   *bool            `protobuf:"varint,10,opt,name=renew,def=0" json:"renew,omitempty"`
 	XXX_unrecognized        []byte           `json:"-"`
 }
 
@@ -934,6 +969,8 @@ func (m *ScanRequest) Reset()         { *m = ScanRequest{} }
 func (m *ScanRequest) String() string { return proto.CompactTextString(m) }
 func (*ScanRequest) ProtoMessage()    {}
 
+const Default_ScanRequest_Renew bool = false
+
 func (m *ScanRequest) GetRegion() *RegionSpecifier {
 	if m != nil {
 		return m.Region
@@ -990,6 +1027,20 @@ func (m *ScanRequest) GetClientHandlesHeartbeats() bool {
 	return false
 }
 
+func (m *ScanRequest) GetTrackScanMetrics() bool {
+	if m != nil && m.TrackScanMetrics != nil {
+		return *m.TrackScanMetrics
+	}
+	return false
+}
+
+func (m *ScanRequest) GetRenew() bool {
+	if m != nil && m.Renew != nil {
+		return *m.Renew
+	}
+	return Default_ScanRequest_Renew
+}
+
 // *
 // The scan response. If there are no more results, more_results will
 // be false.  If it is not specified, it means there are more.
@@ -1026,8 +1077,12 @@ type ScanResponse struct {
 	// Heartbeat messages are sent back to the client to prevent the scanner from
 	// timing out. Seeing a heartbeat message communicates to the Client that the
 	// server would have continued to scan had the time limit not been reached.
-	HeartbeatMessage *bool  `protobuf:"varint,9,opt,name=heartbeat_message" json:"heartbeat_message,omitempty"`
-	XXX_unrecognized []byte `json:"-"`
+	HeartbeatMessage *bool `protobuf:"varint,9,opt,name=heartbeat_message" json:"heartbeat_message,omitempty"`
+	// This field is filled in if the client has requested that scan metrics be tracked.
+	// The metrics tracked here are sent back to the client to be tracked together with
+	// the existing client side metrics.
+	ScanMetrics      *ScanMetrics `protobuf:"bytes,10,opt,name=scan_metrics" json:"scan_metrics,omitempty"`
+	XXX_unrecognized []byte       `json:"-"`
 }
 
 func (m *ScanResponse) Reset()         { *m = ScanResponse{} }
@@ -1097,6 +1152,13 @@ func (m *ScanResponse) GetHeartbeatMessage() bool {
 	return false
 }
 
+func (m *ScanResponse) GetScanMetrics() *ScanMetrics {
+	if m != nil {
+		return m.ScanMetrics
+	}
+	return nil
+}
+
 // *
 // Atomically bulk load multiple HFiles (say from different column families)
 // into an open region.
@@ -1324,9 +1386,11 @@ func (m *Action) GetServiceCall() *CoprocessorServiceCall {
 type RegionAction struct {
 	Region *RegionSpecifier `protobuf:"bytes,1,req,name=region" json:"region,omitempty"`
 	// When set, run mutations as atomic unit.
-	Atomic           *bool     `protobuf:"varint,2,opt,name=atomic" json:"atomic,omitempty"`
-	Action           []*Action `protobuf:"bytes,3,rep,name=action" json:"action,omitempty"`
-	XXX_unrecognized []byte    `json:"-"`
+	Atomic *bool     `protobuf:"varint,2,opt,name=atomic" json:"atomic,omitempty"`
+	Action []*Action `protobuf:"bytes,3,rep,name=action" json:"action,omitempty"`
+	// When set, the actions are only run (atomically) if the condition is met.
+	Condition        *Condition `protobuf:"bytes,4,opt,name=condition" json:"condition,omitempty"`
+	XXX_unrecognized []byte     `json:"-"`
 }
 
 func (m *RegionAction) Reset()         { *m = RegionAction{} }
@@ -1354,6 +1418,13 @@ func (m *RegionAction) GetAction() []*Action {
 	return nil
 }
 
+func (m *RegionAction) GetCondition() *Condition {
+	if m != nil {
+		return m.Condition
+	}
+	return nil
+}
+
 //
 // Statistics about the current load on the region
 type RegionLoadStats struct {
@@ -1447,8 +1518,10 @@ func (m *ResultOrException) GetLoadStats() *RegionLoadStats {
 type RegionActionResult struct {
 	ResultOrException []*ResultOrException `protobuf:"bytes,1,rep,name=resultOrException" json:"resultOrException,omitempty"`
 	// If the operation failed globally for this region, this exception is set
-	Exception        *NameBytesPair `protobuf:"bytes,2,opt,name=exception" json:"exception,omitempty"`
-	XXX_unrecognized []byte         `json:"-"`
+	Exception *NameBytesPair `protobuf:"bytes,2,opt,name=exception" json:"exception,omitempty"`
+	// Whether the condition of a conditional region action was met.
+	Processed        *bool  `protobuf:"varint,3,opt,name=processed" json:"processed,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
 }
 
 func (m *RegionActionResult) Reset()         { *m = RegionActionResult{} }
@@ -1469,6 +1542,13 @@ func (m *RegionActionResult) GetException() *NameBytesPair {
 	return nil
 }
 
+func (m *RegionActionResult) GetProcessed() bool {
+	if m != nil && m.Processed != nil {
+		return *m.Processed
+	}
+	return false
+}
+
 // *
 // Execute a list of actions on a given region in order.
 // Nothing prevents a request to contains a set of RegionAction on the same region.
@@ -427,6 +427,31 @@ func (m *TimeRange) GetTo() uint64 {
 	return 0
 }
 
+// ColumnFamily Specific TimeRange
+type ColumnFamilyTimeRange struct {
+	ColumnFamily     []byte     `protobuf:"bytes,1,req,name=column_family" json:"column_family,omitempty"`
+	TimeRange        *TimeRange `protobuf:"bytes,2,req,name=time_range" json:"time_range,omitempty"`
+	XXX_unrecognized []byte     `json:"-"`
+}
+
+func (m *ColumnFamilyTimeRange) Reset()         { *m = ColumnFamilyTimeRange{} }
+func (m *ColumnFamilyTimeRange) String() string { return proto.CompactTextString(m) }
+func (*ColumnFamilyTimeRange) ProtoMessage()    {}
+
+func (m *ColumnFamilyTimeRange) GetColumnFamily() []byte {
+	if m != nil {
+		return m.ColumnFamily
+	}
+	return nil
+}
+
+func (m *ColumnFamilyTimeRange) GetTimeRange() *TimeRange {
+	if m != nil {
+		return m.TimeRange
+	}
+	return nil
+}
+
 // *
 // Protocol buffer version of ServerName
 type ServerName struct {
@@ -0,0 +1,63 @@
+// Code generated by protoc-gen-go.
+// source: MapReduce.proto
+// DO NOT EDIT!
+
+package pb
+
+import proto "github.com/golang/protobuf/proto"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = math.Inf
+
+type ScanMetrics struct {
+	Metrics          []*NameInt64Pair `protobuf:"bytes,1,rep,name=metrics" json:"metrics,omitempty"`
+	XXX_unrecognized []byte           `json:"-"`
+}
+
+func (m *ScanMetrics) Reset()         { *m = ScanMetrics{} }
+func (m *ScanMetrics) String() string { return proto.CompactTextString(m) }
+func (*ScanMetrics) ProtoMessage()    {}
+
+func (m *ScanMetrics) GetMetrics() []*NameInt64Pair {
+	if m != nil {
+		return m.Metrics
+	}
+	return nil
+}
+
+type TableSnapshotRegionSplit struct {
+	Locations        []string     `protobuf:"bytes,2,rep,name=locations" json:"locations,omitempty"`
+	Table            *TableSchema `protobuf:"bytes,3,opt,name=table" json:"table,omitempty"`
+	Region           *RegionInfo  `protobuf:"bytes,4,opt,name=region" json:"region,omitempty"`
+	XXX_unrecognized []byte       `json:"-"`
+}
+
+func (m *TableSnapshotRegionSplit) Reset()         { *m = TableSnapshotRegionSplit{} }
+func (m *TableSnapshotRegionSplit) String() string { return proto.CompactTextString(m) }
+func (*TableSnapshotRegionSplit) ProtoMessage()    {}
+
+func (m *TableSnapshotRegionSplit) GetLocations() []string {
+	if m != nil {
+		return m.Locations
+	}
+	return nil
+}
+
+func (m *TableSnapshotRegionSplit) GetTable() *TableSchema {
+	if m != nil {
+		return m.Table
+	}
+	return nil
+}
+
+func (m *TableSnapshotRegionSplit) GetRegion() *RegionInfo {
+	if m != nil {
+		return m.Region
+	}
+	return nil
+}
+
+func init() {
+}
@@ -400,13 +400,21 @@ func (m *TruncateTableRequest) GetPreserveSplits() bool {
 }
 
 type TruncateTableResponse struct {
-	XXX_unrecognized []byte `json:"-"`
+	ProcId           *uint64 `protobuf:"varint,1,opt,name=proc_id" json:"proc_id,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
 }
 
 func (m *TruncateTableResponse) Reset()         { *m = TruncateTableResponse{} }
 func (m *TruncateTableResponse) String() string { return proto.CompactTextString(m) }
 func (*TruncateTableResponse) ProtoMessage()    {}
 
+func (m *TruncateTableResponse) GetProcId() uint64 {
+	if m != nil && m.ProcId != nil {
+		return *m.ProcId
+	}
+	return 0
+}
+
 type EnableTableRequest struct {
 	TableName        *TableName `protobuf:"bytes,1,req,name=table_name" json:"table_name,omitempty"`
 	XXX_unrecognized []byte     `json:"-"`
@@ -819,6 +827,86 @@ func (m *IsBalancerEnabledResponse) GetEnabled() bool {
 	return false
 }
 
+type NormalizeRequest struct {
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *NormalizeRequest) Reset()         { *m = NormalizeRequest{} }
+func (m *NormalizeRequest) String() string { return proto.CompactTextString(m) }
+func (*NormalizeRequest) ProtoMessage()    {}
+
+type NormalizeResponse struct {
+	NormalizerRan    *bool  `protobuf:"varint,1,req,name=normalizer_ran" json:"normalizer_ran,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *NormalizeResponse) Reset()         { *m = NormalizeResponse{} }
+func (m *NormalizeResponse) String() string { return proto.CompactTextString(m) }
+func (*NormalizeResponse) ProtoMessage()    {}
+
+func (m *NormalizeResponse) GetNormalizerRan() bool {
+	if m != nil && m.NormalizerRan != nil {
+		return *m.NormalizerRan
+	}
+	return false
+}
+
+type SetNormalizerRunningRequest struct {
+	On               *bool  `protobuf:"varint,1,req,name=on" json:"on,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *SetNormalizerRunningRequest) Reset()         { *m = SetNormalizerRunningRequest{} }
+func (m *SetNormalizerRunningRequest) String() string { return proto.CompactTextString(m) }
+func (*SetNormalizerRunningRequest) ProtoMessage()    {}
+
+func (m *SetNormalizerRunningRequest) GetOn() bool {
+	if m != nil && m.On != nil {
+		return *m.On
+	}
+	return false
+}
+
+type SetNormalizerRunningResponse struct {
+	PrevNormalizerValue *bool  `protobuf:"varint,1,opt,name=prev_normalizer_value" json:"prev_normalizer_value,omitempty"`
+	XXX_unrecognized    []byte `json:"-"`
+}
+
+func (m *SetNormalizerRunningResponse) Reset()         { *m = SetNormalizerRunningResponse{} }
+func (m *SetNormalizerRunningResponse) String() string { return proto.CompactTextString(m) }
+func (*SetNormalizerRunningResponse) ProtoMessage()    {}
+
+func (m *SetNormalizerRunningResponse) GetPrevNormalizerValue() bool {
+	if m != nil && m.PrevNormalizerValue != nil {
+		return *m.PrevNormalizerValue
+	}
+	return false
+}
+
+type IsNormalizerEnabledRequest struct {
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *IsNormalizerEnabledRequest) Reset()         { *m = IsNormalizerEnabledRequest{} }
+func (m *IsNormalizerEnabledRequest) String() string { return proto.CompactTextString(m) }
+func (*IsNormalizerEnabledRequest) ProtoMessage()    {}
+
+type IsNormalizerEnabledResponse struct {
+	Enabled          *bool  `protobuf:"varint,1,req,name=enabled" json:"enabled,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *IsNormalizerEnabledResponse) Reset()         { *m = IsNormalizerEnabledResponse{} }
+func (m *IsNormalizerEnabledResponse) String() string { return proto.CompactTextString(m) }
+func (*IsNormalizerEnabledResponse) ProtoMessage()    {}
+
+func (m *IsNormalizerEnabledResponse) GetEnabled() bool {
+	if m != nil && m.Enabled != nil {
+		return *m.Enabled
+	}
+	return false
+}
+
 type RunCatalogScanRequest struct {
 	XXX_unrecognized []byte `json:"-"`
 }
@@ -1545,6 +1633,98 @@ func (m *MajorCompactionTimestampResponse) GetCompactionTimestamp() int64 {
 	return 0
 }
 
+type DecommissionRegionServersRequest struct {
+	ServerName       []*ServerName `protobuf:"bytes,1,rep,name=server_name" json:"server_name,omitempty"`
+	Offload          *bool         `protobuf:"varint,2,req,name=offload" json:"offload,omitempty"`
+	XXX_unrecognized []byte        `json:"-"`
+}
+
+func (m *DecommissionRegionServersRequest) Reset()         { *m = DecommissionRegionServersRequest{} }
+func (m *DecommissionRegionServersRequest) String() string { return proto.CompactTextString(m) }
+func (*DecommissionRegionServersRequest) ProtoMessage()    {}
+
+func (m *DecommissionRegionServersRequest) GetServerName() []*ServerName {
+	if m != nil {
+		return m.ServerName
+	}
+	return nil
+}
+
+func (m *DecommissionRegionServersRequest) GetOffload() bool {
+	if m != nil && m.Offload != nil {
+		return *m.Offload
+	}
+	return false
+}
+
+type DecommissionRegionServersResponse struct {
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *DecommissionRegionServersResponse) Reset()         { *m = DecommissionRegionServersResponse{} }
+func (m *DecommissionRegionServersResponse) String() string { return proto.CompactTextString(m) }
+func (*DecommissionRegionServersResponse) ProtoMessage()    {}
+
+type ListDecommissionedRegionServersRequest struct {
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *ListDecommissionedRegionServersRequest) Reset() {
+	*m = ListDecommissionedRegionServersRequest{}
+}
+func (m *ListDecommissionedRegionServersRequest) String() string { return proto.CompactTextString(m) }
+func (*ListDecommissionedRegionServersRequest) ProtoMessage()    {}
+
+type ListDecommissionedRegionServersResponse struct {
+	ServerName       []*ServerName `protobuf:"bytes,1,rep,name=server_name" json:"server_name,omitempty"`
+	XXX_unrecognized []byte        `json:"-"`
+}
+
+func (m *ListDecommissionedRegionServersResponse) Reset() {
+	*m = ListDecommissionedRegionServersResponse{}
+}
+func (m *ListDecommissionedRegionServersResponse) String() string { return proto.CompactTextString(m) }
+func (*ListDecommissionedRegionServersResponse) ProtoMessage()    {}
+
+func (m *ListDecommissionedRegionServersResponse) GetServerName() []*ServerName {
+	if m != nil {
+		return m.ServerName
+	}
+	return nil
+}
+
+type RecommissionRegionServerRequest struct {
+	ServerName       *ServerName        `protobuf:"bytes,1,req,name=server_name" json:"server_name,omitempty"`
+	Region           []*RegionSpecifier `protobuf:"bytes,2,rep,name=region" json:"region,omitempty"`
+	XXX_unrecognized []byte             `json:"-"`
+}
+
+func (m *RecommissionRegionServerRequest) Reset()         { *m = RecommissionRegionServerRequest{} }
+func (m *RecommissionRegionServerRequest) String() string { return proto.CompactTextString(m) }
+func (*RecommissionRegionServerRequest) ProtoMessage()    {}
+
+func (m *RecommissionRegionServerRequest) GetServerName() *ServerName {
+	if m != nil {
+		return m.ServerName
+	}
+	return nil
+}
+
+func (m *RecommissionRegionServerRequest) GetRegion() []*RegionSpecifier {
+	if m != nil {
+		return m.Region
+	}
+	return nil
+}
+
+type RecommissionRegionServerResponse struct {
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *RecommissionRegionServerResponse) Reset()         { *m = RecommissionRegionServerResponse{} }
+func (m *RecommissionRegionServerResponse) String() string { return proto.CompactTextString(m) }
+func (*RecommissionRegionServerResponse) ProtoMessage()    {}
+
 func init() {
 	proto.RegisterEnum("pb.GetProcedureResultResponse_State", GetProcedureResultResponse_State_name, GetProcedureResultResponse_State_value)
 }
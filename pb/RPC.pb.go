@@ -232,6 +232,7 @@ type RequestHeader struct {
 	// 0 is NORMAL priority.  200 is HIGH.  If no priority, treat it as NORMAL.
 	// See HConstants.
 	Priority         *uint32 `protobuf:"varint,6,opt,name=priority" json:"priority,omitempty"`
+	Timeout          *uint32 `protobuf:"varint,7,opt,name=timeout" json:"timeout,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
@@ -281,6 +282,13 @@ func (m *RequestHeader) GetPriority() uint32 {
 	return 0
 }
 
+func (m *RequestHeader) GetTimeout() uint32 {
+	if m != nil && m.Timeout != nil {
+		return *m.Timeout
+	}
+	return 0
+}
+
 type ResponseHeader struct {
 	CallId *uint32 `protobuf:"varint,1,opt,name=call_id" json:"call_id,omitempty"`
 	// If present, then request threw an exception and no response message (else we presume one)
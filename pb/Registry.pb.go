@@ -0,0 +1,125 @@
+// Code generated by protoc-gen-go.
+// source: Registry.proto
+// DO NOT EDIT!
+
+package pb
+
+import proto "github.com/golang/protobuf/proto"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = math.Inf
+
+type RegionLocation struct {
+	RegionInfo       *RegionInfo `protobuf:"bytes,1,req,name=region_info" json:"region_info,omitempty"`
+	ServerName       *ServerName `protobuf:"bytes,2,opt,name=server_name" json:"server_name,omitempty"`
+	SeqNum           *int64      `protobuf:"varint,3,req,name=seq_num" json:"seq_num,omitempty"`
+	XXX_unrecognized []byte      `json:"-"`
+}
+
+func (m *RegionLocation) Reset()         { *m = RegionLocation{} }
+func (m *RegionLocation) String() string { return proto.CompactTextString(m) }
+func (*RegionLocation) ProtoMessage()    {}
+
+func (m *RegionLocation) GetRegionInfo() *RegionInfo {
+	if m != nil {
+		return m.RegionInfo
+	}
+	return nil
+}
+
+func (m *RegionLocation) GetServerName() *ServerName {
+	if m != nil {
+		return m.ServerName
+	}
+	return nil
+}
+
+func (m *RegionLocation) GetSeqNum() int64 {
+	if m != nil && m.SeqNum != nil {
+		return *m.SeqNum
+	}
+	return 0
+}
+
+// * Request and response to get the clusterID for this cluster
+type GetClusterIdRequest struct {
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *GetClusterIdRequest) Reset()         { *m = GetClusterIdRequest{} }
+func (m *GetClusterIdRequest) String() string { return proto.CompactTextString(m) }
+func (*GetClusterIdRequest) ProtoMessage()    {}
+
+type GetClusterIdResponse struct {
+	// * Not set if cluster ID could not be determined.
+	ClusterId        *string `protobuf:"bytes,1,opt,name=cluster_id" json:"cluster_id,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *GetClusterIdResponse) Reset()         { *m = GetClusterIdResponse{} }
+func (m *GetClusterIdResponse) String() string { return proto.CompactTextString(m) }
+func (*GetClusterIdResponse) ProtoMessage()    {}
+
+func (m *GetClusterIdResponse) GetClusterId() string {
+	if m != nil && m.ClusterId != nil {
+		return *m.ClusterId
+	}
+	return ""
+}
+
+// * Request and response to get the currently active master name for this cluster
+type GetActiveMasterRequest struct {
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *GetActiveMasterRequest) Reset()         { *m = GetActiveMasterRequest{} }
+func (m *GetActiveMasterRequest) String() string { return proto.CompactTextString(m) }
+func (*GetActiveMasterRequest) ProtoMessage()    {}
+
+type GetActiveMasterResponse struct {
+	// * Not set if an active master could not be determined.
+	ServerName       *ServerName `protobuf:"bytes,1,opt,name=server_name" json:"server_name,omitempty"`
+	XXX_unrecognized []byte      `json:"-"`
+}
+
+func (m *GetActiveMasterResponse) Reset()         { *m = GetActiveMasterResponse{} }
+func (m *GetActiveMasterResponse) String() string { return proto.CompactTextString(m) }
+func (*GetActiveMasterResponse) ProtoMessage()    {}
+
+func (m *GetActiveMasterResponse) GetServerName() *ServerName {
+	if m != nil {
+		return m.ServerName
+	}
+	return nil
+}
+
+// * Request and response to get the current list of meta region locations
+type GetMetaRegionLocationsRequest struct {
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *GetMetaRegionLocationsRequest) Reset()         { *m = GetMetaRegionLocationsRequest{} }
+func (m *GetMetaRegionLocationsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetMetaRegionLocationsRequest) ProtoMessage()    {}
+
+type GetMetaRegionLocationsResponse struct {
+	// * Not set if meta region locations could not be determined.
+	MetaLocations    []*RegionLocation `protobuf:"bytes,1,rep,name=meta_locations" json:"meta_locations,omitempty"`
+	XXX_unrecognized []byte            `json:"-"`
+}
+
+func (m *GetMetaRegionLocationsResponse) Reset()         { *m = GetMetaRegionLocationsResponse{} }
+func (m *GetMetaRegionLocationsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetMetaRegionLocationsResponse) ProtoMessage()    {}
+
+func (m *GetMetaRegionLocationsResponse) GetMetaLocations() []*RegionLocation {
+	if m != nil {
+		return m.MetaLocations
+	}
+	return nil
+}
+
+func init() {
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+// ConnectionsPerServer will return an option that makes the client open the
+// given number of connections to each RegionServer, and send the RPCs over
+// them in turn.  A connection writes its RPCs one after the other, which
+// becomes the bottleneck of the clients that send many RPCs in parallel on
+// many cores.  The connections to a RegionServer fail together: once one of
+// them breaks, they're all closed and opened again.  The default is a
+// single connection.
+func ConnectionsPerServer(n int) Option {
+	return func(c *client) {
+		c.connsPerServer = n
+	}
+}
+
+// connPool is a RegionClient that spreads the RPCs over several connections
+// to the same RegionServer, in a round-robin fashion.
+type connPool struct {
+	clients []hrpc.RegionClient
+
+	// Incremented for every RPC, to pick its connection.
+	next uint32
+}
+
+// Close closes all the connections of the pool.
+func (p *connPool) Close() {
+	for _, client := range p.clients {
+		client.Close()
+	}
+}
+
+// Host returns the host of the RegionServer of the pool.
+func (p *connPool) Host() string {
+	return p.clients[0].Host()
+}
+
+// Port returns the port of the RegionServer of the pool.
+func (p *connPool) Port() uint16 {
+	return p.clients[0].Port()
+}
+
+// QueueRPC queues the given RPC on the next connection of the pool.
+func (p *connPool) QueueRPC(rpc hrpc.Call) error {
+	i := atomic.AddUint32(&p.next, 1)
+	return p.clients[i%uint32(len(p.clients))].QueueRPC(rpc)
+}
+
+// dialRegionServer connects to the RegionServer at the given address in the
+// background, with a pool of connections if the client opens more than one
+// per RegionServer, and sends the result to the given channel.
+func (c *client) dialRegionServer(ctx context.Context, ch chan newRegResult,
+	clientType region.ClientType, host string, port uint16) {
+	if c.connsPerServer > 1 && clientType == region.RegionClient {
+		go newConnPool(ctx, ch, c.connsPerServer, host, port, c.rpcQueueSize,
+			c.flushInterval, c.regionOptions...)
+		return
+	}
+	go newRegionClient(ctx, ch, clientType, host, port, c.rpcQueueSize,
+		c.flushInterval, c.regionOptions...)
+}
+
+// newConnPool opens the given number of connections to the RegionServer at
+// the given address, like newRegionClient.
+func newConnPool(ctx context.Context, ret chan newRegResult, n int, host string, port uint16,
+	queueSize int, flushInterval time.Duration, options ...region.ClientOption) {
+	pool := &connPool{clients: make([]hrpc.RegionClient, 0, n)}
+	var err error
+	for len(pool.clients) < n && err == nil {
+		var client *region.Client
+		client, err = region.NewClient(host, port, region.RegionClient, queueSize,
+			flushInterval, options...)
+		if err == nil {
+			pool.clients = append(pool.clients, client)
+		}
+	}
+	if err != nil {
+		pool.Close()
+		ret <- newRegResult{Err: err}
+		return
+	}
+	select {
+	case ret <- newRegResult{Client: pool}:
+	case <-ctx.Done():
+		pool.Close()
+	}
+}
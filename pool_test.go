@@ -0,0 +1,108 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+// countingRegionClient is a fakeRegionClient that counts its RPCs.
+type countingRegionClient struct {
+	fakeRegionClient
+	rpcs int
+}
+
+func (rc *countingRegionClient) QueueRPC(rpc hrpc.Call) error {
+	rc.rpcs++
+	return nil
+}
+
+func TestConnPool(t *testing.T) {
+	conns := []*countingRegionClient{
+		{fakeRegionClient: fakeRegionClient{host: "rs", port: 16020}},
+		{fakeRegionClient: fakeRegionClient{host: "rs", port: 16020}},
+		{fakeRegionClient: fakeRegionClient{host: "rs", port: 16020}},
+	}
+	pool := &connPool{}
+	for _, conn := range conns {
+		pool.clients = append(pool.clients, conn)
+	}
+	if pool.Host() != "rs" || pool.Port() != 16020 {
+		t.Errorf("Unexpected address %s:%d", pool.Host(), pool.Port())
+	}
+	for i := 0; i < 6; i++ {
+		pool.QueueRPC(nil)
+	}
+	for i, conn := range conns {
+		if conn.rpcs != 2 {
+			t.Errorf("Expected 2 RPCs on connection %d, got %d", i, conn.rpcs)
+		}
+	}
+
+	// A broken connection takes down the whole pool.
+	c := newClient("~invalid.quorum~")
+	reg := &region.Info{Table: []byte("test"), Name: []byte("test,,1"), StopKey: []byte("z")}
+	reg.SetClient(pool)
+	c.clients.put(reg, pool)
+	if down := c.clients.clientDown(reg); len(down) != 1 || down[0] != reg {
+		t.Errorf("Expected %s to be down, got %v", reg, down)
+	}
+	for i, conn := range conns {
+		if !conn.closed {
+			t.Errorf("Expected connection %d to be closed", i)
+		}
+	}
+}
+
+func TestNewConnPool(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	accepted := make(chan net.Conn, 3)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+	host, p, _ := net.SplitHostPort(l.Addr().String())
+	port, _ := strconv.Atoi(p)
+
+	c := newClient("~invalid.quorum~", ConnectionsPerServer(3))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ch := make(chan newRegResult, 1)
+	c.dialRegionServer(ctx, ch, region.RegionClient, host, uint16(port))
+	res := <-ch
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	defer res.Client.Close()
+	pool, ok := res.Client.(*connPool)
+	if !ok || len(pool.clients) != 3 {
+		t.Fatalf("Expected a pool of 3 connections, got %#v", res.Client)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case conn := <-accepted:
+			conn.Close()
+		case <-ctx.Done():
+			t.Fatalf("Only %d connections were opened", i)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+// MetaPrefetch will return an option that makes the client look up the given
+// number of regions that follow a region it looks up in meta, in the
+// background, and add them to its cache.  Sequential workloads, like scans or
+// batch jobs writing keys in order, then don't wait for a lookup of meta
+// every time they reach a new region.
+func MetaPrefetch(regions int) Option {
+	return func(c *client) {
+		c.metaPrefetch = regions
+	}
+}
+
+// prefetchRegions looks up in meta the regions that follow the given one, and
+// adds those that aren't cached yet to the cache.
+func (c *client) prefetchRegions(reg hrpc.RegionInfo) {
+	if len(reg.GetStopKey()) == 0 {
+		// This is the last region of the table.
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), regionLookupTimeout)
+	defer cancel()
+	table := reg.GetTable()
+	// The rows of the regions that follow start with the stop key of this
+	// one, and those of the table end before '-', the byte after ','.
+	start := createRegionSearchKey(table, reg.GetStopKey())
+	start = start[:len(start)-1]
+	stop := append(append([]byte(nil), table...), '-')
+	rpc, err := hrpc.NewSmallScanRange(ctx, metaTableName, start, stop, false,
+		hrpc.Families(infoFamily), hrpc.NumberOfRows(uint32(c.metaPrefetch)))
+	if err != nil {
+		log.Warningf("Failed to prefetch the regions after %s: %s", reg, err)
+		return
+	}
+	rpc.SetRegion(c.metaRegionInfo)
+	resp, err := c.sendRPC(rpc)
+	if err != nil {
+		log.Infof("Failed to prefetch the regions after %s: %s", reg, err)
+		return
+	}
+	for _, result := range resp.(*pb.ScanResponse).GetResults() {
+		next, host, port, err := region.ParseRegionInfo(&pb.GetResponse{Result: result})
+		if err != nil {
+			// The region isn't assigned, it's looked up again once
+			// it's needed.
+			continue
+		}
+		if info := next.GetPB(); info.GetOffline() || info.GetSplit() {
+			continue
+		}
+		c.regionsLock.Lock()
+		if c.getRegionFromCache(table, next.GetStartKey()) == nil {
+			c.cacheRegion(next, host, port)
+		}
+		c.regionsLock.Unlock()
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+// metaResult returns the row of meta of the region of the table "test" that
+// starts at the given key, served by the given host.
+func metaResult(start, stop, host string) *pb.Result {
+	info, _ := proto.Marshal(&pb.RegionInfo{
+		RegionId: proto.Uint64(1),
+		TableName: &pb.TableName{
+			Namespace: []byte("default"),
+			Qualifier: []byte("test"),
+		},
+		StartKey: []byte(start),
+		EndKey:   []byte(stop),
+	})
+	row := []byte("test," + start + ",1.")
+	return &pb.Result{Cell: []*pb.Cell{{
+		Row:       row,
+		Family:    []byte("info"),
+		Qualifier: []byte("regioninfo"),
+		Value:     append(append([]byte("PBUF"), info...), 0, 0, 0, 0),
+	}, {
+		Row:       row,
+		Qualifier: []byte("server"),
+		Value:     []byte(host + ":16020"),
+	}}}
+}
+
+func TestMetaLookupPrefetch(t *testing.T) {
+	c := newClient("~invalid.quorum~", MetaPrefetch(2))
+	defer c.Close()
+	// The regions added to the cache look up meta again in the background
+	// since they can't be connected to, so only the first scans are kept.
+	var m sync.Mutex
+	var scans []*hrpc.Scan
+	meta := &registryClient{
+		fakeRegionClient: fakeRegionClient{host: "meta", port: 16020},
+		respond: func(rpc hrpc.Call) hrpc.RPCResult {
+			scan := rpc.(*hrpc.Scan)
+			m.Lock()
+			if len(scans) < 2 {
+				scans = append(scans, scan)
+			}
+			m.Unlock()
+			if scan.GetReversed() {
+				return hrpc.RPCResult{Msg: &pb.ScanResponse{
+					Results: []*pb.Result{metaResult("b", "c", "~rs1~")},
+				}}
+			}
+			return hrpc.RPCResult{Msg: &pb.ScanResponse{
+				Results: []*pb.Result{
+					metaResult("c", "d", "~rs2~"),
+					metaResult("d", "", "~rs3~"),
+				},
+			}}
+		},
+	}
+	c.metaRegionInfo.SetClient(meta)
+
+	reg, host, port, err := c.locateRegion(context.Background(), []byte("test"),
+		[]byte("bb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(reg.GetStartKey()) != "b" || host != "~rs1~" || port != 16020 {
+		t.Errorf("Unexpected region %s at %s:%d", reg, host, port)
+	}
+	if len(scans) != 1 || !scans[0].GetSmall() ||
+		!bytes.Equal(scans[0].GetStartRow(), []byte("test,bb,:")) ||
+		!bytes.Equal(scans[0].GetStopRow(), []byte("test,")) {
+		t.Fatalf("Expected a reversed scan of meta from the search key, got %v", scans)
+	}
+
+	c.prefetchRegions(reg)
+	m.Lock()
+	defer m.Unlock()
+	if len(scans) != 2 || scans[1].GetReversed() || scans[1].GetNumberOfRows() != 2 ||
+		!bytes.Equal(scans[1].GetStartRow(), []byte("test,c,")) ||
+		!bytes.Equal(scans[1].GetStopRow(), []byte("test-")) {
+		t.Fatalf("Expected a scan of meta for the 2 next regions, got %v", scans)
+	}
+	for _, key := range []string{"c", "x"} {
+		if cached := c.getRegionFromCache([]byte("test"), []byte(key)); cached == nil {
+			t.Errorf("The region of %q wasn't prefetched", key)
+		}
+	}
+	if cached := c.getRegionFromCache([]byte("test"), []byte("a")); cached != nil {
+		t.Errorf("Didn't expect the region of %q to be cached, got %s", "a", cached)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"golang.org/x/net/context"
+)
+
+// GetRanges retrieves the rows of the given [start; stop[ ranges of the given
+// table, an empty stop key meaning the end of the table, without the caller
+// having to manage a Scanner.  The ranges that contain a single row key (their
+// stop key is their start key followed by a 0 byte) are fetched with batched
+// Gets, which are cheaper than opening a scanner, and the other ranges with
+// a single Scan skipping the rows in between the ranges.  The results of both
+// are merged in row key order, each row being returned once even if it's in
+// several ranges.  The options must be valid for both Gets and Scans.
+func (c *client) GetRanges(ctx context.Context, table []byte, ranges [][2][]byte,
+	options ...func(hrpc.Call) error) ([]*hrpc.Result, error) {
+	var keys [][]byte
+	var scanRanges [][2][]byte
+	for _, r := range ranges {
+		if isSingleRow(r) {
+			keys = append(keys, r[0])
+		} else {
+			scanRanges = append(scanRanges, r)
+		}
+	}
+
+	var results []*hrpc.Result
+	if len(scanRanges) != 0 {
+		scan, err := hrpc.NewScan(ctx, table,
+			append(options[:len(options):len(options)], hrpc.Ranges(scanRanges))...)
+		if err != nil {
+			return nil, err
+		}
+		if results, err = c.Scan(scan); err != nil {
+			return nil, err
+		}
+	}
+	if len(keys) != 0 {
+		rows, err := c.GetMulti(ctx, table, keys, options...)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if len(row.Cells) != 0 {
+				results = append(results, row)
+			}
+		}
+	}
+
+	sort.Stable(resultsByRow(results))
+	merged := results[:0]
+	for _, res := range results {
+		if len(merged) == 0 ||
+			!bytes.Equal(resultRow(merged[len(merged)-1]), resultRow(res)) {
+			merged = append(merged, res)
+		}
+	}
+	return merged, nil
+}
+
+// isSingleRow returns true if the given range contains a single row key.
+func isSingleRow(r [2][]byte) bool {
+	return len(r[1]) == len(r[0])+1 && r[1][len(r[0])] == 0 && bytes.HasPrefix(r[1], r[0])
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"errors"
+
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// ErrReadOnlyClient is returned for the mutations, and the other calls that
+// change the cluster, sent through a client created with the ReadOnly option.
+var ErrReadOnlyClient = errors.New("writes aren't allowed by this read-only client")
+
+// ReadOnly will return an option that makes the client refuse to send any
+// call that isn't known to only read: mutations, conditional or not, alone or
+// in a batch, as well as administrative calls such as creating tables,
+// flushing regions or granting permissions, fail with ErrReadOnlyClient
+// without reaching HBase.  This is meant for the services that only read from
+// a cluster, as a guarantee that they can't write to it.
+func ReadOnly() Option {
+	return func(c *client) {
+		c.readOnly = true
+	}
+}
+
+// checkWritable returns ErrReadOnlyClient if the client is read-only and the
+// given RPC may change the cluster.
+func (c *client) checkWritable(rpc hrpc.Call) error {
+	if c.readOnly && !isReadOnlyCall(rpc) {
+		return ErrReadOnlyClient
+	}
+	return nil
+}
+
+// isReadOnlyCall returns true if the given RPC only reads from the cluster, so
+// that it can be sent by a read-only client.  Any other RPC, including those
+// added to hrpc later, is considered to write.
+func isReadOnlyCall(rpc hrpc.Call) bool {
+	switch rpc := rpc.(type) {
+	case *hrpc.Multi:
+		for _, call := range rpc.Calls() {
+			if !isReadOnlyCall(call) {
+				return false
+			}
+		}
+		return true
+	case *hrpc.Get, *hrpc.Scan,
+		*hrpc.GetRegionInfo, *hrpc.GetUserPermissions,
+		*hrpc.GetClusterID, *hrpc.GetActiveMaster, *hrpc.GetMetaLocations,
+		*hrpc.ListTableNames, *hrpc.ListTableNamesByNamespace, *hrpc.GetTableDescriptors,
+		*hrpc.ListNamespaceDescriptors, *hrpc.ListRegionServers, *hrpc.ListSnapshots,
+		*hrpc.ListDecommissionedRegionServers,
+		*hrpc.GetSchemaAlterStatus, *hrpc.GetProcedureState,
+		*hrpc.IsSnapshotDone, *hrpc.IsRestoreSnapshotDone,
+		*hrpc.IsBalancerEnabled, *hrpc.IsNormalizerEnabled, *hrpc.IsCatalogJanitorEnabled:
+		return true
+	}
+	return false
+}
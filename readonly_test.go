@@ -0,0 +1,151 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"testing"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"golang.org/x/net/context"
+)
+
+func TestReadOnly(t *testing.T) {
+	ctx := context.Background()
+	values := map[string]map[string][]byte{"cf": {"a": []byte("1")}}
+	put, err := hrpc.NewPutStr(ctx, "test", "row", values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cas, err := hrpc.NewCheckAndPut(put, "cf", "a", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rm, err := hrpc.NewRowMutations(ctx, put)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get, err := hrpc.NewGetStr(ctx, "test", "row")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newClient("~invalid.quorum~")
+	if err := c.checkWritable(put); err != nil {
+		t.Errorf("Expected mutations to be allowed by default, got %v", err)
+	}
+
+	c = newClient("~invalid.quorum~", ReadOnly())
+	for _, call := range []hrpc.Call{put, cas, rm} {
+		if err := c.checkWritable(call); err != ErrReadOnlyClient {
+			t.Errorf("Expected ErrReadOnlyClient for %s, got %v", call.GetName(), err)
+		}
+	}
+	if err := c.checkWritable(get); err != nil {
+		t.Errorf("Expected a Get to be allowed, got %v", err)
+	}
+
+	// The mutations fail without looking up their region, which would
+	// block on the invalid quorum.
+	if _, err := c.Put(put); err != ErrReadOnlyClient {
+		t.Errorf("Expected Put to fail with ErrReadOnlyClient, got %v", err)
+	}
+	if _, err := c.CheckAndPut(put, "cf", "a", nil); err != ErrReadOnlyClient {
+		t.Errorf("Expected CheckAndPut to fail with ErrReadOnlyClient, got %v", err)
+	}
+	if err := c.MutateRow(rm); err != ErrReadOnlyClient {
+		t.Errorf("Expected MutateRow to fail with ErrReadOnlyClient, got %v", err)
+	}
+	_, err = c.Batch(ctx, []hrpc.Call{put, cas})
+	be, ok := err.(*BatchError)
+	if !ok || len(be.Permanent) != 2 {
+		t.Fatalf("Expected a BatchError with 2 permanent failures, got %v", err)
+	}
+	for _, op := range be.Permanent {
+		if op.Err != ErrReadOnlyClient {
+			t.Errorf("Expected ErrReadOnlyClient for operation %d, got %v", op.Index, op.Err)
+		}
+	}
+
+	// A Multi sent directly can't carry mutations either.
+	multi := hrpc.NewMulti(ctx)
+	multi.Add(get)
+	if err := c.checkWritable(multi); err != nil {
+		t.Errorf("Expected a Multi of Gets to be allowed, got %v", err)
+	}
+	multi.Add(put)
+	if _, err := c.SendRPC(multi); err != ErrReadOnlyClient {
+		t.Errorf("Expected a Multi with a Put to fail with ErrReadOnlyClient, got %v", err)
+	}
+}
+
+func TestReadOnlyAdmin(t *testing.T) {
+	ctx := context.Background()
+	c := newClient("~invalid.quorum~", ReadOnly())
+	table := []byte("test")
+
+	// The calls to the AdminService of the RegionServers fail before their
+	// region is looked up.
+	split, err := hrpc.NewSplitRegion(ctx, table, []byte("m"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.SplitRegion(split); err != ErrReadOnlyClient {
+		t.Errorf("Expected SplitRegion to fail with ErrReadOnlyClient, got %v", err)
+	}
+	compact, err := hrpc.NewCompactRegion(ctx, table, []byte("m"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.CompactRegion(compact); err != ErrReadOnlyClient {
+		t.Errorf("Expected CompactRegion to fail with ErrReadOnlyClient, got %v", err)
+	}
+	if err = c.FlushRegion(hrpc.NewFlushRegion(ctx, table, []byte("m"))); err != ErrReadOnlyClient {
+		t.Errorf("Expected FlushRegion to fail with ErrReadOnlyClient, got %v", err)
+	}
+	if err = c.checkWritable(hrpc.NewGetRegionInfo(ctx, table, nil, true)); err != nil {
+		t.Errorf("Expected GetRegionInfo to be allowed, got %v", err)
+	}
+
+	grant, err := hrpc.NewGrant(ctx, &hrpc.UserPermission{
+		User:    "bob",
+		Actions: []hrpc.Action{hrpc.ActionRead},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Grant(grant); err != ErrReadOnlyClient {
+		t.Errorf("Expected Grant to fail with ErrReadOnlyClient, got %v", err)
+	}
+
+	// The DDL of the admin client is refused, but not the calls that only
+	// read the schema.
+	ac := NewAdminClient("~invalid.quorum~", ReadOnly())
+	create, err := hrpc.NewCreateTable(ctx, table, []string{"cf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ac.CreateTable(create); err != ErrReadOnlyClient {
+		t.Errorf("Expected CreateTable to fail with ErrReadOnlyClient, got %v", err)
+	}
+	if err = ac.DeleteTable(hrpc.NewDeleteTable(ctx, table)); err != ErrReadOnlyClient {
+		t.Errorf("Expected DeleteTable to fail with ErrReadOnlyClient, got %v", err)
+	}
+	list, err := hrpc.NewListTableNames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.checkWritable(list); err != nil {
+		t.Errorf("Expected ListTableNames to be allowed, got %v", err)
+	}
+	server := hrpc.ServerName{Host: "host", Port: 16020, StartCode: 1}
+	err = ac.DecommissionRegionServers(ctx, []hrpc.ServerName{server}, false)
+	if err != ErrReadOnlyClient {
+		t.Errorf("Expected DecommissionRegionServers to fail with ErrReadOnlyClient, got %v", err)
+	}
+	if err = ac.RecommissionRegionServer(ctx, server); err != ErrReadOnlyClient {
+		t.Errorf("Expected RecommissionRegionServer to fail with ErrReadOnlyClient, got %v", err)
+	}
+}
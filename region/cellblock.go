@@ -0,0 +1,277 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package region
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+)
+
+// keyValueCodec is the codec of the cellblocks, as the Java class that the
+// servers use to encode them.
+const keyValueCodec = "org.apache.hadoop.hbase.codec.KeyValueCodec"
+
+// errShortCellBlock is returned for a cellblock that ends in the middle of a
+// cell.
+var errShortCellBlock = errors.New("truncated cellblock")
+
+// CellBlocks returns an option that makes the region client ask the servers to
+// send the cells of the results in cellblocks, alongside the protobuf of the
+// responses, like HBase's Java client does.  Cellblocks are much cheaper to
+// decode than protobuf for the large results.  Only the responses use them:
+// the cells of the mutations are still sent in protobuf.
+func CellBlocks() ClientOption {
+	return func(c *Client) {
+		c.cellBlocks = true
+	}
+}
+
+// CellBlockCompression returns an option that makes the region client ask the
+// servers to send the cells of the results in cellblocks compressed with the
+// given compressor, which cuts the network transfer of large results at the
+// cost of CPU on both ends.
+func CellBlockCompression(comp Compressor) ClientOption {
+	return func(c *Client) {
+		c.cellBlocks = true
+		c.compressor = comp
+	}
+}
+
+// Compressor decompresses the cellblocks compressed by a Hadoop compression
+// codec on the servers.
+type Compressor interface {
+	// Class returns the Java class of the codec, which the servers must
+	// have, like "org.apache.hadoop.io.compress.GzipCodec".
+	Class() string
+
+	// Decompress returns the decompressed content of the given cellblock.
+	Decompress(block []byte) ([]byte, error)
+}
+
+// Gzip is the Compressor of Hadoop's GzipCodec.
+var Gzip Compressor = gzipCompressor{}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Class() string {
+	return "org.apache.hadoop.io.compress.GzipCodec"
+}
+
+func (gzipCompressor) Decompress(block []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(block))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// The Java classes of the block codecs of Hadoop.
+const (
+	SnappyCodec = "org.apache.hadoop.io.compress.SnappyCodec"
+	LZ4Codec    = "org.apache.hadoop.io.compress.Lz4Codec"
+)
+
+// BlockCompressor is the Compressor of a Hadoop codec that compresses the
+// data in blocks, like the SnappyCodec and the Lz4Codec, on top of a library
+// that decompresses the individual blocks.  It handles the framing of the
+// blocks that Hadoop adds.
+type BlockCompressor struct {
+	// Codec is the Java class of the codec, e.g. SnappyCodec.
+	Codec string
+
+	// DecompressBlock decompresses a single block, whose decompressed size
+	// is at most the given size.
+	DecompressBlock func(block []byte, maxSize int) ([]byte, error)
+}
+
+// Class returns the Java class of the codec.
+func (bc *BlockCompressor) Class() string {
+	return bc.Codec
+}
+
+// Decompress decompresses every block of the given cellblock.  Hadoop writes
+// the decompressed length of the data on 4 bytes, followed by the blocks, each
+// prefixed by its length on 4 bytes, and so on until the end of the data.
+func (bc *BlockCompressor) Decompress(buf []byte) ([]byte, error) {
+	var out []byte
+	for len(buf) > 0 {
+		if len(buf) < 4 {
+			return nil, errShortCellBlock
+		}
+		remaining := int(binary.BigEndian.Uint32(buf))
+		buf = buf[4:]
+		for remaining > 0 {
+			if len(buf) < 4 {
+				return nil, errShortCellBlock
+			}
+			length := binary.BigEndian.Uint32(buf)
+			if uint64(len(buf)-4) < uint64(length) {
+				return nil, errShortCellBlock
+			}
+			block, err := bc.DecompressBlock(buf[4:4+length], remaining)
+			if err != nil {
+				return nil, err
+			} else if len(block) == 0 || len(block) > remaining {
+				return nil, fmt.Errorf("invalid block of %d bytes with %d bytes left",
+					len(block), remaining)
+			}
+			out = append(out, block...)
+			remaining -= len(block)
+			buf = buf[4+length:]
+		}
+	}
+	return out, nil
+}
+
+// readCellBlock decodes the cellblock of the given length at the start of the
+// given buffer, decompressing it with the given compressor if not nil, and
+// adds its cells to the given response.
+func readCellBlock(msg proto.Message, buf []byte, length uint32, comp Compressor) error {
+	if uint64(length) > uint64(len(buf)) {
+		return errShortCellBlock
+	}
+	block := buf[:length]
+	if comp != nil {
+		var err error
+		if block, err = comp.Decompress(block); err != nil {
+			return fmt.Errorf("failed to decompress a cellblock: %s", err)
+		}
+	}
+	cells, err := decodeCellBlock(block)
+	if err != nil {
+		return err
+	}
+	return attachCells(msg, cells)
+}
+
+// decodeCellBlock decodes the cells of the given cellblock, encoded with the
+// KeyValueCodec: every cell is a KeyValue prefixed by its length.
+func decodeCellBlock(buf []byte) ([]*pb.Cell, error) {
+	var cells []*pb.Cell
+	for len(buf) > 0 {
+		if len(buf) < 4 {
+			return nil, errShortCellBlock
+		}
+		length := binary.BigEndian.Uint32(buf)
+		if uint64(len(buf)-4) < uint64(length) {
+			return nil, errShortCellBlock
+		}
+		cell, err := decodeKeyValue(buf[4 : 4+length])
+		if err != nil {
+			return nil, err
+		}
+		cells = append(cells, cell)
+		buf = buf[4+length:]
+	}
+	return cells, nil
+}
+
+// decodeKeyValue decodes a KeyValue: the lengths of its key and value on 4
+// bytes each, followed by the key, which is the length of the row on 2 bytes,
+// the row, the length of the family on 1 byte, the family, the qualifier, the
+// timestamp on 8 bytes and the type on 1 byte, followed by the value.  The
+// cell keeps referencing the given buffer.
+func decodeKeyValue(kv []byte) (*pb.Cell, error) {
+	if len(kv) < 8 {
+		return nil, errShortCellBlock
+	}
+	keyLength := uint64(binary.BigEndian.Uint32(kv))
+	valueLength := uint64(binary.BigEndian.Uint32(kv[4:]))
+	if keyLength+valueLength != uint64(len(kv)-8) || keyLength < 2+1+8+1 {
+		return nil, fmt.Errorf("invalid KeyValue of %d bytes with a %d bytes key"+
+			" and a %d bytes value", len(kv), keyLength, valueLength)
+	}
+	key := kv[8 : 8+keyLength]
+	rowLength := uint64(binary.BigEndian.Uint16(key))
+	if 2+rowLength+1+8+1 > keyLength {
+		return nil, fmt.Errorf("invalid KeyValue with a %d bytes row in a %d bytes key",
+			rowLength, keyLength)
+	}
+	row := key[2 : 2+rowLength]
+	familyLength := uint64(key[2+rowLength])
+	rest := key[2+rowLength+1:]
+	if familyLength+8+1 > uint64(len(rest)) {
+		return nil, fmt.Errorf("invalid KeyValue with a %d bytes family in a %d bytes key",
+			familyLength, keyLength)
+	}
+	family := rest[:familyLength]
+	qualifier := rest[familyLength : uint64(len(rest))-8-1]
+	timestamp := binary.BigEndian.Uint64(rest[len(rest)-8-1:])
+	cellType := pb.CellType(rest[len(rest)-1])
+	return &pb.Cell{
+		Row:       row,
+		Family:    family,
+		Qualifier: qualifier,
+		Timestamp: &timestamp,
+		CellType:  &cellType,
+		Value:     kv[8+keyLength:],
+	}, nil
+}
+
+// attachCells adds the given cells, decoded from the cellblock of the given
+// response, to the results of the response that they belong to.
+func attachCells(msg proto.Message, cells []*pb.Cell) error {
+	var err error
+	switch resp := msg.(type) {
+	case *pb.GetResponse:
+		cells, err = takeCells(resp.Result, cells)
+	case *pb.MutateResponse:
+		cells, err = takeCells(resp.Result, cells)
+	case *pb.ScanResponse:
+		// The results are only carried by the cellblock, and the response
+		// says how many cells each of them has.
+		partials := resp.GetPartialFlagPerResult()
+		for i, n := range resp.GetCellsPerResult() {
+			if uint64(n) > uint64(len(cells)) {
+				return errShortCellBlock
+			}
+			res := &pb.Result{Cell: cells[:n]}
+			if i < len(partials) {
+				res.Partial = proto.Bool(partials[i])
+			}
+			if resp.Stale != nil {
+				res.Stale = resp.Stale
+			}
+			resp.Results = append(resp.Results, res)
+			cells = cells[n:]
+		}
+	case *pb.MultiResponse:
+		for _, rar := range resp.GetRegionActionResult() {
+			for _, roe := range rar.GetResultOrException() {
+				if cells, err = takeCells(roe.Result, cells); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err != nil {
+		return err
+	} else if len(cells) != 0 {
+		return fmt.Errorf("%d cells of the cellblock don't belong to any result", len(cells))
+	}
+	return nil
+}
+
+// takeCells adds its associated cells to the given result, and returns the
+// remaining cells.
+func takeCells(res *pb.Result, cells []*pb.Cell) ([]*pb.Cell, error) {
+	n := res.GetAssociatedCellCount()
+	if n == 0 {
+		return cells, nil
+	} else if int64(n) > int64(len(cells)) {
+		return nil, errShortCellBlock
+	}
+	res.Cell = append(res.Cell, cells[:n]...)
+	return cells[n:], nil
+}
@@ -6,6 +6,7 @@
 package region
 
 import (
+	"crypto/tls"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -32,6 +33,10 @@ var (
 	// request that we didn't send
 	ErrMissingCallID = errors.New("HBase responded to a nonsensical call ID")
 
+	// ErrShortResponse is used when a response from HBase ends before one
+	// of the messages it says it contains
+	ErrShortResponse = errors.New("HBase sent a truncated response")
+
 	// javaRetryableExceptions is a map where all Java exceptions that signify
 	// the RPC should be sent again are listed (as keys). If a Java exception
 	// listed here is returned by HBase, the client should attempt to resend
@@ -42,6 +47,21 @@ var (
 		"org.apache.hadoop.hbase.exceptions.RegionOpeningException": struct{}{},
 	}
 
+	// javaOverloadedExceptions lists the Java exceptions that HBase returns
+	// when a RegionServer pushes back because it's overloaded.
+	javaOverloadedExceptions = map[string]struct{}{
+		"org.apache.hadoop.hbase.CallQueueTooBigException": struct{}{},
+	}
+
+	// javaNotRunningExceptions lists the Java exceptions that HBase returns
+	// when a server isn't running or, for the masters, isn't the active
+	// master or isn't initialized yet.
+	javaNotRunningExceptions = map[string]struct{}{
+		"org.apache.hadoop.hbase.MasterNotRunningException":        struct{}{},
+		"org.apache.hadoop.hbase.PleaseHoldException":              struct{}{},
+		"org.apache.hadoop.hbase.ipc.ServerNotRunningYetException": struct{}{},
+	}
+
 	// log is used to standardize logging across all subpackages
 	log = logger.Log
 )
@@ -53,6 +73,14 @@ const (
 	// MasterClient is a ClientType that means this client will talk to the
 	// master server
 	MasterClient = ClientType("MasterService")
+
+	// AdminClient is a ClientType that means this client will talk to the
+	// admin service of a region server
+	AdminClient = ClientType("AdminService")
+
+	// RegistryClient is a ClientType that means this client will talk to the
+	// connection registry of a master server, from HBase 2.3
+	RegistryClient = ClientType("ClientMetaService")
 )
 
 // UnrecoverableError is an error that this region.Client can't recover from.
@@ -76,6 +104,28 @@ func (e RetryableError) Error() string {
 	return e.error.Error()
 }
 
+// ServerOverloadedError is an error that indicates the RegionServer rejected
+// the RPC because its call queue is full.  The RPC can be sent again to the
+// same RegionServer, but only after backing off.
+type ServerOverloadedError struct {
+	error
+}
+
+func (e ServerOverloadedError) Error() string {
+	return e.error.Error()
+}
+
+// ServerNotRunningError is an error that indicates the server rejected the RPC
+// because it isn't running yet or anymore.  A master also rejects the RPCs
+// this way when it's a backup master or while it becomes the active master.
+type ServerNotRunningError struct {
+	error
+}
+
+func (e ServerNotRunningError) Error() string {
+	return e.error.Error()
+}
+
 // Client manages a connection to a RegionServer.
 type Client struct {
 	id uint32
@@ -104,17 +154,44 @@ type Client struct {
 	process chan struct{}
 
 	// sentRPCs contains the mapping of sent call IDs to RPC calls, so that when
-	// a response is received it can be tied to the correct RPC
+	// a response is received it can be tied to the correct RPC.  The calls
+	// that were forgotten map to nil, so that their response is discarded.
 	sentRPCs      map[uint32]hrpc.Call
 	sentRPCsMutex *sync.Mutex
 
 	rpcQueueSize  int
 	flushInterval time.Duration
+
+	// How to authenticate to the RegionServer with Kerberos, if needed.
+	kerberos *Kerberos
+
+	// The configuration of TLS, if the connection uses it.
+	tlsConfig *tls.Config
+
+	// The user reported to the RegionServer with simple authentication,
+	// if not the default one.
+	effectiveUser string
+
+	// The user that the RPCs are run on behalf of, if any.
+	proxyUser string
+
+	// Whether the server is asked to send the cells in cellblocks, and how
+	// they're compressed, if they are.
+	cellBlocks bool
+	compressor Compressor
+
+	// Where the RPCs that can't be (de)serialized are reported, if
+	// anywhere, with up to maxCapturedPayload bytes of their payload.
+	serializationErrorSink func(*SerializationError)
+	maxCapturedPayload     int
 }
 
+// ClientOption is an option of a region client.
+type ClientOption func(*Client)
+
 // NewClient creates a new RegionClient.
 func NewClient(host string, port uint16, ctype ClientType,
-	queueSize int, flushInterval time.Duration) (*Client, error) {
+	queueSize int, flushInterval time.Duration, options ...ClientOption) (*Client, error) {
 	addr := fmt.Sprintf("%s:%d", host, port)
 	conn, err := net.Dial("tcp", addr) // TODO: DialTimeout
 	if err != nil {
@@ -132,8 +209,18 @@ func NewClient(host string, port uint16, ctype ClientType,
 		rpcQueueSize:  queueSize,
 		flushInterval: flushInterval,
 	}
+	for _, option := range options {
+		option(c)
+	}
+	if c.tlsConfig != nil {
+		if err = c.startTLS(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
 	err = c.sendHello(ctype)
 	if err != nil {
+		c.conn.Close()
 		return nil, err
 	}
 	go c.processRpcs() // Writer goroutine
@@ -253,12 +340,13 @@ func (c *Client) receiveRpcs() {
 		}
 
 		resp := &pb.ResponseHeader{}
-		respLen, nb := proto.DecodeVarint(buf)
-		buf = buf[nb:]
-		err = proto.UnmarshalMerge(buf[:respLen], resp)
-		buf = buf[respLen:]
+		header, buf, err := splitDelimited(buf)
+		if err == nil {
+			err = proto.UnmarshalMerge(header, resp)
+		}
 		if err != nil {
 			// Failed to deserialize the response header
+			c.captureSerializationError(err, true, nil, 0, header)
 			c.setSendErr(err)
 			c.errorEncountered()
 			return
@@ -273,9 +361,15 @@ func (c *Client) receiveRpcs() {
 
 		c.sentRPCsMutex.Lock()
 		rpc, ok := c.sentRPCs[*resp.CallId]
+		if ok && rpc == nil {
+			// The call was forgotten, nobody waits for its response.
+			delete(c.sentRPCs, *resp.CallId)
+		}
 		c.sentRPCsMutex.Unlock()
 
-		if !ok {
+		if ok && rpc == nil {
+			continue
+		} else if !ok {
 			log.Errorf("Received a response with an unexpected call ID: %v", *resp.CallId)
 
 			log.Error("Waiting for responses to the following calls:")
@@ -293,20 +387,27 @@ func (c *Client) receiveRpcs() {
 
 		var rpcResp proto.Message
 		if resp.Exception == nil {
-			respLen, nb = proto.DecodeVarint(buf)
-			buf = buf[nb:]
+			var msg []byte
 			rpcResp = rpc.NewResponse()
-			err = proto.UnmarshalMerge(buf, rpcResp)
-			buf = buf[respLen:]
+			msg, buf, err = splitDelimited(buf)
+			if err == nil {
+				err = proto.UnmarshalMerge(msg, rpcResp)
+			}
+			if err != nil {
+				c.captureSerializationError(err, true, rpc, *resp.CallId, msg)
+			}
+			if meta := resp.CellBlockMeta; err == nil && meta != nil {
+				// The cells of the results follow the response.
+				err = readCellBlock(rpcResp, buf, meta.GetLength(), c.compressor)
+				if err != nil {
+					c.captureSerializationError(err, true, rpc, *resp.CallId, buf)
+				}
+			}
 		} else {
-			javaClass := *resp.Exception.ExceptionClassName
-			err = fmt.Errorf("HBase Java exception %s: \n%s", javaClass,
+			err = NewException(*resp.Exception.ExceptionClassName,
 				*resp.Exception.StackTrace)
-			if _, ok := javaRetryableExceptions[javaClass]; ok {
-				// This is a recoverable error. The client should retry.
-				err = RetryableError{err}
-			}
 		}
+		c.logResponse(rpc, *resp.CallId, rpcResp, err)
 		rpc.GetResultChan() <- hrpc.RPCResult{Msg: rpcResp, Error: err}
 
 		c.sentRPCsMutex.Lock()
@@ -315,6 +416,37 @@ func (c *Client) receiveRpcs() {
 	}
 }
 
+// splitDelimited returns the message at the start of the given buffer, which
+// is prefixed by its varint length, and what follows it.  It returns
+// ErrShortResponse if the buffer ends before the message does.
+func splitDelimited(buf []byte) ([]byte, []byte, error) {
+	size, nb := proto.DecodeVarint(buf)
+	if nb == 0 || size > uint64(len(buf)-nb) {
+		return nil, nil, ErrShortResponse
+	}
+	end := nb + int(size)
+	return buf[nb:end], buf[end:], nil
+}
+
+// NewException returns the error corresponding to the given Java exception
+// raised by HBase.  It's a RetryableError if the RPC should be sent again,
+// a ServerOverloadedError if it should be sent again after backing off, or a
+// ServerNotRunningError if the server can't serve RPCs.
+func NewException(javaClass, stackTrace string) error {
+	err := fmt.Errorf("HBase Java exception %s: \n%s", javaClass, stackTrace)
+	if _, ok := javaRetryableExceptions[javaClass]; ok {
+		// This is a recoverable error. The client should retry.
+		return RetryableError{err}
+	}
+	if _, ok := javaOverloadedExceptions[javaClass]; ok {
+		return ServerOverloadedError{err}
+	}
+	if _, ok := javaNotRunningExceptions[javaClass]; ok {
+		return ServerNotRunningError{err}
+	}
+	return err
+}
+
 func (c *Client) errorEncountered() {
 	c.writeMutex.Lock()
 	res := hrpc.RPCResult{Error: UnrecoverableError{c.getSendErr()}}
@@ -326,7 +458,9 @@ func (c *Client) errorEncountered() {
 
 	c.sentRPCsMutex.Lock()
 	for _, rpc := range c.sentRPCs {
-		rpc.GetResultChan() <- res
+		if rpc != nil {
+			rpc.GetResultChan() <- res
+		}
 	}
 	c.sentRPCs = nil
 	c.sentRPCsMutex.Unlock()
@@ -359,25 +493,40 @@ func (c *Client) readFully(buf []byte) error {
 	return nil
 }
 
-// Sends the "hello" message needed when opening a new connection.
+// Sends the "hello" message needed when opening a new connection, after
+// authenticating with SASL if Kerberos is used.
 func (c *Client) sendHello(ctype ClientType) error {
+	auth := byte(simpleAuth)
+	if c.kerberos != nil {
+		auth = kerberosAuth
+	}
 	connHeader := &pb.ConnectionHeader{
-		UserInfo: &pb.UserInformation{
-			EffectiveUser: proto.String("gopher"),
-		},
+		UserInfo:    c.userInfo(),
 		ServiceName: proto.String(string(ctype)),
-		//CellBlockCodecClass: "org.apache.hadoop.hbase.codec.KeyValueCodec",
+		VersionInfo: versionInfo(),
+	}
+	if c.cellBlocks {
+		connHeader.CellBlockCodecClass = proto.String(keyValueCodec)
+	}
+	if c.compressor != nil {
+		connHeader.CellBlockCompressorClass = proto.String(c.compressor.Class())
 	}
 	data, err := proto.Marshal(connHeader)
 	if err != nil {
 		return fmt.Errorf("failed to marshal connection header: %s", err)
 	}
 
-	const header = "HBas\x00\x50" // \x50 = Simple Auth.
-	buf := make([]byte, 0, len(header)+4+len(data))
-	buf = append(buf, header...)
-	buf = buf[:len(header)+4]
-	binary.BigEndian.PutUint32(buf[6:], uint32(len(data)))
+	if err = c.write([]byte{'H', 'B', 'a', 's', 0, auth}); err != nil {
+		return err
+	}
+	if c.kerberos != nil {
+		if err = c.saslConnect(); err != nil {
+			return err
+		}
+	}
+
+	buf := make([]byte, 4, 4+len(data))
+	binary.BigEndian.PutUint32(buf, uint32(len(data)))
 	buf = append(buf, data...)
 
 	return c.write(buf)
@@ -402,6 +551,28 @@ func (c *Client) QueueRPC(rpc hrpc.Call) error {
 	return nil
 }
 
+// Forget drops the given RPC, which its caller stopped waiting for: it isn't
+// sent if it's still queued, and its response is discarded if it was sent.
+func (c *Client) Forget(rpc hrpc.Call) {
+	c.writeMutex.Lock()
+	for i, queued := range c.rpcs {
+		if queued == rpc {
+			c.rpcs = append(c.rpcs[:i], c.rpcs[i+1:]...)
+			break
+		}
+	}
+	c.writeMutex.Unlock()
+
+	c.sentRPCsMutex.Lock()
+	for id, sent := range c.sentRPCs {
+		if sent == rpc {
+			c.sentRPCs[id] = nil
+			break
+		}
+	}
+	c.sentRPCsMutex.Unlock()
+}
+
 // sendRPC sends an RPC out to the wire.
 // Returns the response (for now, as the call is synchronous).
 func (c *Client) sendRPC(rpc hrpc.Call) error {
@@ -412,11 +583,19 @@ func (c *Client) sendRPC(rpc hrpc.Call) error {
 		MethodName:   proto.String(rpc.GetName()),
 		RequestParam: proto.Bool(true),
 	}
+	if t, ok := rpc.(interface {
+		GetTimeout() time.Duration
+	}); ok && t.GetTimeout() > 0 {
+		// Let the RegionServer drop the call once its caller gave up.
+		reqheader.Timeout = proto.Uint32(uint32(t.GetTimeout() / time.Millisecond))
+	}
 
 	payload, err := rpc.Serialize()
 	if err != nil {
+		c.captureSerializationError(err, false, rpc, c.id, payload)
 		return fmt.Errorf("Failed to serialize RPC: %s", err)
 	}
+	c.logRequest(rpc, c.id, payload)
 	payloadLen := proto.EncodeVarint(uint64(len(payload)))
 
 	headerData, err := proto.Marshal(reqheader)
@@ -6,8 +6,29 @@
 package region
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
 )
 
 func TestErrors(t *testing.T) {
@@ -16,3 +37,802 @@ func TestErrors(t *testing.T) {
 		t.Errorf("Wrong error message. Got %q, wanted %q", ue, "oops")
 	}
 }
+
+func TestNewException(t *testing.T) {
+	err := NewException("org.apache.hadoop.hbase.NotServingRegionException", "")
+	if _, ok := err.(RetryableError); !ok {
+		t.Errorf("Expected a RetryableError, got %T", err)
+	}
+	err = NewException("org.apache.hadoop.hbase.CallQueueTooBigException", "")
+	if _, ok := err.(ServerOverloadedError); !ok {
+		t.Errorf("Expected a ServerOverloadedError, got %T", err)
+	}
+	err = NewException("org.apache.hadoop.hbase.MasterNotRunningException", "")
+	if _, ok := err.(ServerNotRunningError); !ok {
+		t.Errorf("Expected a ServerNotRunningError, got %T", err)
+	}
+	err = NewException("org.apache.hadoop.hbase.TableNotFoundException", "")
+	switch err.(type) {
+	case RetryableError, ServerOverloadedError, ServerNotRunningError:
+		t.Errorf("Expected a plain error, got %T", err)
+	}
+}
+
+func TestCaptureSerializationError(t *testing.T) {
+	var captured []*SerializationError
+	c := &Client{host: "rs.example.com", port: 16020}
+	SerializationErrorSink(func(e *SerializationError) {
+		captured = append(captured, e)
+	}, 4)(c)
+	get, err := hrpc.NewGetStr(context.Background(), "test", "row")
+	if err != nil {
+		t.Fatal(err)
+	}
+	get.SetRegion(&Info{Name: []byte("test,,1234567890")})
+	// A Scan can't be sent in a Multi, so serializing the Multi fails.
+	scan, err := hrpc.NewScanStr(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scan.SetRegion(get.GetRegion())
+	multi := hrpc.NewMulti(context.Background())
+	multi.Add(scan)
+	if err := c.sendRPC(multi); err == nil {
+		t.Fatal("Expected sendRPC to fail")
+	}
+	c.captureSerializationError(errors.New("bad response"), true, get, 42,
+		[]byte("\x01\x02\x03\x04\x05\x06"))
+
+	if len(captured) != 2 {
+		t.Fatalf("Expected 2 captured errors, got %d", len(captured))
+	}
+	if e := captured[0]; e.Response || e.Call != "Multi" || string(e.Table) != "test" ||
+		e.Host != "rs.example.com" || e.Port != 16020 || e.Payload != nil {
+		t.Errorf("Unexpected serialization error: %#v", e)
+	}
+	e := captured[1]
+	if !e.Response || e.Call != "Get" || e.CallID != 42 || string(e.Key) != "row" ||
+		string(e.Region) != "test,,1234567890" || e.Size != 6 ||
+		!bytes.Equal(e.Payload, []byte("\x01\x02\x03\x04")) {
+		t.Errorf("Unexpected deserialization error: %#v", e)
+	}
+	if dump := e.Dump(); !strings.Contains(dump, "01 02 03 04") {
+		t.Errorf("Unexpected dump: %q", dump)
+	}
+}
+
+func TestFormatProto(t *testing.T) {
+	cell := &pb.Cell{
+		Row:       []byte("row"),
+		Family:    []byte("cf"),
+		Qualifier: []byte("secret"),
+		Value:     []byte("hunter2"),
+	}
+	resp := &pb.GetResponse{Result: &pb.Result{Cell: []*pb.Cell{cell}}}
+	s := formatProto(resp, DefaultMaxLoggedProto)
+	if strings.Contains(s, "hunter2") || !strings.Contains(s, "<7 bytes>") ||
+		!strings.Contains(s, "secret") {
+		t.Errorf("Expected the value of the cell to be redacted, got %q", s)
+	}
+	if string(cell.Value) != "hunter2" {
+		t.Errorf("Expected the protobuf to be left untouched, got value %q", cell.Value)
+	}
+	if s := formatProto(resp, 10); !strings.HasPrefix(s, "result:<ce...") ||
+		!strings.HasSuffix(s, "more bytes)") {
+		t.Errorf("Expected the protobuf to be truncated, got %q", s)
+	}
+
+	get, err := hrpc.NewGetStr(context.Background(), "test", "row")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := logProtos(get); ok {
+		t.Error("Expected the protobufs not to be logged by default")
+	}
+	get, err = hrpc.NewGetStr(context.Background(), "test", "row", hrpc.DebugProtos(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, max := logProtos(get); !ok || max != DefaultMaxLoggedProto {
+		t.Errorf("Expected the protobufs to be logged, got %v (max %d)", ok, max)
+	}
+	scan, err := hrpc.NewScanStr(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetProtoLogging(true, 100)
+	defer SetProtoLogging(false, 0)
+	if ok, max := logProtos(scan); !ok || max != 100 {
+		t.Errorf("Expected the protobufs of all calls to be logged, got %v (max %d)", ok, max)
+	}
+}
+
+func TestSendHelloVersionInfo(t *testing.T) {
+	SetApplication("myapp")
+	defer SetApplication("")
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+	c := &Client{conn: local}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.sendHello(RegionClient)
+	}()
+
+	preamble := make([]byte, 10)
+	if _, err := io.ReadFull(remote, preamble); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, binary.BigEndian.Uint32(preamble[6:]))
+	if _, err := io.ReadFull(remote, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	header := &pb.ConnectionHeader{}
+	if err := proto.Unmarshal(data, header); err != nil {
+		t.Fatal(err)
+	}
+	if v := header.VersionInfo; v.GetVersion() != Version || v.GetUser() != "myapp" {
+		t.Errorf("Unexpected version info: %v", v)
+	}
+	if user := header.UserInfo.GetEffectiveUser(); user != defaultUser {
+		t.Errorf("Expected the default user, got %q", user)
+	}
+}
+
+func TestUserInfo(t *testing.T) {
+	c := &Client{}
+	EffectiveUser("bob")(c)
+	if info := c.userInfo(); info.GetEffectiveUser() != "bob" || info.RealUser != nil {
+		t.Errorf("Expected the effective user, got %v", info)
+	}
+	ProxyUser("carol")(c)
+	if info := c.userInfo(); info.GetEffectiveUser() != "carol" ||
+		info.GetRealUser() != "bob" {
+		t.Errorf("Expected bob to impersonate carol, got %v", info)
+	}
+	KerberosAuth(&Kerberos{User: "alice@EXAMPLE.COM"})(c)
+	if info := c.userInfo(); info.GetEffectiveUser() != "carol" || info.RealUser != nil {
+		t.Errorf("Expected the Kerberos principal to impersonate carol, got %v", info)
+	}
+	c.proxyUser = ""
+	if info := c.userInfo(); info.GetEffectiveUser() != "alice@EXAMPLE.COM" {
+		t.Errorf("Expected the Kerberos principal, got %v", info)
+	}
+}
+
+// fakeGSSContext establishes a context in two steps, and wraps the messages
+// by prefixing them with a 'w', or an 'e' if they're encrypted.
+type fakeGSSContext struct {
+	steps int
+}
+
+func (f *fakeGSSContext) Init(token []byte) ([]byte, bool, error) {
+	f.steps++
+	if f.steps == 1 && token == nil {
+		return []byte("token1"), false, nil
+	} else if f.steps == 2 && string(token) == "challenge1" {
+		return []byte("token2"), true, nil
+	}
+	return nil, false, fmt.Errorf("unexpected token %q at step %d", token, f.steps)
+}
+
+func (f *fakeGSSContext) Wrap(msg []byte, conf bool) ([]byte, error) {
+	if conf {
+		return append([]byte("e"), msg...), nil
+	}
+	return append([]byte("w"), msg...), nil
+}
+
+func (f *fakeGSSContext) Unwrap(msg []byte) ([]byte, bool, error) {
+	if len(msg) == 0 || (msg[0] != 'w' && msg[0] != 'e') {
+		return nil, false, fmt.Errorf("not wrapped: %q", msg)
+	}
+	return msg[1:], msg[0] == 'e', nil
+}
+
+// readFrame reads a message prefixed by its length.
+func readFrame(r io.Reader) ([]byte, error) {
+	var sz [4]byte
+	if _, err := io.ReadFull(r, sz[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint32(sz[:]))
+	_, err := io.ReadFull(r, buf)
+	return buf, err
+}
+
+// writeSASLStep writes a successful SASL step with the given token.
+func writeSASLStep(w io.Writer, token []byte) error {
+	buf := make([]byte, 8, 8+len(token))
+	binary.BigEndian.PutUint32(buf[4:], uint32(len(token)))
+	_, err := w.Write(append(buf, token...))
+	return err
+}
+
+func TestSendHelloKerberos(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+	var principal string
+	c := &Client{conn: local, host: "RS.example.com", port: 16020}
+	KerberosAuth(&Kerberos{
+		User:             "alice@EXAMPLE.COM",
+		ServicePrincipal: "hbase/_HOST@EXAMPLE.COM",
+		NewContext: func(servicePrincipal string) (GSSContext, error) {
+			principal = servicePrincipal
+			return &fakeGSSContext{}, nil
+		},
+	})(c)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.sendHello(RegionClient)
+	}()
+
+	preamble := make([]byte, 6)
+	if _, err := io.ReadFull(remote, preamble); err != nil {
+		t.Fatal(err)
+	}
+	if string(preamble) != "HBas\x00\x51" {
+		t.Errorf("Expected the preamble of Kerberos authentication, got %q", preamble)
+	}
+	steps := []struct {
+		token     string
+		challenge []byte
+	}{
+		{token: "token1", challenge: []byte("challenge1")},
+		// The server supports all the security layers and messages of up
+		// to 64KB.
+		{token: "token2", challenge: []byte("w\x07\x01\x00\x00")},
+		{token: "w\x01\x00\x00\x00"},
+	}
+	for _, step := range steps {
+		token, err := readFrame(remote)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(token) != step.token {
+			t.Errorf("Expected SASL token %q, got %q", step.token, token)
+		}
+		if step.challenge == nil {
+			break
+		}
+		if err = writeSASLStep(remote, step.challenge); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := readFrame(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if principal != "hbase/rs.example.com@EXAMPLE.COM" {
+		t.Errorf("Unexpected service principal: %q", principal)
+	}
+	header := &pb.ConnectionHeader{}
+	if err := proto.Unmarshal(data, header); err != nil {
+		t.Fatal(err)
+	}
+	if user := header.UserInfo.GetEffectiveUser(); user != "alice@EXAMPLE.COM" {
+		t.Errorf("Expected the Kerberos principal as effective user, got %q", user)
+	}
+}
+
+func TestSendHelloKerberosFailure(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+	c := &Client{conn: local, host: "rs.example.com", port: 16020}
+	KerberosAuth(&Kerberos{
+		NewContext: func(string) (GSSContext, error) {
+			return &fakeGSSContext{}, nil
+		},
+	})(c)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.sendHello(RegionClient)
+	}()
+
+	preamble := make([]byte, 6)
+	if _, err := io.ReadFull(remote, preamble); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readFrame(remote); err != nil {
+		t.Fatal(err)
+	}
+	const class = "javax.security.sasl.SaslException"
+	buf := []byte{0, 0, 0, 1, 0, 0, 0, byte(len(class))}
+	buf = append(buf, class...)
+	buf = append(buf, 0, 0, 0, 3)
+	buf = append(buf, "bad"...)
+	if _, err := remote.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	err := <-errc
+	if err == nil || !strings.Contains(err.Error(), class) {
+		t.Errorf("Expected a SaslException, got %v", err)
+	}
+}
+
+func TestSendHelloKerberosPrivacy(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+	c := &Client{conn: local, host: "rs.example.com", port: 16020}
+	KerberosAuth(&Kerberos{
+		NewContext: func(string) (GSSContext, error) {
+			return &fakeGSSContext{}, nil
+		},
+		Protection: Privacy,
+	})(c)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.sendHello(RegionClient)
+	}()
+
+	preamble := make([]byte, 6)
+	if _, err := io.ReadFull(remote, preamble); err != nil {
+		t.Fatal(err)
+	}
+	for _, challenge := range []string{"challenge1", "w\x06\x00\x10\x00"} {
+		if _, err := readFrame(remote); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeSASLStep(remote, []byte(challenge)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	token, err := readFrame(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(token) != "w\x04\x01\x00\x00" {
+		t.Errorf("Expected to choose privacy with messages of up to 64KB, got %q", token)
+	}
+	// The connection header is encrypted.
+	wrapped, err := readFrame(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if wrapped[0] != 'e' {
+		t.Fatalf("Expected an encrypted connection header, got %q", wrapped)
+	}
+	header := &pb.ConnectionHeader{}
+	if err := proto.Unmarshal(wrapped[5:], header); err != nil {
+		t.Fatal(err)
+	}
+	if header.GetServiceName() != string(RegionClient) {
+		t.Errorf("Unexpected connection header: %v", header)
+	}
+
+	// So are the responses, which can be read across wrapped messages.
+	go func() {
+		for _, msg := range []string{"e\x00\x00", "e\x00\x02ok"} {
+			buf := make([]byte, 4, 4+len(msg))
+			binary.BigEndian.PutUint32(buf, uint32(len(msg)))
+			remote.Write(append(buf, msg...))
+		}
+		remote.Write([]byte("\x00\x00\x00\x03wno"))
+	}()
+	buf := make([]byte, 6)
+	if err := c.readFully(buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "\x00\x00\x00\x02ok" {
+		t.Errorf("Unexpected unwrapped data: %q", buf)
+	}
+	if err := c.readFully(buf[:1]); err == nil {
+		t.Error("Expected an error for a message that wasn't encrypted")
+	}
+}
+
+func TestGSSAPIProtectionMismatch(t *testing.T) {
+	g := &gssapiClient{ctx: &fakeGSSContext{}, protection: Privacy, established: true}
+	// The server only supports integrity.
+	if _, err := g.step([]byte("w\x02\x01\x00\x00")); err == nil ||
+		!strings.Contains(err.Error(), "privacy") {
+		t.Errorf("Expected an error about the privacy protection, got %v", err)
+	}
+}
+
+func TestSASLConnLimits(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+	// The server can receive wrapped messages of up to 10 bytes of data.
+	conn := &saslConn{Conn: local, ctx: &fakeGSSContext{}, maxSendSize: wrapOverhead + 10}
+	errc := make(chan error, 1)
+	go func() {
+		_, err := conn.Write([]byte("0123456789abcdefghijklmno"))
+		errc <- err
+	}()
+	var data []byte
+	for _, expected := range []string{"w0123456789", "wabcdefghij", "wklmno"} {
+		msg, err := readFrame(remote)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(msg) != expected {
+			t.Errorf("Expected wrapped message %q, got %q", expected, msg)
+		}
+		data = append(data, msg[1:]...)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "0123456789abcdefghijklmno" {
+		t.Errorf("Unexpected data: %q", data)
+	}
+
+	// The length of the messages read is checked before allocating them.
+	go remote.Write([]byte("\xff\xff\xff\xff"))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Error("Expected an error for a message longer than maxWrappedSize")
+	}
+}
+
+func TestReadSASLTokenLimit(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+	go remote.Write([]byte("\x00\x00\x00\x00\x7f\xff\xff\xff"))
+	c := &Client{conn: local}
+	if _, err := c.readSASLToken(); err == nil ||
+		!strings.Contains(err.Error(), "invalid SASL token length") {
+		t.Errorf("Expected an error about the length of the token, got %v", err)
+	}
+}
+
+// selfSignedCertificate returns a certificate for the given host, signed by
+// itself.
+func selfSignedCertificate(t *testing.T, host string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: host},
+		DNSNames:              []string{host},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	if cert.Leaf, err = x509.ParseCertificate(der); err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestStartTLS(t *testing.T) {
+	cert := selfSignedCertificate(t, "rs.example.com")
+	roots := x509.NewCertPool()
+	roots.AddCert(cert.Leaf)
+
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+	server := tls.Server(remote, &tls.Config{Certificates: []tls.Certificate{cert}})
+	errc := make(chan error, 1)
+	go func() {
+		c := &Client{conn: local, host: "rs.example.com", port: 16020}
+		TLS(&tls.Config{RootCAs: roots})(c)
+		if err := c.startTLS(); err != nil {
+			errc <- err
+			return
+		}
+		errc <- c.sendHello(RegionClient)
+	}()
+
+	preamble := make([]byte, 10)
+	if _, err := io.ReadFull(server, preamble); err != nil {
+		t.Fatal(err)
+	}
+	if string(preamble[:6]) != "HBas\x00\x50" {
+		t.Errorf("Expected the preamble over TLS, got %q", preamble)
+	}
+	data := make([]byte, binary.BigEndian.Uint32(preamble[6:]))
+	if _, err := io.ReadFull(server, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+
+	// The certificate of the server must be for the host connected to.  The
+	// connection is buffered, so that neither side blocks writing while the
+	// other gives up on the handshake.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			errc <- err
+			return
+		}
+		defer conn.Close()
+		errc <- tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}}).Handshake()
+	}()
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c := &Client{conn: conn, host: "other.example.com", port: 16020}
+	TLS(&tls.Config{RootCAs: roots})(c)
+	if err := c.startTLS(); err == nil {
+		t.Error("Expected the handshake to fail for another host")
+	}
+	conn.Close()
+	if err := <-errc; err == nil {
+		t.Error("Expected the server to see the handshake fail")
+	}
+}
+
+// encodeKeyValue encodes a Put cell with the KeyValueCodec.
+func encodeKeyValue(row, family, qualifier, value string, ts uint64) []byte {
+	key := make([]byte, 2, 2+len(row)+1+len(family)+len(qualifier)+8+1)
+	binary.BigEndian.PutUint16(key, uint16(len(row)))
+	key = append(key, row...)
+	key = append(key, byte(len(family)))
+	key = append(key, family...)
+	key = append(key, qualifier...)
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], ts)
+	key = append(append(key, buf[:]...), byte(pb.CellType_PUT))
+
+	kv := make([]byte, 12, 12+len(key)+len(value))
+	binary.BigEndian.PutUint32(kv, uint32(8+len(key)+len(value)))
+	binary.BigEndian.PutUint32(kv[4:], uint32(len(key)))
+	binary.BigEndian.PutUint32(kv[8:], uint32(len(value)))
+	return append(append(kv, key...), value...)
+}
+
+func TestReadCellBlock(t *testing.T) {
+	var block []byte
+	block = append(block, encodeKeyValue("r1", "cf", "a", "1", 42)...)
+	block = append(block, encodeKeyValue("r1", "cf", "b", "", 42)...)
+	block = append(block, encodeKeyValue("r2", "cf", "", "2", 43)...)
+
+	scan := &pb.ScanResponse{CellsPerResult: []uint32{2, 1},
+		PartialFlagPerResult: []bool{false, true}}
+	if err := readCellBlock(scan, block, uint32(len(block)), nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(scan.Results) != 2 || len(scan.Results[0].Cell) != 2 ||
+		len(scan.Results[1].Cell) != 1 || !scan.Results[1].GetPartial() {
+		t.Fatalf("Unexpected results %v", scan.Results)
+	}
+	cell := scan.Results[0].Cell[0]
+	if string(cell.Row) != "r1" || string(cell.Family) != "cf" ||
+		string(cell.Qualifier) != "a" || string(cell.Value) != "1" ||
+		cell.GetTimestamp() != 42 || cell.GetCellType() != pb.CellType_PUT {
+		t.Errorf("Unexpected cell %v", cell)
+	}
+	if cell = scan.Results[1].Cell[0]; string(cell.Row) != "r2" ||
+		len(cell.Qualifier) != 0 || string(cell.Value) != "2" {
+		t.Errorf("Unexpected cell %v", cell)
+	}
+
+	get := &pb.GetResponse{Result: &pb.Result{AssociatedCellCount: proto.Int32(2)}}
+	if err := readCellBlock(get, block, uint32(len(block)), nil); err == nil {
+		t.Error("Expected an error for the cells that don't belong to any result")
+	}
+	get = &pb.GetResponse{Result: &pb.Result{AssociatedCellCount: proto.Int32(3)}}
+	if err := readCellBlock(get, block, uint32(len(block)), nil); err != nil {
+		t.Fatal(err)
+	} else if len(get.Result.Cell) != 3 {
+		t.Errorf("Expected 3 cells, got %v", get.Result)
+	}
+
+	for _, length := range []int{len(block) + 1, len(block) - 1} {
+		if err := readCellBlock(&pb.ScanResponse{CellsPerResult: []uint32{3}},
+			block, uint32(length), nil); err == nil {
+			t.Errorf("Expected an error for a cellblock of %d bytes", length)
+		}
+	}
+}
+
+func TestSplitDelimited(t *testing.T) {
+	buf := append(proto.EncodeVarint(3), "abcdef"...)
+	msg, rest, err := splitDelimited(buf)
+	if err != nil || string(msg) != "abc" || string(rest) != "def" {
+		t.Errorf("Expected abc then def, got %q, %q (%v)", msg, rest, err)
+	}
+	// The servers can't make the client read past the frame.
+	for _, short := range [][]byte{nil, {0x80}, append(proto.EncodeVarint(10), "abc"...)} {
+		if _, _, err = splitDelimited(short); err != ErrShortResponse {
+			t.Errorf("Expected ErrShortResponse for %q, got %v", short, err)
+		}
+	}
+}
+
+func TestForget(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+	c := &Client{
+		conn:          local,
+		writeMutex:    &sync.Mutex{},
+		sentRPCsMutex: &sync.Mutex{},
+		sentRPCs:      make(map[uint32]hrpc.Call),
+	}
+	reg := &Info{Name: []byte("test,,1234567890")}
+	newGet := func() *hrpc.Get {
+		get, _ := hrpc.NewGetStr(context.Background(), "test", "a",
+			hrpc.Timeout(250*time.Millisecond))
+		get.SetRegion(reg)
+		return get
+	}
+	send := func(rpc hrpc.Call) *pb.RequestHeader {
+		errc := make(chan error, 1)
+		go func() {
+			errc <- c.sendRPC(rpc)
+		}()
+		var sz [4]byte
+		if _, err := io.ReadFull(remote, sz[:]); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, binary.BigEndian.Uint32(sz[:]))
+		if _, err := io.ReadFull(remote, buf); err != nil {
+			t.Fatal(err)
+		}
+		if err := <-errc; err != nil {
+			t.Fatal(err)
+		}
+		header := &pb.RequestHeader{}
+		if err := proto.Unmarshal(buf[1:1+buf[0]], header); err != nil {
+			t.Fatal(err)
+		}
+		return header
+	}
+
+	forgotten := newGet()
+	if header := send(forgotten); header.GetTimeout() != 250 {
+		t.Errorf("Expected a timeout of 250ms in the header, got %d", header.GetTimeout())
+	}
+	forgottenID := c.id
+	queued := newGet()
+	c.rpcs = []hrpc.Call{queued}
+	c.Forget(forgotten)
+	c.Forget(queued)
+	if len(c.rpcs) != 0 {
+		t.Errorf("Expected the queued RPC to be dropped, got %v", c.rpcs)
+	}
+
+	// The response of the forgotten RPC is discarded, the connection
+	// stays up for the others.
+	answered := newGet()
+	send(answered)
+	go c.receiveRpcs()
+	for _, id := range []uint32{forgottenID, c.id} {
+		header, _ := proto.Marshal(&pb.ResponseHeader{CallId: proto.Uint32(id)})
+		var frame []byte
+		frame = append(frame, proto.EncodeVarint(uint64(len(header)))...)
+		frame = append(frame, header...)
+		frame = append(frame, proto.EncodeVarint(0)...)
+		var sz [4]byte
+		binary.BigEndian.PutUint32(sz[:], uint32(len(frame)))
+		if _, err := remote.Write(append(sz[:], frame...)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if res := <-answered.GetResultChan(); res.Error != nil {
+		t.Errorf("Expected a response, got %v", res.Error)
+	}
+	select {
+	case res := <-forgotten.GetResultChan():
+		t.Errorf("Expected the response of the forgotten RPC to be discarded, got %v", res)
+	default:
+	}
+}
+
+func TestSendHelloCellBlocks(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+	c := &Client{conn: local}
+	CellBlocks()(c)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.sendHello(RegionClient)
+	}()
+	preamble := make([]byte, 10)
+	if _, err := io.ReadFull(remote, preamble); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, binary.BigEndian.Uint32(preamble[6:]))
+	if _, err := io.ReadFull(remote, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	header := &pb.ConnectionHeader{}
+	if err := proto.Unmarshal(data, header); err != nil {
+		t.Fatal(err)
+	}
+	if codec := header.GetCellBlockCodecClass(); codec != keyValueCodec {
+		t.Errorf("Expected the KeyValueCodec, got %q", codec)
+	}
+}
+
+func TestCellBlockCompression(t *testing.T) {
+	block := encodeKeyValue("r1", "cf", "a", "1", 42)
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write(block)
+	w.Close()
+	get := &pb.GetResponse{Result: &pb.Result{AssociatedCellCount: proto.Int32(1)}}
+	if err := readCellBlock(get, gz.Bytes(), uint32(gz.Len()), Gzip); err != nil {
+		t.Fatal(err)
+	} else if len(get.Result.Cell) != 1 || string(get.Result.Cell[0].Value) != "1" {
+		t.Errorf("Unexpected result %v", get.Result)
+	}
+
+	// The blocks are "compressed" by reversing their bytes.
+	reversed := func(b []byte) []byte {
+		r := make([]byte, len(b))
+		for i := range b {
+			r[len(b)-1-i] = b[i]
+		}
+		return r
+	}
+	bc := &BlockCompressor{
+		Codec: SnappyCodec,
+		DecompressBlock: func(block []byte, maxSize int) ([]byte, error) {
+			if len(block) > maxSize {
+				return nil, errors.New("block too large")
+			}
+			return reversed(block), nil
+		},
+	}
+	frame := func(blocks ...[]byte) []byte {
+		var total int
+		for _, b := range blocks {
+			total += len(b)
+		}
+		buf := make([]byte, 4)
+		binary.BigEndian.PutUint32(buf, uint32(total))
+		for _, b := range blocks {
+			var length [4]byte
+			binary.BigEndian.PutUint32(length[:], uint32(len(b)))
+			buf = append(append(buf, length[:]...), reversed(b)...)
+		}
+		return buf
+	}
+	compressed := append(frame(block[:10], block[10:]), frame(block)...)
+	scan := &pb.ScanResponse{CellsPerResult: []uint32{1, 1}}
+	if err := readCellBlock(scan, compressed, uint32(len(compressed)), bc); err != nil {
+		t.Fatal(err)
+	} else if len(scan.Results) != 2 || string(scan.Results[1].Cell[0].Row) != "r1" {
+		t.Errorf("Unexpected results %v", scan.Results)
+	}
+	if _, err := bc.Decompress(compressed[:len(compressed)-1]); err == nil {
+		t.Error("Expected an error for a truncated block")
+	}
+
+	c := &Client{}
+	CellBlockCompression(Gzip)(c)
+	if !c.cellBlocks || c.compressor.Class() != "org.apache.hadoop.io.compress.GzipCodec" {
+		t.Errorf("Expected compressed cellblocks, got %v, %v", c.cellBlocks, c.compressor)
+	}
+}
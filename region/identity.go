@@ -0,0 +1,99 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package region
+
+import (
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+)
+
+const (
+	// Version is the version of GoHBase reported to the servers in the
+	// header of each connection.
+	Version = "0.1.0"
+
+	// url is the URL reported to the servers in the header of each
+	// connection.
+	url = "https://github.com/tsuna/gohbase"
+
+	// defaultUser is the user reported to the servers when using simple
+	// authentication, unless set with EffectiveUser.
+	defaultUser = "gopher"
+)
+
+var (
+	applicationLock sync.RWMutex
+	application     string
+)
+
+// SetApplication sets the name or ID of the application reported to the
+// servers in the header of the connections opened from now on, so that their
+// operators can tell which applications are connected.
+func SetApplication(name string) {
+	applicationLock.Lock()
+	application = name
+	applicationLock.Unlock()
+}
+
+// versionInfo returns the version information sent in the connection header.
+// Since HBase doesn't have a field for it, the name of the application is
+// reported as the user that built the client.
+func versionInfo() *pb.VersionInfo {
+	applicationLock.RLock()
+	app := application
+	applicationLock.RUnlock()
+	return &pb.VersionInfo{
+		Version:     proto.String(Version),
+		Url:         proto.String(url),
+		Revision:    proto.String(""),
+		User:        proto.String(app),
+		Date:        proto.String(""),
+		SrcChecksum: proto.String(""),
+	}
+}
+
+// EffectiveUser returns an option that sets the user reported to the server
+// in the header of the connection when using simple authentication, which the
+// server then runs the RPCs as.  With Kerberos, the user is always the
+// principal of the client.
+func EffectiveUser(user string) ClientOption {
+	return func(c *Client) {
+		c.effectiveUser = user
+	}
+}
+
+// ProxyUser returns an option that makes the server run the RPCs of the
+// connection on behalf of the given user, like a Hadoop proxy user does.  The
+// real user, which is the effective user with simple authentication or the
+// principal of the client with Kerberos, must be allowed to impersonate it
+// with the hadoop.proxyuser settings of the cluster.
+func ProxyUser(user string) ClientOption {
+	return func(c *Client) {
+		c.proxyUser = user
+	}
+}
+
+// userInfo returns the user information reported in the header of the
+// connection.
+func (c *Client) userInfo() *pb.UserInformation {
+	user := defaultUser
+	if c.kerberos != nil {
+		user = c.kerberos.User
+	} else if c.effectiveUser != "" {
+		user = c.effectiveUser
+	}
+	if c.proxyUser == "" {
+		return &pb.UserInformation{EffectiveUser: proto.String(user)}
+	}
+	info := &pb.UserInformation{EffectiveUser: proto.String(c.proxyUser)}
+	if c.kerberos == nil {
+		// With Kerberos, the real user is the authenticated one.
+		info.RealUser = proto.String(user)
+	}
+	return info
+}
@@ -8,9 +8,13 @@ package region
 
 import (
 	"bytes"
+	"crypto/md5"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/golang/protobuf/proto"
@@ -32,6 +36,16 @@ type Info struct {
 	// StopKey.
 	StopKey []byte
 
+	// ID of the region (the timestamp at which it was created).
+	ID uint64
+
+	// ReplicaID is 0 for the primary replica of the region.
+	ReplicaID uint32
+
+	// The region info as found in the meta table, nil if this region wasn't
+	// looked up in meta.
+	pb *pb.RegionInfo
+
 	// The attributes before this mutex are supposed to be immutable.
 	// The attributes defined below can be changed and accesses must
 	// be protected with this mutex.
@@ -47,9 +61,9 @@ type Info struct {
 	available chan struct{}
 }
 
-// infoFromCell parses a KeyValue from the meta table and creates the
-// corresponding Info object.
-func infoFromCell(cell *pb.Cell) (*Info, error) {
+// InfoFromCell parses an info:regioninfo KeyValue from the meta table and
+// creates the corresponding Info object.
+func InfoFromCell(cell *pb.Cell) (*Info, error) {
 	value := cell.Value
 	if len(value) == 0 {
 		return nil, fmt.Errorf("empty value in %q", cell)
@@ -68,13 +82,41 @@ func infoFromCell(cell *pb.Cell) (*Info, error) {
 		return nil, fmt.Errorf("failed to decode %q: %s", cell, err)
 	}
 	return &Info{
-		Table:    regInfo.TableName.Qualifier,
+		Table:    hrpc.FullTableName(regInfo.TableName),
 		Name:     cell.Row,
 		StartKey: regInfo.StartKey,
 		StopKey:  regInfo.EndKey,
+		ID:       regInfo.GetRegionId(),
+		pb:       regInfo,
 	}, nil
 }
 
+// Replica describes one replica of a region and where it is served.
+type Replica struct {
+	Info *Info
+	Host string
+	Port uint16
+}
+
+// ServerFromCell parses the value of an info:server cell from the meta table.
+// An empty host and a port of 0 are returned if the cell is empty, which
+// happens while the region is being moved.
+func ServerFromCell(cell *pb.Cell) (string, uint16, error) {
+	value := cell.Value
+	if len(value) == 0 {
+		return "", 0, nil // Empty during NSRE.
+	}
+	colon := bytes.IndexByte(value, ':')
+	if colon < 1 { // Colon can't be at the beginning.
+		return "", 0, fmt.Errorf("broken meta: no colon found in info:server %q", cell)
+	}
+	port, err := strconv.ParseUint(string(value[colon+1:]), 10, 16)
+	if err != nil {
+		return "", 0, err
+	}
+	return string(value[:colon]), uint16(port), nil
+}
+
 // ParseRegionInfo parses the contents of a row from the meta table.
 // It's guaranteed to return a region info and a host/port OR return an error.
 func ParseRegionInfo(metaRow *pb.GetResponse) (
@@ -88,26 +130,16 @@ func ParseRegionInfo(metaRow *pb.GetResponse) (
 		switch string(cell.Qualifier) {
 		case "regioninfo":
 			var err error
-			reg, err = infoFromCell(cell)
+			reg, err = InfoFromCell(cell)
 			if err != nil {
 				return nil, "", 0, err
 			}
 		case "server":
-			value := cell.Value
-			if len(value) == 0 {
-				continue // Empty during NSRE.
-			}
-			colon := bytes.IndexByte(value, ':')
-			if colon < 1 { // Colon can't be at the beginning.
-				return nil, "", 0,
-					fmt.Errorf("broken meta: no colon found in info:server %q", cell)
-			}
-			host = string(value[:colon])
-			portU64, err := strconv.ParseUint(string(value[colon+1:]), 10, 16)
+			var err error
+			host, port, err = ServerFromCell(cell)
 			if err != nil {
 				return nil, "", 0, err
 			}
-			port = uint16(portU64)
 		default:
 			// Other kinds of qualifiers: ignore them.
 			// TODO: If this is the parent of a split region, there are two other
@@ -131,6 +163,106 @@ func ParseRegionInfo(metaRow *pb.GetResponse) (
 	return reg, host, port, nil
 }
 
+// ParseRegionReplicas parses the contents of a row from the meta table and
+// returns all the replicas of the region that are currently assigned to a
+// RegionServer, ordered by replica ID (so the primary replica comes first if
+// it's assigned).
+func ParseRegionReplicas(metaRow *pb.GetResponse) ([]Replica, error) {
+	var reg *Info
+	locations := make(map[uint32]Replica)
+	for _, cell := range metaRow.Result.Cell {
+		qualifier := string(cell.Qualifier)
+		switch {
+		case qualifier == "regioninfo":
+			var err error
+			reg, err = InfoFromCell(cell)
+			if err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(qualifier, "server"):
+			var replicaID uint64
+			if suffix := qualifier[len("server"):]; suffix != "" {
+				if suffix[0] != '_' {
+					continue // e.g. info:serverstartcode
+				}
+				var err error
+				replicaID, err = strconv.ParseUint(suffix[1:], 16, 16)
+				if err != nil {
+					return nil, fmt.Errorf("broken meta: invalid replica ID in %q", cell)
+				}
+			}
+			host, port, err := ServerFromCell(cell)
+			if err != nil {
+				return nil, err
+			}
+			if port != 0 {
+				locations[uint32(replicaID)] = Replica{Host: host, Port: port}
+			}
+		}
+	}
+
+	if reg == nil {
+		return nil, fmt.Errorf("Meta seems to be broken, there was no region in %s",
+			metaRow)
+	} else if len(locations) == 0 {
+		return nil, fmt.Errorf("Meta doesn't have a server location in %s", metaRow)
+	}
+	ids := make([]int, 0, len(locations))
+	for id := range locations {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	replicas := make([]Replica, len(ids))
+	for i, id := range ids {
+		replicas[i] = locations[uint32(id)]
+		replicas[i].Info = reg.Replica(uint32(id))
+	}
+	return replicas, nil
+}
+
+// Replica returns the Info of the given replica of this region.
+func (i *Info) Replica(replicaID uint32) *Info {
+	if replicaID == i.ReplicaID {
+		return i
+	}
+	// Region names are of the form: table,start_key,id[_replica][.MD5.]
+	// where the MD5 is the hex-encoded hash of the name that precedes it.
+	name := i.Name
+	const md5Len = 2 * md5.Size
+	newFormat := len(name) > md5Len+2 && name[len(name)-1] == '.' &&
+		name[len(name)-md5Len-2] == '.'
+	if newFormat {
+		name = name[:len(name)-md5Len-2]
+	}
+	if i.ReplicaID != 0 {
+		name = name[:bytes.LastIndexByte(name, '_')]
+	}
+	replicaName := make([]byte, len(name), len(name)+md5Len+7)
+	copy(replicaName, name)
+	if replicaID != 0 {
+		replicaName = append(replicaName, fmt.Sprintf("_%04X", replicaID)...)
+	}
+	if newFormat {
+		sum := md5.Sum(replicaName)
+		replicaName = append(replicaName, '.')
+		replicaName = append(replicaName, hex.EncodeToString(sum[:])...)
+		replicaName = append(replicaName, '.')
+	}
+	replica := &Info{
+		Table:     i.Table,
+		Name:      replicaName,
+		StartKey:  i.StartKey,
+		StopKey:   i.StopKey,
+		ID:        i.ID,
+		ReplicaID: replicaID,
+	}
+	if i.pb != nil {
+		replica.pb = proto.Clone(i.pb).(*pb.RegionInfo)
+		replica.pb.ReplicaId = proto.Int32(int32(replicaID))
+	}
+	return replica
+}
+
 // IsUnavailable returns true if this region has been marked as unavailable.
 func (i *Info) IsUnavailable() bool {
 	i.m.Lock()
@@ -198,6 +330,100 @@ func (i *Info) GetTable() []byte {
 	return i.Table
 }
 
+// GetID returns the ID of the region, i.e. the timestamp at which it was
+// created.
+func (i *Info) GetID() uint64 {
+	return i.ID
+}
+
+// GetEncodedName returns the encoded name of the region, which is how HBase
+// names the region in its UI, logs and on HDFS.  It's the MD5 suffix of the
+// region name, or for the regions with an old-style name (like the meta
+// region), the Jenkins hash of the name.
+func (i *Info) GetEncodedName() string {
+	name := i.Name
+	const md5Len = 2 * md5.Size
+	if len(name) > md5Len+2 && name[len(name)-1] == '.' && name[len(name)-md5Len-2] == '.' {
+		return string(name[len(name)-md5Len-1 : len(name)-1])
+	}
+	return strconv.FormatUint(uint64(jenkinsHash(name)&0x7fffffff), 10)
+}
+
+// GetPB returns the region info as stored in the meta table.  For the
+// regions that weren't looked up in meta, it's rebuilt from this Info.
+// The returned message must not be modified.
+func (i *Info) GetPB() *pb.RegionInfo {
+	if i.pb != nil {
+		return i.pb
+	}
+	return &pb.RegionInfo{
+		RegionId:  proto.Uint64(i.ID),
+		TableName: hrpc.ProtoTableName(i.Table),
+		StartKey:  i.StartKey,
+		EndKey:    i.StopKey,
+		ReplicaId: proto.Int32(int32(i.ReplicaID)),
+	}
+}
+
+// jenkinsHash is the hash function HBase uses to encode old-style region
+// names (Bob Jenkins' lookup3 hashlittle with an initial value of 0).
+func jenkinsHash(key []byte) uint32 {
+	rot := func(x uint32, k uint) uint32 {
+		return x<<k | x>>(32-k)
+	}
+	a := 0xdeadbeef + uint32(len(key))
+	b, c := a, a
+	for len(key) > 12 {
+		a += binary.LittleEndian.Uint32(key)
+		b += binary.LittleEndian.Uint32(key[4:])
+		c += binary.LittleEndian.Uint32(key[8:])
+		// mix(a, b, c)
+		a -= c
+		a ^= rot(c, 4)
+		c += b
+		b -= a
+		b ^= rot(a, 6)
+		a += c
+		c -= b
+		c ^= rot(b, 8)
+		b += a
+		a -= c
+		a ^= rot(c, 16)
+		c += b
+		b -= a
+		b ^= rot(a, 19)
+		a += c
+		c -= b
+		c ^= rot(b, 4)
+		b += a
+		key = key[12:]
+	}
+	if len(key) == 0 {
+		return c
+	}
+	var tail [12]byte
+	copy(tail[:], key)
+	a += binary.LittleEndian.Uint32(tail[:])
+	b += binary.LittleEndian.Uint32(tail[4:])
+	c += binary.LittleEndian.Uint32(tail[8:])
+	// final(a, b, c)
+	c ^= b
+	c -= rot(b, 14)
+	a ^= c
+	a -= rot(c, 11)
+	b ^= a
+	b -= rot(a, 25)
+	c ^= b
+	c -= rot(b, 16)
+	a ^= c
+	a -= rot(c, 4)
+	b ^= a
+	b -= rot(a, 14)
+	c ^= b
+	c -= rot(b, 24)
+	return c
+}
+
 // GetClient returns region client
 func (i *Info) GetClient() hrpc.RegionClient {
 	i.m.Lock()
@@ -27,12 +27,12 @@ func TestInfoFromMeta(t *testing.T) {
 		Timestamp: proto.Uint64(1431921690626),
 		CellType:  &put,
 	}
-	info, err := infoFromCell(cell)
+	info, err := InfoFromCell(cell)
 	if err == nil || !strings.HasPrefix(err.Error(), "empty value") {
 		t.Errorf("Unexpected error on empty value: %s", err)
 	}
 	cell.Value = buf
-	info, err = infoFromCell(cell)
+	info, err = InfoFromCell(cell)
 	if err != nil {
 		t.Fatalf("Failed to parse cell: %s", err)
 	}
@@ -48,29 +48,130 @@ func TestInfoFromMeta(t *testing.T) {
 	if s := info.String(); s != expected {
 		t.Errorf("Unexpected string representation.\nExpected: %q\n  Actual: %q", expected, s)
 	}
+	if name := info.GetEncodedName(); name != "53e41f94d5c3087af0d13259b8c4186d" {
+		t.Errorf("Unexpected encoded name: %q", name)
+	}
+	if id := info.GetID(); id != 1431921690563 {
+		t.Errorf("Unexpected region ID: %d", id)
+	}
+	if regInfo := info.GetPB(); string(regInfo.TableName.Namespace) != "default" ||
+		string(regInfo.TableName.Qualifier) != "table" || regInfo.GetRegionId() != info.ID {
+		t.Errorf("Unexpected region info: %s", regInfo)
+	}
 
 	// Corrupt the protobuf.
 	buf[4] = 0xFF
-	_, err = infoFromCell(cell)
+	_, err = InfoFromCell(cell)
 	if err == nil || !strings.HasPrefix(err.Error(), "failed to decode") {
 		t.Errorf("Unexpected error on corrupt protobuf: %s", err)
 	}
 
 	// Corrupt the magic number.
 	buf[1] = 0xFF
-	_, err = infoFromCell(cell)
+	_, err = InfoFromCell(cell)
 	if err == nil || !strings.HasPrefix(err.Error(), "invalid magic number") {
 		t.Errorf("Unexpected error on invalid magic number %s", err)
 	}
 
 	// Corrupt the magic number (first byte).
 	buf[0] = 0xFF
-	_, err = infoFromCell(cell)
+	_, err = InfoFromCell(cell)
 	if err == nil || !strings.HasPrefix(err.Error(), "unsupported region info version") {
 		t.Errorf("Unexpected error on invalid magic number %s", err)
 	}
 }
 
+func TestGetEncodedName(t *testing.T) {
+	meta := &Info{Table: []byte("hbase:meta"), Name: []byte("hbase:meta,,1"), ID: 1}
+	if name := meta.GetEncodedName(); name != "1588230740" {
+		t.Errorf("Unexpected encoded name for the meta region: %q", name)
+	}
+	regInfo := meta.GetPB()
+	if string(regInfo.TableName.Namespace) != "hbase" ||
+		string(regInfo.TableName.Qualifier) != "meta" || regInfo.GetRegionId() != 1 {
+		t.Errorf("Unexpected region info for the meta region: %s", regInfo)
+	}
+}
+
+func TestInfoFromMetaNamespace(t *testing.T) {
+	regInfo := &pb.RegionInfo{
+		RegionId:  proto.Uint64(1431921690563),
+		TableName: &pb.TableName{Namespace: []byte("ns"), Qualifier: []byte("table")},
+	}
+	b, err := proto.Marshal(regInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cell := &pb.Cell{
+		Row:       []byte("ns:table,,1431921690563.53e41f94d5c3087af0d13259b8c4186d."),
+		Family:    []byte("info"),
+		Qualifier: []byte("regioninfo"),
+		// The value ends with 4 bytes that aren't part of the protobuf.
+		Value: append(append([]byte("PBUF"), b...), 0, 0, 0, 0),
+	}
+	info, err := InfoFromCell(cell)
+	if err != nil {
+		t.Fatalf("Failed to parse cell: %s", err)
+	}
+	if string(info.Table) != "ns:table" {
+		t.Errorf("Expected table ns:table, got %q", info.Table)
+	}
+	rebuilt := &Info{Table: info.Table, ID: info.ID}
+	if tn := rebuilt.GetPB().TableName; string(tn.Namespace) != "ns" ||
+		string(tn.Qualifier) != "table" {
+		t.Errorf("Unexpected table name in the rebuilt region info: %s", tn)
+	}
+}
+
+func TestParseRegionReplicas(t *testing.T) {
+	put := pb.CellType_PUT
+	regionName := []byte("table,foo,1431921690563.53e41f94d5c3087af0d13259b8c4186d.")
+	cell := func(qualifier, value string) *pb.Cell {
+		return &pb.Cell{
+			Row:       regionName,
+			Family:    []byte("info"),
+			Qualifier: []byte(qualifier),
+			Value:     []byte(value),
+			CellType:  &put,
+		}
+	}
+	metaRow := &pb.GetResponse{Result: &pb.Result{Cell: []*pb.Cell{
+		cell("regioninfo", "PBUF\010\303\217\274\251\326)\022\020\n\007default"+
+			"\022\005table\032\003foo\"\000(\0000\0008\000"),
+		cell("seqnumDuringOpen", "\000\000\000\000\000\000\000\002"),
+		cell("server", "rs1:16020"),
+		cell("server_0001", "rs2:16020"),
+		cell("server_0002", ""), // Not assigned.
+		cell("serverstartcode", "\000\000\001M\201j\244\020"),
+		cell("serverstartcode_0001", "\000\000\001M\201j\244\020"),
+	}}}
+	replicas, err := ParseRegionReplicas(metaRow)
+	if err != nil {
+		t.Fatalf("Failed to parse meta row: %s", err)
+	}
+	if len(replicas) != 2 {
+		t.Fatalf("Expected 2 replicas, got %d: %v", len(replicas), replicas)
+	}
+	if r := replicas[0]; r.Host != "rs1" || r.Port != 16020 ||
+		r.Info.ReplicaID != 0 || !bytes.Equal(r.Info.Name, regionName) {
+		t.Errorf("Unexpected primary replica %s on %s:%d", r.Info, r.Host, r.Port)
+	}
+	replicaName := []byte("table,foo,1431921690563_0001.997a3c1f23d1239f30dc7e5fa88f803f.")
+	if r := replicas[1]; r.Host != "rs2" || r.Port != 16020 ||
+		r.Info.ReplicaID != 1 || !bytes.Equal(r.Info.Name, replicaName) {
+		t.Errorf("Unexpected secondary replica %s on %s:%d", r.Info, r.Host, r.Port)
+	}
+	if !bytes.Equal(replicas[1].Info.StartKey, []byte("foo")) {
+		t.Errorf("Expected StartKey %q, got %q", "foo", replicas[1].Info.StartKey)
+	}
+	// The MD5 in regionName above is bogus, so going back to the primary
+	// yields a different (but correct) name.
+	primaryName := []byte("table,foo,1431921690563.4e8e6baf2a8cd88415140d6c63d6ecab.")
+	if primary := replicas[1].Info.Replica(0); !bytes.Equal(primary.Name, primaryName) {
+		t.Errorf("Expected primary region name %q, got %q", primaryName, primary.Name)
+	}
+}
+
 func TestCompare(t *testing.T) {
 	// Test cases from AsyncHBase
 	testcases := []struct {
@@ -0,0 +1,202 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package region
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+)
+
+// DefaultMaxLoggedProto is the number of bytes of each decoded protobuf
+// logged when no other limit is set.
+const DefaultMaxLoggedProto = 4096
+
+var (
+	protoLoggingLock sync.RWMutex
+	protoLoggingAll  bool
+	maxLoggedProto   = DefaultMaxLoggedProto
+
+	// requestTypes are the protobufs of the requests of the RPC methods, so
+	// that the requests can be logged as they were sent on the wire.
+	requestTypes = map[string]proto.Message{
+		"Get":                       &pb.GetRequest{},
+		"Scan":                      &pb.ScanRequest{},
+		"Mutate":                    &pb.MutateRequest{},
+		"Multi":                     &pb.MultiRequest{},
+		"ExecService":               &pb.CoprocessorServiceRequest{},
+		"CreateTable":               &pb.CreateTableRequest{},
+		"DeleteTable":               &pb.DeleteTableRequest{},
+		"truncateTable":             &pb.TruncateTableRequest{},
+		"EnableTable":               &pb.EnableTableRequest{},
+		"DisableTable":              &pb.DisableTableRequest{},
+		"GetTableNames":             &pb.GetTableNamesRequest{},
+		"GetTableDescriptors":       &pb.GetTableDescriptorsRequest{},
+		"ModifyTable":               &pb.ModifyTableRequest{},
+		"AddColumn":                 &pb.AddColumnRequest{},
+		"ModifyColumn":              &pb.ModifyColumnRequest{},
+		"DeleteColumn":              &pb.DeleteColumnRequest{},
+		"GetSchemaAlterStatus":      &pb.GetSchemaAlterStatusRequest{},
+		"CreateNamespace":           &pb.CreateNamespaceRequest{},
+		"ModifyNamespace":           &pb.ModifyNamespaceRequest{},
+		"DeleteNamespace":           &pb.DeleteNamespaceRequest{},
+		"ListNamespaceDescriptors":  &pb.ListNamespaceDescriptorsRequest{},
+		"ListTableNamesByNamespace": &pb.ListTableNamesByNamespaceRequest{},
+		"Snapshot":                  &pb.SnapshotRequest{},
+		"IsSnapshotDone":            &pb.IsSnapshotDoneRequest{},
+		"DeleteSnapshot":            &pb.DeleteSnapshotRequest{},
+		"GetCompletedSnapshots":     &pb.GetCompletedSnapshotsRequest{},
+		"RestoreSnapshot":           &pb.RestoreSnapshotRequest{},
+		"IsRestoreSnapshotDone":     &pb.IsRestoreSnapshotDoneRequest{},
+		"DispatchMergingRegions":    &pb.DispatchMergingRegionsRequest{},
+		"MoveRegion":                &pb.MoveRegionRequest{},
+		"AssignRegion":              &pb.AssignRegionRequest{},
+		"UnassignRegion":            &pb.UnassignRegionRequest{},
+		"OfflineRegion":             &pb.OfflineRegionRequest{},
+		"SetBalancerRunning":        &pb.SetBalancerRunningRequest{},
+		"IsBalancerEnabled":         &pb.IsBalancerEnabledRequest{},
+		"Balance":                   &pb.BalanceRequest{},
+		"SetNormalizerRunning":      &pb.SetNormalizerRunningRequest{},
+		"IsNormalizerEnabled":       &pb.IsNormalizerEnabledRequest{},
+		"Normalize":                 &pb.NormalizeRequest{},
+		"EnableCatalogJanitor":      &pb.EnableCatalogJanitorRequest{},
+		"IsCatalogJanitorEnabled":   &pb.IsCatalogJanitorEnabledRequest{},
+		"RunCatalogScan":            &pb.RunCatalogScanRequest{},
+		"GetClusterStatus":          &pb.GetClusterStatusRequest{},
+		"DecommissionRegionServers": &pb.DecommissionRegionServersRequest{},
+		"RecommissionRegionServer":  &pb.RecommissionRegionServerRequest{},
+		"getProcedureResult":        &pb.GetProcedureResultRequest{},
+		"GetRegionInfo":             &pb.GetRegionInfoRequest{},
+		"CompactRegion":             &pb.CompactRegionRequest{},
+		"FlushRegion":               &pb.FlushRegionRequest{},
+		"SplitRegion":               &pb.SplitRegionRequest{},
+
+		"ListDecommissionedRegionServers": &pb.ListDecommissionedRegionServersRequest{},
+	}
+)
+
+// SetProtoLogging sets whether the requests and responses of all the RPCs
+// are logged as decoded protobufs, which helps diagnosing disagreements
+// with a given version of HBase on the wire.  When disabled, which is the
+// default, only the calls created with the hrpc.DebugProtos option are
+// logged.  At most maxSize bytes of each protobuf are logged
+// (DefaultMaxLoggedProto if maxSize <= 0), and the values of the cells and
+// attributes are replaced by their size, since they may hold secrets.
+func SetProtoLogging(all bool, maxSize int) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxLoggedProto
+	}
+	protoLoggingLock.Lock()
+	protoLoggingAll = all
+	maxLoggedProto = maxSize
+	protoLoggingLock.Unlock()
+}
+
+// logProtos returns whether the protobufs of the given call must be logged,
+// and the maximum size to log.
+func logProtos(rpc hrpc.Call) (bool, int) {
+	protoLoggingLock.RLock()
+	all, max := protoLoggingAll, maxLoggedProto
+	protoLoggingLock.RUnlock()
+	if all {
+		return true, max
+	}
+	d, ok := rpc.(interface {
+		GetDebugProtos() bool
+	})
+	return ok && d.GetDebugProtos(), max
+}
+
+// logRequest logs the given serialized request of the given call, if needed.
+func (c *Client) logRequest(rpc hrpc.Call, callID uint32, payload []byte) {
+	ok, max := logProtos(rpc)
+	if !ok {
+		return
+	}
+	var req string
+	if request, ok := requestTypes[rpc.GetName()]; !ok {
+		req = truncate(hex.EncodeToString(payload), max)
+	} else if msg := proto.Clone(request); proto.Unmarshal(payload, msg) != nil {
+		req = truncate(hex.EncodeToString(payload), max)
+	} else {
+		req = formatProto(msg, max)
+	}
+	log.Infof("Sending %s request (call ID %d, %d bytes) to %s:%d: %s",
+		rpc.GetName(), callID, len(payload), c.host, c.port, req)
+}
+
+// logResponse logs the response of the given call, if needed.
+func (c *Client) logResponse(rpc hrpc.Call, callID uint32, msg proto.Message, err error) {
+	ok, max := logProtos(rpc)
+	if !ok {
+		return
+	}
+	if err != nil {
+		log.Infof("Received %s error (call ID %d) from %s:%d: %s",
+			rpc.GetName(), callID, c.host, c.port, truncate(err.Error(), max))
+		return
+	}
+	log.Infof("Received %s response (call ID %d) from %s:%d: %s",
+		rpc.GetName(), callID, c.host, c.port, formatProto(msg, max))
+}
+
+// formatProto returns the text representation of the given protobuf, with
+// its values redacted and truncated to the given size.
+func formatProto(msg proto.Message, max int) string {
+	if msg == nil {
+		return "<nil>"
+	}
+	msg = proto.Clone(msg)
+	redact(reflect.ValueOf(msg))
+	return truncate(proto.CompactTextString(msg), max)
+}
+
+// redact replaces, in place, the Value fields of the given protobuf and of
+// the protobufs it contains by their size.  That covers the values of the
+// cells, of the columns of the mutations, of the attributes and of the
+// comparators.
+func redact(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			redact(v.Elem())
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			redact(v.Index(i))
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Field(i)
+			if t.Field(i).Name == "Value" && f.Type() == reflect.TypeOf([]byte(nil)) {
+				if !f.IsNil() {
+					f.SetBytes([]byte(fmt.Sprintf("<%d bytes>", f.Len())))
+				}
+				continue
+			}
+			if t.Field(i).PkgPath == "" {
+				redact(f)
+			}
+		}
+	}
+}
+
+// truncate truncates the given string to the given size.
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return fmt.Sprintf("%s... (%d more bytes)", s[:max], len(s)-max)
+}
@@ -0,0 +1,357 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package region
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// Authentication methods, as sent in the preamble of the connections.
+const (
+	simpleAuth   = 0x50
+	kerberosAuth = 0x51
+)
+
+// switchToSimpleAuth is sent by the servers instead of the length of a SASL
+// token when they don't require authentication.
+const switchToSimpleAuth = -88
+
+// Protection is the protection of the messages of a connection once
+// authenticated, which is a security layer of the SASL GSSAPI mechanism (RFC
+// 4752).  It must be one of those accepted by hbase.rpc.protection.
+type Protection byte
+
+const (
+	// Authentication leaves the messages unprotected once authenticated.
+	Authentication = Protection(1)
+	// Integrity signs the messages.
+	Integrity = Protection(2)
+	// Privacy signs and encrypts the messages.
+	Privacy = Protection(4)
+)
+
+func (p Protection) String() string {
+	switch p {
+	case Authentication:
+		return "authentication"
+	case Integrity:
+		return "integrity"
+	case Privacy:
+		return "privacy"
+	}
+	return fmt.Sprintf("Protection(%d)", byte(p))
+}
+
+// maxWrappedSize is the maximum size of the wrapped messages that we tell the
+// servers we can receive, like HBase's Java client does.
+const maxWrappedSize = 65536
+
+// maxSASLTokenSize is the maximum size of the tokens, and of the strings of
+// the errors, that the servers may send while authenticating, well above that
+// of the Kerberos tokens.
+const maxSASLTokenSize = 1 << 20
+
+// wrapOverhead is an upper bound of what wrapping a message adds to it, with
+// any of the Kerberos encryption types.
+const wrapOverhead = 64
+
+// GSSContext is a GSS-API security context for the Kerberos V5 mechanism, as
+// provided by a Kerberos library.  The SASL GSSAPI mechanism (RFC 4752) that
+// HBase uses is implemented on top of it.  Once the context is established,
+// Wrap and Unwrap may be called concurrently to protect the messages with
+// Integrity or Privacy.
+type GSSContext interface {
+	// Init processes the token sent by the server, which is nil the first
+	// time, and returns the token to send back to it, if any, and whether
+	// the context is now established.
+	Init(token []byte) (out []byte, established bool, err error)
+
+	// Wrap protects the given message with the keys of the context, and
+	// encrypts it if conf is true.
+	Wrap(msg []byte, conf bool) ([]byte, error)
+
+	// Unwrap checks and, if it was encrypted, decrypts a message wrapped by
+	// the server, and returns whether it was encrypted.
+	Unwrap(msg []byte) (out []byte, conf bool, err error)
+}
+
+// Kerberos holds what a region client needs to authenticate with Kerberos to
+// the servers of a secure cluster.
+type Kerberos struct {
+	// User is the Kerberos principal of the client, like
+	// "user@EXAMPLE.COM".
+	User string
+
+	// ServicePrincipal is the Kerberos principal of the servers, as set in
+	// hbase.regionserver.kerberos.principal, like
+	// "hbase/_HOST@EXAMPLE.COM".  _HOST is replaced with the host of the
+	// server being connected to.
+	ServicePrincipal string
+
+	// NewContext creates a new security context to authenticate User to
+	// the given service principal.  It's called for every new connection.
+	NewContext func(servicePrincipal string) (GSSContext, error)
+
+	// Protection is how the RPCs are protected once authenticated.  The
+	// default is Authentication.
+	Protection Protection
+}
+
+// servicePrincipal returns the principal of the server on the given host.
+func (k *Kerberos) servicePrincipal(host string) string {
+	return strings.Replace(k.ServicePrincipal, "_HOST", strings.ToLower(host), -1)
+}
+
+// KerberosAuth returns an option that makes the region client authenticate
+// to the server with Kerberos, through SASL.
+func KerberosAuth(k *Kerberos) ClientOption {
+	return func(c *Client) {
+		c.kerberos = k
+	}
+}
+
+// gssapiClient is the client side of the SASL GSSAPI mechanism.
+type gssapiClient struct {
+	ctx GSSContext
+
+	// The security layer to negotiate.
+	protection Protection
+
+	// Set once the security context is established, after which the
+	// security layer is negotiated.
+	established bool
+
+	// Set once the security layer is negotiated.
+	complete bool
+
+	// The maximum size of the wrapped messages that the server can
+	// receive, or 0 if it didn't set any.
+	maxSendSize int
+}
+
+// step processes the given challenge from the server, which is nil the first
+// time, and returns the response to send back.
+func (g *gssapiClient) step(challenge []byte) ([]byte, error) {
+	if !g.established {
+		token, established, err := g.ctx.Init(challenge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize the GSS-API context: %s", err)
+		}
+		g.established = established
+		if token == nil {
+			token = []byte{}
+		}
+		return token, nil
+	}
+
+	// The server sends the security layers it supports and the maximum
+	// size of the messages it can receive.
+	msg, _, err := g.ctx.Unwrap(challenge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap the SASL security layers: %s", err)
+	}
+	if len(msg) != 4 {
+		return nil, fmt.Errorf("invalid SASL security layers: %q", msg)
+	}
+	if Protection(msg[0])&g.protection == 0 {
+		return nil, fmt.Errorf("the server doesn't support the %s protection", g.protection)
+	}
+	g.maxSendSize = int(msg[1])<<16 | int(msg[2])<<8 | int(msg[3])
+	if g.protection != Authentication && g.maxSendSize != 0 && g.maxSendSize <= wrapOverhead {
+		return nil, fmt.Errorf("the server can't receive wrapped messages of more than %d bytes",
+			g.maxSendSize)
+	}
+	// The security layer chosen, followed by the maximum size of the
+	// messages we can receive, which is 0 without security layer.
+	response := make([]byte, 4)
+	if g.protection != Authentication {
+		binary.BigEndian.PutUint32(response, maxWrappedSize)
+	}
+	response[0] = byte(g.protection)
+	response, err = g.ctx.Wrap(response, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap the SASL security layer: %s", err)
+	}
+	g.complete = true
+	return response, nil
+}
+
+// saslConnect authenticates the connection with the SASL GSSAPI mechanism,
+// right after the preamble of the connection, and then protects the messages
+// sent over it if needed.
+func (c *Client) saslConnect() error {
+	ctx, err := c.kerberos.NewContext(c.kerberos.servicePrincipal(c.host))
+	if err != nil {
+		return fmt.Errorf("failed to create a GSS-API context: %s", err)
+	}
+	mech := &gssapiClient{ctx: ctx, protection: c.kerberos.Protection}
+	if mech.protection == 0 {
+		mech.protection = Authentication
+	}
+	token, err := mech.step(nil)
+	for err == nil {
+		if err = c.writeSASLToken(token); err != nil || mech.complete {
+			break
+		}
+		var challenge []byte
+		if challenge, err = c.readSASLToken(); err == nil {
+			token, err = mech.step(challenge)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to authenticate to %s:%d: %s", c.host, c.port, err)
+	}
+	if mech.protection != Authentication {
+		c.conn = &saslConn{
+			Conn:        c.conn,
+			ctx:         ctx,
+			conf:        mech.protection == Privacy,
+			maxSendSize: mech.maxSendSize,
+		}
+	}
+	return nil
+}
+
+// writeSASLToken sends the given token to the server, prefixed by its length.
+func (c *Client) writeSASLToken(token []byte) error {
+	buf := make([]byte, 4, 4+len(token))
+	binary.BigEndian.PutUint32(buf, uint32(len(token)))
+	return c.write(append(buf, token...))
+}
+
+// readSASLToken reads the status of the last SASL step from the server,
+// followed by its next token if the step succeeded.
+func (c *Client) readSASLToken() ([]byte, error) {
+	status, err := c.readInt()
+	if err != nil {
+		return nil, err
+	}
+	if status != 0 {
+		class, err := c.readString()
+		if err != nil {
+			return nil, err
+		}
+		msg, err := c.readString()
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("HBase Java exception %s: %s", class, msg)
+	}
+	length, err := c.readInt()
+	if err != nil {
+		return nil, err
+	}
+	if length == switchToSimpleAuth {
+		return nil, errors.New("the server asked to fall back to simple authentication")
+	} else if length < 0 || length > maxSASLTokenSize {
+		return nil, fmt.Errorf("invalid SASL token length: %d", length)
+	}
+	token := make([]byte, length)
+	return token, c.readFully(token)
+}
+
+// readInt reads a 32 bit signed integer, as written by Java's DataOutput.
+func (c *Client) readInt() (int32, error) {
+	var buf [4]byte
+	if err := c.readFully(buf[:]); err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(buf[:])), nil
+}
+
+// readString reads a string, as written by Hadoop's WritableUtils.
+func (c *Client) readString() (string, error) {
+	length, err := c.readInt()
+	if err != nil || length <= 0 {
+		return "", err
+	} else if length > maxSASLTokenSize {
+		return "", fmt.Errorf("invalid string length: %d", length)
+	}
+	buf := make([]byte, length)
+	if err = c.readFully(buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// saslConn is a connection whose messages are protected by the security layer
+// negotiated through SASL: every write is wrapped and sent prefixed by its
+// length, in as many messages as the maximum size that the server can receive
+// requires, and so are the messages read.
+type saslConn struct {
+	net.Conn
+
+	ctx GSSContext
+
+	// Whether the messages are encrypted.
+	conf bool
+
+	// The maximum size of the wrapped messages that the server can
+	// receive, or 0 if it didn't set any.
+	maxSendSize int
+
+	// The unwrapped data that wasn't read yet.
+	buf []byte
+}
+
+func (s *saslConn) Write(b []byte) (int, error) {
+	var written int
+	for len(b) > 0 {
+		chunk := b
+		if s.maxSendSize != 0 && len(chunk) > s.maxSendSize-wrapOverhead {
+			chunk = chunk[:s.maxSendSize-wrapOverhead]
+		}
+		token, err := s.ctx.Wrap(chunk, s.conf)
+		if err != nil {
+			return written, fmt.Errorf("failed to wrap a message: %s", err)
+		} else if s.maxSendSize != 0 && len(token) > s.maxSendSize {
+			return written, fmt.Errorf("wrapped a message into %d bytes, more than the %d"+
+				" that the server can receive", len(token), s.maxSendSize)
+		}
+		buf := make([]byte, 4, 4+len(token))
+		binary.BigEndian.PutUint32(buf, uint32(len(token)))
+		if _, err = s.Conn.Write(append(buf, token...)); err != nil {
+			return written, err
+		}
+		written += len(chunk)
+		b = b[len(chunk):]
+	}
+	return written, nil
+}
+
+func (s *saslConn) Read(b []byte) (int, error) {
+	for len(s.buf) == 0 {
+		var sz [4]byte
+		if _, err := io.ReadFull(s.Conn, sz[:]); err != nil {
+			return 0, err
+		}
+		length := binary.BigEndian.Uint32(sz[:])
+		if length > maxWrappedSize {
+			return 0, fmt.Errorf("the wrapped message is %d bytes long, more than the %d"+
+				" we can receive", length, maxWrappedSize)
+		}
+		token := make([]byte, length)
+		if _, err := io.ReadFull(s.Conn, token); err != nil {
+			return 0, err
+		}
+		var conf bool
+		var err error
+		s.buf, conf, err = s.ctx.Unwrap(token)
+		if err != nil {
+			return 0, fmt.Errorf("failed to unwrap a message: %s", err)
+		} else if s.conf && !conf {
+			return 0, errors.New("received a message that wasn't encrypted")
+		}
+	}
+	n := copy(b, s.buf)
+	s.buf = s.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,116 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package region
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// DefaultMaxCapturedPayload is the number of bytes of the payload captured
+// in a SerializationError when no other limit is set.
+const DefaultMaxCapturedPayload = 4096
+
+// SerializationError describes an RPC that couldn't be serialized, or whose
+// response couldn't be decoded, along with the offending payload.
+type SerializationError struct {
+	// Err is the error returned by the (de)serialization.
+	Err error
+
+	// Response is true if the response couldn't be decoded, false if the
+	// request couldn't be serialized.
+	Response bool
+
+	// Metadata of the call.  Call is empty if the response header itself
+	// couldn't be decoded, in which case the call isn't known yet.
+	Call   string
+	CallID uint32
+	Table  []byte
+	Key    []byte
+	Region []byte
+	Host   string
+	Port   uint16
+
+	// Payload is the beginning of the offending payload, nil if there was
+	// none (e.g. the request couldn't be serialized at all).
+	Payload []byte
+
+	// Size is the size of the whole payload, which may be larger than
+	// Payload if it was truncated.
+	Size int
+}
+
+func (e *SerializationError) Error() string {
+	what := "serialize request"
+	if e.Response {
+		what = "decode response"
+	}
+	return fmt.Sprintf("failed to %s of %s (call ID %d, table %q, key %q, region %q)"+
+		" from %s:%d, payload of %d bytes: %s", what, e.Call, e.CallID, e.Table, e.Key,
+		e.Region, e.Host, e.Port, e.Size, e.Err)
+}
+
+// Dump returns a hex dump of the captured payload.
+func (e *SerializationError) Dump() string {
+	return hex.Dump(e.Payload)
+}
+
+// SerializationErrorSink returns an option that makes the region client call
+// the given function, from its goroutines, each time an RPC can't be
+// serialized or its response can't be decoded.  At most maxPayload bytes of
+// the offending payload are captured (DefaultMaxCapturedPayload if maxPayload
+// <= 0).  Without this option, nothing is captured.
+func SerializationErrorSink(sink func(*SerializationError), maxPayload int) ClientOption {
+	if maxPayload <= 0 {
+		maxPayload = DefaultMaxCapturedPayload
+	}
+	return func(c *Client) {
+		c.serializationErrorSink = sink
+		c.maxCapturedPayload = maxPayload
+	}
+}
+
+// LogSerializationError is a sink for SerializationErrorSink that logs the
+// errors along with a hex dump of their payload.
+func LogSerializationError(e *SerializationError) {
+	log.Errorf("%s\n%s", e, e.Dump())
+}
+
+// captureSerializationError sends the given (de)serialization error to the
+// sink, if any.  rpc is nil if the call isn't known.
+func (c *Client) captureSerializationError(err error, response bool, rpc hrpc.Call,
+	callID uint32, payload []byte) {
+	sink, max := c.serializationErrorSink, c.maxCapturedPayload
+	if sink == nil {
+		return
+	}
+	e := &SerializationError{
+		Err:      err,
+		Response: response,
+		CallID:   callID,
+		Host:     c.host,
+		Port:     c.port,
+		Size:     len(payload),
+	}
+	if rpc != nil {
+		e.Call = rpc.GetName()
+		e.Table = rpc.Table()
+		e.Key = rpc.Key()
+		if reg := rpc.GetRegion(); reg != nil {
+			e.Region = reg.GetName()
+		}
+	}
+	if len(payload) > max {
+		payload = payload[:max]
+	}
+	if payload != nil {
+		// Copy it since the buffer may be reused.
+		e.Payload = append([]byte(nil), payload...)
+	}
+	sink(e)
+}
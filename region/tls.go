@@ -0,0 +1,38 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package region
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// TLS returns an option that makes the region client talk to the server over
+// TLS, with the given configuration.  The certificate authorities trusted to
+// sign the certificate of the server are in config.RootCAs, the system ones if
+// nil, and the certificates of the client, if the server asks for one, are in
+// config.Certificates.  Unless config.ServerName is set, the certificate of
+// the server must be for the host the client connects to.
+func TLS(config *tls.Config) ClientOption {
+	return func(c *Client) {
+		c.tlsConfig = config
+	}
+}
+
+// startTLS does the TLS handshake over the connection, before the preamble.
+func (c *Client) startTLS() error {
+	config := c.tlsConfig
+	if config.ServerName == "" {
+		config = config.Clone()
+		config.ServerName = c.host
+	}
+	conn := tls.Client(c.conn, config)
+	if err := conn.Handshake(); err != nil {
+		return fmt.Errorf("TLS handshake with %s:%d failed: %s", c.host, c.port, err)
+	}
+	c.conn = conn
+	return nil
+}
@@ -0,0 +1,256 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+// adminClients caches the connections to the AdminService of the
+// RegionServers, which are distinct from the connections to their
+// ClientService.
+type adminClients struct {
+	m sync.Mutex
+
+	clients map[string]hrpc.RegionClient
+}
+
+// get returns a connection to the AdminService of the given RegionServer,
+// connecting to it if needed.
+func (ac *adminClients) get(ctx context.Context, host string, port uint16,
+	queueSize int, flushInterval time.Duration,
+	options ...region.ClientOption) (hrpc.RegionClient, error) {
+	addr := net.JoinHostPort(host, strconv.Itoa(int(port)))
+	ac.m.Lock()
+	client := ac.clients[addr]
+	ac.m.Unlock()
+	if client != nil {
+		return client, nil
+	}
+
+	ch := make(chan newRegResult, 1)
+	go newRegionClient(ctx, ch, region.AdminClient, host, port, queueSize, flushInterval,
+		options...)
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		ac.m.Lock()
+		defer ac.m.Unlock()
+		if client = ac.clients[addr]; client != nil {
+			// Somebody else connected to this RegionServer concurrently.
+			res.Client.Close()
+			return client, nil
+		}
+		if ac.clients == nil {
+			ac.clients = make(map[string]hrpc.RegionClient)
+		}
+		ac.clients[addr] = res.Client
+		return res.Client, nil
+	case <-ctx.Done():
+		return nil, ErrDeadline
+	}
+}
+
+// del forgets about the given connection and closes it.
+func (ac *adminClients) del(client hrpc.RegionClient) {
+	addr := net.JoinHostPort(client.Host(), strconv.Itoa(int(client.Port())))
+	ac.m.Lock()
+	if ac.clients[addr] == client {
+		delete(ac.clients, addr)
+	}
+	ac.m.Unlock()
+	client.Close()
+}
+
+// closeAll closes all the connections.
+func (ac *adminClients) closeAll() {
+	ac.m.Lock()
+	defer ac.m.Unlock()
+	for addr, client := range ac.clients {
+		client.Close()
+		delete(ac.clients, addr)
+	}
+}
+
+// sendAdminRPC sends the given RPC to the AdminService of the RegionServer
+// serving the region of the RPC.  The RPC is sent again, after relocating
+// its region, if the region moved.
+func (c *client) sendAdminRPC(rpc hrpc.Call) (proto.Message, error) {
+	if err := c.checkWritable(rpc); err != nil {
+		return nil, err
+	}
+	ctx, cancel := c.operationContext(rpc.GetContext())
+	defer cancel()
+	backoff := c.backoffStart()
+	for {
+		reg, err := c.findRegion(ctx, rpc.Table(), rpc.Key())
+		if err != nil {
+			return nil, err
+		}
+		rpc.SetRegion(reg)
+		if err = c.attempt(ctx); err != nil {
+			return nil, err
+		}
+		msg, err := c.sendAdminRPCToRegion(ctx, rpc, reg)
+		if _, ok := err.(region.RetryableError); !ok && err != errNoClient {
+			return msg, err
+		}
+		// The region isn't served by this RegionServer anymore.
+		if reg.MarkUnavailable() {
+			go c.reestablishRegion(reg)
+		}
+		if err = c.retryAfter(ctx, RegionError, err); err != nil {
+			return nil, err
+		}
+		if backoff, err = c.sleepAndIncreaseBackoff(ctx, backoff); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sendAdminRPCToRegion sends the given RPC to the AdminService of the
+// RegionServer currently serving the given region.
+func (c *client) sendAdminRPCToRegion(ctx context.Context, rpc hrpc.Call,
+	reg hrpc.RegionInfo) (proto.Message, error) {
+	rsClient := reg.GetClient()
+	if rsClient == nil {
+		return nil, errNoClient
+	}
+	client, err := c.adminClients.get(ctx, rsClient.Host(), rsClient.Port(),
+		c.rpcQueueSize, c.flushInterval, c.regionOptions...)
+	if err != nil {
+		return nil, err
+	}
+	if err = client.QueueRPC(rpc); err != nil {
+		c.adminClients.del(client)
+		return nil, err
+	}
+	var res hrpc.RPCResult
+	select {
+	case res = <-rpc.GetResultChan():
+	case <-ctx.Done():
+		return nil, ErrDeadline
+	}
+	if _, ok := res.Error.(region.UnrecoverableError); ok {
+		c.adminClients.del(client)
+	}
+	return res.Msg, res.Error
+}
+
+// GetCompactionState returns the state of the compactions of the given table,
+// which is the combination of the compaction states of all its regions.  This
+// can be used to wait for a major compaction to finish.
+func (c *client) GetCompactionState(ctx context.Context,
+	table []byte) (hrpc.CompactionState, error) {
+	ranges, err := c.regionRanges(ctx, table, nil, nil)
+	if err != nil {
+		return hrpc.CompactionNone, err
+	}
+	state := hrpc.CompactionNone
+	for _, r := range ranges {
+		msg, err := c.sendAdminRPC(hrpc.NewGetRegionInfo(ctx, table, r.start, true))
+		if err != nil {
+			return hrpc.CompactionNone, err
+		}
+		res, ok := msg.(*pb.GetRegionInfoResponse)
+		if !ok {
+			return hrpc.CompactionNone,
+				fmt.Errorf("sendAdminRPC returned a %T instead of GetRegionInfoResponse", msg)
+		}
+		state = state.Merge(hrpc.CompactionState(res.GetCompactionState()))
+	}
+	return state, nil
+}
+
+// SplitRegion asks the RegionServer serving the region of the given request
+// to split it.  The split happens asynchronously, after this returns.
+func (c *client) SplitRegion(s *hrpc.SplitRegion) error {
+	msg, err := c.sendAdminRPC(s)
+	if err != nil {
+		return err
+	}
+	if _, ok := msg.(*pb.SplitRegionResponse); !ok {
+		return fmt.Errorf("sendAdminRPC returned a %T instead of SplitRegionResponse", msg)
+	}
+	return nil
+}
+
+// CompactRegion asks the RegionServer serving the region of the given request
+// to compact it.  The compaction is queued and runs after this returns, which
+// GetCompactionState can tell.
+func (c *client) CompactRegion(cr *hrpc.CompactRegion) error {
+	msg, err := c.sendAdminRPC(cr)
+	if err != nil {
+		return err
+	}
+	if _, ok := msg.(*pb.CompactRegionResponse); !ok {
+		return fmt.Errorf("sendAdminRPC returned a %T instead of CompactRegionResponse", msg)
+	}
+	return nil
+}
+
+// CompactTable asks the RegionServers serving the regions of the given table
+// to compact them, with the options of NewCompactRegion, like
+// MajorCompaction.  The compactions are queued and run after this returns,
+// which GetCompactionState can tell.
+func (c *client) CompactTable(ctx context.Context, table []byte,
+	options ...func(hrpc.Call) error) error {
+	ranges, err := c.regionRanges(ctx, table, nil, nil)
+	if err != nil {
+		return err
+	}
+	for _, r := range ranges {
+		cr, err := hrpc.NewCompactRegion(ctx, table, r.start, options...)
+		if err != nil {
+			return err
+		}
+		if err = c.CompactRegion(cr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FlushRegion asks the RegionServer serving the region of the given request
+// to flush its memstores, and returns once they're flushed.
+func (c *client) FlushRegion(fr *hrpc.FlushRegion) error {
+	msg, err := c.sendAdminRPC(fr)
+	if err != nil {
+		return err
+	}
+	if _, ok := msg.(*pb.FlushRegionResponse); !ok {
+		return fmt.Errorf("sendAdminRPC returned a %T instead of FlushRegionResponse", msg)
+	}
+	return nil
+}
+
+// FlushTable asks the RegionServers serving the regions of the given table to
+// flush their memstores, one region at a time, and returns once they're all
+// flushed.
+func (c *client) FlushTable(ctx context.Context, table []byte) error {
+	ranges, err := c.regionRanges(ctx, table, nil, nil)
+	if err != nil {
+		return err
+	}
+	for _, r := range ranges {
+		if err = c.FlushRegion(hrpc.NewFlushRegion(ctx, table, r.start)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"bytes"
+	"container/list"
+	"sort"
+	"time"
+
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// RegionCacheStats describes the region cache of a client.
+type RegionCacheStats struct {
+	// Regions is the number of regions in the cache.
+	Regions int
+
+	// Evictions is the number of regions evicted from the cache because
+	// it was full.
+	Evictions int
+
+	// Hits and Misses are the numbers of RPCs whose region was found in the
+	// cache or had to be looked up in meta.
+	Hits   int
+	Misses int
+
+	// Expirations is the number of regions looked up again because they
+	// were in the cache longer than its TTL.
+	Expirations int
+
+	// Overlaps is the number of regions removed from the cache because a
+	// region looked up in meta overlapped them, which happens after they
+	// split or merged.  Many overlaps along with many misses mean that the
+	// cache is thrashing.
+	Overlaps int
+
+	// MetaLookups is the number of regions looked up in meta, of which
+	// MetaLookupErrors failed, and MetaLookupTime the total time they took.
+	MetaLookups      int
+	MetaLookupErrors int
+	MetaLookupTime   time.Duration
+
+	// MetaLookupLatencies is the histogram of the latencies of the meta
+	// lookups: MetaLookupLatencies[i] is the number of lookups that took
+	// at most MetaLookupBuckets[i], and more than the bucket before, and
+	// the last element the number of lookups that took longer than all the
+	// buckets.
+	MetaLookupLatencies []int
+}
+
+// MetaLookupBuckets are the upper bounds of the buckets of the histogram of
+// the latencies of the meta lookups.
+var MetaLookupBuckets = []time.Duration{
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	20 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	5 * time.Second,
+}
+
+// lookupStats are the statistics of the meta lookups of a client.
+type lookupStats struct {
+	count   int
+	errors  int
+	total   time.Duration
+	buckets []int
+}
+
+// MaxCachedRegions will return an option that bounds the number of regions in
+// the region cache of the client, for the clients that access many tables.
+// Once the cache is full, the least recently used regions are evicted, and
+// looked up in meta again if they're needed.  The connections to the
+// RegionServers that have no cached region left are closed.  0 or less, the
+// default, doesn't bound the cache.
+func MaxCachedRegions(n int) Option {
+	return func(c *client) {
+		if n <= 0 {
+			return
+		}
+		c.regions.maxSize = n
+		c.regions.lru = list.New()
+		c.regions.elements = make(map[string]*list.Element)
+	}
+}
+
+// RegionCacheTTL will return an option that makes the client look up the
+// regions in meta again once they've been in its region cache for the given
+// duration, so that a long-running client eventually follows the regions that
+// moved, rather than only once their RegionServers reject its RPCs.
+func RegionCacheTTL(ttl time.Duration) Option {
+	return func(c *client) {
+		c.regions.ttl = ttl
+		c.regions.added = make(map[string]time.Time)
+	}
+}
+
+// RegionCacheStats returns the statistics of the region cache of the client.
+func (c *client) RegionCacheStats() RegionCacheStats {
+	c.regions.m.Lock()
+	defer c.regions.m.Unlock()
+	lookups := c.regions.lookups
+	return RegionCacheStats{
+		Regions:             c.regions.regions.Len(),
+		Evictions:           c.regions.evictions,
+		Hits:                c.regions.hits,
+		Misses:              c.regions.misses,
+		Expirations:         c.regions.expirations,
+		Overlaps:            c.regions.overlaps,
+		MetaLookups:         lookups.count,
+		MetaLookupErrors:    lookups.errors,
+		MetaLookupTime:      lookups.total,
+		MetaLookupLatencies: append([]int(nil), lookups.buckets...),
+	}
+}
+
+// countLookup counts a lookup of the region of an RPC in the cache.
+func (krc *keyRegionCache) countLookup(hit bool) {
+	krc.m.Lock()
+	if hit {
+		krc.hits++
+	} else {
+		krc.misses++
+	}
+	krc.m.Unlock()
+}
+
+// recordLookup records a meta lookup that took the given time.
+func (krc *keyRegionCache) recordLookup(latency time.Duration, err error) {
+	krc.m.Lock()
+	defer krc.m.Unlock()
+	krc.lookups.count++
+	if err != nil {
+		krc.lookups.errors++
+	}
+	krc.lookups.total += latency
+	if krc.lookups.buckets == nil {
+		krc.lookups.buckets = make([]int, len(MetaLookupBuckets)+1)
+	}
+	i := sort.Search(len(MetaLookupBuckets), func(i int) bool {
+		return latency <= MetaLookupBuckets[i]
+	})
+	krc.lookups.buckets[i]++
+}
+
+// CachedRegions returns a snapshot of the regions of the given table that are
+// in the region cache of the client, in order, with the RegionServers that
+// the client sends their RPCs to.  Host is empty for the regions that the
+// client isn't connected to, e.g. while they're looked up again after they
+// moved.  This is meant for debugging where the RPCs go, since the cache
+// changes as the regions split and move.
+func (c *client) CachedRegions(table []byte) []*TableRegion {
+	var regions []*TableRegion
+	for _, reg := range c.regions.regionsOf(table) {
+		regions = append(regions, tableRegionOf(reg))
+	}
+	return regions
+}
+
+// InvalidateRegion removes the region with the given full name, like
+// TableRegion.Name, from the region cache of the client, so that the next RPCs
+// to it look it up in meta again.  This is for the applications that learn
+// about a split or a move before the client does.  The connection to the
+// RegionServer of the region is kept.
+func (c *client) InvalidateRegion(name []byte) {
+	if reg := c.regions.remove(name); reg != nil {
+		c.clients.del(reg)
+	}
+}
+
+// InvalidateTable removes all the regions of the given table from the region
+// cache of the client, like InvalidateRegion.
+func (c *client) InvalidateTable(table []byte) {
+	for _, reg := range c.regions.regionsOf(table) {
+		c.InvalidateRegion(reg.GetName())
+	}
+}
+
+// remove removes the region with the given name from the cache and returns
+// it, if it was there.
+func (krc *keyRegionCache) remove(name []byte) hrpc.RegionInfo {
+	krc.m.Lock()
+	defer krc.m.Unlock()
+	v, ok := krc.regions.Get(name)
+	if !ok {
+		return nil
+	}
+	krc.regions.Delete(name)
+	krc.forget(name)
+	return v.(hrpc.RegionInfo)
+}
+
+// regionsOf returns the cached regions of the given table, in order.
+func (krc *keyRegionCache) regionsOf(table []byte) []hrpc.RegionInfo {
+	krc.m.Lock()
+	defer krc.m.Unlock()
+	var regions []hrpc.RegionInfo
+	enum, err := krc.regions.SeekFirst()
+	if err != nil {
+		// The cache is empty.
+		return nil
+	}
+	defer enum.Close()
+	for _, v, err := enum.Next(); err == nil; _, v, err = enum.Next() {
+		if reg := v.(hrpc.RegionInfo); bytes.Equal(reg.GetTable(), table) {
+			regions = append(regions, reg)
+		}
+	}
+	return regions
+}
+
+// touch makes the region with the given name the most recently used one.  It
+// must be called with the lock held, like the other methods below.
+func (krc *keyRegionCache) touch(name []byte) {
+	if krc.maxSize == 0 {
+		return
+	}
+	if e, ok := krc.elements[string(name)]; ok {
+		krc.lru.MoveToFront(e)
+	}
+}
+
+// expired returns true if the region with the given name was added to the
+// cache longer than the TTL of the cache ago.
+func (krc *keyRegionCache) expired(name []byte, now time.Time) bool {
+	if krc.ttl == 0 {
+		return false
+	}
+	added, ok := krc.added[string(name)]
+	return ok && now.Sub(added) > krc.ttl
+}
+
+// add adds the given region to the most recently used ones, and records when
+// it was added.
+func (krc *keyRegionCache) add(reg hrpc.RegionInfo) {
+	name := string(reg.GetName())
+	if krc.ttl != 0 {
+		krc.added[name] = time.Now()
+	}
+	if krc.maxSize == 0 {
+		return
+	}
+	if e, ok := krc.elements[name]; ok {
+		e.Value = reg
+		krc.lru.MoveToFront(e)
+		return
+	}
+	krc.elements[name] = krc.lru.PushFront(reg)
+}
+
+// forget removes the region with the given name from the recently used ones,
+// and forgets its replicas.
+func (krc *keyRegionCache) forget(name []byte) {
+	delete(krc.replicas, string(name))
+	if krc.ttl != 0 {
+		delete(krc.added, string(name))
+	}
+	if krc.maxSize == 0 {
+		return
+	}
+	if e, ok := krc.elements[string(name)]; ok {
+		krc.lru.Remove(e)
+		delete(krc.elements, string(name))
+	}
+}
+
+// evict evicts the least recently used regions while the cache is full, and
+// returns them.  The regions being connected to aren't evicted, since they're
+// put back in the cache once connected.
+func (krc *keyRegionCache) evict() []hrpc.RegionInfo {
+	if krc.maxSize == 0 {
+		return nil
+	}
+	var evicted []hrpc.RegionInfo
+	e := krc.lru.Back()
+	for krc.lru.Len() > krc.maxSize && e != nil {
+		prev := e.Prev()
+		if reg := e.Value.(hrpc.RegionInfo); !reg.IsUnavailable() {
+			krc.regions.Delete(reg.GetName())
+			krc.forget(reg.GetName())
+			evicted = append(evicted, reg)
+			krc.evictions++
+		}
+		e = prev
+	}
+	return evicted
+}
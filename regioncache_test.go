@@ -0,0 +1,237 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tsuna/gohbase/region"
+)
+
+func TestMaxCachedRegions(t *testing.T) {
+	c := newClient("~invalid.quorum~", MaxCachedRegions(2))
+	rs := &fakeRegionClient{host: "rs", port: 16020}
+	newRegion := func(table string) *region.Info {
+		reg := &region.Info{Table: []byte(table), Name: []byte(table + ",,1"),
+			StopKey: []byte{}}
+		reg.SetClient(rs)
+		c.clients.put(reg, rs)
+		return reg
+	}
+	reg1, reg2, reg3 := newRegion("t1"), newRegion("t2"), newRegion("t3")
+	if removed := c.regions.put(reg1); len(removed) != 0 {
+		t.Errorf("Didn't expect any region to be removed, got %v", removed)
+	}
+	c.regions.put(reg2)
+	// reg1 is now the most recently used.
+	if reg := c.getRegionFromCache([]byte("t1"), []byte("key")); reg != reg1 {
+		t.Fatalf("Expected %s in the cache, got %v", reg1, reg)
+	}
+
+	removed := c.regions.put(reg3)
+	if len(removed) != 1 || removed[0] != reg2 {
+		t.Errorf("Expected %s to be evicted, got %v", reg2, removed)
+	}
+	if reg := c.getRegionFromCache([]byte("t2"), []byte("key")); reg != nil {
+		t.Errorf("Expected %s to be evicted, got %s", reg2, reg)
+	}
+	for _, reg := range []*region.Info{reg1, reg3} {
+		if cached := c.getRegionFromCache(reg.Table, []byte("key")); cached != reg {
+			t.Errorf("Expected %s in the cache, got %v", reg, cached)
+		}
+	}
+	if stats := c.RegionCacheStats(); stats.Regions != 2 || stats.Evictions != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	// The regions being connected to aren't evicted.
+	c.regions.put(reg3)
+	reg1.MarkUnavailable()
+	reg3.MarkUnavailable()
+	reg4 := newRegion("t4")
+	reg4.MarkUnavailable()
+	if removed = c.regions.put(reg4); len(removed) != 0 {
+		t.Errorf("Didn't expect unavailable regions to be evicted, got %v", removed)
+	}
+	if c.regions.del(reg4.Name); c.RegionCacheStats().Regions != 2 {
+		t.Errorf("Expected 2 regions in the cache, got %+v", c.RegionCacheStats())
+	}
+}
+
+func TestMaxCachedRegionsClients(t *testing.T) {
+	c := newClient("~invalid.quorum~", MaxCachedRegions(1))
+	rs1 := &fakeRegionClient{host: "rs1", port: 16020}
+	rs2 := &fakeRegionClient{host: "rs2", port: 16020}
+	reg1 := &region.Info{Table: []byte("t1"), Name: []byte("t1,,1"), StopKey: []byte{}}
+	reg2 := &region.Info{Table: []byte("t2"), Name: []byte("t2,,1"), StopKey: []byte{}}
+	for _, r := range []struct {
+		reg *region.Info
+		rs  *fakeRegionClient
+	}{{reg1, rs1}, {reg2, rs2}} {
+		r.reg.SetClient(r.rs)
+		c.clients.put(r.reg, r.rs)
+		c.uncacheRegions(c.regions.put(r.reg))
+	}
+	// The connection to rs1 has no cached region left once reg1 is
+	// evicted.
+	if !rs1.closed || c.clients.checkForClient("rs1", 16020) != nil {
+		t.Error("Expected the connection to rs1 to be closed and uncached")
+	}
+	if rs2.closed || c.clients.checkForClient("rs2", 16020) != rs2 {
+		t.Error("Expected the connection to rs2 to be kept")
+	}
+
+	// A bound of 0 or less doesn't bound the cache.
+	c = newClient("~invalid.quorum~", MaxCachedRegions(-1))
+	for _, reg := range []*region.Info{reg1, reg2} {
+		if removed := c.regions.put(reg); len(removed) != 0 {
+			t.Errorf("Didn't expect any region to be evicted, got %v", removed)
+		}
+	}
+	if stats := c.RegionCacheStats(); stats.Regions != 2 || stats.Evictions != 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestRegionCacheTTL(t *testing.T) {
+	c := newClient("~invalid.quorum~", RegionCacheTTL(time.Minute))
+	reg := &region.Info{Table: []byte("test"), Name: []byte("test,,1"), StopKey: []byte("z")}
+	c.regions.put(reg)
+	if cached := c.getRegionFromCache([]byte("test"), []byte("key")); cached != reg {
+		t.Fatalf("Expected %s in the cache, got %v", reg, cached)
+	}
+
+	// Pretend the region was looked up a while ago.
+	c.regions.added[string(reg.Name)] = time.Now().Add(-2 * time.Minute)
+	if cached := c.getRegionFromCache([]byte("test"), []byte("key")); cached != nil {
+		t.Errorf("Expected %s to have expired, got %s", reg, cached)
+	}
+
+	// Once looked up again, the region is cached anew.
+	again := &region.Info{Table: []byte("test"), Name: []byte("test,,1"), StopKey: []byte("z")}
+	if removed := c.regions.put(again); len(removed) != 1 || removed[0] != reg {
+		t.Errorf("Expected the expired region to be replaced, got %v", removed)
+	}
+	if cached := c.getRegionFromCache([]byte("test"), []byte("key")); cached != again {
+		t.Errorf("Expected %s in the cache, got %v", again, cached)
+	}
+	if c.regions.del(again.Name); len(c.regions.added) != 0 {
+		t.Errorf("Expected the regions to be forgotten, got %v", c.regions.added)
+	}
+}
+
+func TestCachedRegions(t *testing.T) {
+	c := newClient("~invalid.quorum~")
+	if regions := c.CachedRegions([]byte("test")); len(regions) != 0 {
+		t.Errorf("Expected no cached region, got %v", regions)
+	}
+	rs := &fakeRegionClient{host: "rs", port: 16020}
+	for _, reg := range []*region.Info{
+		{Table: []byte("test"), Name: []byte("test,m,2"), StartKey: []byte("m"),
+			StopKey: []byte{}, ID: 2},
+		{Table: []byte("other"), Name: []byte("other,,3"), StopKey: []byte{}, ID: 3},
+		{Table: []byte("test"), Name: []byte("test,,1"), StopKey: []byte("m"), ID: 1},
+	} {
+		if reg.ID != 2 {
+			reg.SetClient(rs)
+			c.clients.put(reg, rs)
+		}
+		c.regions.put(reg)
+	}
+
+	regions := c.CachedRegions([]byte("test"))
+	if len(regions) != 2 {
+		t.Fatalf("Expected 2 cached regions, got %v", regions)
+	}
+	if r := regions[0]; string(r.Name) != "test,,1" || string(r.StopKey) != "m" ||
+		r.ID != 1 || r.Host != "rs" || r.Port != 16020 {
+		t.Errorf("Unexpected first region %+v", r)
+	}
+	if r := regions[1]; string(r.Name) != "test,m,2" || string(r.StartKey) != "m" ||
+		r.ID != 2 || r.Host != "" || r.Port != 0 {
+		t.Errorf("Unexpected second region %+v", r)
+	}
+}
+
+func TestRegionCacheMetrics(t *testing.T) {
+	c := newClient("~invalid.quorum~")
+	parent := &region.Info{Table: []byte("test"), Name: []byte("test,,1"), StopKey: []byte("z")}
+	c.regions.put(parent)
+	c.getRegionFromCache([]byte("test"), []byte("a"))
+	c.getRegionFromCache([]byte("test"), []byte("zz"))
+	c.getRegionFromCache([]byte("other"), []byte("a"))
+	if stats := c.RegionCacheStats(); stats.Hits != 1 || stats.Misses != 2 {
+		t.Errorf("Expected 1 hit and 2 misses, got %+v", stats)
+	}
+
+	// The daughters of the region replace it once looked up.
+	c.regions.put(&region.Info{Table: []byte("test"), Name: []byte("test,,2"),
+		StopKey: []byte("m")})
+	c.regions.put(&region.Info{Table: []byte("test"), Name: []byte("test,m,2"),
+		StartKey: []byte("m"), StopKey: []byte("z")})
+	// Putting a region back in the cache, e.g. after it was reconnected to,
+	// doesn't count as an overlap.
+	c.regions.put(&region.Info{Table: []byte("test"), Name: []byte("test,,2"),
+		StopKey: []byte("m")})
+	if stats := c.RegionCacheStats(); stats.Overlaps != 1 || stats.Regions != 2 {
+		t.Errorf("Expected 1 overlap and 2 regions, got %+v", stats)
+	}
+
+	c.regions.recordLookup(3*time.Millisecond, nil)
+	c.regions.recordLookup(5*time.Millisecond, nil)
+	c.regions.recordLookup(time.Minute, ErrDeadline)
+	stats := c.RegionCacheStats()
+	if stats.MetaLookups != 3 || stats.MetaLookupErrors != 1 ||
+		stats.MetaLookupTime != time.Minute+8*time.Millisecond {
+		t.Errorf("Unexpected meta lookup stats %+v", stats)
+	}
+	latencies := stats.MetaLookupLatencies
+	if len(latencies) != len(MetaLookupBuckets)+1 || latencies[2] != 2 ||
+		latencies[len(MetaLookupBuckets)] != 1 {
+		t.Errorf("Unexpected meta lookup latencies %v", latencies)
+	}
+}
+
+func TestInvalidateRegions(t *testing.T) {
+	c := newClient("~invalid.quorum~")
+	rs := &fakeRegionClient{host: "rs", port: 16020}
+	reg1 := &region.Info{Table: []byte("test"), Name: []byte("test,,1"), StopKey: []byte("m")}
+	reg2 := &region.Info{Table: []byte("test"), Name: []byte("test,m,1"),
+		StartKey: []byte("m"), StopKey: []byte("z")}
+	other := &region.Info{Table: []byte("other"), Name: []byte("other,,1"),
+		StopKey: []byte("z")}
+	for _, reg := range []*region.Info{reg1, reg2, other} {
+		reg.SetClient(rs)
+		c.clients.put(reg, rs)
+		c.regions.put(reg)
+	}
+
+	c.InvalidateRegion(reg1.Name)
+	if reg := c.getRegionFromCache([]byte("test"), []byte("a")); reg != nil {
+		t.Errorf("Expected %s to be invalidated, got %s", reg1, reg)
+	}
+	if reg1.GetClient() != nil || len(c.clients.regions[rs]) != 2 {
+		t.Errorf("Expected %s to be removed from its client, got %v",
+			reg1, c.clients.regions[rs])
+	}
+	if reg := c.getRegionFromCache([]byte("test"), []byte("n")); reg != reg2 {
+		t.Errorf("Expected %s in the cache, got %v", reg2, reg)
+	}
+	// Invalidating a region that isn't cached does nothing.
+	c.InvalidateRegion(reg1.Name)
+
+	c.InvalidateTable([]byte("test"))
+	if regions := c.CachedRegions([]byte("test")); len(regions) != 0 {
+		t.Errorf("Expected the regions of the table to be invalidated, got %v", regions)
+	}
+	if reg := c.getRegionFromCache([]byte("other"), []byte("a")); reg != other {
+		t.Errorf("Expected %s in the cache, got %v", other, reg)
+	}
+	if rs.closed {
+		t.Error("Didn't expect the region client to be closed")
+	}
+}
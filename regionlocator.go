@@ -0,0 +1,69 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+// RegionLocator tells where the regions of the tables are, like the
+// RegionLocator of HBase's Java client, for the frameworks that partition
+// their work by region.
+type RegionLocator interface {
+	// GetRegionLocation returns the region that holds the given row key of
+	// the given table, with the RegionServer serving it.  The region comes
+	// from the region cache of the client unless reload is true, in which
+	// case it's looked up in meta again, and cached anew.
+	GetRegionLocation(ctx context.Context, table, key []byte, reload bool) (*TableRegion,
+		error)
+
+	// GetAllRegionLocations returns all the online regions of the given
+	// table, in order, as found in meta.
+	GetAllRegionLocations(ctx context.Context, table []byte) ([]*TableRegion, error)
+}
+
+// GetRegionLocation returns the region that holds the given row key of the
+// given table, connecting to its RegionServer if needed.
+func (c *client) GetRegionLocation(ctx context.Context, table, key []byte,
+	reload bool) (*TableRegion, error) {
+	if reload {
+		if cached := c.getRegionFromCache(table, key); cached != nil {
+			c.InvalidateRegion(cached.GetName())
+		}
+	}
+	reg, err := c.findRegion(ctx, table, key)
+	if err != nil {
+		return nil, err
+	}
+	return tableRegionOf(reg), nil
+}
+
+// GetAllRegionLocations returns all the online regions of the given table,
+// like GetTableRegions.
+func (c *client) GetAllRegionLocations(ctx context.Context, table []byte) ([]*TableRegion,
+	error) {
+	return c.GetTableRegions(ctx, table)
+}
+
+// tableRegionOf returns the given region with the RegionServer that its client
+// is connected to, if any.
+func tableRegionOf(reg hrpc.RegionInfo) *TableRegion {
+	tr := &TableRegion{
+		Name:     reg.GetName(),
+		StartKey: reg.GetStartKey(),
+		StopKey:  reg.GetStopKey(),
+	}
+	if info, ok := reg.(*region.Info); ok {
+		tr.ID = info.ID
+	}
+	if client := reg.GetClient(); client != nil {
+		tr.Host = client.Host()
+		tr.Port = client.Port()
+	}
+	return tr
+}
@@ -0,0 +1,57 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"testing"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+func TestGetRegionLocation(t *testing.T) {
+	c := newClient("~invalid.quorum~")
+	defer c.Close()
+	old := &fakeRegionClient{host: "old", port: 16020}
+	cached := &region.Info{Table: []byte("test"), Name: []byte("test,b,1"),
+		StartKey: []byte("b"), StopKey: []byte("c")}
+	cached.SetClient(old)
+	c.clients.put(cached, old)
+	c.regions.put(cached)
+	c.metaRegionInfo.SetClient(&registryClient{
+		fakeRegionClient: fakeRegionClient{host: "meta", port: 16020},
+		respond: func(rpc hrpc.Call) hrpc.RPCResult {
+			return hrpc.RPCResult{Msg: &pb.ScanResponse{
+				Results: []*pb.Result{metaResult("b", "c", "~rs1~")},
+			}}
+		},
+	})
+	// The region moved to a RegionServer the client is already connected to.
+	c.clients.addClient(&fakeRegionClient{host: "~rs1~", port: 16020})
+
+	ctx := context.Background()
+	loc, err := c.GetRegionLocation(ctx, []byte("test"), []byte("bb"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(loc.Name) != "test,b,1" || loc.Host != "old" || loc.Port != 16020 {
+		t.Errorf("Expected the cached region, got %+v", loc)
+	}
+
+	loc, err = c.GetRegionLocation(ctx, []byte("test"), []byte("bb"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(loc.StartKey) != "b" || string(loc.StopKey) != "c" || loc.Host != "~rs1~" {
+		t.Errorf("Expected the region to be looked up again, got %+v", loc)
+	}
+	if reg := c.getRegionFromCache([]byte("test"), []byte("bb")); reg == cached ||
+		reg.GetClient().Host() != "~rs1~" {
+		t.Errorf("Expected the new location to be cached, got %v", reg)
+	}
+}
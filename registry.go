@@ -0,0 +1,221 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"github.com/tsuna/gohbase/region"
+	"github.com/tsuna/gohbase/zk"
+	"golang.org/x/net/context"
+)
+
+// defaultMasterPort is the default port of the masters, as set in
+// hbase.master.port.
+const defaultMasterPort = 16000
+
+var errNoMasters = errors.New("the master registry has no master address")
+
+// masterRegistry is the connection registry of HBase 2.3+, which the clients
+// bootstrap from instead of ZooKeeper: any master, active or backup, serves
+// the location of meta, the active master and the cluster ID.
+type masterRegistry struct {
+	m sync.Mutex
+
+	// The addresses of the masters, like "host:port".
+	masters []string
+
+	// The index of the master that the calls are sent to, the last one that
+	// answered.
+	current int
+
+	// The connection to the current master, if any.
+	client hrpc.RegionClient
+}
+
+// MasterRegistry will return an option that makes the client bootstrap from
+// the connection registry of the given masters, as listed in hbase.masters,
+// rather than from ZooKeeper.  The addresses are like "host:port", and the
+// port defaults to 16000.  The cluster must run HBase 2.3 or later.  The
+// ZooKeeper quorum of the client may then be empty, in which case the
+// features that talk to ZooKeeper directly, like the WatchRegionServers
+// option, aren't available.
+func MasterRegistry(masters ...string) Option {
+	return func(c *client) {
+		c.registry = &masterRegistry{masters: masters}
+	}
+}
+
+// splitMasterAddr returns the host and port of the given master address.
+func splitMasterAddr(addr string) (string, uint16, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		// Assume there's no port.
+		return addr, defaultMasterPort, nil
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port in master address %q: %s", addr, err)
+	}
+	return host, uint16(port), nil
+}
+
+// connect returns the connection to the current master, connecting to it if
+// needed.
+func (r *masterRegistry) connect(ctx context.Context, c *client) (hrpc.RegionClient, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.client != nil {
+		return r.client, nil
+	}
+	host, port, err := splitMasterAddr(r.masters[r.current])
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan newRegResult, 1)
+	go newRegionClient(ctx, ch, region.RegistryClient, host, port,
+		c.rpcQueueSize, c.flushInterval, c.regionOptions...)
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		r.client = res.Client
+		return res.Client, nil
+	case <-ctx.Done():
+		return nil, ErrDeadline
+	}
+}
+
+// failed moves on to the next master after the given connection to the
+// current one failed, or after connecting to it failed if client is nil.
+func (r *masterRegistry) failed(client hrpc.RegionClient) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.client != client {
+		// Somebody else moved on already.
+		return
+	}
+	if client != nil {
+		client.Close()
+		r.client = nil
+	}
+	r.current = (r.current + 1) % len(r.masters)
+}
+
+// close closes the connection to the current master, if any.
+func (r *masterRegistry) close() {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.client != nil {
+		r.client.Close()
+		r.client = nil
+	}
+}
+
+// registryCall sends the given RPC to the connection registry of the masters,
+// trying them in turn until one answers.
+func (c *client) registryCall(ctx context.Context, rpc hrpc.Call) (proto.Message, error) {
+	r := c.registry
+	if len(r.masters) == 0 {
+		return nil, errNoMasters
+	}
+	var err error
+	for i := 0; i < len(r.masters); i++ {
+		var client hrpc.RegionClient
+		client, err = r.connect(ctx, c)
+		if err == ErrDeadline {
+			return nil, err
+		} else if err == nil {
+			var res hrpc.RPCResult
+			if err = client.QueueRPC(rpc); err == nil {
+				select {
+				case res = <-rpc.GetResultChan():
+				case <-ctx.Done():
+					return nil, ErrDeadline
+				}
+				if res.Error == nil {
+					return res.Msg, nil
+				}
+				err = res.Error
+			}
+		}
+		log.Warningf("The master registry failed to %s: %s", rpc.GetName(), err)
+		r.failed(client)
+	}
+	return nil, err
+}
+
+// registryLookup looks up the active master, or the location of the given
+// replica of meta, in the connection registry of the masters.
+func (c *client) registryLookup(ctx context.Context, res zk.ResourceName) (string,
+	uint16, error) {
+	if res == zk.Master {
+		msg, err := c.registryCall(ctx, hrpc.NewGetActiveMaster(ctx))
+		if err != nil {
+			return "", 0, err
+		}
+		server := msg.(*pb.GetActiveMasterResponse).GetServerName()
+		if server == nil {
+			return "", 0, errors.New("the master registry doesn't know the active master")
+		}
+		return server.GetHostName(), uint16(server.GetPort()), nil
+	}
+	locations, err := c.registryMetaLocations(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	for _, loc := range locations {
+		if loc.ReplicaID == 0 {
+			return loc.Host, loc.Port, nil
+		}
+	}
+	return "", 0, errors.New("the master registry doesn't know the location of meta")
+}
+
+// registryMetaLocations returns the locations of the replicas of meta that are
+// known to the connection registry of the masters.
+func (c *client) registryMetaLocations(ctx context.Context) ([]ReplicaLocation, error) {
+	msg, err := c.registryCall(ctx, hrpc.NewGetMetaLocations(ctx))
+	if err != nil {
+		return nil, err
+	}
+	resp := msg.(*pb.GetMetaRegionLocationsResponse)
+	locations := make([]ReplicaLocation, 0, len(resp.GetMetaLocations()))
+	for _, loc := range resp.GetMetaLocations() {
+		if loc.GetServerName() == nil {
+			// The replica isn't assigned.
+			continue
+		}
+		locations = append(locations, ReplicaLocation{
+			ReplicaID: uint32(loc.GetRegionInfo().GetReplicaId()),
+			Host:      loc.GetServerName().GetHostName(),
+			Port:      uint16(loc.GetServerName().GetPort()),
+		})
+	}
+	return locations, nil
+}
+
+// registryClusterID reads the ID of the cluster from the connection registry
+// of the masters.
+func (c *client) registryClusterID(ctx context.Context) (string, error) {
+	msg, err := c.registryCall(ctx, hrpc.NewGetClusterID(ctx))
+	if err != nil {
+		return "", err
+	}
+	id := msg.(*pb.GetClusterIdResponse).GetClusterId()
+	if id == "" {
+		return "", errors.New("the master registry doesn't know the cluster ID")
+	}
+	return id, nil
+}
@@ -0,0 +1,129 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"github.com/tsuna/gohbase/zk"
+	"golang.org/x/net/context"
+)
+
+// registryClient is a connection to a fake master registry.
+type registryClient struct {
+	fakeRegionClient
+	respond func(rpc hrpc.Call) hrpc.RPCResult
+}
+
+func (rc *registryClient) QueueRPC(rpc hrpc.Call) error {
+	rpc.GetResultChan() <- rc.respond(rpc)
+	return nil
+}
+
+func TestMasterRegistry(t *testing.T) {
+	c := newClient("", MasterRegistry("master1", "master2:16001"))
+	defer c.Close()
+	ctx := context.Background()
+	master := &registryClient{
+		fakeRegionClient: fakeRegionClient{host: "master1", port: defaultMasterPort},
+		respond: func(rpc hrpc.Call) hrpc.RPCResult {
+			var msg proto.Message
+			switch rpc.(type) {
+			case *hrpc.GetActiveMaster:
+				msg = &pb.GetActiveMasterResponse{ServerName: &pb.ServerName{
+					HostName: proto.String("master2"),
+					Port:     proto.Uint32(16001),
+				}}
+			case *hrpc.GetMetaLocations:
+				msg = &pb.GetMetaRegionLocationsResponse{
+					MetaLocations: []*pb.RegionLocation{{
+						RegionInfo: &pb.RegionInfo{ReplicaId: proto.Int32(1)},
+						ServerName: &pb.ServerName{
+							HostName: proto.String("rs2"),
+							Port:     proto.Uint32(16020),
+						},
+					}, {
+						// Unassigned.
+						RegionInfo: &pb.RegionInfo{ReplicaId: proto.Int32(2)},
+					}, {
+						RegionInfo: &pb.RegionInfo{ReplicaId: proto.Int32(0)},
+						ServerName: &pb.ServerName{
+							HostName: proto.String("rs1"),
+							Port:     proto.Uint32(16020),
+						},
+					}},
+				}
+			case *hrpc.GetClusterID:
+				msg = &pb.GetClusterIdResponse{ClusterId: proto.String("cluster")}
+			}
+			return hrpc.RPCResult{Msg: msg}
+		},
+	}
+	c.registry.client = master
+
+	host, port, err := c.lookupResource(ctx, zk.Master)
+	if err != nil || host != "master2" || port != 16001 {
+		t.Errorf("Expected the master at master2:16001, got %s:%d, %v", host, port, err)
+	}
+	host, port, err = c.lookupResource(ctx, zk.Meta)
+	if err != nil || host != "rs1" || port != 16020 {
+		t.Errorf("Expected meta at rs1:16020, got %s:%d, %v", host, port, err)
+	}
+	replicas, err := c.lookupMetaReplicas(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replicas) != 2 || replicas[0].Host != "rs1" || replicas[1].Host != "rs2" ||
+		replicas[1].Info.ReplicaID != 1 {
+		t.Errorf("Unexpected replicas of meta: %v", replicas)
+	}
+	if id, err := c.ClusterID(ctx); err != nil || id != "cluster" {
+		t.Errorf("Expected cluster ID %q, got %q, %v", "cluster", id, err)
+	}
+
+	// Once the connection fails, the next master is tried.
+	master.respond = func(rpc hrpc.Call) hrpc.RPCResult {
+		return hrpc.RPCResult{Error: errors.New("connection closed")}
+	}
+	c.registry.masters[1] = "~invalid.master~:16001"
+	if _, _, err = c.lookupResource(ctx, zk.Master); err == nil {
+		t.Error("Expected an error once no master answers")
+	}
+	if !master.closed {
+		t.Error("The failed connection wasn't closed")
+	}
+	if c.registry.client != nil || c.registry.current != 0 {
+		t.Errorf("Expected to be back to the first master, at %d", c.registry.current)
+	}
+}
+
+func TestSplitMasterAddr(t *testing.T) {
+	for _, tc := range []struct {
+		addr string
+		host string
+		port uint16
+		err  bool
+	}{
+		{addr: "master", host: "master", port: defaultMasterPort},
+		{addr: "master:16001", host: "master", port: 16001},
+		{addr: "[::1]:16001", host: "::1", port: 16001},
+		{addr: "master:port", err: true},
+	} {
+		host, port, err := splitMasterAddr(tc.addr)
+		if tc.err {
+			if err == nil {
+				t.Errorf("Expected an error for %q", tc.addr)
+			}
+		} else if err != nil || host != tc.host || port != tc.port {
+			t.Errorf("Expected %s:%d for %q, got %s:%d, %v", tc.host, tc.port, tc.addr,
+				host, port, err)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+// ReplicaLocation describes where one of the replicas of a region is served.
+type ReplicaLocation struct {
+	// ReplicaID is 0 for the primary replica.
+	ReplicaID uint32
+
+	Host string
+	Port uint16
+}
+
+// ReplicaSelector decides in which order the replicas of a region are tried
+// when a read is allowed to be served by any replica (TIMELINE consistency).
+type ReplicaSelector interface {
+	// Select returns the given replicas sorted by order of preference.  The
+	// replicas are passed primary first, and the returned slice must contain
+	// each of them exactly once.
+	Select(replicas []ReplicaLocation) []ReplicaLocation
+}
+
+// primaryFirst is the default ReplicaSelector: it always prefers the primary
+// replica, then the secondary ones in order of replica ID.
+type primaryFirst struct{}
+
+func (primaryFirst) Select(replicas []ReplicaLocation) []ReplicaLocation {
+	return replicas
+}
+
+// LocalityReplicaSelector prefers the replicas served by RegionServers that
+// share the locality label (e.g. a rack or an availability zone) of the
+// client, so as to minimize cross-rack or cross-zone traffic.  Replicas with
+// the same locality are kept in their original order, primary first.
+type LocalityReplicaSelector struct {
+	// Local is the locality label of the host running this client.
+	Local string
+
+	// Labels maps RegionServer host names to their locality label.  Hosts
+	// that aren't in the map are considered remote.
+	Labels map[string]string
+}
+
+// NewLocalityReplicaSelector creates a ReplicaSelector that prefers the
+// replicas served by the hosts that have the `local' label.
+func NewLocalityReplicaSelector(local string,
+	labels map[string]string) *LocalityReplicaSelector {
+	return &LocalityReplicaSelector{
+		Local:  local,
+		Labels: labels,
+	}
+}
+
+// Select returns the local replicas first, followed by the remote ones.
+func (s *LocalityReplicaSelector) Select(replicas []ReplicaLocation) []ReplicaLocation {
+	sorted := make([]ReplicaLocation, 0, len(replicas))
+	var remote []ReplicaLocation
+	for _, r := range replicas {
+		if label, ok := s.Labels[r.Host]; ok && label == s.Local {
+			sorted = append(sorted, r)
+		} else {
+			remote = append(remote, r)
+		}
+	}
+	return append(sorted, remote...)
+}
+
+// SetReplicaSelector will return an option that sets the ReplicaSelector used
+// to pick a replica for reads that can be served by secondary replicas.
+func SetReplicaSelector(s ReplicaSelector) Option {
+	return func(c *client) {
+		c.replicaSelector = s
+	}
+}
+
+// isTimelineRead returns true if the given RPC can be served by any replica
+// of its region.
+func isTimelineRead(rpc hrpc.Call) bool {
+	switch r := rpc.(type) {
+	case *hrpc.Get:
+		return r.GetConsistency() == hrpc.TimelineConsistency
+	case *hrpc.Scan:
+		return r.GetConsistency() == hrpc.TimelineConsistency
+	}
+	return false
+}
+
+// sendTimelineRPC sends the given RPC to the replicas of its region, in the
+// order chosen by the ReplicaSelector, until one of them successfully serves
+// it or the operation of the given context is done.
+func (c *client) sendTimelineRPC(ctx context.Context, rpc hrpc.Call) (proto.Message, error) {
+	replicas, err := c.lookupReplicas(ctx, rpc)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make([]ReplicaLocation, len(replicas))
+	byID := make(map[uint32]region.Replica, len(replicas))
+	for i, r := range replicas {
+		locations[i] = ReplicaLocation{ReplicaID: r.Info.ReplicaID, Host: r.Host, Port: r.Port}
+		byID[r.Info.ReplicaID] = r
+	}
+	for _, loc := range c.replicaSelector.Select(locations) {
+		r := byID[loc.ReplicaID]
+		if err = c.attempt(ctx); err != nil {
+			return nil, err
+		}
+		var client hrpc.RegionClient
+		client, err = c.regionClientFor(ctx, r.Host, r.Port)
+		if err == nil {
+			r.Info.SetClient(client)
+			var msg proto.Message
+			msg, err = c.sendRPCDirect(ctx, rpc, r.Info)
+			if err == nil {
+				return msg, nil
+			}
+		}
+		if err == ErrDeadline {
+			return nil, err
+		}
+		// The replica may have moved.
+		c.regions.forgetReplicas(replicas[0].Info.Name)
+		log.Infof("Failed to send %s to replica %d of %s, trying the next one: %s",
+			rpc.GetName(), loc.ReplicaID, r.Info, err)
+	}
+	return nil, err
+}
+
+// sendReplicaRPC sends the given Get to the given replica of its region, as
+// part of the operation of the given context.
+func (c *client) sendReplicaRPC(ctx context.Context, get *hrpc.Get,
+	id uint32) (proto.Message, error) {
+	replicas, err := c.lookupReplicas(ctx, get)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range replicas {
+		if r.Info.ReplicaID != id {
+			continue
+		}
+		if err = c.attempt(ctx); err != nil {
+			return nil, err
+		}
+		client, err := c.regionClientFor(ctx, r.Host, r.Port)
+		if err == nil {
+			r.Info.SetClient(client)
+			var msg proto.Message
+			if msg, err = c.sendRPCDirect(ctx, get, r.Info); err == nil {
+				return msg, nil
+			}
+		}
+		if err != ErrDeadline {
+			// The replica may have moved.
+			c.regions.forgetReplicas(replicas[0].Info.Name)
+		}
+		return nil, err
+	}
+	return nil, fmt.Errorf("replica %d of region %s isn't served anywhere", id, replicas[0].Info)
+}
+
+// lookupReplicas returns all the replicas of the region of the given RPC,
+// primary first, from the region cache if they're there, or from meta until
+// the given context is done.
+func (c *client) lookupReplicas(ctx context.Context, rpc hrpc.Call) ([]region.Replica, error) {
+	if bytes.Equal(rpc.Table(), metaTableName) {
+		return c.lookupMetaReplicas(ctx)
+	}
+	if !c.noRegionCache {
+		if reg := c.getRegionFromCache(rpc.Table(), rpc.Key()); reg != nil {
+			if replicas := c.regions.getReplicas(reg.GetName()); replicas != nil {
+				return replicas, nil
+			}
+		}
+	}
+	metaRow, err := c.metaLookup(ctx, rpc.Table(), rpc.Key())
+	if err != nil {
+		return nil, err
+	}
+	replicas, err := region.ParseRegionReplicas(metaRow)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkMetaEntry(rpc.Table(), rpc.Key(), replicas[0].Info); err != nil {
+		return nil, err
+	}
+	if !c.noRegionCache {
+		c.cacheReplicas(rpc.Table(), rpc.Key(), metaRow, replicas)
+	}
+	return replicas, nil
+}
+
+// cacheReplicas caches the given replicas, just looked up in the given row of
+// meta, with their primary region, which is added to the cache if it isn't
+// there yet so that the replicas are evicted along with it.
+func (c *client) cacheReplicas(table, key []byte, metaRow *pb.GetResponse,
+	replicas []region.Replica) {
+	c.regionsLock.Lock()
+	defer c.regionsLock.Unlock()
+	if c.getRegionFromCache(table, key) == nil {
+		// The replicas get connected to on their own, so the primary
+		// region is a separate copy.
+		reg, host, port, err := region.ParseRegionInfo(metaRow)
+		if err != nil {
+			return
+		}
+		c.cacheRegion(reg, host, port)
+	}
+	c.regions.putReplicas(replicas)
+}
+
+// getReplicas returns the cached replicas of the region with the given name,
+// if any.
+func (krc *keyRegionCache) getReplicas(name []byte) []region.Replica {
+	krc.m.Lock()
+	defer krc.m.Unlock()
+	return krc.replicas[string(name)]
+}
+
+// putReplicas caches the given replicas, primary first, as long as their
+// primary region is in the cache.
+func (krc *keyRegionCache) putReplicas(replicas []region.Replica) {
+	krc.m.Lock()
+	defer krc.m.Unlock()
+	name := replicas[0].Info.Name
+	if _, ok := krc.regions.Get(name); !ok {
+		// The cache has another region for this key, e.g. the parent of
+		// the region if it split since it was cached.
+		return
+	}
+	if krc.replicas == nil {
+		krc.replicas = make(map[string][]region.Replica)
+	}
+	krc.replicas[string(name)] = replicas
+}
+
+// forgetReplicas forgets the cached replicas of the region with the given
+// name, so that they're looked up in meta again.
+func (krc *keyRegionCache) forgetReplicas(name []byte) {
+	krc.m.Lock()
+	delete(krc.replicas, string(name))
+	krc.m.Unlock()
+}
+
+// regionClientFor returns a client for the RegionServer at the given
+// address, connecting to it if needed.
+func (c *client) regionClientFor(ctx context.Context, host string,
+	port uint16) (hrpc.RegionClient, error) {
+	if client := c.clients.checkForClient(host, port); client != nil {
+		return client, nil
+	}
+	ch := make(chan newRegResult, 1)
+	c.dialRegionServer(ctx, ch, region.RegionClient, host, port)
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		client := c.clients.addClient(res.Client)
+		if client != res.Client {
+			// Somebody else connected to this RegionServer concurrently.
+			res.Client.Close()
+		}
+		return client, nil
+	case <-ctx.Done():
+		return nil, ErrDeadline
+	}
+}
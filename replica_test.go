@@ -0,0 +1,162 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"github.com/tsuna/gohbase/zk"
+	"golang.org/x/net/context"
+)
+
+func TestLocalityReplicaSelector(t *testing.T) {
+	replicas := []ReplicaLocation{
+		{ReplicaID: 0, Host: "rs1", Port: 16020},
+		{ReplicaID: 1, Host: "rs2", Port: 16020},
+		{ReplicaID: 2, Host: "rs3", Port: 16020},
+		{ReplicaID: 3, Host: "rs4", Port: 16020},
+	}
+	s := NewLocalityReplicaSelector("us-east-1b", map[string]string{
+		"rs1": "us-east-1a",
+		"rs2": "us-east-1b",
+		"rs4": "us-east-1b",
+	})
+	expected := []ReplicaLocation{replicas[1], replicas[3], replicas[0], replicas[2]}
+	if sorted := s.Select(replicas); !reflect.DeepEqual(sorted, expected) {
+		t.Errorf("Expected replicas %v, got %v", expected, sorted)
+	}
+
+	client := newClient("~invalid.quorum~")
+	if sorted := client.replicaSelector.Select(replicas); !reflect.DeepEqual(sorted, replicas) {
+		t.Errorf("Expected the default selector to keep %v, got %v", replicas, sorted)
+	}
+}
+
+func TestLookupMetaReplicas(t *testing.T) {
+	c := newClient("~invalid.quorum~")
+	c.metaReplicas = metaReplicas{
+		watched: true,
+		ids:     []uint32{0, 1, 2},
+		// The location of replica 1 wasn't read yet.
+		locations: map[uint32]ReplicaLocation{
+			0: {ReplicaID: 0, Host: "rs1", Port: 16020},
+			2: {ReplicaID: 2, Host: "rs3", Port: 16020},
+		},
+	}
+	replicas, err := c.lookupMetaReplicas(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replicas) != 2 {
+		t.Fatalf("Expected 2 replicas, got %v", replicas)
+	}
+	if r := replicas[0]; string(r.Info.Name) != "hbase:meta,,1" || r.Host != "rs1" ||
+		r.Info == c.metaRegionInfo {
+		t.Errorf("Unexpected primary replica: %v at %s", r.Info, r.Host)
+	}
+	if r := replicas[1]; string(r.Info.Name) != "hbase:meta,,1_0002" ||
+		r.Info.ReplicaID != 2 || r.Host != "rs3" {
+		t.Errorf("Unexpected secondary replica: %v at %s", r.Info, r.Host)
+	}
+
+	if zk.MetaReplica(0) != zk.Meta || zk.MetaReplica(2) != zk.Meta+"-2" {
+		t.Errorf("Unexpected znodes of the replicas of meta: %s, %s",
+			zk.MetaReplica(0), zk.MetaReplica(2))
+	}
+}
+
+func TestReplicaCache(t *testing.T) {
+	c := newClient("~invalid.quorum~")
+	defer c.Close()
+	var m sync.Mutex
+	var lookups int
+	c.metaRegionInfo.SetClient(&registryClient{
+		fakeRegionClient: fakeRegionClient{host: "meta", port: 16020},
+		respond: func(rpc hrpc.Call) hrpc.RPCResult {
+			m.Lock()
+			lookups++
+			m.Unlock()
+			row := metaResult("b", "c", "~rs1~")
+			row.Cell = append(row.Cell, &pb.Cell{
+				Qualifier: []byte("server_0001"),
+				Value:     []byte("~rs2~:16020"),
+			})
+			return hrpc.RPCResult{Msg: &pb.ScanResponse{Results: []*pb.Result{row}}}
+		},
+	})
+	c.clients.addClient(&fakeRegionClient{host: "~rs1~", port: 16020})
+
+	get, err := hrpc.NewGetStr(context.Background(), "test", "bb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		replicas, err := c.lookupReplicas(context.Background(), get)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(replicas) != 2 || replicas[1].Host != "~rs2~" ||
+			replicas[1].Info.ReplicaID != 1 {
+			t.Fatalf("Unexpected replicas %v", replicas)
+		}
+	}
+	m.Lock()
+	if lookups != 1 {
+		t.Errorf("Expected the replicas to be looked up once, got %d lookups", lookups)
+	}
+	m.Unlock()
+	reg := c.getRegionFromCache([]byte("test"), []byte("bb"))
+	if reg == nil || string(reg.GetStartKey()) != "b" {
+		t.Fatalf("Expected the primary region to be cached, got %v", reg)
+	}
+
+	// The replicas are forgotten along with their primary region.
+	c.InvalidateRegion(reg.GetName())
+	if replicas := c.regions.getReplicas(reg.GetName()); replicas != nil {
+		t.Errorf("Expected the replicas to be forgotten, got %v", replicas)
+	}
+}
+
+func TestTimelineAttempts(t *testing.T) {
+	c := newClient("~invalid.quorum~", MaxAttempts(1))
+	defer c.Close()
+	c.metaRegionInfo.SetClient(&registryClient{
+		fakeRegionClient: fakeRegionClient{host: "meta", port: 16020},
+		respond: func(rpc hrpc.Call) hrpc.RPCResult {
+			row := metaResult("b", "c", "~rs1~")
+			row.Cell = append(row.Cell, &pb.Cell{
+				Qualifier: []byte("server_0001"),
+				Value:     []byte("~rs2~:16020"),
+			})
+			return hrpc.RPCResult{Msg: &pb.ScanResponse{Results: []*pb.Result{row}}}
+		},
+	})
+	failure := errors.New("replica failed")
+	for _, host := range []string{"~rs1~", "~rs2~"} {
+		c.clients.addClient(&registryClient{
+			fakeRegionClient: fakeRegionClient{host: host, port: 16020},
+			respond: func(rpc hrpc.Call) hrpc.RPCResult {
+				return hrpc.RPCResult{Error: failure}
+			},
+		})
+	}
+
+	// The replicas count as attempts of the operation, so the second one
+	// isn't tried.
+	get, err := hrpc.NewGetStr(context.Background(), "test", "bb",
+		hrpc.Consistency(hrpc.TimelineConsistency))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Get(get); err != ErrMaxAttempts {
+		t.Errorf("Expected ErrMaxAttempts, got %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"math/rand"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ErrorClass is a class of errors after which the RPCs are sent again.
+type ErrorClass int
+
+const (
+	// RegionError is the class of the errors that tell that a region moved,
+	// split or isn't online, after which the region is looked up again.
+	RegionError ErrorClass = iota
+
+	// ConnectionError is the class of the errors that broke a connection
+	// to a RegionServer, after which it's connected to again.
+	ConnectionError
+
+	// OverloadedError is the class of the pushbacks of the RegionServers
+	// whose call queue is full.
+	OverloadedError
+
+	numErrorClasses
+)
+
+// Backoff is how long the client waits before trying again, exponentially
+// longer after every failure.
+type Backoff struct {
+	// Start is the first backoff.  The default is 16ms, or 250ms after the
+	// pushbacks of a RegionServer.
+	Start time.Duration
+
+	// Max caps the backoff.  Without Max nor Multiplier, the backoff
+	// doubles up to 5s, and then grows by 5s every time, or up to 10s
+	// after the pushbacks of a RegionServer.
+	Max time.Duration
+
+	// Multiplier is how much longer the backoff gets after every failure.
+	// The default is 2.
+	Multiplier float64
+
+	// Jitter is the fraction of every backoff that's random, between 0 and
+	// 1, so that the clients that failed together don't all try again at
+	// the same time.  The default is no jitter.
+	Jitter float64
+}
+
+// first returns the first backoff, or the given default one.
+func (b Backoff) first(def time.Duration) time.Duration {
+	if b.Start > 0 {
+		return b.Start
+	}
+	return def
+}
+
+// next returns the backoff that follows the given one.
+func (b Backoff) next(backoff time.Duration) time.Duration {
+	if b.Max == 0 && b.Multiplier == 0 {
+		if backoff < 5000*time.Millisecond {
+			return backoff * 2
+		}
+		return backoff + 5000*time.Millisecond
+	}
+	multiplier := b.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+	backoff = time.Duration(float64(backoff) * multiplier)
+	if b.Max > 0 && backoff > b.Max {
+		backoff = b.Max
+	}
+	return backoff
+}
+
+// jittered returns the time to actually wait for the given backoff.
+func (b Backoff) jittered(backoff time.Duration) time.Duration {
+	if b.Jitter <= 0 {
+		return backoff
+	}
+	jitter := b.Jitter
+	if jitter > 1 {
+		jitter = 1
+	}
+	random := time.Duration(float64(backoff) * jitter * rand.Float64())
+	return backoff - time.Duration(float64(backoff)*jitter) + random
+}
+
+// sleep waits for the given backoff, or until the given context is done, and
+// returns the next backoff.
+func (b Backoff) sleep(ctx context.Context, backoff time.Duration) (time.Duration, error) {
+	select {
+	case <-time.After(b.jittered(backoff)):
+	case <-ctx.Done():
+		return 0, ErrDeadline
+	}
+	return b.next(backoff), nil
+}
+
+// RetryPolicy decides how the client sends the RPCs again after they failed,
+// and how it looks up and connects to the regions again.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times an operation is sent to
+	// a RegionServer, like with the MaxAttempts option.
+	MaxAttempts int
+
+	// Backoff is the backoff between the lookups of the regions in meta
+	// and between the connections to their RegionServers, between the
+	// retries of the batches and of the lookups of the active master, and
+	// between the polls of the master for the end of its procedures.
+	Backoff Backoff
+
+	// Overrides are the policies after the errors of the given classes.
+	Overrides map[ErrorClass]ClassPolicy
+}
+
+// ClassPolicy is how the RPCs are sent again after the errors of a class.
+type ClassPolicy struct {
+	// MaxAttempts is the maximum number of times an operation is sent
+	// again after errors of the class, after which it fails with the last
+	// of them.  0 doesn't limit it.
+	MaxAttempts int
+
+	// Backoff is how long to wait before sending the RPC again.  By
+	// default, the RPCs are sent again as soon as their region is found
+	// again, or after the backoff of their RegionServer if it pushed back.
+	Backoff Backoff
+}
+
+// SetRetryPolicy will return an option that sets how the client retries, for
+// the services that must bound their latency rather than wait for the
+// regions to come back.
+func SetRetryPolicy(p RetryPolicy) Option {
+	return func(c *client) {
+		c.retry = p
+		if p.MaxAttempts != 0 {
+			c.maxAttempts = p.MaxAttempts
+		}
+		if o, ok := p.Overrides[OverloadedError]; ok {
+			c.overload.backoff = o.Backoff
+		}
+	}
+}
+
+// sleepAndIncreaseBackoff waits for the given backoff according to the retry
+// policy of the client, and returns the next one.
+func (c *client) sleepAndIncreaseBackoff(ctx context.Context,
+	backoff time.Duration) (time.Duration, error) {
+	return c.retry.Backoff.sleep(ctx, backoff)
+}
+
+// backoffStart returns the first backoff of the retry policy of the client.
+func (c *client) backoffStart() time.Duration {
+	return c.retry.Backoff.first(backoffStart)
+}
+
+// retryAfter decides whether the operation of the given context is sent
+// again after the given error of the given class, and waits for the backoff
+// of the class if needed.  It returns the error to fail the operation with, if
+// it mustn't be sent again.
+func (c *client) retryAfter(ctx context.Context, class ErrorClass, err error) error {
+	p, ok := c.retry.Overrides[class]
+	if !ok {
+		return nil
+	}
+	a, _ := ctx.Value(attemptsKey{}).(*attempts)
+	backoff := p.Backoff.first(0)
+	if class == OverloadedError {
+		// The backoff after the pushbacks is that of the RegionServer.
+		backoff = 0
+	}
+	if a != nil {
+		a.m.Lock()
+		a.byClass[class]++
+		n := a.byClass[class]
+		if backoff > 0 {
+			if a.backoffs[class] == 0 {
+				a.backoffs[class] = backoff
+			}
+			backoff = a.backoffs[class]
+			a.backoffs[class] = p.Backoff.next(backoff)
+		}
+		a.m.Unlock()
+		if p.MaxAttempts > 0 && n > p.MaxAttempts {
+			return err
+		}
+	}
+	if backoff == 0 {
+		return nil
+	}
+	_, sleepErr := p.Backoff.sleep(ctx, backoff)
+	return sleepErr
+}
@@ -0,0 +1,108 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+func TestBackoff(t *testing.T) {
+	// The default backoff doubles up to 5s, and then grows by 5s.
+	var b Backoff
+	if d := b.first(backoffStart); d != backoffStart {
+		t.Errorf("Expected to start at %s, got %s", backoffStart, d)
+	}
+	if d := b.next(time.Second); d != 2*time.Second {
+		t.Errorf("Expected 2s, got %s", d)
+	}
+	if d := b.next(8 * time.Second); d != 13*time.Second {
+		t.Errorf("Expected 13s, got %s", d)
+	}
+
+	b = Backoff{Start: 10 * time.Millisecond, Max: time.Second, Multiplier: 3, Jitter: 0.5}
+	if d := b.next(100 * time.Millisecond); d != 300*time.Millisecond {
+		t.Errorf("Expected 300ms, got %s", d)
+	}
+	if d := b.next(500 * time.Millisecond); d != time.Second {
+		t.Errorf("Expected the backoff to be capped at 1s, got %s", d)
+	}
+	for i := 0; i < 100; i++ {
+		if d := b.jittered(time.Second); d < 500*time.Millisecond || d > time.Second {
+			t.Fatalf("Expected between 500ms and 1s, got %s", d)
+		}
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	c := newClient("~invalid.quorum~", SetRetryPolicy(RetryPolicy{
+		MaxAttempts: 5,
+		Backoff:     Backoff{Start: time.Millisecond, Max: 10 * time.Millisecond},
+		Overrides: map[ErrorClass]ClassPolicy{
+			RegionError: {MaxAttempts: 2, Backoff: Backoff{Start: time.Millisecond}},
+			OverloadedError: {Backoff: Backoff{Start: time.Second, Max: 4 * time.Second,
+				Multiplier: 4}},
+		},
+	}))
+	if c.maxAttempts != 5 || c.backoffStart() != time.Millisecond {
+		t.Errorf("Unexpected attempts %d and backoff %s", c.maxAttempts, c.backoffStart())
+	}
+
+	ctx, cancel := c.operationContext(context.Background())
+	defer cancel()
+	moved := errors.New("region moved")
+	for i := 0; i < 2; i++ {
+		if err := c.retryAfter(ctx, RegionError, moved); err != nil {
+			t.Fatalf("Expected retry %d to be allowed, got %v", i+1, err)
+		}
+	}
+	if err := c.retryAfter(ctx, RegionError, moved); err != moved {
+		t.Errorf("Expected the operation to fail with %v, got %v", moved, err)
+	}
+	// The other classes aren't limited.
+	if err := c.retryAfter(ctx, ConnectionError, moved); err != nil {
+		t.Errorf("Expected no limit on the connection errors, got %v", err)
+	}
+
+	// The backoff after pushbacks follows the policy.
+	now := time.Now()
+	for _, expected := range []time.Duration{time.Second, 4 * time.Second, 4 * time.Second} {
+		if d := c.overload.pushedBack(nil, 1, now); d != expected {
+			t.Errorf("Expected to back off for %s, got %s", expected, d)
+		}
+	}
+}
+
+func TestRetryPolicyWaits(t *testing.T) {
+	c := newClient("~invalid.quorum~", SetRetryPolicy(RetryPolicy{
+		Backoff: Backoff{Start: time.Hour},
+	}))
+	// The polls of the master back off according to the policy.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var polls int
+	err := c.pollTableState(ctx, pb.Table_ENABLED, func() (pb.Table_State, error) {
+		polls++
+		return pb.Table_ENABLING, nil
+	})
+	if err != ErrDeadline || polls != 1 {
+		t.Errorf("Expected ErrDeadline after 1 poll, got %v after %d", err, polls)
+	}
+
+	// And so do the lookups of the active master, up to masterBackoffMax.
+	var mf masterFailover
+	if backoff := mf.next(Backoff{Start: time.Second}); backoff != time.Second {
+		t.Errorf("Expected a first backoff of 1s, got %s", backoff)
+	}
+	backoff := mf.next(Backoff{Start: time.Second, Multiplier: 10})
+	if backoff != masterBackoffMax {
+		t.Errorf("Expected the backoff to be capped at %s, got %s", masterBackoffMax, backoff)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"bytes"
+	"errors"
+	"hash/fnv"
+	"sort"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"golang.org/x/net/context"
+)
+
+// SaltCodec prefixes row keys with the number of a bucket derived from a hash
+// of the key.  Sequential keys (e.g. timestamps) are thus spread over as many
+// regions as there are buckets instead of all hitting the last region of the
+// table.  Writers and readers of a table must use the same codec.
+type SaltCodec struct {
+	buckets int
+	// Number of bytes of the prefix: 1 for up to 256 buckets, 2 otherwise.
+	width int
+	hash  func(key []byte) uint32
+}
+
+// NewSaltCodec creates a SaltCodec that spreads keys over the given number of
+// buckets, which can't be more than 65536.  If hash is nil, the 32-bit FNV-1a
+// hash of the key is used.
+func NewSaltCodec(buckets int, hash func(key []byte) uint32) (*SaltCodec, error) {
+	if buckets < 1 || buckets > 1<<16 {
+		return nil, errors.New("The number of salt buckets must be between 1 and 65536.")
+	}
+	if hash == nil {
+		hash = func(key []byte) uint32 {
+			h := fnv.New32a()
+			h.Write(key)
+			return h.Sum32()
+		}
+	}
+	width := 1
+	if buckets > 1<<8 {
+		width = 2
+	}
+	return &SaltCodec{buckets: buckets, width: width, hash: hash}, nil
+}
+
+// Buckets returns the number of buckets of this codec.
+func (c *SaltCodec) Buckets() int {
+	return c.buckets
+}
+
+// Prefix returns the prefix of the keys of the given bucket.
+func (c *SaltCodec) Prefix(bucket int) []byte {
+	if c.width == 1 {
+		return []byte{byte(bucket)}
+	}
+	return []byte{byte(bucket >> 8), byte(bucket)}
+}
+
+// Salt returns the given key prefixed with its bucket.
+func (c *SaltCodec) Salt(key []byte) []byte {
+	bucket := int(c.hash(key) % uint32(c.buckets))
+	return append(c.Prefix(bucket), key...)
+}
+
+// Unsalt returns the given salted key without its prefix.
+func (c *SaltCodec) Unsalt(key []byte) []byte {
+	if len(key) < c.width {
+		return key
+	}
+	return key[c.width:]
+}
+
+// ranges returns the ranges of salted keys, one per bucket, that contain the
+// keys of the [startRow; stopRow[ range.
+func (c *SaltCodec) ranges(startRow, stopRow []byte) []keyRange {
+	ranges := make([]keyRange, c.buckets)
+	for b := range ranges {
+		prefix := c.Prefix(b)
+		ranges[b].start = append(prefix, startRow...)
+		if len(stopRow) != 0 {
+			ranges[b].stop = append(c.Prefix(b), stopRow...)
+		} else if b+1 < 1<<uint(8*c.width) {
+			ranges[b].stop = c.Prefix(b + 1)
+		}
+	}
+	return ranges
+}
+
+// unsalt strips the salt of the row keys of the given result, in place.
+func (c *SaltCodec) unsalt(res *hrpc.Result) {
+	for _, cell := range res.Cells {
+		cell.Row = c.Unsalt(cell.Row)
+	}
+}
+
+// defaultSaltedScanParallelism is how many buckets a SaltedTable scans
+// concurrently by default.
+const defaultSaltedScanParallelism = 16
+
+// SaltedTable is a handle on a table whose row keys are salted with a
+// SaltCodec.  It takes and returns unsalted keys, and salts them transparently.
+type SaltedTable struct {
+	client Client
+	table  string
+	codec  *SaltCodec
+
+	// How many buckets Scan scans concurrently.
+	parallelism int
+}
+
+// NewSaltedTable creates a handle to access the given table with the given
+// client, salting the row keys with the given codec.
+func NewSaltedTable(c Client, table string, codec *SaltCodec) *SaltedTable {
+	return &SaltedTable{
+		client:      c,
+		table:       table,
+		codec:       codec,
+		parallelism: defaultSaltedScanParallelism,
+	}
+}
+
+// SetScanParallelism sets how many buckets Scan scans concurrently, 16 by
+// default.  It must be called before the table is used.
+func (t *SaltedTable) SetScanParallelism(n int) {
+	if n < 1 {
+		n = 1
+	}
+	t.parallelism = n
+}
+
+// Get retrieves the given row.
+func (t *SaltedTable) Get(ctx context.Context, key []byte,
+	options ...func(hrpc.Call) error) (*hrpc.Result, error) {
+	get, err := hrpc.NewGet(ctx, []byte(t.table), t.codec.Salt(key), options...)
+	if err != nil {
+		return nil, err
+	}
+	res, err := t.client.Get(get)
+	if err != nil {
+		return nil, err
+	}
+	t.codec.unsalt(res)
+	return res, nil
+}
+
+// Put inserts the given family-column-values in the given row.
+func (t *SaltedTable) Put(ctx context.Context, key []byte, values map[string]map[string][]byte,
+	options ...func(hrpc.Call) error) (*hrpc.Result, error) {
+	put, err := hrpc.NewPutStr(ctx, t.table, string(t.codec.Salt(key)), values, options...)
+	if err != nil {
+		return nil, err
+	}
+	return t.client.Put(put)
+}
+
+// Delete deletes the given family-column-values from the given row.
+func (t *SaltedTable) Delete(ctx context.Context, key []byte,
+	values map[string]map[string][]byte, options ...func(hrpc.Call) error) (*hrpc.Result, error) {
+	del, err := hrpc.NewDelStr(ctx, t.table, string(t.codec.Salt(key)), values, options...)
+	if err != nil {
+		return nil, err
+	}
+	return t.client.Delete(del)
+}
+
+// Scan retrieves the rows of the [startRow; stopRow[ range, an empty stopRow
+// meaning the end of the table.  Since the rows of the range are spread over
+// all the buckets, this sends one scan per bucket, up to the scan parallelism
+// of the table at a time, and merges the results back in unsalted row key
+// order.  The other scans are canceled as soon as one fails.  The Ranges
+// option can't be used since its keys wouldn't be salted.
+func (t *SaltedTable) Scan(ctx context.Context, startRow, stopRow []byte,
+	options ...func(hrpc.Call) error) ([]*hrpc.Result, error) {
+	ranges := t.codec.ranges(startRow, stopRow)
+	perBucket := make([][]*hrpc.Result, len(ranges))
+	err := scanConcurrently(ctx, len(ranges), t.parallelism,
+		func(ctx context.Context, i int) error {
+			scan, err := hrpc.NewScanRange(ctx, []byte(t.table), ranges[i].start,
+				ranges[i].stop, options...)
+			if err == nil {
+				perBucket[i], err = t.client.Scan(scan)
+			}
+			return err
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*hrpc.Result
+	for i := range ranges {
+		for _, res := range perBucket[i] {
+			t.codec.unsalt(res)
+		}
+		results = append(results, perBucket[i]...)
+	}
+	sort.Stable(resultsByRow(results))
+	return results, nil
+}
+
+// resultsByRow sorts results by row key.
+type resultsByRow []*hrpc.Result
+
+func (r resultsByRow) Len() int {
+	return len(r)
+}
+
+func (r resultsByRow) Less(i, j int) bool {
+	return bytes.Compare(resultRow(r[i]), resultRow(r[j])) < 0
+}
+
+func (r resultsByRow) Swap(i, j int) {
+	r[i], r[j] = r[j], r[i]
+}
+
+func resultRow(res *hrpc.Result) []byte {
+	if len(res.Cells) == 0 {
+		return nil
+	}
+	return res.Cells[0].Row
+}
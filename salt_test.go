@@ -0,0 +1,127 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"golang.org/x/net/context"
+)
+
+func TestSaltCodec(t *testing.T) {
+	if _, err := NewSaltCodec(0, nil); err == nil {
+		t.Error("Expected an error with 0 buckets")
+	}
+	if _, err := NewSaltCodec(1<<16+1, nil); err == nil {
+		t.Error("Expected an error with more than 65536 buckets")
+	}
+
+	c, err := NewSaltCodec(16, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[byte]bool)
+	for _, key := range []string{"row1", "row2", "row3", "row4", "row5", "row6", "row7"} {
+		salted := c.Salt([]byte(key))
+		if len(salted) != len(key)+1 || salted[0] >= 16 {
+			t.Errorf("Unexpected salted key %q for %q", salted, key)
+		}
+		if again := c.Salt([]byte(key)); !bytes.Equal(again, salted) {
+			t.Errorf("Salting %q isn't deterministic: %q vs %q", key, salted, again)
+		}
+		if unsalted := c.Unsalt(salted); string(unsalted) != key {
+			t.Errorf("Expected %q once unsalted, got %q", key, unsalted)
+		}
+		seen[salted[0]] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("Expected sequential keys to be spread over buckets, got %v", seen)
+	}
+
+	c, err = NewSaltCodec(1000, func(key []byte) uint32 { return 258 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if salted := c.Salt([]byte("row")); !bytes.Equal(salted, []byte("\x01\x02row")) {
+		t.Errorf("Unexpected salted key with a 2-byte prefix: %q", salted)
+	}
+}
+
+func TestSaltCodecRanges(t *testing.T) {
+	c, err := NewSaltCodec(256, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ranges := c.ranges([]byte("a"), []byte("b"))
+	if len(ranges) != 256 {
+		t.Fatalf("Expected one range per bucket, got %d", len(ranges))
+	}
+	if r := ranges[3]; !bytes.Equal(r.start, []byte("\x03a")) ||
+		!bytes.Equal(r.stop, []byte("\x03b")) {
+		t.Errorf("Unexpected range for bucket 3: [%q; %q[", r.start, r.stop)
+	}
+
+	// Without a stop row, each range stops at the beginning of the next bucket.
+	ranges = c.ranges(nil, nil)
+	if r := ranges[3]; !bytes.Equal(r.start, []byte("\x03")) ||
+		!bytes.Equal(r.stop, []byte("\x04")) {
+		t.Errorf("Unexpected range for bucket 3: [%q; %q[", r.start, r.stop)
+	}
+	if r := ranges[255]; !bytes.Equal(r.start, []byte("\xff")) || len(r.stop) != 0 {
+		t.Errorf("Unexpected range for the last bucket: [%q; %q[", r.start, r.stop)
+	}
+}
+
+// scanClient is a Client whose scans are served by a function.
+type scanClient struct {
+	Client
+	scan func(s *hrpc.Scan) ([]*hrpc.Result, error)
+}
+
+func (c *scanClient) Scan(s *hrpc.Scan) ([]*hrpc.Result, error) {
+	return c.scan(s)
+}
+
+func TestSaltedTableScan(t *testing.T) {
+	codec, err := NewSaltCodec(8, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failure := errors.New("scan failed")
+	var m sync.Mutex
+	var running, maxRunning int
+	client := &scanClient{scan: func(s *hrpc.Scan) ([]*hrpc.Result, error) {
+		m.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		m.Unlock()
+		defer func() {
+			m.Lock()
+			running--
+			m.Unlock()
+		}()
+		if s.GetStartRow()[0] == 1 {
+			return nil, failure
+		}
+		// The scans of the other buckets only return once canceled.
+		<-s.GetContext().Done()
+		return nil, ErrDeadline
+	}}
+	table := NewSaltedTable(client, "test", codec)
+	table.SetScanParallelism(3)
+	if _, err = table.Scan(context.Background(), nil, nil); err != failure {
+		t.Errorf("Expected the error of the failed scan, got %v", err)
+	}
+	if maxRunning > 3 {
+		t.Errorf("Expected at most 3 concurrent scans, got %d", maxRunning)
+	}
+}
@@ -0,0 +1,489 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+// scannerLostExceptions are the Java exceptions returned for an open scanner
+// that the RegionServer doesn't know about (anymore).
+var scannerLostExceptions = []string{
+	"org.apache.hadoop.hbase.UnknownScannerException",
+	"org.apache.hadoop.hbase.regionserver.LeaseException",
+}
+
+// Scanner iterates over the rows returned by a Scan, fetching them from the
+// RegionServers as the caller consumes them.
+type Scanner interface {
+	// Next returns the next row of the scan, or io.EOF once all the rows
+	// have been returned.
+	Next() (*hrpc.Result, error)
+
+	// Close releases the scanner on the RegionServer.  It must be called
+	// when the caller stops calling Next before getting io.EOF.
+	Close() error
+
+	// Metrics returns the scan metrics returned by the RegionServers so far
+	// (e.g. "ROWS_SCANNED", "ROWS_FILTERED", "BYTES_IN_RESULTS"), summed
+	// across all the regions scanned.  It's empty unless the TrackScanMetrics
+	// option was set on the Scan.
+	Metrics() map[string]int64
+}
+
+// scanner scans one region at a time: it opens a scanner on the region that
+// contains startRow, fetches rows from it until the region is exhausted, then
+// closes it and moves on to the next region.
+type scanner struct {
+	// When Next was last called and when the scanner was created, in
+	// nanoseconds since the epoch, for the watchdog.  lastNext is accessed
+	// atomically, without the lock, so it must stay first to be 64-bit
+	// aligned.
+	lastNext int64
+	started  int64
+	// Set to 1 once the watchdog aborted the scanner.
+	aborted int32
+
+	c *client
+	s *hrpc.Scan
+
+	// m protects all the fields below, and serializes the RPCs sent for the
+	// scanner open on the current region.
+	m sync.Mutex
+
+	// Start of the region to scan next.
+	startRow []byte
+
+	// State of the scanner open on the current region, if any.
+	open      bool
+	scannerID uint64
+	key       []byte
+	region    hrpc.RegionInfo
+	send      func(hrpc.Call) (proto.Message, error)
+
+	// Rows fetched but not returned by Next yet.
+	results []*pb.Result
+
+	// Row key of the last row fetched from the current region.
+	lastRow []byte
+
+	// Scan metrics returned by the RegionServers.
+	metrics map[string]int64
+
+	// err is returned by Next once all the fetched rows have been returned.
+	// It's io.EOF at the end of the scan.
+	err error
+
+	// Lease renewal of the scanner open on the current region.
+	renewInterval time.Duration
+	lastRPC       time.Time
+	stopRenew     chan struct{}
+}
+
+// newScanner creates a scanner for the given Scan.  If renewInterval isn't 0,
+// the lease of the scanner is renewed whenever the caller doesn't call Next
+// for that long.
+func (c *client) newScanner(s *hrpc.Scan, renewInterval time.Duration) *scanner {
+	now := time.Now().UnixNano()
+	return &scanner{
+		c:             c,
+		s:             s,
+		startRow:      s.GetStartRow(),
+		renewInterval: renewInterval,
+		metrics:       make(map[string]int64),
+		started:       now,
+		lastNext:      now,
+	}
+}
+
+// Scanner returns a Scanner that iterates over the rows of the given Scan.
+func (c *client) Scanner(s *hrpc.Scan) Scanner {
+	return c.newScanner(s, c.scannerRenewInterval)
+}
+
+// ErrStopScan can be returned by the visitor of ScanEach to stop the scan
+// early, ScanEach then returning nil.
+var ErrStopScan = errors.New("scan stopped by the visitor")
+
+// ScanEach calls visit with each row of the given Scan, in order, fetching the
+// rows from the RegionServers as they're visited.  The scan stops at the first
+// error of visit, which is returned unless it's ErrStopScan.
+func (c *client) ScanEach(s *hrpc.Scan, visit func(*hrpc.Result) error) error {
+	return visitRows(c.Scanner(s), visit)
+}
+
+// visitRows calls visit with each row returned by the given scanner, and
+// closes it if visit stops before the end of the scan.
+func visitRows(sc Scanner, visit func(*hrpc.Result) error) error {
+	for {
+		res, err := sc.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			sc.Close()
+			return err
+		}
+		if err = visit(res); err != nil {
+			sc.Close()
+			if err == ErrStopScan {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (s *scanner) Next() (*hrpc.Result, error) {
+	r, err := s.next()
+	if err != nil {
+		return nil, err
+	}
+	res := hrpc.ToLocalResult(r)
+	res.OrderColumns(s.s.GetColumnOrder())
+	return res, nil
+}
+
+func (s *scanner) next() (*pb.Result, error) {
+	atomic.StoreInt64(&s.lastNext, time.Now().UnixNano())
+	s.m.Lock()
+	defer s.m.Unlock()
+	for len(s.results) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		s.err = s.fetch()
+	}
+	r := s.results[0]
+	s.results[0] = nil
+	s.results = s.results[1:]
+	return r, nil
+}
+
+// fetch fetches the next rows of the scan, opening a scanner on the next
+// region if needed.  Must be called with the lock held.
+func (s *scanner) fetch() error {
+	var rpc *hrpc.Scan
+	if s.open {
+		rpc = hrpc.NewScanFromID(s.s.GetContext(), s.s.Table(), s.scannerID, s.key,
+			hrpc.TrackScanMetrics(s.s.GetTrackScanMetrics()),
+			hrpc.DebugProtos(s.s.GetDebugProtos()))
+	} else {
+		var err error
+		rpc, err = cloneScan(s.s, s.startRow, s.s.GetStopRow())
+		if err != nil {
+			return err
+		}
+		s.send = s.c.sendRPC
+		_, to := s.s.GetTimeRange()
+		s.c.serverClock.checkTimeRange(s.s, to, s.s.GetClockSkew())
+	}
+
+	res, err := s.send(rpc)
+	if err != nil && s.open && isScannerLost(err) {
+		// The region moved or was split, or its RegionServer forgot about
+		// our scanner: reopen it where we left off.
+		log.Infof("Lost scanner %d on %s, resuming the scan after row %q: %s",
+			s.scannerID, s.region, s.lastRow, err)
+		s.resume(err)
+		return nil
+	} else if err != nil {
+		s.stopRenewal()
+		if err == ErrCallTimeout && s.open {
+			// The RegionServer may still send the rows we gave up on, so
+			// the scanner isn't where we left off anymore: release it.
+			s.open = false
+			s.c.scanners.remove(s)
+			s.send(hrpc.NewCloseFromID(s.s.GetContext(), s.s.Table(), s.scannerID, s.key))
+		}
+		return err
+	}
+	scanres := res.(*pb.ScanResponse)
+	s.lastRPC = time.Now()
+	if !s.open {
+		s.open = true
+		s.scannerID = scanres.GetScannerId()
+		s.key = rpc.Key()
+		s.region = rpc.GetRegion()
+		s.c.scanners.add(s)
+		// The subsequent requests for this scanner must be sent to the
+		// region (or replica) on which the scanner was opened.
+		reg := s.region
+		s.send = func(rpc hrpc.Call) (proto.Message, error) {
+			return s.c.sendRPCDirect(rpc.GetContext(), rpc, reg)
+		}
+		s.startRenewal()
+	}
+	for _, metric := range scanres.GetScanMetrics().GetMetrics() {
+		s.metrics[metric.GetName()] += metric.GetValue()
+	}
+	s.results = markStale(scanres)
+	if n := len(s.results); n != 0 && len(s.results[n-1].Cell) != 0 {
+		s.lastRow = s.results[n-1].Cell[0].Row
+	}
+
+	// TODO: The more_results field of the ScanResponse object was always
+	// true, so we should figure out if there's a better way to know when
+	// to move on to the next region than making an extra request and
+	// seeing if there were no results
+	if len(s.results) == 0 {
+		return s.closeRegion()
+	}
+	return nil
+}
+
+// closeRegion closes the scanner open on the current region and moves on to
+// the next region, if any.  Must be called with the lock held.
+func (s *scanner) closeRegion() error {
+	rpc := hrpc.NewCloseFromID(s.s.GetContext(), s.s.Table(), s.scannerID, s.key)
+	s.send(rpc)
+	s.open = false
+	s.c.scanners.remove(s)
+	s.lastRow = nil
+
+	// Check to see if this region is the last we should scan (either
+	// because (1) it's the last region or (3) because its stop_key is
+	// greater than or equal to the stop_key of this scanner provided
+	// that (2) we're not trying to scan until the end of the table).
+	regionStop := s.region.GetStopKey()
+	stopRow := s.s.GetStopRow()
+	// (1)
+	if len(regionStop) == 0 ||
+		// (2)                (3)
+		len(stopRow) != 0 && bytes.Compare(stopRow, regionStop) <= 0 {
+		s.stopRenewal()
+		return io.EOF
+	}
+	s.startRow = regionStop
+	return nil
+}
+
+// resume forgets about the scanner that was open on the current region, so
+// that the next fetch reopens a scanner right after the last row received.
+// Must be called with the lock held.
+func (s *scanner) resume(err error) {
+	s.open = false
+	s.c.scanners.remove(s)
+	if s.lastRow != nil {
+		// The smallest row key that sorts after lastRow.
+		s.startRow = append(append(make([]byte, 0, len(s.lastRow)+1), s.lastRow...), 0)
+	}
+	if _, ok := err.(region.RetryableError); ok && !isTimelineRead(s.s) {
+		// The region isn't served there anymore, look it up again before
+		// reopening the scanner.
+		if s.region.MarkUnavailable() {
+			go s.c.reestablishRegion(s.region)
+		}
+		s.c.clients.del(s.region)
+	}
+}
+
+// isScannerLost returns true if the given error, returned by an RPC sent to
+// an open scanner, means that the scanner doesn't exist anymore and that it
+// must be reopened.
+func isScannerLost(err error) bool {
+	switch err.(type) {
+	case region.RetryableError, region.UnrecoverableError:
+		return true
+	}
+	if err == errNoClient {
+		return true
+	}
+	msg := err.Error()
+	for _, exception := range scannerLostExceptions {
+		if strings.HasPrefix(msg, "HBase Java exception "+exception+":") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *scanner) Metrics() map[string]int64 {
+	s.m.Lock()
+	defer s.m.Unlock()
+	metrics := make(map[string]int64, len(s.metrics))
+	for name, value := range s.metrics {
+		metrics[name] = value
+	}
+	return metrics
+}
+
+func (s *scanner) Close() error {
+	return s.close(s.s.GetContext())
+}
+
+// close closes the scanner open on the current region, if any, using the
+// given context for the CloseScanner RPC.
+func (s *scanner) close(ctx context.Context) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.stopRenewal()
+	s.results = nil
+	if s.err == nil {
+		s.err = io.EOF
+	}
+	if !s.open {
+		return nil
+	}
+	s.open = false
+	s.c.scanners.remove(s)
+	rpc := hrpc.NewCloseFromID(ctx, s.s.Table(), s.scannerID, s.key)
+	_, err := s.send(rpc)
+	return err
+}
+
+// startRenewal starts the goroutine that renews the lease of the scanner, if
+// lease renewal is enabled and the goroutine isn't running yet.  Must be
+// called with the lock held.
+func (s *scanner) startRenewal() {
+	if s.renewInterval <= 0 || s.stopRenew != nil {
+		return
+	}
+	s.stopRenew = make(chan struct{})
+	go s.renewLoop(s.stopRenew)
+}
+
+// stopRenewal stops the lease renewal goroutine, if it's running.  Must be
+// called with the lock held.
+func (s *scanner) stopRenewal() {
+	if s.stopRenew != nil {
+		close(s.stopRenew)
+		s.stopRenew = nil
+	}
+}
+
+// renewLoop periodically renews the lease of the scanner open on the current
+// region if the caller hasn't fetched rows from it recently, so that slow
+// consumers don't get a LeaseException.
+func (s *scanner) renewLoop(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.renewInterval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		s.m.Lock()
+		select {
+		case <-stop:
+			// We were stopped while waiting on the lock.
+			s.m.Unlock()
+			return
+		default:
+		}
+		if s.open && time.Since(s.lastRPC) >= s.renewInterval {
+			rpc := hrpc.NewRenewFromID(s.s.GetContext(), s.s.Table(), s.scannerID, s.key)
+			if _, err := s.send(rpc); err != nil {
+				log.Warningf("Failed to renew the lease of scanner %d on %s: %s",
+					s.scannerID, s.region, err)
+			}
+			s.lastRPC = time.Now()
+		}
+		s.m.Unlock()
+	}
+}
+
+// ScannerStats counts the scanners that were still open on the RegionServers
+// when the client was closed.
+type ScannerStats struct {
+	// Closed is the number of scanners that Close successfully closed.
+	Closed int
+
+	// Leaked is the number of scanners that Close failed to close in time.
+	// They will linger on their RegionServer until their lease expires.
+	Leaked int
+}
+
+// openScanners keeps track of the scanners that are open on RegionServers,
+// so that they can be closed when the client is closed.
+type openScanners struct {
+	m sync.Mutex
+
+	scanners map[*scanner]struct{}
+
+	stats ScannerStats
+}
+
+func (o *openScanners) add(s *scanner) {
+	o.m.Lock()
+	o.scanners[s] = struct{}{}
+	o.m.Unlock()
+}
+
+func (o *openScanners) remove(s *scanner) {
+	o.m.Lock()
+	delete(o.scanners, s)
+	o.m.Unlock()
+}
+
+func (o *openScanners) list() []*scanner {
+	o.m.Lock()
+	defer o.m.Unlock()
+	scanners := make([]*scanner, 0, len(o.scanners))
+	for s := range o.scanners {
+		scanners = append(scanners, s)
+	}
+	return scanners
+}
+
+// closeAll sends a CloseScanner RPC for each open scanner, and gives up on
+// the ones that aren't closed within the given timeout.
+func (o *openScanners) closeAll(timeout time.Duration) {
+	scanners := o.list()
+	if len(scanners) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var closed int32
+	var wg sync.WaitGroup
+	for _, s := range scanners {
+		wg.Add(1)
+		go func(s *scanner) {
+			defer wg.Done()
+			if err := s.close(ctx); err == nil {
+				atomic.AddInt32(&closed, 1)
+			} else {
+				log.Warningf("Failed to close scanner %d on %s: %s", s.scannerID, s.region, err)
+			}
+		}(s)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	n := int(atomic.LoadInt32(&closed))
+	o.m.Lock()
+	o.stats.Closed += n
+	o.stats.Leaked += len(scanners) - n
+	o.m.Unlock()
+}
+
+// ScannerStats returns the number of scanners closed and leaked by Close.
+func (c *client) ScannerStats() ScannerStats {
+	c.scanners.m.Lock()
+	defer c.scanners.m.Unlock()
+	return c.scanners.stats
+}
@@ -0,0 +1,267 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+func TestCloseScanners(t *testing.T) {
+	c := newClient("~invalid.quorum~")
+	scan, err := hrpc.NewScanStr(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := &region.Info{Table: []byte("test"), Name: []byte("test,,1234567890")}
+
+	closed := c.newScanner(scan, 0)
+	closed.open = true
+	closed.region = reg
+	closed.send = func(rpc hrpc.Call) (proto.Message, error) {
+		return nil, nil
+	}
+	c.scanners.add(closed)
+
+	leaked := c.newScanner(scan, 0)
+	leaked.open = true
+	leaked.region = reg
+	leaked.send = func(rpc hrpc.Call) (proto.Message, error) {
+		<-rpc.GetContext().Done()
+		return nil, ErrDeadline
+	}
+	c.scanners.add(leaked)
+
+	c.scanners.closeAll(10 * time.Millisecond)
+	expected := ScannerStats{Closed: 1, Leaked: 1}
+	if stats := c.ScannerStats(); stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+	if n := len(c.scanners.list()); n != 0 {
+		t.Errorf("Expected no open scanners, got %d", n)
+	}
+	if _, err := closed.Next(); err == nil {
+		t.Error("Expected Next to fail on a closed scanner")
+	}
+}
+
+func TestScannerResume(t *testing.T) {
+	unknownScanner := errors.New("HBase Java exception " +
+		"org.apache.hadoop.hbase.UnknownScannerException: \nstack trace")
+	testcases := []struct {
+		err  error
+		lost bool
+	}{
+		{unknownScanner, true},
+		{region.RetryableError{}, true},
+		{region.UnrecoverableError{}, true},
+		{errNoClient, true},
+		{ErrDeadline, false},
+		{errors.New("HBase Java exception " +
+			"org.apache.hadoop.hbase.regionserver.NoSuchColumnFamilyException: \n"), false},
+	}
+	for _, tc := range testcases {
+		if lost := isScannerLost(tc.err); lost != tc.lost {
+			t.Errorf("Expected isScannerLost(%q) to be %v", tc.err, tc.lost)
+		}
+	}
+
+	c := newClient("~invalid.quorum~")
+	scan, err := hrpc.NewScanRangeStr(context.Background(), "test", "a", "z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := c.newScanner(scan, 0)
+	s.open = true
+	c.scanners.add(s)
+	s.resume(unknownScanner)
+	if s.open || !bytes.Equal(s.startRow, []byte("a")) {
+		t.Errorf("Expected to resume from the start, got open=%v startRow=%q",
+			s.open, s.startRow)
+	}
+	s.open = true
+	s.lastRow = []byte("foo")
+	s.resume(unknownScanner)
+	if expected := []byte("foo\x00"); !bytes.Equal(s.startRow, expected) {
+		t.Errorf("Expected to resume from %q, got %q", expected, s.startRow)
+	}
+	if n := len(c.scanners.list()); n != 0 {
+		t.Errorf("Expected no open scanners, got %d", n)
+	}
+}
+
+func TestScannerTimeout(t *testing.T) {
+	c := newClient("~invalid.quorum~")
+	scan, err := hrpc.NewScanStr(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := c.newScanner(scan, 0)
+	s.open = true
+	s.region = &region.Info{Table: []byte("test"), Name: []byte("test,,1234567890")}
+	var sent int
+	s.send = func(rpc hrpc.Call) (proto.Message, error) {
+		sent++
+		if sent > 1 {
+			// The CloseScanner RPC.
+			return nil, nil
+		}
+		return nil, ErrCallTimeout
+	}
+	c.scanners.add(s)
+	// The scanner isn't resumed from a position the RegionServer may have
+	// moved past, it's released and the scan fails.
+	for i := 0; i < 2; i++ {
+		if _, err := s.Next(); err != ErrCallTimeout {
+			t.Errorf("Expected ErrCallTimeout, got %v", err)
+		}
+	}
+	if sent != 2 || s.open {
+		t.Errorf("Expected the scanner to be closed, got %d RPCs, open=%v", sent, s.open)
+	}
+	if n := len(c.scanners.list()); n != 0 {
+		t.Errorf("Expected no open scanners, got %d", n)
+	}
+}
+
+func TestScannerMetrics(t *testing.T) {
+	c := newClient("~invalid.quorum~")
+	scan, err := hrpc.NewScanStr(context.Background(), "test", hrpc.TrackScanMetrics(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics := func(scanned int64) *pb.ScanMetrics {
+		return &pb.ScanMetrics{Metrics: []*pb.NameInt64Pair{
+			{Name: proto.String("ROWS_SCANNED"), Value: proto.Int64(scanned)},
+		}}
+	}
+	cell := &pb.Cell{Row: []byte("row")}
+	responses := []*pb.ScanResponse{
+		{Results: []*pb.Result{{Cell: []*pb.Cell{cell}}}, ScanMetrics: metrics(3)},
+		{ScanMetrics: metrics(2)},
+		{}, // Response to the CloseScanner RPC.
+	}
+
+	s := c.newScanner(scan, 0)
+	s.open = true
+	s.region = &region.Info{Table: []byte("test"), Name: []byte("test,,1234567890")}
+	s.send = func(rpc hrpc.Call) (proto.Message, error) {
+		if len(responses) > 1 && !rpc.(*hrpc.Scan).GetTrackScanMetrics() {
+			t.Error("Expected the scanner RPCs to track scan metrics")
+		}
+		res := responses[0]
+		responses = responses[1:]
+		return res, nil
+	}
+	if _, err := s.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Next(); err != io.EOF {
+		t.Fatalf("Expected io.EOF, got %v", err)
+	}
+	if m := s.Metrics(); m["ROWS_SCANNED"] != 5 {
+		t.Errorf("Expected 5 rows scanned, got %v", m)
+	}
+}
+
+func TestScanWatchdog(t *testing.T) {
+	c := newClient("~invalid.quorum~", ScanWatchdog(time.Hour, time.Minute))
+	scan, err := hrpc.NewScanStr(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := make(chan struct{})
+	s := c.newScanner(scan, 0)
+	s.open = true
+	s.region = &region.Info{Table: []byte("test"), Name: []byte("test,,1234567890")}
+	s.send = func(rpc hrpc.Call) (proto.Message, error) {
+		close(closed)
+		return &pb.ScanResponse{}, nil
+	}
+	c.scanners.add(s)
+
+	c.checkScanners(time.Now().Add(30 * time.Second))
+	if n := len(c.scanners.list()); n != 1 {
+		t.Fatalf("Expected the scanner not to be aborted yet, got %d open scanners", n)
+	}
+	c.checkScanners(time.Now().Add(2 * time.Minute))
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the idle scanner to be closed")
+	}
+	if _, err := s.Next(); err != ErrScanAborted {
+		t.Errorf("Expected ErrScanAborted, got %v", err)
+	}
+	c.Close()
+}
+
+// sliceScanner is a Scanner returning the rows of a slice.
+type sliceScanner struct {
+	rows   []*hrpc.Result
+	closed bool
+}
+
+func (s *sliceScanner) Next() (*hrpc.Result, error) {
+	if s.closed || len(s.rows) == 0 {
+		return nil, io.EOF
+	}
+	r := s.rows[0]
+	s.rows = s.rows[1:]
+	return r, nil
+}
+
+func (s *sliceScanner) Close() error {
+	s.closed = true
+	return nil
+}
+
+func (s *sliceScanner) Metrics() map[string]int64 {
+	return nil
+}
+
+func TestVisitRows(t *testing.T) {
+	rows := []*hrpc.Result{{}, {}, {}}
+	var visited int
+	count := func(*hrpc.Result) error {
+		visited++
+		return nil
+	}
+	if err := visitRows(&sliceScanner{rows: rows}, count); err != nil || visited != 3 {
+		t.Errorf("Expected 3 rows visited without error, got %d (%v)", visited, err)
+	}
+
+	visited = 0
+	sc := &sliceScanner{rows: rows}
+	err := visitRows(sc, func(r *hrpc.Result) error {
+		if visited++; visited == 2 {
+			return ErrStopScan
+		}
+		return nil
+	})
+	if err != nil || visited != 2 || !sc.closed {
+		t.Errorf("Expected the scan to stop after 2 rows, got %d (%v, closed=%v)",
+			visited, err, sc.closed)
+	}
+
+	oops := errors.New("oops")
+	sc = &sliceScanner{rows: rows}
+	err = visitRows(sc, func(r *hrpc.Result) error {
+		return oops
+	})
+	if err != oops || !sc.closed {
+		t.Errorf("Expected the error of the visitor, got %v (closed=%v)", err, sc.closed)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"sync"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"golang.org/x/net/context"
+)
+
+// ScanTables runs the given Scan on each of the given tables, e.g. the shards
+// of a time-partitioned schema like events_201601, events_201602..., and
+// returns their rows merged in the order of the tables.  The tables share the
+// deadline of the context of the Scan and, if maxRows > 0, a budget of
+// maxRows rows, after which the scans stop.  Up to `parallelism' tables are
+// scanned concurrently: when scanning them one at a time, the rows returned
+// are the first ones in the order of the tables, but when scanning several at
+// a time, the budget goes to the tables that return their rows first.  The
+// scans stop at the first error, which is returned.
+func (c *client) ScanTables(s *hrpc.Scan, tables [][]byte, maxRows,
+	parallelism int) ([]*hrpc.Result, error) {
+	return scanTables(s.GetContext(), len(tables), maxRows, parallelism,
+		func(ctx context.Context, i int) (Scanner, error) {
+			scan, err := hrpc.NewScanRange(ctx, tables[i], s.GetStartRow(), s.GetStopRow(),
+				scanOptions(s)...)
+			if err != nil {
+				return nil, err
+			}
+			return c.Scanner(scan), nil
+		})
+}
+
+// scanTables collects the rows of the n scanners returned by open, scanning
+// up to `parallelism' of them at a time, in order, until maxRows rows were
+// collected if maxRows > 0.
+func scanTables(ctx context.Context, n, maxRows, parallelism int,
+	open func(ctx context.Context, i int) (Scanner, error)) ([]*hrpc.Result, error) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Protects the fields below.
+	var m sync.Mutex
+	budget := maxRows
+	perTable := make([][]*hrpc.Result, n)
+	var firstErr error
+
+	spent := func() bool {
+		m.Lock()
+		defer m.Unlock()
+		return maxRows > 0 && budget == 0 || firstErr != nil
+	}
+	visit := func(i int) func(*hrpc.Result) error {
+		return func(res *hrpc.Result) error {
+			m.Lock()
+			defer m.Unlock()
+			if maxRows > 0 && budget == 0 || firstErr != nil {
+				return ErrStopScan
+			}
+			perTable[i] = append(perTable[i], res)
+			if budget--; maxRows > 0 && budget == 0 {
+				return ErrStopScan
+			}
+			return nil
+		}
+	}
+
+	next := make(chan int)
+	go func() {
+		defer close(next)
+		for i := 0; i < n; i++ {
+			select {
+			case next <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if spent() {
+					continue
+				}
+				sc, err := open(ctx, i)
+				if err == nil {
+					err = visitRows(sc, visit(i))
+				}
+				if err != nil {
+					m.Lock()
+					if firstErr == nil {
+						// Stop the scans of the other tables.
+						firstErr = err
+						cancel()
+					}
+					m.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	var results []*hrpc.Result
+	for _, rows := range perTable {
+		results = append(results, rows...)
+	}
+	return results, nil
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"golang.org/x/net/context"
+)
+
+func TestScanTables(t *testing.T) {
+	rows := func(table string, n int) []*hrpc.Result {
+		results := make([]*hrpc.Result, n)
+		for i := range results {
+			results[i] = &hrpc.Result{Cells: []*hrpc.Cell{{Row: []byte(table)}}}
+		}
+		return results
+	}
+	tables := [][]*hrpc.Result{rows("a", 3), rows("b", 2), rows("c", 4)}
+	opened := make([]bool, len(tables))
+	open := func(ctx context.Context, i int) (Scanner, error) {
+		opened[i] = true
+		return &sliceScanner{rows: tables[i]}, nil
+	}
+	tablesOf := func(results []*hrpc.Result) string {
+		var s string
+		for _, res := range results {
+			s += string(res.Cells[0].Row)
+		}
+		return s
+	}
+
+	results, err := scanTables(context.Background(), len(tables), 0, 1, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := tablesOf(results); s != "aaabbcccc" {
+		t.Errorf("Expected all the rows in the order of the tables, got %q", s)
+	}
+
+	opened = make([]bool, len(tables))
+	results, err = scanTables(context.Background(), len(tables), 4, 1, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := tablesOf(results); s != "aaab" {
+		t.Errorf("Expected the first 4 rows, got %q", s)
+	}
+	if opened[2] {
+		t.Error("Expected the last table not to be scanned once the budget was spent")
+	}
+
+	results, err = scanTables(context.Background(), len(tables), 5, 3, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 5 {
+		t.Errorf("Expected 5 rows, got %d", len(results))
+	}
+
+	oops := errors.New("oops")
+	_, err = scanTables(context.Background(), len(tables), 0, 2,
+		func(ctx context.Context, i int) (Scanner, error) {
+			if i == 1 {
+				return nil, oops
+			}
+			return &sliceScanner{rows: tables[i]}, nil
+		})
+	if err != oops {
+		t.Errorf("Expected the error of the second table, got %v", err)
+	}
+}
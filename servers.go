@@ -0,0 +1,46 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+)
+
+// ListRegionServers returns the live RegionServers and their load, as last
+// reported by them to the master, ordered by host and port.
+func (c *client) ListRegionServers(t *hrpc.ListRegionServers) ([]*hrpc.RegionServerLoad, error) {
+	pbmsg, err := c.sendRPC(t)
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := pbmsg.(*pb.GetClusterStatusResponse)
+	if !ok {
+		return nil, fmt.Errorf("sendRPC returned not a GetClusterStatusResponse")
+	}
+	live := r.GetClusterStatus().GetLiveServers()
+	servers := make([]*hrpc.RegionServerLoad, 0, len(live))
+	for _, info := range live {
+		servers = append(servers, hrpc.ToLocalRegionServerLoad(info))
+	}
+	sort.Sort(regionServerLoads(servers))
+	return servers, nil
+}
+
+type regionServerLoads []*hrpc.RegionServerLoad
+
+func (l regionServerLoads) Len() int      { return len(l) }
+func (l regionServerLoads) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l regionServerLoads) Less(i, j int) bool {
+	if l[i].Host != l[j].Host {
+		return l[i].Host < l[j].Host
+	}
+	return l[i].Port < l[j].Port
+}
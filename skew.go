@@ -0,0 +1,85 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// skewWarningInterval is the minimum interval between two warnings about a
+// time range ending in the future for the RegionServers.
+const skewWarningInterval = time.Minute
+
+// serverClock estimates the offset of the clock of the RegionServers compared
+// to ours, from the timestamps they assign to the cells of Appends and
+// Increments.
+type serverClock struct {
+	m sync.Mutex
+	// Set once an offset was observed.
+	known bool
+	// How far ahead of ours the clock of the RegionServers is.
+	offset time.Duration
+	// Last time a warning was logged.
+	lastWarning time.Time
+}
+
+// observe updates the offset from the cells of the result of an
+// Append or Increment, received at the given time.
+func (sc *serverClock) observe(res *hrpc.Result, received time.Time) {
+	var latest uint64
+	for _, cell := range res.Cells {
+		if ts := cell.TimestampMillis(); ts > latest {
+			latest = ts
+		}
+	}
+	if latest == 0 {
+		return
+	}
+	sc.m.Lock()
+	sc.offset = hrpc.MillisToTime(latest).Sub(received)
+	sc.known = true
+	sc.m.Unlock()
+}
+
+// futureTimeRange returns how far in the future for the RegionServers the
+// time range ending at the given time ends, once widened by the given clock
+// skew allowance, along with the offset of their clock.  It returns false if
+// the range doesn't end in the future or if a warning was already due less
+// than skewWarningInterval ago.
+func (sc *serverClock) futureTimeRange(to uint64, skew time.Duration,
+	now time.Time) (time.Duration, time.Duration, bool) {
+	if to == hrpc.MaxTimestamp {
+		return 0, 0, false
+	}
+	sc.m.Lock()
+	defer sc.m.Unlock()
+	if !sc.known {
+		return 0, 0, false
+	}
+	ahead := hrpc.MillisToTime(to).Add(skew).Sub(now.Add(sc.offset))
+	if ahead <= 0 || now.Sub(sc.lastWarning) < skewWarningInterval {
+		return 0, 0, false
+	}
+	sc.lastWarning = now
+	return ahead, sc.offset, true
+}
+
+// checkTimeRange logs a warning, at most once per skewWarningInterval, when
+// the time range of the given request ends in the future for the
+// RegionServers, since cells written after the request but timestamped before
+// the end of the range would then be missed by the same request sent later.
+func (sc *serverClock) checkTimeRange(rpc hrpc.Call, to uint64, skew time.Duration) {
+	ahead, offset, ok := sc.futureTimeRange(to, skew, time.Now())
+	if !ok {
+		return
+	}
+	log.Warningf("The time range of a %s on table %q ends %s in the future for the "+
+		"RegionServers, whose clock is %s ahead of ours: check the clocks or the ClockSkew "+
+		"option", rpc.GetName(), rpc.Table(), ahead, offset)
+}
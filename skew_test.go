@@ -0,0 +1,44 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+func TestServerClock(t *testing.T) {
+	var sc serverClock
+	now := time.Unix(1000, 0)
+	if _, _, ok := sc.futureTimeRange(hrpc.TimeToMillis(now), 0, now); ok {
+		t.Error("Expected no warning without any observed offset")
+	}
+
+	// The RegionServers are a minute behind us.
+	ts := hrpc.TimeToMillis(now.Add(-time.Minute))
+	sc.observe(&hrpc.Result{Cells: []*hrpc.Cell{{Timestamp: proto.Uint64(ts)}}}, now)
+	if _, _, ok := sc.futureTimeRange(hrpc.MaxTimestamp, 0, now); ok {
+		t.Error("Expected no warning for a time range without an end")
+	}
+	if _, _, ok := sc.futureTimeRange(ts-1000, 0, now); ok {
+		t.Error("Expected no warning for a time range ending in the past")
+	}
+	ahead, offset, ok := sc.futureTimeRange(hrpc.TimeToMillis(now), 10*time.Second, now)
+	if !ok || ahead != 70*time.Second || offset != -time.Minute {
+		t.Errorf("Expected a warning for a time range ending 70s ahead of a clock 1m behind,"+
+			" got ok=%v ahead=%s offset=%s", ok, ahead, offset)
+	}
+	if _, _, ok := sc.futureTimeRange(hrpc.TimeToMillis(now), 0, now.Add(time.Second)); ok {
+		t.Error("Expected the warnings to be rate limited")
+	}
+	later := now.Add(skewWarningInterval)
+	if _, _, ok := sc.futureTimeRange(hrpc.TimeToMillis(later), 0, later); !ok {
+		t.Error("Expected a warning again after skewWarningInterval")
+	}
+}
@@ -59,6 +59,30 @@ func TestCreateTable(t *testing.T) {
 	}
 }
 
+func TestCreateTablePreSplit(t *testing.T) {
+	testTableName := "test1_" + getTimestampString()
+	t.Log("testTableName=" + testTableName)
+
+	ac := gohbase.NewAdminClient(*host)
+	crt, err := hrpc.NewCreateTable(context.Background(), []byte(testTableName),
+		[]string{"cf"}, hrpc.SplitKeys(hrpc.HexStringSplit(4)))
+	if err != nil {
+		t.Fatalf("NewCreateTable returned an error: %s", err)
+	}
+	if err := ac.CreateTable(crt); err != nil {
+		t.Fatalf("CreateTable returned an error: %v", err)
+	}
+
+	c := gohbase.NewClient(*host)
+	n, err := c.TableRegionCount(context.Background(), []byte(testTableName))
+	if err != nil {
+		t.Fatalf("TableRegionCount returned an error: %v", err)
+	}
+	if n != 4 {
+		t.Errorf("Expected 4 regions, got %d", n)
+	}
+}
+
 func TestDisableDeleteTable(t *testing.T) {
 	testTableName := "test1_" + getTimestampString()
 	t.Log("testTableName=" + testTableName)
@@ -106,6 +130,599 @@ func TestDisableDeleteTable(t *testing.T) {
 	}
 }
 
+func TestTruncateTable(t *testing.T) {
+	testTableName := "test1_" + getTimestampString()
+	t.Log("testTableName=" + testTableName)
+	ac := gohbase.NewAdminClient(*host)
+
+	crt, err := hrpc.NewCreateTable(context.Background(), []byte(testTableName),
+		[]string{"cf"}, hrpc.SplitKeys([][]byte{[]byte("m")}))
+	if err != nil {
+		t.Fatalf("NewCreateTable returned an error: %s", err)
+	}
+	if err := ac.CreateTable(crt); err != nil {
+		t.Fatalf("CreateTable returned an error: %v", err)
+	}
+
+	c := gohbase.NewClient(*host)
+	put, err := hrpc.NewPutStr(context.Background(), testTableName, "row",
+		map[string]map[string][]byte{"cf": {"a": []byte("1")}})
+	if err != nil {
+		t.Fatalf("NewPutStr returned an error: %v", err)
+	}
+	if _, err := c.Put(put); err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+
+	dit := hrpc.NewDisableTable(context.Background(), []byte(testTableName))
+	if err := ac.DisableTable(dit); err != nil {
+		t.Fatalf("DisableTable returned an error: %v", err)
+	}
+	trt, err := hrpc.NewTruncateTable(context.Background(), []byte(testTableName),
+		hrpc.PreserveSplits())
+	if err != nil {
+		t.Fatalf("NewTruncateTable returned an error: %v", err)
+	}
+	if err := ac.TruncateTable(trt); err != nil {
+		t.Fatalf("TruncateTable returned an error: %v", err)
+	}
+
+	// A truncated table is enabled again.
+	c = gohbase.NewClient(*host)
+	get, err := hrpc.NewGetStr(context.Background(), testTableName, "row")
+	if err != nil {
+		t.Fatalf("NewGetStr returned an error: %v", err)
+	}
+	rsp, err := c.Get(get)
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	if len(rsp.Cells) != 0 {
+		t.Errorf("Expected no cells after the truncation, got %d", len(rsp.Cells))
+	}
+	n, err := c.TableRegionCount(context.Background(), []byte(testTableName))
+	if err != nil {
+		t.Fatalf("TableRegionCount returned an error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected the 2 regions to be preserved, got %d", n)
+	}
+}
+
+func TestListTables(t *testing.T) {
+	testTableName := "test1_" + getTimestampString()
+	t.Log("testTableName=" + testTableName)
+	ac := gohbase.NewAdminClient(*host)
+
+	crt, err := hrpc.NewCreateTable(context.Background(), []byte(testTableName),
+		[]string{"cf"}, hrpc.FamilyAttributes("cf", map[string]string{"VERSIONS": "1"}))
+	if err != nil {
+		t.Fatalf("NewCreateTable returned an error: %s", err)
+	}
+	if err := ac.CreateTable(crt); err != nil {
+		t.Fatalf("CreateTable returned an error: %v", err)
+	}
+
+	lt, err := hrpc.NewListTableNames(context.Background(),
+		hrpc.TableRegex(testTableName))
+	if err != nil {
+		t.Fatalf("NewListTableNames returned an error: %v", err)
+	}
+	names, err := ac.ListTableNames(lt)
+	if err != nil {
+		t.Fatalf("ListTableNames returned an error: %v", err)
+	}
+	if len(names) != 1 || string(names[0]) != testTableName {
+		t.Errorf("Expected to list only %s, got %q", testTableName, names)
+	}
+
+	gt, err := hrpc.NewGetTableDescriptors(context.Background(),
+		[][]byte{[]byte(testTableName)})
+	if err != nil {
+		t.Fatalf("NewGetTableDescriptors returned an error: %v", err)
+	}
+	descriptors, err := ac.GetTableDescriptors(gt)
+	if err != nil {
+		t.Fatalf("GetTableDescriptors returned an error: %v", err)
+	}
+	if len(descriptors) != 1 {
+		t.Fatalf("Expected 1 table descriptor, got %d", len(descriptors))
+	}
+	if versions := descriptors[0].Families["cf"]["VERSIONS"]; versions != "1" {
+		t.Errorf("Expected 1 version in cf, got %q", versions)
+	}
+}
+
+func TestAlterColumnFamilies(t *testing.T) {
+	testTableName := "test1_" + getTimestampString()
+	t.Log("testTableName=" + testTableName)
+	ac := gohbase.NewAdminClient(*host)
+
+	crt, err := hrpc.NewCreateTable(context.Background(), []byte(testTableName),
+		[]string{"cf"})
+	if err != nil {
+		t.Fatalf("NewCreateTable returned an error: %s", err)
+	}
+	if err := ac.CreateTable(crt); err != nil {
+		t.Fatalf("CreateTable returned an error: %v", err)
+	}
+
+	acf, err := hrpc.NewAddColumnFamily(context.Background(), []byte(testTableName),
+		"cf2", nil, hrpc.Versions(5))
+	if err != nil {
+		t.Fatalf("NewAddColumnFamily returned an error: %v", err)
+	}
+	if err := ac.AddColumnFamily(acf); err != nil {
+		t.Fatalf("AddColumnFamily returned an error: %v", err)
+	}
+
+	descriptor := func() *hrpc.TableDescriptor {
+		gt, err := hrpc.NewGetTableDescriptors(context.Background(),
+			[][]byte{[]byte(testTableName)})
+		if err != nil {
+			t.Fatalf("NewGetTableDescriptors returned an error: %v", err)
+		}
+		descriptors, err := ac.GetTableDescriptors(gt)
+		if err != nil || len(descriptors) != 1 {
+			t.Fatalf("Expected 1 table descriptor, got %d (%v)", len(descriptors), err)
+		}
+		return descriptors[0]
+	}
+	td := descriptor()
+	if versions := td.Families["cf2"]["VERSIONS"]; versions != "5" {
+		t.Errorf("Expected 5 versions in cf2, got %q", versions)
+	}
+
+	mcf, err := hrpc.NewModifyColumnFamily(context.Background(), []byte(testTableName),
+		"cf2", td.Families["cf2"], hrpc.TimeToLive(3600))
+	if err != nil {
+		t.Fatalf("NewModifyColumnFamily returned an error: %v", err)
+	}
+	if err := ac.ModifyColumnFamily(mcf); err != nil {
+		t.Fatalf("ModifyColumnFamily returned an error: %v", err)
+	}
+	td = descriptor()
+	if ttl, versions := td.Families["cf2"]["TTL"], td.Families["cf2"]["VERSIONS"]; ttl !=
+		"3600" || versions != "5" {
+		t.Errorf("Expected a TTL of 3600 and 5 versions in cf2, got %q and %q", ttl, versions)
+	}
+
+	dcf, err := hrpc.NewDeleteColumnFamily(context.Background(), []byte(testTableName), "cf")
+	if err != nil {
+		t.Fatalf("NewDeleteColumnFamily returned an error: %v", err)
+	}
+	if err := ac.DeleteColumnFamily(dcf); err != nil {
+		t.Fatalf("DeleteColumnFamily returned an error: %v", err)
+	}
+
+	td = descriptor()
+	td.Attributes["MAX_FILESIZE"] = "10737418240"
+	if err := ac.ModifyTable(hrpc.NewModifyTable(context.Background(), td)); err != nil {
+		t.Fatalf("ModifyTable returned an error: %v", err)
+	}
+	td = descriptor()
+	if _, ok := td.Families["cf"]; ok || len(td.Families) != 1 {
+		t.Errorf("Expected only cf2 to be left, got %v", td.Families)
+	}
+	if size := td.Attributes["MAX_FILESIZE"]; size != "10737418240" {
+		t.Errorf("Expected a MAX_FILESIZE of 10737418240, got %q", size)
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	namespace := "ns_" + getTimestampString()
+	testTableName := namespace + ":test1"
+	t.Log("testTableName=" + testTableName)
+	ac := gohbase.NewAdminClient(*host)
+
+	nd := &hrpc.NamespaceDescriptor{Name: namespace}
+	if err := ac.CreateNamespace(hrpc.NewCreateNamespace(context.Background(), nd)); err != nil {
+		t.Fatalf("CreateNamespace returned an error: %v", err)
+	}
+	nd.Configuration = map[string]string{"hbase.namespace.quota.maxtables": "10"}
+	if err := ac.ModifyNamespace(hrpc.NewModifyNamespace(context.Background(), nd)); err != nil {
+		t.Fatalf("ModifyNamespace returned an error: %v", err)
+	}
+	descriptors, err := ac.ListNamespaceDescriptors(
+		hrpc.NewListNamespaceDescriptors(context.Background()))
+	if err != nil {
+		t.Fatalf("ListNamespaceDescriptors returned an error: %v", err)
+	}
+	var found bool
+	for _, descriptor := range descriptors {
+		if descriptor.Name == namespace {
+			found = descriptor.Configuration["hbase.namespace.quota.maxtables"] == "10"
+		}
+	}
+	if !found {
+		t.Errorf("Expected namespace %s to be listed with its quota, got %+v",
+			namespace, descriptors)
+	}
+
+	crt, err := hrpc.NewCreateTable(context.Background(), []byte(testTableName),
+		[]string{"cf"})
+	if err != nil {
+		t.Fatalf("NewCreateTable returned an error: %s", err)
+	}
+	if err := ac.CreateTable(crt); err != nil {
+		t.Fatalf("CreateTable returned an error: %v", err)
+	}
+	names, err := ac.ListTableNamesByNamespace(
+		hrpc.NewListTableNamesByNamespace(context.Background(), namespace))
+	if err != nil {
+		t.Fatalf("ListTableNamesByNamespace returned an error: %v", err)
+	}
+	if len(names) != 1 || string(names[0]) != testTableName {
+		t.Errorf("Expected to list only %s, got %q", testTableName, names)
+	}
+
+	// Regions of tables of other namespaces are looked up in meta like the
+	// others.
+	c := gohbase.NewClient(*host)
+	put, err := hrpc.NewPutStr(context.Background(), testTableName, "row",
+		map[string]map[string][]byte{"cf": {"a": []byte("1")}})
+	if err != nil {
+		t.Fatalf("NewPutStr returned an error: %v", err)
+	}
+	if _, err := c.Put(put); err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+	get, err := hrpc.NewGetStr(context.Background(), testTableName, "row")
+	if err != nil {
+		t.Fatalf("NewGetStr returned an error: %v", err)
+	}
+	rsp, err := c.Get(get)
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	if len(rsp.Cells) != 1 || string(rsp.Cells[0].Value) != "1" {
+		t.Errorf("Expected to get the cell put, got %v", rsp.Cells)
+	}
+
+	dit := hrpc.NewDisableTable(context.Background(), []byte(testTableName))
+	if err := ac.DisableTable(dit); err != nil {
+		t.Fatalf("DisableTable returned an error: %v", err)
+	}
+	det := hrpc.NewDeleteTable(context.Background(), []byte(testTableName))
+	if err := ac.DeleteTable(det); err != nil {
+		t.Fatalf("DeleteTable returned an error: %v", err)
+	}
+	if err := ac.DeleteNamespace(hrpc.NewDeleteNamespace(context.Background(),
+		namespace)); err != nil {
+		t.Errorf("DeleteNamespace returned an error: %v", err)
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	testTableName := "test1_" + getTimestampString()
+	snapshotName := testTableName + "_snapshot"
+	t.Log("testTableName=" + testTableName)
+	ac := gohbase.NewAdminClient(*host)
+
+	crt, err := hrpc.NewCreateTable(context.Background(), []byte(testTableName),
+		[]string{"cf"})
+	if err != nil {
+		t.Fatalf("NewCreateTable returned an error: %s", err)
+	}
+	if err := ac.CreateTable(crt); err != nil {
+		t.Fatalf("CreateTable returned an error: %v", err)
+	}
+
+	sn, err := hrpc.NewSnapshot(context.Background(), snapshotName, []byte(testTableName))
+	if err != nil {
+		t.Fatalf("NewSnapshot returned an error: %v", err)
+	}
+	if err := ac.Snapshot(sn); err != nil {
+		t.Fatalf("Snapshot returned an error: %v", err)
+	}
+	done, err := ac.IsSnapshotDone(hrpc.NewIsSnapshotDone(context.Background(),
+		snapshotName, []byte(testTableName)))
+	if err != nil || !done {
+		t.Errorf("Expected the snapshot to be done, got %v (%v)", done, err)
+	}
+
+	listed := func() bool {
+		snapshots, err := ac.ListSnapshots(hrpc.NewListSnapshots(context.Background()))
+		if err != nil {
+			t.Fatalf("ListSnapshots returned an error: %v", err)
+		}
+		for _, snapshot := range snapshots {
+			if snapshot.Name == snapshotName {
+				return string(snapshot.Table) == testTableName
+			}
+		}
+		return false
+	}
+	if !listed() {
+		t.Errorf("Expected snapshot %s of table %s to be listed", snapshotName, testTableName)
+	}
+
+	cloneTableName := testTableName + "_clone"
+	cls := hrpc.NewCloneSnapshot(context.Background(), snapshotName, []byte(cloneTableName))
+	if err := ac.CloneSnapshot(cls); err != nil {
+		t.Fatalf("CloneSnapshot returned an error: %v", err)
+	}
+	gt, err := hrpc.NewGetTableDescriptors(context.Background(),
+		[][]byte{[]byte(cloneTableName)})
+	if err != nil {
+		t.Fatalf("NewGetTableDescriptors returned an error: %v", err)
+	}
+	if descriptors, err := ac.GetTableDescriptors(gt); err != nil || len(descriptors) != 1 {
+		t.Errorf("Expected the clone %s to exist, got %d tables (%v)",
+			cloneTableName, len(descriptors), err)
+	}
+
+	dit := hrpc.NewDisableTable(context.Background(), []byte(testTableName))
+	if err := ac.DisableTable(dit); err != nil {
+		t.Fatalf("DisableTable returned an error: %v", err)
+	}
+	rs := hrpc.NewRestoreSnapshot(context.Background(), snapshotName, []byte(testTableName))
+	if err := ac.RestoreSnapshot(rs); err != nil {
+		t.Fatalf("RestoreSnapshot returned an error: %v", err)
+	}
+
+	err = ac.DeleteSnapshot(hrpc.NewDeleteSnapshot(context.Background(), snapshotName))
+	if err != nil {
+		t.Fatalf("DeleteSnapshot returned an error: %v", err)
+	}
+	if listed() {
+		t.Errorf("Expected snapshot %s to be deleted", snapshotName)
+	}
+}
+
+func TestSplitMergeRegions(t *testing.T) {
+	testTableName := "test1_" + getTimestampString()
+	t.Log("testTableName=" + testTableName)
+	table := []byte(testTableName)
+
+	ac := gohbase.NewAdminClient(*host)
+	crt, err := hrpc.NewCreateTable(context.Background(), table, []string{"cf"})
+	if err != nil {
+		t.Fatalf("NewCreateTable returned an error: %s", err)
+	}
+	if err := ac.CreateTable(crt); err != nil {
+		t.Fatalf("CreateTable returned an error: %v", err)
+	}
+
+	c := gohbase.NewClient(*host)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	sr, err := hrpc.NewSplitRegion(ctx, table, nil, hrpc.SplitPoint([]byte("m")))
+	if err != nil {
+		t.Fatalf("NewSplitRegion returned an error: %s", err)
+	}
+	if err := c.SplitRegion(sr); err != nil {
+		t.Fatalf("SplitRegion returned an error: %v", err)
+	}
+	waitForRegionCount(t, ctx, c, table, 2)
+
+	// Since the regions moved, use a new client to find them.
+	c = gohbase.NewClient(*host)
+	parts, err := c.PartitionKeys(ctx, table, [][]byte{[]byte("a"), []byte("z")})
+	if err != nil {
+		t.Fatalf("PartitionKeys returned an error: %v", err)
+	}
+	var regions []string
+	for _, part := range parts {
+		for _, reg := range part.Regions {
+			regions = append(regions, reg.Region.GetEncodedName())
+		}
+	}
+	if len(regions) != 2 {
+		t.Fatalf("Expected 2 regions, got %v", regions)
+	}
+	mr, err := hrpc.NewMergeTableRegions(ctx, regions[0], regions[1])
+	if err != nil {
+		t.Fatalf("NewMergeTableRegions returned an error: %s", err)
+	}
+	if err := ac.MergeTableRegions(mr); err != nil {
+		t.Fatalf("MergeTableRegions returned an error: %v", err)
+	}
+	waitForRegionCount(t, ctx, c, table, 1)
+}
+
+// waitForRegionCount waits until the given table has the given number of
+// regions in meta.
+func waitForRegionCount(t *testing.T, ctx context.Context, c gohbase.Client,
+	table []byte, expected int) {
+	for {
+		n, err := c.TableRegionCount(ctx, table)
+		if err != nil {
+			t.Fatalf("TableRegionCount returned an error: %v", err)
+		}
+		if n == expected {
+			return
+		}
+		select {
+		case <-time.After(500 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatalf("Expected %d regions, still got %d", expected, n)
+		}
+	}
+}
+
+func TestAssignRegions(t *testing.T) {
+	testTableName := "test1_" + getTimestampString()
+	t.Log("testTableName=" + testTableName)
+	table := []byte(testTableName)
+
+	ac := gohbase.NewAdminClient(*host)
+	crt, err := hrpc.NewCreateTable(context.Background(), table, []string{"cf"})
+	if err != nil {
+		t.Fatalf("NewCreateTable returned an error: %s", err)
+	}
+	if err := ac.CreateTable(crt); err != nil {
+		t.Fatalf("CreateTable returned an error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	c := gohbase.NewClient(*host)
+	parts, err := c.PartitionKeys(ctx, table, [][]byte{[]byte("a")})
+	if err != nil {
+		t.Fatalf("PartitionKeys returned an error: %v", err)
+	}
+	reg := parts[0].Regions[0].Region
+
+	ur, err := hrpc.NewUnassignRegion(ctx, reg.GetName())
+	if err != nil {
+		t.Fatalf("NewUnassignRegion returned an error: %s", err)
+	}
+	if err := ac.UnassignRegion(ur); err != nil {
+		t.Fatalf("UnassignRegion returned an error: %v", err)
+	}
+	ar, err := hrpc.NewAssignRegion(ctx, reg.GetName())
+	if err != nil {
+		t.Fatalf("NewAssignRegion returned an error: %s", err)
+	}
+	if err := ac.AssignRegion(ar); err != nil {
+		t.Fatalf("AssignRegion returned an error: %v", err)
+	}
+	mr, err := hrpc.NewMoveRegion(ctx, reg.GetEncodedName())
+	if err != nil {
+		t.Fatalf("NewMoveRegion returned an error: %s", err)
+	}
+	if err := ac.MoveRegion(mr); err != nil {
+		t.Fatalf("MoveRegion returned an error: %v", err)
+	}
+
+	// The client finds where the region went.
+	get, err := hrpc.NewGetStr(ctx, testTableName, "a")
+	if err != nil {
+		t.Fatalf("NewGetStr returned an error: %s", err)
+	}
+	if _, err := c.Get(get); err != nil {
+		t.Errorf("Get returned an error after the region moved: %v", err)
+	}
+}
+
+func TestBalancer(t *testing.T) {
+	ac := gohbase.NewAdminClient(*host)
+	if _, err := ac.SetBalancerRunning(
+		hrpc.NewSetBalancerRunning(context.Background(), false, true)); err != nil {
+		t.Fatalf("SetBalancerRunning returned an error: %v", err)
+	}
+	enabled, err := ac.IsBalancerEnabled(hrpc.NewIsBalancerEnabled(context.Background()))
+	if err != nil {
+		t.Fatalf("IsBalancerEnabled returned an error: %v", err)
+	}
+	if enabled {
+		t.Error("Expected the balancer to be off")
+	}
+	ran, err := ac.Balance(hrpc.NewBalance(context.Background()))
+	if err != nil {
+		t.Fatalf("Balance returned an error: %v", err)
+	}
+	if ran {
+		t.Error("Expected the balancer not to run while it's off")
+	}
+
+	prev, err := ac.SetBalancerRunning(
+		hrpc.NewSetBalancerRunning(context.Background(), true, true))
+	if err != nil {
+		t.Fatalf("SetBalancerRunning returned an error: %v", err)
+	}
+	if prev {
+		t.Error("Expected the balancer to have been off")
+	}
+	if _, err := ac.Balance(hrpc.NewBalance(context.Background())); err != nil {
+		t.Fatalf("Balance returned an error: %v", err)
+	}
+}
+
+func TestCatalogJanitorAndNormalizer(t *testing.T) {
+	ac := gohbase.NewAdminClient(*host)
+	ctx := context.Background()
+	if _, err := ac.EnableCatalogJanitor(hrpc.NewEnableCatalogJanitor(ctx, false)); err != nil {
+		t.Fatalf("EnableCatalogJanitor returned an error: %v", err)
+	}
+	enabled, err := ac.IsCatalogJanitorEnabled(hrpc.NewIsCatalogJanitorEnabled(ctx))
+	if err != nil {
+		t.Fatalf("IsCatalogJanitorEnabled returned an error: %v", err)
+	}
+	if enabled {
+		t.Error("Expected the catalog janitor to be off")
+	}
+	prev, err := ac.EnableCatalogJanitor(hrpc.NewEnableCatalogJanitor(ctx, true))
+	if err != nil {
+		t.Fatalf("EnableCatalogJanitor returned an error: %v", err)
+	}
+	if prev {
+		t.Error("Expected the catalog janitor to have been off")
+	}
+	if _, err := ac.RunCatalogScan(hrpc.NewRunCatalogScan(ctx)); err != nil {
+		t.Fatalf("RunCatalogScan returned an error: %v", err)
+	}
+
+	if _, err := ac.SetNormalizerRunning(hrpc.NewSetNormalizerRunning(ctx, true)); err != nil {
+		t.Fatalf("SetNormalizerRunning returned an error: %v", err)
+	}
+	enabled, err = ac.IsNormalizerEnabled(hrpc.NewIsNormalizerEnabled(ctx))
+	if err != nil {
+		t.Fatalf("IsNormalizerEnabled returned an error: %v", err)
+	}
+	if !enabled {
+		t.Error("Expected the normalizer to be on")
+	}
+	if _, err := ac.Normalize(hrpc.NewNormalize(ctx)); err != nil {
+		t.Fatalf("Normalize returned an error: %v", err)
+	}
+}
+
+func TestListRegionServers(t *testing.T) {
+	ac := gohbase.NewAdminClient(*host)
+	servers, err := ac.ListRegionServers(hrpc.NewListRegionServers(context.Background()))
+	if err != nil {
+		t.Fatalf("ListRegionServers returned an error: %v", err)
+	}
+	if len(servers) == 0 {
+		t.Fatal("Expected at least one RegionServer")
+	}
+	regions := 0
+	for _, rs := range servers {
+		if rs.Host == "" || rs.Port == 0 {
+			t.Errorf("Expected the address of the RegionServer, got %+v", rs)
+		}
+		regions += rs.Regions
+	}
+	if regions == 0 {
+		t.Error("Expected the RegionServers to serve at least the meta region")
+	}
+}
+
+func TestDecommissionRegionServers(t *testing.T) {
+	ac := gohbase.NewAdminClient(*host)
+	ctx := context.Background()
+	servers, err := ac.ListRegionServers(hrpc.NewListRegionServers(ctx))
+	if err != nil {
+		t.Fatalf("ListRegionServers returned an error: %v", err)
+	}
+	server := servers[0].ServerName
+	// Don't offload the regions, since there may be no other RegionServer.
+	err = ac.DecommissionRegionServers(ctx, []hrpc.ServerName{server}, false)
+	if err != nil {
+		t.Fatalf("DecommissionRegionServers returned an error: %v", err)
+	}
+	decommissioned, err := ac.ListDecommissionedRegionServers(ctx)
+	if err != nil {
+		t.Fatalf("ListDecommissionedRegionServers returned an error: %v", err)
+	}
+	if len(decommissioned) != 1 || decommissioned[0] != server {
+		t.Errorf("Expected %v to be decommissioned, got %v", server, decommissioned)
+	}
+
+	if err := ac.RecommissionRegionServer(ctx, server); err != nil {
+		t.Fatalf("RecommissionRegionServer returned an error: %v", err)
+	}
+	decommissioned, err = ac.ListDecommissionedRegionServers(ctx)
+	if err != nil {
+		t.Fatalf("ListDecommissionedRegionServers returned an error: %v", err)
+	}
+	if len(decommissioned) != 0 {
+		t.Errorf("Expected no decommissioned RegionServer, got %v", decommissioned)
+	}
+}
+
 func TestEnableTable(t *testing.T) {
 	testTableName := "test1_" + getTimestampString()
 	t.Log("testTableName=" + testTableName)
@@ -0,0 +1,202 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+// scanMetaForTable scans the rows of meta of the regions of the given table,
+// in order, fetching the given columns of the info family.
+func (c *client) scanMetaForTable(ctx context.Context, table []byte,
+	columns ...string) ([]*hrpc.Result, error) {
+	// ',' is the separator between the table and the start key in the meta
+	// row keys, and '-' the byte right after it.
+	start := append(append([]byte(nil), table...), ',')
+	stop := append(append([]byte(nil), table...), '-')
+	scan, err := hrpc.NewScanRange(ctx, metaTableName, start, stop,
+		hrpc.Families(map[string][]string{"info": columns}))
+	if err != nil {
+		return nil, err
+	}
+	return c.Scan(scan)
+}
+
+// tableRegions scans meta for the online regions of the given table, in
+// order.  Only the info:regioninfo column is fetched, which is enough to tell
+// apart the split parents that linger in meta until they're cleaned up.
+func (c *client) tableRegions(ctx context.Context, table []byte) ([]*region.Info, error) {
+	results, err := c.scanMetaForTable(ctx, table, "regioninfo")
+	if err != nil {
+		return nil, err
+	}
+	var regions []*region.Info
+	for _, res := range results {
+		for _, cell := range res.Cells {
+			reg, err := region.InfoFromCell((*pb.Cell)(cell))
+			if err != nil {
+				return nil, err
+			}
+			if info := reg.GetPB(); info.GetOffline() || info.GetSplit() {
+				continue
+			}
+			regions = append(regions, reg)
+		}
+	}
+	if len(regions) == 0 {
+		return nil, TableNotFound
+	}
+	return regions, nil
+}
+
+// TableRegionCount returns the number of regions of the given table, as
+// found in meta.
+func (c *client) TableRegionCount(ctx context.Context, table []byte) (int, error) {
+	regions, err := c.tableRegions(ctx, table)
+	if err != nil {
+		return 0, err
+	}
+	return len(regions), nil
+}
+
+// TableSplitKeys returns the split keys of the given table, i.e. the start
+// keys of all its regions but the first one, in order.
+func (c *client) TableSplitKeys(ctx context.Context, table []byte) ([][]byte, error) {
+	regions, err := c.tableRegions(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([][]byte, 0, len(regions)-1)
+	for _, reg := range regions[1:] {
+		keys = append(keys, reg.StartKey)
+	}
+	return keys, nil
+}
+
+// TableRegion describes a region of a table and where it's served, as found in
+// meta or in the region cache of the client.
+type TableRegion struct {
+	// Name is the full name of the region, like returned by
+	// hrpc.RegionInfo.GetName.
+	Name []byte
+
+	StartKey []byte
+	StopKey  []byte
+
+	// ID is the ID of the region, the time at which it was created in
+	// milliseconds since the epoch.
+	ID uint64
+
+	// Host and Port of the RegionServer serving the region.  Host is empty
+	// while the region isn't assigned, e.g. while it's being moved.
+	Host string
+	Port uint16
+}
+
+// GetTableRegions returns the online regions of the given table, in order,
+// with the RegionServers serving them, as found in meta.  Since regions can
+// split and move, this is a snapshot suitable for planning work, like the
+// splits of a parallel job, but callers mustn't rely on it staying accurate.
+func (c *client) GetTableRegions(ctx context.Context, table []byte) ([]*TableRegion, error) {
+	results, err := c.scanMetaForTable(ctx, table, "regioninfo", "server")
+	if err != nil {
+		return nil, err
+	}
+	var regions []*TableRegion
+	for _, res := range results {
+		reg, host, port, err := parseMetaResult(res)
+		if err != nil {
+			return nil, err
+		} else if reg == nil {
+			continue
+		}
+		regions = append(regions, &TableRegion{
+			Name:     reg.Name,
+			StartKey: reg.StartKey,
+			StopKey:  reg.StopKey,
+			ID:       reg.ID,
+			Host:     host,
+			Port:     port,
+		})
+	}
+	if len(regions) == 0 {
+		return nil, TableNotFound
+	}
+	return regions, nil
+}
+
+// parseMetaResult parses the info:regioninfo and info:server columns of the
+// given row of meta.  The region is nil if the row isn't that of an online
+// region, and the host is empty if the region isn't assigned.
+func parseMetaResult(res *hrpc.Result) (*region.Info, string, uint16, error) {
+	var reg *region.Info
+	var host string
+	var port uint16
+	var err error
+	for _, cell := range res.Cells {
+		switch string(cell.Qualifier) {
+		case "regioninfo":
+			reg, err = region.InfoFromCell((*pb.Cell)(cell))
+		case "server":
+			host, port, err = region.ServerFromCell((*pb.Cell)(cell))
+		}
+		if err != nil {
+			return nil, "", 0, err
+		}
+	}
+	if reg != nil {
+		if info := reg.GetPB(); info.GetOffline() || info.GetSplit() {
+			reg = nil
+		}
+	}
+	return reg, host, port, nil
+}
+
+// WarmRegions looks up all the regions of the given table in meta, with a
+// single scan, and adds those that aren't cached yet to the region cache of
+// the client, then waits for the client to be connected to their
+// RegionServers.  This saves a lookup of meta to the first RPC sent to every
+// region, e.g. at the start of a batch job.  The regions that aren't assigned
+// are skipped, and looked up once they're needed.
+func (c *client) WarmRegions(ctx context.Context, table []byte) error {
+	results, err := c.scanMetaForTable(ctx, table, "regioninfo", "server")
+	if err != nil {
+		return err
+	}
+	var regions []hrpc.RegionInfo
+	for _, res := range results {
+		reg, host, port, err := parseMetaResult(res)
+		if err != nil {
+			return err
+		} else if reg == nil || host == "" {
+			continue
+		}
+		c.regionsLock.Lock()
+		cached := c.getRegionFromCache(table, reg.GetStartKey())
+		if cached == nil {
+			cached = reg
+			c.cacheRegion(reg, host, port)
+		}
+		c.regionsLock.Unlock()
+		regions = append(regions, cached)
+	}
+	if len(regions) == 0 {
+		return TableNotFound
+	}
+	for _, reg := range regions {
+		if ch := reg.GetAvailabilityChan(); ch != nil {
+			select {
+			case <-ch:
+			case <-ctx.Done():
+				return ErrDeadline
+			}
+		}
+	}
+	return nil
+}
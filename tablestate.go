@@ -0,0 +1,152 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"github.com/tsuna/gohbase/zk"
+	"golang.org/x/net/context"
+)
+
+// tableStateFamily is the column of meta that holds the states of the tables
+// in HBase 2.
+var tableStateFamily = map[string][]string{
+	"table": []string{"state"},
+}
+
+// waitForTableState polls the state of the given table, in ZooKeeper or in
+// meta, until it is the given one or the context expires, since the master
+// can report the procedure enabling or disabling a table as finished, or not
+// report any procedure at all, before the table is in its new state.
+func (c *client) waitForTableState(ctx context.Context, table []byte,
+	state pb.Table_State) error {
+	if c.registry != nil {
+		// The clusters that have a master registry keep the states of
+		// the tables in meta rather than in ZooKeeper, and only report
+		// the procedures as finished once the tables are in their new
+		// state.
+		return nil
+	}
+	return c.pollTableState(ctx, state, func() (pb.Table_State, error) {
+		return c.tableState(ctx, table)
+	})
+}
+
+// pollTableState calls getState, backing off between the calls according to
+// the retry policy, until it returns the given state, an error, or the context
+// expires.
+func (c *client) pollTableState(ctx context.Context, state pb.Table_State,
+	getState func() (pb.Table_State, error)) error {
+	backoff := c.backoffStart()
+	for {
+		current, err := getState()
+		if err != nil {
+			return err
+		} else if current == state {
+			return nil
+		}
+		backoff, err = c.sleepAndIncreaseBackoff(ctx, backoff)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// tableState looks up the state of the given table in ZooKeeper, or in meta
+// if the table has no znode, as in HBase 2.
+func (c *client) tableState(ctx context.Context, table []byte) (pb.Table_State, error) {
+	state, err := c.zkTableState(ctx, table)
+	if err == zk.ErrNoTableState {
+		return c.metaTableState(ctx, table)
+	}
+	return state, err
+}
+
+// zkTableState asynchronously looks up the state of the given table in
+// ZooKeeper.
+func (c *client) zkTableState(ctx context.Context, table []byte) (pb.Table_State, error) {
+	type result struct {
+		state pb.Table_State
+		err   error
+	}
+	// Buffered so that the lookup doesn't block forever if we time out.
+	reschan := make(chan result, 1)
+	go func() {
+		state, err := c.zkSession.GetTableState(string(table))
+		reschan <- result{state, err}
+	}()
+	select {
+	case res := <-reschan:
+		return res.state, res.err
+	case <-ctx.Done():
+		return 0, ErrDeadline
+	}
+}
+
+// metaTableState reads the state of the given table from its row in meta.
+// The admin client isn't connected to meta, so it connects to the
+// RegionServer that zkLocateMeta finds.
+func (c *client) metaTableState(ctx context.Context, table []byte) (pb.Table_State, error) {
+	host, port, err := c.zkLocateMeta(ctx)
+	if err != nil {
+		return 0, err
+	}
+	client, err := c.regionClientFor(ctx, host, port)
+	if err != nil {
+		return 0, err
+	}
+	get, err := hrpc.NewGet(ctx, metaTableName, table, hrpc.Families(tableStateFamily))
+	if err != nil {
+		return 0, err
+	}
+	reg := newMetaRegionInfo()
+	reg.SetClient(client)
+	msg, err := c.sendRPCDirect(ctx, get, reg)
+	if err != nil {
+		return 0, err
+	}
+	resp, ok := msg.(*pb.GetResponse)
+	if !ok {
+		return 0, fmt.Errorf("sendRPC returned not a GetResponse")
+	}
+	return parseMetaTableState(table, resp)
+}
+
+// parseMetaTableState returns the state of the given table from its row in
+// meta.
+func parseMetaTableState(table []byte, resp *pb.GetResponse) (pb.Table_State, error) {
+	cells := resp.GetResult().GetCell()
+	if len(cells) == 0 {
+		return 0, fmt.Errorf("Table %q has no state in ZooKeeper nor in meta", table)
+	}
+	// The TableState message of HBase 2 is compatible with the Table one.
+	state := &pb.Table{}
+	if err := proto.Unmarshal(cells[0].GetValue(), state); err != nil {
+		return 0, fmt.Errorf("Failed to deserialize the state of table %q from meta: %s",
+			table, err)
+	}
+	return state.GetState(), nil
+}
+
+// zkLocateMeta asynchronously looks up the location of meta in ZooKeeper,
+// without watching it like zkLookup.
+func (c *client) zkLocateMeta(ctx context.Context) (string, uint16, error) {
+	reschan := make(chan zkResult, 1)
+	go func() {
+		host, port, err := c.zkSession.LocateResource(zk.Meta)
+		reschan <- zkResult{host, port, err}
+	}()
+	select {
+	case res := <-reschan:
+		return res.host, res.port, res.err
+	case <-ctx.Done():
+		return "", 0, ErrDeadline
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/pb"
+	"golang.org/x/net/context"
+)
+
+func TestPollTableState(t *testing.T) {
+	states := []pb.Table_State{pb.Table_ENABLED, pb.Table_DISABLING, pb.Table_DISABLED}
+	c := newClient("~invalid.quorum~")
+	var polls int
+	err := c.pollTableState(context.Background(), pb.Table_DISABLED,
+		func() (pb.Table_State, error) {
+			state := states[polls]
+			polls++
+			return state, nil
+		})
+	if err != nil || polls != 3 {
+		t.Errorf("Expected the table to be disabled after 3 polls, got %d (%v)", polls, err)
+	}
+
+	oops := errors.New("oops")
+	err = c.pollTableState(context.Background(), pb.Table_ENABLED,
+		func() (pb.Table_State, error) {
+			return 0, oops
+		})
+	if err != oops {
+		t.Errorf("Expected the error of the lookup, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = c.pollTableState(ctx, pb.Table_ENABLED, func() (pb.Table_State, error) {
+		return pb.Table_ENABLING, nil
+	})
+	if err != ErrDeadline {
+		t.Errorf("Expected ErrDeadline, got %v", err)
+	}
+}
+
+func TestParseMetaTableState(t *testing.T) {
+	table := []byte("test")
+	value, err := proto.Marshal(&pb.Table{State: pb.Table_DISABLED.Enum()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := &pb.GetResponse{Result: &pb.Result{Cell: []*pb.Cell{{
+		Row:       table,
+		Family:    []byte("table"),
+		Qualifier: []byte("state"),
+		Value:     value,
+	}}}}
+	if state, err := parseMetaTableState(table, resp); err != nil || state != pb.Table_DISABLED {
+		t.Errorf("Expected the table to be disabled, got %s (%v)", state, err)
+	}
+
+	// A table without a state isn't presumed enabled.
+	if _, err := parseMetaTableState(table, &pb.GetResponse{Result: &pb.Result{}}); err == nil {
+		t.Error("Expected an error for a table without a state")
+	}
+
+	resp.Result.Cell[0].Value = []byte{0xff}
+	if _, err := parseMetaTableState(table, resp); err == nil {
+		t.Error("Expected an error for a corrupt state")
+	}
+}
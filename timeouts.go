@@ -0,0 +1,104 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// ErrCallTimeout is returned for the RPCs whose RegionServer didn't answer
+// within their timeout, as set by CallTimeouts or hrpc.Timeout.
+var ErrCallTimeout = errors.New("timed out waiting for the RegionServer to answer")
+
+// Timeouts are how long the RPCs wait for their RegionServer to answer,
+// depending on what they do.  0 doesn't limit the wait, only the context of
+// the operation does.
+type Timeouts struct {
+	// Read is the timeout of the Gets.
+	Read time.Duration
+
+	// Write is the timeout of the mutations and of the other RPCs sent to
+	// the regions of the tables, like the batches and coprocessor calls.
+	Write time.Duration
+
+	// Scan is the timeout of every RPC of a scan.
+	Scan time.Duration
+
+	// Meta is the timeout of the RPCs sent to meta, like the lookups of
+	// the regions.
+	Meta time.Duration
+}
+
+// CallTimeouts will return an option that sets the default timeouts of the
+// RPCs sent to the RegionServers.  Unlike MaxOperationTime, which bounds
+// an operation including its retries, a timeout bounds a single attempt, so
+// that a RegionServer that hangs fails the RPC with ErrCallTimeout instead of
+// leaving it waiting for the deadline of its context.  The RPCs that time
+// out aren't retried, and neither are the lookups in meta of the region of an
+// RPC.  The timeout is also sent to the RegionServer, which drops the RPCs
+// whose caller timed out.  The timeout of an RPC can be overridden with
+// hrpc.Timeout.
+func CallTimeouts(t Timeouts) Option {
+	return func(c *client) {
+		c.timeouts = t
+	}
+}
+
+// callTimeout returns the timeout of the given RPC sent to the given region.
+func (c *client) callTimeout(rpc hrpc.Call, reg hrpc.RegionInfo) time.Duration {
+	if t, ok := rpc.(interface {
+		GetTimeout() time.Duration
+	}); ok && t.GetTimeout() > 0 {
+		return t.GetTimeout()
+	}
+	switch {
+	case reg == c.metaRegionInfo:
+		return c.timeouts.Meta
+	case reg == c.adminRegionInfo:
+		// The calls to the master, like the creation of a table, can
+		// legitimately take long.
+		return 0
+	}
+	switch rpc.(type) {
+	case *hrpc.Get:
+		return c.timeouts.Read
+	case *hrpc.Scan:
+		return c.timeouts.Scan
+	}
+	return c.timeouts.Write
+}
+
+// callTimer returns a channel that receives once the timeout of the given RPC
+// sent to the given region expires, which is nil if the RPC has no timeout,
+// and the function to call once the RPC is answered.  The timeout is set on the
+// RPC, so that its region client sends it to the RegionServer.
+func (c *client) callTimer(rpc hrpc.Call, reg hrpc.RegionInfo) (<-chan time.Time, func()) {
+	d := c.callTimeout(rpc, reg)
+	if d <= 0 {
+		return nil, func() {}
+	}
+	if t, ok := rpc.(interface {
+		SetTimeout(time.Duration)
+	}); ok {
+		t.SetTimeout(d)
+	}
+	t := time.NewTimer(d)
+	return t.C, func() { t.Stop() }
+}
+
+// forget tells the given region client to drop the given RPC, which timed out,
+// so that it isn't sent if it's still queued and that its response is
+// discarded.
+func forget(client hrpc.RegionClient, rpc hrpc.Call) {
+	if f, ok := client.(interface {
+		Forget(hrpc.Call)
+	}); ok {
+		f.Forget(rpc)
+	}
+}
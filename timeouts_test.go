@@ -0,0 +1,105 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/region"
+	"golang.org/x/net/context"
+)
+
+func TestCallTimeouts(t *testing.T) {
+	c := newClient("~invalid.quorum~", CallTimeouts(Timeouts{
+		Read:  time.Second,
+		Write: 2 * time.Second,
+		Scan:  3 * time.Second,
+		Meta:  4 * time.Second,
+	}))
+	ctx := context.Background()
+	reg := &region.Info{Table: []byte("test"), Name: []byte("test,,1"), StopKey: []byte("z")}
+	get, _ := hrpc.NewGetStr(ctx, "test", "a")
+	scan, _ := hrpc.NewScanStr(ctx, "test")
+	put, _ := hrpc.NewPutStr(ctx, "test", "a", nil)
+	quick, _ := hrpc.NewGetStr(ctx, "test", "a", hrpc.Timeout(time.Millisecond))
+	for _, tc := range []struct {
+		rpc      hrpc.Call
+		reg      hrpc.RegionInfo
+		expected time.Duration
+	}{
+		{get, reg, time.Second},
+		{put, reg, 2 * time.Second},
+		{scan, reg, 3 * time.Second},
+		{scan, c.metaRegionInfo, 4 * time.Second},
+		{quick, reg, time.Millisecond},
+	} {
+		if d := c.callTimeout(tc.rpc, tc.reg); d != tc.expected {
+			t.Errorf("Expected a timeout of %s for %s, got %s", tc.expected, tc.rpc.GetName(), d)
+		}
+	}
+
+	// The RegionServer never answers.
+	rs := &forgettingClient{fakeRegionClient: fakeRegionClient{host: "rs", port: 16020}}
+	reg.SetClient(rs)
+	start := time.Now()
+	if _, err := c.sendRPCToRegion(ctx, quick, reg); err != ErrCallTimeout {
+		t.Errorf("Expected ErrCallTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the RPC to time out quickly, it took %s", elapsed)
+	}
+	if len(rs.forgotten) != 1 || rs.forgotten[0] != quick {
+		t.Errorf("Expected the region client to forget the RPC, got %v", rs.forgotten)
+	}
+
+	// The default timeout is sent to the RegionServer with the RPC.
+	if _, err := c.sendRPCDirect(ctx, put, reg); err != ErrCallTimeout {
+		t.Errorf("Expected ErrCallTimeout, got %v", err)
+	}
+	if d := put.GetTimeout(); d != 2*time.Second {
+		t.Errorf("Expected the RPC to have a timeout of 2s, got %s", d)
+	}
+}
+
+// forgettingClient is a region client that never answers and records the
+// RPCs it was told to forget.
+type forgettingClient struct {
+	fakeRegionClient
+	forgotten []hrpc.Call
+}
+
+func (rc *forgettingClient) Forget(rpc hrpc.Call) {
+	rc.forgotten = append(rc.forgotten, rpc)
+}
+
+func TestTimeoutOption(t *testing.T) {
+	ctx := context.Background()
+	put, _ := hrpc.NewPutStr(ctx, "test", "a", nil)
+	cas, _ := hrpc.NewCheckAndPut(put, "cf", "q", nil)
+	rm, _ := hrpc.NewRowMutations(ctx, put)
+	for _, rpc := range []hrpc.Call{cas, hrpc.NewMulti(ctx), rm} {
+		if err := hrpc.Timeout(time.Second)(rpc); err != nil {
+			t.Errorf("Expected %s to take a timeout, got %v", rpc.GetName(), err)
+		}
+		if d := rpc.(interface {
+			GetTimeout() time.Duration
+		}).GetTimeout(); d != time.Second {
+			t.Errorf("Expected %s to have a timeout of 1s, got %s", rpc.GetName(), d)
+		}
+	}
+}
+
+func TestMetaTimeoutNotRetried(t *testing.T) {
+	c := newClient("~invalid.quorum~", CallTimeouts(Timeouts{Meta: time.Millisecond}))
+	c.metaRegionInfo.SetClient(&fakeRegionClient{host: "meta", port: 16020})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := c.findRegion(ctx, []byte("test"), []byte("a")); err != ErrCallTimeout {
+		t.Errorf("Expected ErrCallTimeout, got %v", err)
+	}
+}
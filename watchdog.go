@@ -0,0 +1,91 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package gohbase
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ErrScanAborted is returned by the Next method of the scanners aborted by
+// the watchdog set with the ScanWatchdog option.
+var ErrScanAborted = errors.New("scan aborted by the watchdog")
+
+// ScanWatchdog will return an option that aborts the scanners that have been
+// running for longer than maxDuration, or whose caller hasn't called Next for
+// longer than maxIdle, so that forgotten scanners don't hold resources on the
+// RegionServers forever (their lease being renewed).  A zero ceiling isn't
+// enforced.  The aborted scanners are logged along with their state, and
+// their Next method returns ErrScanAborted.
+func ScanWatchdog(maxDuration, maxIdle time.Duration) Option {
+	return func(c *client) {
+		c.scanMaxDuration = maxDuration
+		c.scanMaxIdle = maxIdle
+	}
+}
+
+// watchScanners periodically aborts the open scanners that exceed the
+// ceilings of the watchdog, until the client is closed.
+func (c *client) watchScanners() {
+	interval := c.scanMaxDuration
+	if interval <= 0 || c.scanMaxIdle > 0 && c.scanMaxIdle < interval {
+		interval = c.scanMaxIdle
+	}
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+		c.checkScanners(time.Now())
+	}
+}
+
+// checkScanners aborts the open scanners that exceed the ceilings of the
+// watchdog at the given time.
+func (c *client) checkScanners(now time.Time) {
+	for _, s := range c.scanners.list() {
+		runtime := now.Sub(time.Unix(0, s.started))
+		idle := now.Sub(time.Unix(0, atomic.LoadInt64(&s.lastNext)))
+		var reason string
+		if c.scanMaxDuration > 0 && runtime > c.scanMaxDuration {
+			reason = "running for too long"
+		} else if c.scanMaxIdle > 0 && idle > c.scanMaxIdle {
+			reason = "idle for too long"
+		} else {
+			continue
+		}
+		if atomic.CompareAndSwapInt32(&s.aborted, 0, 1) {
+			// Don't block the watchdog on a scanner waiting on an RPC.
+			go s.abort(reason, runtime, idle)
+		}
+	}
+}
+
+// abort logs the state of the scanner and closes it, making Next return
+// ErrScanAborted.
+func (s *scanner) abort(reason string, runtime, idle time.Duration) {
+	s.m.Lock()
+	log.Warningf("Aborting scanner %d of table %q on %s, %s: running for %s, idle for %s, "+
+		"start row %q, stop row %q, last row %q, %d rows buffered, metrics %v",
+		s.scannerID, s.s.Table(), s.region, reason, runtime, idle, s.s.GetStartRow(),
+		s.s.GetStopRow(), s.lastRow, len(s.results), s.metrics)
+	s.err = ErrScanAborted
+	s.results = nil
+	id, reg := s.scannerID, s.region
+	s.m.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), scannerCloseTimeout)
+	defer cancel()
+	if err := s.close(ctx); err != nil {
+		log.Warningf("Failed to close aborted scanner %d on %s: %s", id, reg, err)
+	}
+}
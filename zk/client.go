@@ -8,12 +8,13 @@ package zk
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
-	"time"
+	"net"
+	"strings"
 
 	"github.com/tsuna/gohbase/logger"
 
-	"github.com/dropbox/gozk/zookeeper"
 	"github.com/golang/protobuf/proto"
 	"github.com/tsuna/gohbase/pb"
 )
@@ -30,15 +31,34 @@ var Meta ResourceName
 // server is what will be fetched
 var Master ResourceName
 
+// ClusterID is a ResourceName that indicates that the ID of the cluster is
+// what will be fetched
+var ClusterID ResourceName
+
+// Tables is a ResourceName that indicates the parent of the znodes holding
+// the states of the tables
+var Tables ResourceName
+
+// RegionServers is a ResourceName that indicates the parent of the ephemeral
+// znodes of the live RegionServers
+var RegionServers ResourceName
+
 // log is used to standardize logging across all subpackages
 var log = logger.Log
 
+// ErrNoTableState is returned by GetTableState for the tables without a
+// znode, whose state HBase 2 keeps in meta instead.
+var ErrNoTableState = errors.New("no znode holds the state of the table")
+
 const (
-	sessionTimeout = 30
-	znodeRoot      = "hbase"
+	znodeRoot   = "hbase"
+	defaultPort = "2181"
 
-	MetaTemplate   = "/%s/meta-region-server"
-	MasterTemplate = "/%s/master"
+	MetaTemplate      = "/%s/meta-region-server"
+	MasterTemplate    = "/%s/master"
+	ClusterIDTemplate = "/%s/hbaseid"
+	TablesTemplate    = "/%s/table"
+	RSTemplate        = "/%s/rs"
 )
 
 func init() {
@@ -49,45 +69,109 @@ func init() {
 func SetZnodeRoot(name string) {
 	Meta = ResourceName(fmt.Sprintf(MetaTemplate, name))
 	Master = ResourceName(fmt.Sprintf(MasterTemplate, name))
+	ClusterID = ResourceName(fmt.Sprintf(ClusterIDTemplate, name))
+	Tables = ResourceName(fmt.Sprintf(TablesTemplate, name))
+	RegionServers = ResourceName(fmt.Sprintf(RSTemplate, name))
 }
 
-// LocateResource returns the location of the specified resource.
-func LocateResource(zkquorum string, resource ResourceName) (string, uint16, error) {
-	zkconn, _, err := zookeeper.Dial(zkquorum, time.Duration(sessionTimeout)*time.Second)
-	if err != nil {
-		return "", 0,
-			fmt.Errorf("Error connecting to ZooKeeper at %v: %s", zkquorum, err)
+// MetaReplica returns the ResourceName of the location of the given replica
+// of meta, which is Meta for the primary replica.
+func MetaReplica(replicaID uint32) ResourceName {
+	if replicaID == 0 {
+		return Meta
 	}
-	defer zkconn.Close()
-	sbuf, _, err := zkconn.Get(string(resource))
+	return ResourceName(fmt.Sprintf("%s-%d", Meta, replicaID))
+}
 
-	buf := []byte(sbuf)
-	if err != nil {
-		return "", 0,
-			fmt.Errorf("Failed to read the %s znode: %s", resource, err)
+// servers returns the addresses of the servers of the given quorum, like
+// "host1:port1,host2", with the default port of ZooKeeper if none is given.
+func servers(zkquorum string) []string {
+	addrs := strings.Split(zkquorum, ",")
+	for i, addr := range addrs {
+		addr = strings.TrimSpace(addr)
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, defaultPort)
+		}
+		addrs[i] = addr
 	}
+	return addrs
+}
+
+// parseZnode returns the protobuf-encoded contents of the given data of the
+// znode of the specified resource.
+func parseZnode(resource ResourceName, buf []byte) ([]byte, error) {
 	if len(buf) == 0 {
-		log.Fatalf("%s was empty!", resource)
+		return nil, fmt.Errorf("%s was empty", resource)
 	} else if buf[0] != 0xFF {
-		return "", 0,
-			fmt.Errorf("The first byte of %s was 0x%x, not 0xFF", resource, buf[0])
+		return nil, fmt.Errorf("The first byte of %s was 0x%x, not 0xFF", resource, buf[0])
+	} else if len(buf) < 1+4 {
+		return nil, fmt.Errorf("%s was truncated in the metadata length", resource)
 	}
 	metadataLen := binary.BigEndian.Uint32(buf[1:])
 	if metadataLen < 1 || metadataLen > 65000 {
-		return "", 0, fmt.Errorf("Invalid metadata length for %s: %d", resource, metadataLen)
+		return nil, fmt.Errorf("Invalid metadata length for %s: %d", resource, metadataLen)
+	} else if uint32(len(buf)) < 1+4+metadataLen+4 {
+		return nil, fmt.Errorf("%s was truncated after %d bytes, with metadata of %d bytes",
+			resource, len(buf), metadataLen)
 	}
 	buf = buf[1+4+metadataLen:]
 	magic := binary.BigEndian.Uint32(buf)
 	const pbufMagic = 1346524486 // 4 bytes: "PBUF"
 
 	if magic != pbufMagic {
-		return "", 0, fmt.Errorf("Invalid magic number for %s: %d", resource, magic)
+		return nil, fmt.Errorf("Invalid magic number for %s: %d", resource, magic)
+	}
+	return buf[4:], nil
+}
+
+// GetClusterID returns the ID of the cluster that the quorum of the session
+// belongs to.
+func (s *Session) GetClusterID() (string, error) {
+	buf, err := s.read(ClusterID)
+	if err != nil {
+		return "", err
 	}
-	buf = buf[4:]
+	id := &pb.ClusterId{}
+	err = proto.UnmarshalMerge(buf, id)
+	if err != nil {
+		return "", fmt.Errorf("Failed to deserialize the ClusterId entry from ZK: %s", err)
+	}
+	return id.GetClusterId(), nil
+}
+
+// GetTableState returns the state of the given table, whose name is
+// prefixed by its namespace and a colon unless it's in the default namespace.
+// It returns ErrNoTableState if the table has no znode.
+func (s *Session) GetTableState(table string) (pb.Table_State, error) {
+	buf, err := s.readZnode(ResourceName(string(Tables)+"/"+table), true)
+	if err != nil {
+		return 0, err
+	} else if buf == nil {
+		return 0, ErrNoTableState
+	}
+	state := &pb.Table{}
+	err = proto.UnmarshalMerge(buf, state)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to deserialize the Table entry from ZK: %s", err)
+	}
+	return state.GetState(), nil
+}
+
+// LocateResource returns the location of the specified resource, over a new
+// session with the given quorum.
+func LocateResource(zkquorum string, resource ResourceName) (string, uint16, error) {
+	s := NewSession(zkquorum)
+	defer s.Close()
+	return s.LocateResource(resource)
+}
+
+// parseLocation returns the location held by the protobuf-encoded contents
+// of the znode of the specified resource.
+func parseLocation(resource ResourceName, buf []byte) (string, uint16, error) {
 	var server *pb.ServerName
-	if resource == Meta {
+	if strings.HasPrefix(string(resource), string(Meta)) {
 		meta := &pb.MetaRegionServer{}
-		err = proto.UnmarshalMerge(buf, meta)
+		err := proto.UnmarshalMerge(buf, meta)
 		if err != nil {
 			return "", 0,
 				fmt.Errorf("Failed to deserialize the MetaRegionServer entry from ZK: %s", err)
@@ -95,12 +179,15 @@ func LocateResource(zkquorum string, resource ResourceName) (string, uint16, err
 		server = meta.Server
 	} else {
 		master := &pb.Master{}
-		err = proto.UnmarshalMerge(buf, master)
+		err := proto.UnmarshalMerge(buf, master)
 		if err != nil {
 			return "", 0,
 				fmt.Errorf("Failed to deserialize the Master entry from ZK: %s", err)
 		}
 		server = master.Master
 	}
+	if server == nil || server.HostName == nil || server.Port == nil {
+		return "", 0, fmt.Errorf("The %s znode has no location", resource)
+	}
 	return *server.HostName, uint16(*server.Port), nil
 }
@@ -0,0 +1,103 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package zk
+
+import (
+	"fmt"
+	"time"
+
+	zookeeper "github.com/samuel/go-zookeeper/zk"
+)
+
+// readPolicy is how the znodes are read.
+type readPolicy struct {
+	// How long an attempt can take, if limited.
+	timeout time.Duration
+
+	// How many times the read is attempted.
+	attempts int
+
+	// How long to wait before the first retry, doubled for every next one.
+	backoff time.Duration
+}
+
+const defaultSessionTimeout = 30 * time.Second
+
+// defaultReadPolicy attempts the reads once, without timeout.
+var defaultReadPolicy = readPolicy{attempts: 1, backoff: 100 * time.Millisecond}
+
+// SessionTimeout returns an option that sets the timeout of the session, after
+// which ZooKeeper expires it once it lost contact with its client.  The
+// default is 30s.
+func SessionTimeout(timeout time.Duration) SessionOption {
+	return func(s *Session) {
+		s.sessionTimeout = timeout
+	}
+}
+
+// ReadPolicy returns an option that sets how the session reads the znodes:
+// each attempt fails after the given timeout, unless it's 0, and a failed
+// read is attempted up to the given number of times, waiting for the given
+// backoff after the first attempt and twice as long after every next one.  A
+// znode that doesn't exist isn't read again.  By default, reads are attempted
+// once, without timeout.
+func ReadPolicy(timeout time.Duration, attempts int, backoff time.Duration) SessionOption {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return func(s *Session) {
+		s.policy = readPolicy{timeout: timeout, attempts: attempts, backoff: backoff}
+	}
+}
+
+// get returns the data of the znode at the given path, read according to the
+// read policy of the session.
+func (s *Session) get(conn *zookeeper.Conn, path string) ([]byte, error) {
+	return s.policy.read(path, func() ([]byte, error) {
+		buf, _, err := conn.Get(path)
+		return buf, err
+	})
+}
+
+// read calls the given function, which reads the znode at the given path,
+// until it succeeds or the attempts are exhausted.
+func (p readPolicy) read(path string, f func() ([]byte, error)) ([]byte, error) {
+	backoff := p.backoff
+	for attempt := 1; ; attempt++ {
+		buf, err := p.attempt(path, f)
+		if err == nil || err == zookeeper.ErrNoNode || attempt >= p.attempts {
+			return buf, err
+		}
+		log.Warningf("Failed to read the %s znode (attempt %d of %d), retrying in %s: %s",
+			path, attempt, p.attempts, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// attempt calls the given function, which reads the znode at the given path,
+// and gives up on it after the timeout.
+func (p readPolicy) attempt(path string, f func() ([]byte, error)) ([]byte, error) {
+	if p.timeout <= 0 {
+		return f()
+	}
+	type result struct {
+		buf []byte
+		err error
+	}
+	// Buffered so that the read doesn't block forever if we time out.
+	res := make(chan result, 1)
+	go func() {
+		buf, err := f()
+		res <- result{buf, err}
+	}()
+	select {
+	case r := <-res:
+		return r.buf, r.err
+	case <-time.After(p.timeout):
+		return nil, fmt.Errorf("Timed out after %s reading the %s znode", p.timeout, path)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package zk
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	zookeeper "github.com/samuel/go-zookeeper/zk"
+	"github.com/tsuna/gohbase/pb"
+)
+
+func TestReadPolicy(t *testing.T) {
+	p := readPolicy{timeout: 10 * time.Millisecond, attempts: 3, backoff: time.Millisecond}
+	// The attempts that time out keep running, so calls is atomic.
+	var calls int32
+	buf, err := p.read("/hbase/master", func() ([]byte, error) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			return nil, errors.New("connection loss")
+		case 2:
+			// Slower than the timeout.
+			time.Sleep(50 * time.Millisecond)
+		}
+		return []byte("master"), nil
+	})
+	if n := atomic.LoadInt32(&calls); err != nil || string(buf) != "master" || n != 3 {
+		t.Errorf("Expected to read the znode at the 3rd attempt, got %q, %v after %d",
+			buf, err, n)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	_, err = p.read("/hbase/master", func() ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, zookeeper.ErrNoNode
+	})
+	if n := atomic.LoadInt32(&calls); err != zookeeper.ErrNoNode || n != 1 {
+		t.Errorf("Expected a missing znode not to be read again, got %v after %d", err, n)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	_, err = p.read("/hbase/master", func() ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errors.New("connection loss")
+	})
+	if n := atomic.LoadInt32(&calls); err == nil || n != 3 {
+		t.Errorf("Expected to give up after 3 attempts, got %v after %d", err, n)
+	}
+}
+
+func TestServers(t *testing.T) {
+	addrs := servers("zk1,zk2:2182, zk3")
+	expected := []string{"zk1:2181", "zk2:2182", "zk3:2181"}
+	if len(addrs) != len(expected) {
+		t.Fatalf("Expected %q, got %q", expected, addrs)
+	}
+	for i, addr := range addrs {
+		if addr != expected[i] {
+			t.Errorf("Expected %q, got %q", expected, addrs)
+		}
+	}
+}
+
+func TestParseZnode(t *testing.T) {
+	// The znode of the master, with 4 bytes of metadata.
+	master, err := proto.Marshal(&pb.Master{Master: &pb.ServerName{
+		HostName: proto.String("host"),
+		Port:     proto.Uint32(16000),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	znode := append([]byte("\xff\x00\x00\x00\x04meta"+"PBUF"), master...)
+	buf, err := parseZnode(Master, znode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, port, err := parseLocation(Master, buf)
+	if err != nil || host != "host" || port != 16000 {
+		t.Errorf("Expected host:16000, got %s:%d (%v)", host, port, err)
+	}
+
+	// Every truncation of the znode is an error rather than a panic.
+	for i := 0; i < len("\xff\x00\x00\x00\x04metaPBUF"); i++ {
+		if _, err := parseZnode(Master, znode[:i]); err == nil {
+			t.Errorf("Expected an error for the znode truncated to %d bytes", i)
+		}
+	}
+	if _, _, err := parseLocation(Master, []byte{}); err == nil {
+		t.Error("Expected an error for a master without location")
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package zk
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	zookeeper "github.com/samuel/go-zookeeper/zk"
+)
+
+// rewatchInterval is how long to wait before watching a znode again after
+// failing to.
+var rewatchInterval = time.Second
+
+// ErrSessionClosed is returned when using a Session that was closed.
+var ErrSessionClosed = errors.New("ZooKeeper session closed")
+
+// Session is a long-lived ZooKeeper session over which the locations of the
+// resources are looked up and watched, rather than connecting for every
+// lookup.  It connects on first use, and reconnects once expired.
+type Session struct {
+	zkquorum string
+
+	// The scheme and credentials of the authentication, if any.
+	authScheme string
+	authCert   string
+
+	sessionTimeout time.Duration
+
+	// How the znodes are read.
+	policy readPolicy
+
+	// Protects conn and closed.
+	m sync.Mutex
+
+	// The current connection, if any.
+	conn *zookeeper.Conn
+
+	closed bool
+
+	// Closed when the session is closed.
+	done chan struct{}
+}
+
+// zkLogger logs what the ZooKeeper library logs, like every connection to a
+// server, at the debug level of our logger rather than to the standard one.
+type zkLogger struct{}
+
+func (zkLogger) Printf(format string, args ...interface{}) {
+	log.Debugf(format, args...)
+}
+
+// SessionOption is a function used to configure a Session.
+type SessionOption func(*Session)
+
+// Auth returns an option that makes the session authenticate with the given
+// scheme and credentials, so that it can read the znodes protected by ACLs.
+// For the "digest" scheme, the credentials are like "user:password".
+func Auth(scheme, cert string) SessionOption {
+	return func(s *Session) {
+		s.authScheme = scheme
+		s.authCert = cert
+	}
+}
+
+// NewSession creates a new session with the given quorum.  It doesn't do any
+// IO until it's used.
+func NewSession(zkquorum string, options ...SessionOption) *Session {
+	s := &Session{
+		zkquorum:       zkquorum,
+		sessionTimeout: defaultSessionTimeout,
+		policy:         defaultReadPolicy,
+		done:           make(chan struct{}),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// dial connects to the quorum of the session, authenticates if needed, and
+// returns the channel of the events of the connection as well.
+func (s *Session) dial() (*zookeeper.Conn, <-chan zookeeper.Event, error) {
+	zkconn, events, err := zookeeper.Connect(servers(s.zkquorum), s.sessionTimeout,
+		zookeeper.WithLogger(zkLogger{}))
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error connecting to ZooKeeper at %v: %s", s.zkquorum, err)
+	}
+	if s.authScheme != "" {
+		if err = zkconn.AddAuth(s.authScheme, []byte(s.authCert)); err != nil {
+			zkconn.Close()
+			return nil, nil, fmt.Errorf("Failed to authenticate to ZooKeeper at %v with %s: %s",
+				s.zkquorum, s.authScheme, err)
+		}
+	}
+	return zkconn, events, nil
+}
+
+// connection returns the current connection, connecting if needed.
+func (s *Session) connection() (*zookeeper.Conn, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.closed {
+		return nil, ErrSessionClosed
+	}
+	if s.conn == nil {
+		conn, events, err := s.dial()
+		if err != nil {
+			return nil, err
+		}
+		s.conn = conn
+		go s.watchSession(conn, events)
+	}
+	return s.conn, nil
+}
+
+// watchSession drops the given connection once its session is lost for good,
+// so that the next use reconnects.
+func (s *Session) watchSession(conn *zookeeper.Conn, events <-chan zookeeper.Event) {
+	for event := range events {
+		if event.State == zookeeper.StateExpired ||
+			event.State == zookeeper.StateAuthFailed {
+			log.Warningf("Lost the ZooKeeper session with %s: %v", s.zkquorum, event)
+			s.m.Lock()
+			if s.conn == conn {
+				s.conn = nil
+			}
+			s.m.Unlock()
+			conn.Close()
+			return
+		}
+	}
+}
+
+// read returns the protobuf-encoded contents of the specified resource.
+func (s *Session) read(resource ResourceName) ([]byte, error) {
+	return s.readZnode(resource, false)
+}
+
+// readZnode returns the protobuf-encoded contents of the specified resource,
+// or nil if it's optional and its znode doesn't exist.
+func (s *Session) readZnode(resource ResourceName, optional bool) ([]byte, error) {
+	conn, err := s.connection()
+	if err != nil {
+		return nil, err
+	}
+	buf, err := s.get(conn, string(resource))
+	if err == zookeeper.ErrNoNode && optional {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Failed to read the %s znode: %s", resource, err)
+	}
+	return parseZnode(resource, buf)
+}
+
+// LocateResource returns the location of the specified resource.
+func (s *Session) LocateResource(resource ResourceName) (string, uint16, error) {
+	buf, err := s.read(resource)
+	if err != nil {
+		return "", 0, err
+	}
+	return parseLocation(resource, buf)
+}
+
+// MetaReplicaIDs returns the sorted IDs of the secondary replicas of meta
+// whose location is in ZooKeeper, if meta has replicas.
+func (s *Session) MetaReplicaIDs() ([]uint32, error) {
+	conn, err := s.connection()
+	if err != nil {
+		return nil, err
+	}
+	parent := path.Dir(string(Meta))
+	children, _, err := conn.Children(parent)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list the children of the %s znode: %s", parent, err)
+	}
+	prefix := path.Base(string(Meta)) + "-"
+	var ids replicaIDs
+	for _, child := range children {
+		if !strings.HasPrefix(child, prefix) {
+			continue
+		}
+		id, err := strconv.ParseUint(child[len(prefix):], 10, 32)
+		if err == nil && id != 0 {
+			ids = append(ids, uint32(id))
+		}
+	}
+	sort.Sort(ids)
+	return ids, nil
+}
+
+// replicaIDs sorts IDs of replicas.
+type replicaIDs []uint32
+
+func (r replicaIDs) Len() int           { return len(r) }
+func (r replicaIDs) Less(i, j int) bool { return r[i] < r[j] }
+func (r replicaIDs) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+
+// WatchResource calls onChange with the location of the specified resource
+// once it's read and then every time its znode changes, from another
+// goroutine, until the session is closed.
+func (s *Session) WatchResource(resource ResourceName, onChange func(host string, port uint16)) {
+	go s.watch(resource, func() (<-chan zookeeper.Event, error) {
+		return s.watchOnce(resource, onChange)
+	})
+}
+
+// WatchRegionServers calls onChange with the names of the live RegionServers,
+// like "host,port,startcode", once they're listed and then every time one
+// starts or dies, from another goroutine, until the session is closed.
+func (s *Session) WatchRegionServers(onChange func(servers []string)) {
+	go s.watch(RegionServers, func() (<-chan zookeeper.Event, error) {
+		conn, err := s.connection()
+		if err != nil {
+			return nil, err
+		}
+		servers, _, watch, err := conn.ChildrenW(string(RegionServers))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list the children of the %s znode: %s",
+				RegionServers, err)
+		}
+		onChange(servers)
+		return watch, nil
+	})
+}
+
+// watch calls the given function, which reads the znode at the given path
+// and sets a watch on it, again every time the watch fires, until the session
+// is closed.
+func (s *Session) watch(path ResourceName, watchOnce func() (<-chan zookeeper.Event, error)) {
+	for {
+		watch, err := watchOnce()
+		if err == ErrSessionClosed {
+			return
+		} else if err != nil {
+			log.Warningf("Failed to watch the %s znode: %s", path, err)
+			select {
+			case <-time.After(rewatchInterval):
+				continue
+			case <-s.done:
+				return
+			}
+		}
+		// Whether the znode changed or the watch was lost along with the
+		// connection, the znode is read and watched again.
+		select {
+		case <-watch:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// watchOnce reads the location of the specified resource, calls onChange with
+// it, and returns the channel of the watch set on its znode.
+func (s *Session) watchOnce(resource ResourceName,
+	onChange func(host string, port uint16)) (<-chan zookeeper.Event, error) {
+	conn, err := s.connection()
+	if err != nil {
+		return nil, err
+	}
+	buf, _, watch, err := conn.GetW(string(resource))
+	if err == zookeeper.ErrNoNode {
+		// The znode doesn't exist, e.g. while the master fails over:
+		// wait for it to be created.
+		var exists bool
+		exists, _, watch, err = conn.ExistsW(string(resource))
+		if err == nil && !exists {
+			return watch, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the %s znode: %s", resource, err)
+	}
+	buf, err = parseZnode(resource, buf)
+	if err == nil {
+		var host string
+		var port uint16
+		if host, port, err = parseLocation(resource, buf); err == nil {
+			onChange(host, port)
+		}
+	}
+	if err != nil {
+		// The next version of the znode may be better.
+		log.Warningf("Failed to parse the %s znode: %s", resource, err)
+	}
+	return watch, nil
+}
+
+// Close closes the session.  The watches stop and the lookups fail from now
+// on.
+func (s *Session) Close() {
+	s.m.Lock()
+	if s.closed {
+		s.m.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.done)
+	conn := s.conn
+	s.conn = nil
+	s.m.Unlock()
+	if conn != nil {
+		conn.Close()
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright (C) 2016  The GoHBase Authors.  All rights reserved.
+// This file is part of GoHBase.
+// Use of this source code is governed by the Apache License 2.0
+// that can be found in the COPYING file.
+
+package zk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionAuth(t *testing.T) {
+	s := NewSession("zk1", Auth("digest", "user:password"))
+	defer s.Close()
+	if s.authScheme != "digest" || s.authCert != "user:password" {
+		t.Errorf("Expected digest authentication, got %q %q", s.authScheme, s.authCert)
+	}
+	// The other sessions aren't affected.
+	other := NewSession("zk1")
+	defer other.Close()
+	if other.authScheme != "" {
+		t.Errorf("Expected no authentication, got %q", other.authScheme)
+	}
+}
+
+func TestSessionReadPolicy(t *testing.T) {
+	s := NewSession("zk1", SessionTimeout(5*time.Second),
+		ReadPolicy(time.Second, 0, time.Millisecond))
+	defer s.Close()
+	if s.sessionTimeout != 5*time.Second {
+		t.Errorf("Expected a session timeout of 5s, got %s", s.sessionTimeout)
+	}
+	expected := readPolicy{timeout: time.Second, attempts: 1, backoff: time.Millisecond}
+	if s.policy != expected {
+		t.Errorf("Expected the read policy %+v, got %+v", expected, s.policy)
+	}
+	// The other sessions keep the defaults.
+	other := NewSession("zk1")
+	defer other.Close()
+	if other.sessionTimeout != defaultSessionTimeout || other.policy != defaultReadPolicy {
+		t.Errorf("Expected the defaults, got %s and %+v", other.sessionTimeout, other.policy)
+	}
+}